@@ -0,0 +1,58 @@
+package dbx
+
+import (
+	"errors"
+	"reflect"
+)
+
+// errMultiDimArray is returned when an array column would have to map into
+// a nested slice. pgx flattens multi-dimensional arrays, so their shape
+// cannot be recovered from the row values.
+var errMultiDimArray = errors.New("multi-dimensional arrays are not supported")
+
+// isArrayField reports whether t is a slice type that array columns should
+// be converted into element by element. []byte is excluded since it holds
+// bytea values.
+func isArrayField(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+// assignSlice sets a slice field from an array value such as the []any pgx
+// returns for array columns, converting each element with assignValue. An
+// empty array yields an empty, non-nil slice.
+func assignSlice(field, val reflect.Value, opts *options) error {
+	if isArrayField(field.Type().Elem()) {
+		return errMultiDimArray
+	}
+
+	slice := reflect.MakeSlice(field.Type(), val.Len(), val.Len())
+	for i := 0; i < val.Len(); i++ {
+		elem := val.Index(i)
+		if elem.Kind() == reflect.Interface {
+			elem = elem.Elem()
+		}
+		if elem.IsValid() && isArrayField(elem.Type()) {
+			return errMultiDimArray
+		}
+
+		var v any
+		if elem.IsValid() {
+			v = elem.Interface()
+		}
+		if err := assignValue(slice.Index(i), v, opts); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// convertArray applies convert to each element of an array value, for row
+// values returned by QueryMaps and QueryJSON.
+func convertArray(values []any, opts *options, convert func(any, *options) any) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = convert(v, opts)
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestQueryStructsArrays(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("tags", "scores", "ids", "labels", "empty", "missing"),
+		rows: []mockRow{{values: []interface{}{
+			[]any{"a", "b"},
+			[]any{int32(1), int32(2)},
+			[]any{testUUIDBytes},
+			[]any{"x", nil},
+			[]any{},
+			nil,
+		}}},
+	}
+
+	type Row struct {
+		Tags    []string    `db:"tags"`
+		Scores  []int64     `db:"scores"`
+		IDs     []plainUUID `db:"ids"`
+		Labels  []*string   `db:"labels"`
+		Empty   []string    `db:"empty"`
+		Missing []string    `db:"missing"`
+	}
+
+	var rows []Row
+	if err := QueryStructs(ctx, mock, "SELECT * FROM t", &rows); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	got := rows[0]
+	if !reflect.DeepEqual(got.Tags, []string{"a", "b"}) {
+		t.Errorf("Tags = %#v", got.Tags)
+	}
+	if !reflect.DeepEqual(got.Scores, []int64{1, 2}) {
+		t.Errorf("Scores = %#v", got.Scores)
+	}
+	if !reflect.DeepEqual(got.IDs, []plainUUID{plainUUID(testUUIDBytes)}) {
+		t.Errorf("IDs = %#v", got.IDs)
+	}
+	if len(got.Labels) != 2 || *got.Labels[0] != "x" || got.Labels[1] != nil {
+		t.Errorf("Labels = %#v", got.Labels)
+	}
+	if got.Empty == nil || len(got.Empty) != 0 {
+		t.Errorf("Expected empty non-nil slice, got %#v", got.Empty)
+	}
+	if got.Missing != nil {
+		t.Errorf("Expected nil slice for NULL array, got %#v", got.Missing)
+	}
+}
+
+func TestQueryStructsMultiDimArray(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("grid"),
+		rows:   []mockRow{{values: []interface{}{[]any{int32(1), int32(2)}}}},
+	}
+
+	var rows []struct {
+		Grid [][]int32 `db:"grid"`
+	}
+	err := QueryStructs(ctx, mock, "SELECT grid FROM t", &rows)
+
+	if !errors.Is(err, errMultiDimArray) {
+		t.Errorf("Expected errMultiDimArray, got %v", err)
+	}
+}
+
+func TestQueryStructsArrayElementError(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("scores"),
+		rows:   []mockRow{{values: []interface{}{[]any{"high"}}}},
+	}
+
+	var rows []struct {
+		Scores []int64 `db:"scores"`
+	}
+	err := QueryStructs(ctx, mock, "SELECT scores FROM t", &rows)
+
+	var convErr *ConversionError
+	if !errors.As(err, &convErr) || convErr.Column != "scores" {
+		t.Errorf("Expected ConversionError for scores, got %v", err)
+	}
+}
+
+func TestInsertStructPassesSlices(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	type Post struct {
+		Tags []string `db:"tags"`
+	}
+
+	if err := InsertStruct(ctx, mock, "posts", Post{Tags: []string{"go", "sql"}}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if !reflect.DeepEqual(mock.lastArgs, []any{[]string{"go", "sql"}}) {
+		t.Errorf("Expected slice to be passed through, got %#v", mock.lastArgs)
+	}
+}
+
+func TestQueryMapsArrays(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("ids"),
+		rows:   []mockRow{{values: []interface{}{[]any{testUUIDBytes}}}},
+	}
+
+	rows, err := QueryMaps(ctx, mock, "SELECT ids FROM t")
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if !reflect.DeepEqual(rows[0]["ids"], []any{testUUIDString}) {
+		t.Errorf("Expected uuid strings, got %#v", rows[0]["ids"])
+	}
+}
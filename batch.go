@@ -0,0 +1,148 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Batcher is implemented by database handles that can send a pgx.Batch.
+// *pgxpool.Pool, *pgx.Conn and pgx.Tx all satisfy it.
+type Batcher interface {
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// Batch queues statements to be sent to the database in a single round trip
+// with ExecBatch. The zero value is ready to use. Errors from building a
+// statement (for example an invalid struct) are recorded and reported by
+// ExecBatch before anything is sent.
+type Batch struct {
+	batch pgx.Batch
+	err   error
+}
+
+// Exec queues a raw SQL statement.
+func (b *Batch) Exec(sql string, args ...any) {
+	b.batch.Queue(sql, args...)
+}
+
+// InsertStruct queues an INSERT built the same way as InsertStruct. The
+// BeforeInsert hook runs when the statement is queued, with a background
+// context.
+func (b *Batch) InsertStruct(table string, data any) {
+	data, err := beforeInsert(context.Background(), data)
+	if err != nil {
+		b.queue("", nil, err)
+		return
+	}
+	sql, args, err := buildInsertStruct(nil, table, data)
+	b.queue(sql, args, err)
+}
+
+// UpdateStruct queues an UPDATE built the same way as UpdateStruct. A
+// version-tagged field is checked and incremented in SQL, but ErrStaleRow is
+// not reported and the struct is not updated. The BeforeUpdate hook runs
+// when the statement is queued, with a background context.
+func (b *Batch) UpdateStruct(table string, data any, where string, whereArgs ...any) {
+	data, err := beforeUpdate(context.Background(), data)
+	if err != nil {
+		b.queue("", nil, err)
+		return
+	}
+	sql, args, _, err := buildUpdateStruct(nil, table, data, where, whereArgs)
+	b.queue(sql, args, err)
+}
+
+// Len returns the number of statements queued so far.
+func (b *Batch) Len() int {
+	return b.batch.Len()
+}
+
+func (b *Batch) queue(sql string, args []any, err error) {
+	if err != nil {
+		if b.err == nil {
+			b.err = fmt.Errorf("statement %d: %w", b.batch.Len(), err)
+		}
+		return
+	}
+	b.batch.Queue(sql, args...)
+}
+
+// BatchStatementError describes the failure of a single statement in a batch.
+type BatchStatementError struct {
+	Index int
+	SQL   string
+	Err   error
+}
+
+func (e *BatchStatementError) Error() string {
+	return fmt.Sprintf("statement %d failed: %v", e.Index, e.Err)
+}
+
+func (e *BatchStatementError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError aggregates the per-statement failures of a batch.
+// Note that Postgres runs a batch in an implicit transaction, so statements
+// after the first failure typically fail as well.
+type BatchError struct {
+	Errors []*BatchStatementError
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "batch failed: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the individual statement errors so errors.Is and errors.As
+// can inspect them.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ExecBatch sends all statements queued on b in a single round trip and
+// returns the number of rows affected by each, in queue order.
+// If any statement fails the returned error is a *BatchError identifying
+// which statements failed.
+func ExecBatch(ctx context.Context, db Batcher, b *Batch) ([]int64, error) {
+	if b.err != nil {
+		return nil, fmt.Errorf("failed to build batch: %w", b.err)
+	}
+
+	if b.batch.Len() == 0 {
+		return nil, nil
+	}
+
+	results := db.SendBatch(ctx, &b.batch)
+
+	affected := make([]int64, b.batch.Len())
+	var batchErr BatchError
+	for i, qq := range b.batch.QueuedQueries {
+		tag, err := results.Exec()
+		if err != nil {
+			batchErr.Errors = append(batchErr.Errors, &BatchStatementError{Index: i, SQL: qq.SQL, Err: err})
+			continue
+		}
+		affected[i] = tag.RowsAffected()
+	}
+
+	if err := results.Close(); err != nil && len(batchErr.Errors) == 0 {
+		return affected, fmt.Errorf("batch failed: %w", err)
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return affected, &batchErr
+	}
+
+	return affected, nil
+}
@@ -0,0 +1,116 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type mockBatcher struct {
+	sent    []*pgx.QueuedQuery
+	results []mockBatchResult
+}
+
+type mockBatchResult struct {
+	tag pgconn.CommandTag
+	err error
+}
+
+func (m *mockBatcher) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	m.sent = b.QueuedQueries
+	return &mockBatchResults{results: m.results}
+}
+
+type mockBatchResults struct {
+	results []mockBatchResult
+	next    int
+}
+
+func (m *mockBatchResults) Exec() (pgconn.CommandTag, error) {
+	r := m.results[m.next]
+	m.next++
+	return r.tag, r.err
+}
+
+func (m *mockBatchResults) Query() (pgx.Rows, error) { return nil, errors.New("not implemented") }
+func (m *mockBatchResults) QueryRow() pgx.Row        { return nil }
+func (m *mockBatchResults) Close() error             { return nil }
+
+func TestExecBatch(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockBatcher{results: []mockBatchResult{
+		{tag: pgconn.NewCommandTag("INSERT 0 1")},
+		{tag: pgconn.NewCommandTag("UPDATE 3")},
+		{tag: pgconn.NewCommandTag("DELETE 0")},
+	}}
+
+	var b Batch
+	b.InsertStruct("users", insertUser{Name: "John", Email: "john@example.com"})
+	b.UpdateStruct("users", insertUser{Name: "Jane"}, "id = $1", 2)
+	b.Exec("DELETE FROM sessions WHERE user_id = $1", 2)
+
+	affected, err := ExecBatch(ctx, mock, &b)
+	if err != nil {
+		t.Fatalf("ExecBatch failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(affected, []int64{1, 3, 0}) {
+		t.Errorf("Expected rows affected [1 3 0], got %v", affected)
+	}
+
+	expectedSQL := []string{
+		`INSERT INTO "users" ("name", "email") VALUES ($1, $2)`,
+		`UPDATE "users" SET "name" = $1, "email" = $2 WHERE id = $3`,
+		"DELETE FROM sessions WHERE user_id = $1",
+	}
+	for i, qq := range mock.sent {
+		if qq.SQL != expectedSQL[i] {
+			t.Errorf("Statement %d: expected %q, got %q", i, expectedSQL[i], qq.SQL)
+		}
+	}
+}
+
+func TestExecBatchStatementError(t *testing.T) {
+	ctx := context.Background()
+	failure := errors.New("duplicate key")
+	mock := &mockBatcher{results: []mockBatchResult{
+		{tag: pgconn.NewCommandTag("INSERT 0 1")},
+		{err: failure},
+	}}
+
+	var b Batch
+	b.Exec("INSERT INTO a VALUES (1)")
+	b.Exec("INSERT INTO b VALUES (1)")
+
+	_, err := ExecBatch(ctx, mock, &b)
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Expected *BatchError, got %v", err)
+	}
+	if len(batchErr.Errors) != 1 || batchErr.Errors[0].Index != 1 {
+		t.Errorf("Expected failure at statement 1, got %+v", batchErr.Errors)
+	}
+	if !errors.Is(err, failure) {
+		t.Error("Expected errors.Is to find the statement error")
+	}
+}
+
+func TestExecBatchBuildError(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockBatcher{}
+
+	var b Batch
+	b.InsertStruct("users", 42)
+
+	if _, err := ExecBatch(ctx, mock, &b); err == nil {
+		t.Error("Expected error for invalid queued struct")
+	}
+	if mock.sent != nil {
+		t.Error("Expected nothing to be sent")
+	}
+}
@@ -0,0 +1,115 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// benchStruct returns a struct type with cols fields tagged c0, c1, and so
+// on, alternating between int64 and string, and a query result with rows
+// rows of matching values.
+func benchStruct(rows, cols int) (reflect.Type, *mockQueryer) {
+	fields := make([]reflect.StructField, cols)
+	names := make([]string, cols)
+	for i := range fields {
+		names[i] = fmt.Sprintf("c%d", i)
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("C%d", i),
+			Type: reflect.TypeOf(int64(0)),
+			Tag:  reflect.StructTag(fmt.Sprintf(`db:"%s"`, names[i])),
+		}
+		if i%2 == 1 {
+			fields[i].Type = reflect.TypeOf("")
+		}
+	}
+
+	mock := &mockQueryer{fields: mockFields(names...), rows: make([]mockRow, rows)}
+	for i := range mock.fields {
+		mock.fields[i].DataTypeOID = pgtype.Int8OID
+		if i%2 == 1 {
+			mock.fields[i].DataTypeOID = pgtype.TextOID
+		}
+	}
+	for r := range mock.rows {
+		values := make([]any, cols)
+		for i := range values {
+			if i%2 == 1 {
+				values[i] = "value"
+			} else {
+				values[i] = int64(r)
+			}
+		}
+		mock.rows[r].values = values
+	}
+	return reflect.StructOf(fields), mock
+}
+
+func BenchmarkQueryStructs(b *testing.B) {
+	for _, rows := range []int{1000, 100000} {
+		for _, cols := range []int{5, 50} {
+			typ, mock := benchStruct(rows, cols)
+			for _, capacity := range []bool{false, true} {
+				name := fmt.Sprintf("rows=%d/cols=%d", rows, cols)
+				var args []any
+				if capacity {
+					name += "/capacity"
+					args = append(args, Capacity(rows))
+				}
+				b.Run(name, func(b *testing.B) {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						dest := reflect.New(reflect.SliceOf(typ))
+						if err := QueryStructs(context.Background(), mock, "SELECT * FROM bench", dest.Interface(), args...); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+// BenchmarkQueryStructsDirectScan compares the Values and DirectScan paths
+// on rows decoded by pgx's codecs, as from a real connection.
+func BenchmarkQueryStructsDirectScan(b *testing.B) {
+	for _, cols := range []int{5, 50} {
+		typ, mock := benchStruct(1000, cols)
+		for _, direct := range []bool{false, true} {
+			name := fmt.Sprintf("rows=1000/cols=%d/values", cols)
+			args := []any{Capacity(1000)}
+			if direct {
+				name = fmt.Sprintf("rows=1000/cols=%d/direct", cols)
+				args = append(args, DirectScan())
+			}
+			b.Run(name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					dest := reflect.New(reflect.SliceOf(typ))
+					if err := QueryStructs(context.Background(), &pgxQueryer{mockQueryer: mock}, "SELECT * FROM bench", dest.Interface(), args...); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkQueryMaps(b *testing.B) {
+	for _, rows := range []int{1000, 100000} {
+		for _, cols := range []int{5, 50} {
+			_, mock := benchStruct(rows, cols)
+			b.Run(fmt.Sprintf("rows=%d/cols=%d", rows, cols), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := QueryMaps(context.Background(), mock, "SELECT * FROM bench", Capacity(rows)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
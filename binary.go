@@ -0,0 +1,95 @@
+package dbx
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// BinaryFormat selects how the JSON and CSV helpers render bytea values.
+type BinaryFormat int
+
+const (
+	// BinaryDefault renders bytea as each helper does without BinaryAs:
+	// base64 in JSON output and bare hex digits in CSV. It is the zero
+	// value.
+	BinaryDefault BinaryFormat = iota
+
+	// BinaryBase64 renders bytea as standard base64, as encoding/json does.
+	BinaryBase64
+
+	// BinaryHex renders bytea in Postgres hex format, such as \x48656c6c6f.
+	BinaryHex
+
+	// BinaryOmit leaves bytea and bytea[] columns out of the output
+	// altogether.
+	BinaryOmit
+)
+
+// binaryDigits renders bytea as bare hex digits, QueryCSV's default.
+const binaryDigits BinaryFormat = -1
+
+// BinaryAs sets how QueryJSON, QueryJSONStream, QueryNDJSON, and the other
+// JSON helpers, and QueryCSV, render bytea values. Without it JSON output
+// has them in base64 and CSV in bare hex digits.
+//
+//	err := dbx.QueryCSV(ctx, db, w, "SELECT * FROM attachments", dbx.BinaryAs(dbx.BinaryOmit))
+func BinaryAs(format BinaryFormat) Option {
+	return func(o *options) {
+		o.binaryFormat = format
+	}
+}
+
+// MaxBinarySize truncates bytea values longer than n bytes in JSON and CSV
+// output to their first n bytes, followed by an indicator of the full
+// length, such as "\x0102...(1048576 bytes)". Truncated values can't be
+// decoded, and are meant for display and logs.
+func MaxBinarySize(n int) Option {
+	return func(o *options) {
+		o.maxBinary = n
+	}
+}
+
+// keptColumns returns the indices of the columns that appear in JSON and
+// CSV output: all of them, unless BinaryOmit drops those holding bytea.
+func keptColumns(fieldDescs []pgconn.FieldDescription, opts *options) []int {
+	kept := make([]int, 0, len(fieldDescs))
+	for i, fd := range fieldDescs {
+		if opts.binaryFormat == BinaryOmit && (fd.DataTypeOID == pgtype.ByteaOID || fd.DataTypeOID == pgtype.ByteaArrayOID) {
+			continue
+		}
+		kept = append(kept, i)
+	}
+	return kept
+}
+
+// binaryText renders b in format, which defaults to def, truncating it to
+// the MaxBinarySize limit.
+func binaryText(b []byte, def BinaryFormat, opts *options) string {
+	size := len(b)
+	if opts.maxBinary > 0 && size > opts.maxBinary {
+		b = b[:opts.maxBinary]
+	}
+
+	format := opts.binaryFormat
+	if format != BinaryBase64 && format != BinaryHex {
+		format = def
+	}
+
+	var text string
+	switch format {
+	case BinaryBase64:
+		text = base64.StdEncoding.EncodeToString(b)
+	case BinaryHex:
+		text = `\x` + hex.EncodeToString(b)
+	default:
+		text = hex.EncodeToString(b)
+	}
+	if len(b) < size {
+		text += "...(" + strconv.Itoa(size) + " bytes)"
+	}
+	return text
+}
@@ -0,0 +1,96 @@
+package dbx
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// binaryMock returns a mock with an id column and a bytea column.
+func binaryMock() *mockQueryer {
+	fields := mockFields("id", "data")
+	fields[1].DataTypeOID = pgtype.ByteaOID
+	return &mockQueryer{
+		fields: fields,
+		rows: []mockRow{
+			{values: []interface{}{int64(1), []byte("Hello")}},
+			{values: []interface{}{int64(2), nil}},
+		},
+	}
+}
+
+func TestBinaryJSON(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		opts []any
+		want string
+	}{
+		{"default", nil, `[{"id":1,"data":"SGVsbG8="},{"id":2,"data":null}]`},
+		{"reset to default", []any{BinaryAs(BinaryHex), BinaryAs(BinaryDefault)}, `[{"id":1,"data":"SGVsbG8="},{"id":2,"data":null}]`},
+		{"base64", []any{BinaryAs(BinaryBase64)}, `[{"id":1,"data":"SGVsbG8="},{"id":2,"data":null}]`},
+		{"hex", []any{BinaryAs(BinaryHex)}, `[{"id":1,"data":"\\x48656c6c6f"},{"id":2,"data":null}]`},
+		{"omit", []any{BinaryAs(BinaryOmit)}, `[{"id":1},{"id":2}]`},
+		{"truncated", []any{MaxBinarySize(2)}, `[{"id":1,"data":"SGU=...(5 bytes)"},{"id":2,"data":null}]`},
+		{"truncated hex", []any{BinaryAs(BinaryHex), MaxBinarySize(2)}, `[{"id":1,"data":"\\x4865...(5 bytes)"},{"id":2,"data":null}]`},
+		{"under limit", []any{BinaryAs(BinaryHex), MaxBinarySize(5)}, `[{"id":1,"data":"\\x48656c6c6f"},{"id":2,"data":null}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := QueryJSON(ctx, binaryMock(), "SELECT id, data FROM blobs", tt.opts...)
+			if err != nil {
+				t.Fatalf("QueryJSON failed: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, data)
+			}
+
+			var buf bytes.Buffer
+			if _, err := QueryJSONStream(ctx, binaryMock(), &buf, "SELECT id, data FROM blobs", tt.opts...); err != nil {
+				t.Fatalf("QueryJSONStream failed: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Expected QueryJSONStream to match QueryJSON, got %s", buf.String())
+			}
+		})
+	}
+}
+
+func TestBinaryNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := QueryNDJSON(context.Background(), binaryMock(), &buf, "SELECT id, data FROM blobs", BinaryAs(BinaryOmit))
+	if err != nil {
+		t.Fatalf("QueryNDJSON failed: %v", err)
+	}
+	if want := "{\"id\":1}\n{\"id\":2}\n"; buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestBinaryCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []any
+		want string
+	}{
+		{"default", nil, "id,data\n1,48656c6c6f\n2,\n"},
+		{"reset to default", []any{BinaryAs(BinaryBase64), BinaryAs(BinaryDefault)}, "id,data\n1,48656c6c6f\n2,\n"},
+		{"base64", []any{BinaryAs(BinaryBase64)}, "id,data\n1,SGVsbG8=\n2,\n"},
+		{"hex", []any{BinaryAs(BinaryHex)}, "id,data\n1,\\x48656c6c6f\n2,\n"},
+		{"omit", []any{BinaryAs(BinaryOmit)}, "id\n1\n2\n"},
+		{"truncated", []any{MaxBinarySize(1)}, "id,data\n1,48...(5 bytes)\n2,\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := QueryCSV(context.Background(), binaryMock(), &buf, "SELECT id, data FROM blobs", tt.opts...); err != nil {
+				t.Fatalf("QueryCSV failed: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, buf.String())
+			}
+		})
+	}
+}
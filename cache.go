@@ -0,0 +1,473 @@
+package dbx
+
+import (
+	"container/list"
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// CacheInvalidation selects what a CachedDB drops from its cache when a
+// statement that may change data runs through it.
+type CacheInvalidation int
+
+const (
+	// InvalidateAll drops every cached result. It is the default.
+	InvalidateAll CacheInvalidation = iota
+
+	// InvalidateTables drops the results whose SQL mentions the table the
+	// statement writes to, as found after INSERT INTO, UPDATE, DELETE FROM,
+	// TRUNCATE, or MERGE INTO. Statements whose table can't be found, such
+	// as DDL, drop everything. Results that depend on a table only through
+	// a view or function are missed, so use it only where queries name
+	// their tables.
+	InvalidateTables
+
+	// InvalidateNone leaves the cache alone, relying on the TTL alone.
+	InvalidateNone
+)
+
+// CacheOpts configures a CachedDB.
+type CacheOpts struct {
+	TTL        time.Duration     // how long a result is served from the cache; default one minute
+	MaxEntries int               // most results kept, evicting the least recently used; default 1000
+	Invalidate CacheInvalidation // what writes drop from the cache
+}
+
+// CachedDB is a DB that keeps the results of SELECT queries in memory for
+// a while, for reference data that is read constantly but rarely changes.
+// Create one with NewCachedDB.
+type CachedDB struct {
+	DB
+	opts CacheOpts
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // of *cacheEntry, most recently used first
+}
+
+// cacheEntry is a cached result set.
+type cacheEntry struct {
+	key     string
+	sql     string
+	fields  []pgconn.FieldDescription
+	rows    [][]any
+	tag     pgconn.CommandTag
+	expires time.Time
+}
+
+// NewCachedDB wraps db so that SELECT queries run through it, by any
+// helper, are answered from an in-memory cache for opts.TTL after they
+// first run. Results are keyed by their SQL and arguments, and only result
+// sets that were read to the end without error are kept. Every caller gets
+// its own copy of the values, so changing a returned RowMap or struct
+// doesn't affect the cache.
+//
+// Pointer arguments are keyed by the value they point to, and
+// driver.Valuer arguments by the value they give. A query with an argument
+// that can't be keyed that way, such as a struct holding pointers, runs
+// uncached.
+//
+// SELECT ... FOR UPDATE and FOR SHARE are never cached, nor are WITH
+// queries holding an INSERT, UPDATE, DELETE, or MERGE; other WITH queries
+// are cached like SELECTs. Other statements are treated as writes, and
+// invalidate the cache as opts.Invalidate says.
+// Writes made elsewhere, including in transactions begun on the underlying
+// pool, aren't seen until the TTL expires or Invalidate is called.
+//
+// Comment tags set with SetCommentTags are left out of the cache key and
+// appended only to the statements the CachedDB passes on, so results are
+// shared between requests with different tag values.
+//
+// If the metrics collector implements CacheCollector, each lookup is
+// reported to it as a hit or a miss.
+//
+//	plans := dbx.NewCachedDB(pool, dbx.CacheOpts{TTL: time.Minute})
+//	err := dbx.QueryStructs(ctx, plans, "SELECT * FROM plans", &catalog)
+func NewCachedDB(db DB, opts CacheOpts) *CachedDB {
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 1000
+	}
+	return &CachedDB{DB: db, opts: opts, entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+// Query answers cacheable queries from the cache when it holds a fresh
+// result, and otherwise runs the query on the underlying DB.
+func (c *CachedDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if !cacheable(sql) {
+		c.invalidate(sql)
+		return c.DB.Query(ctx, commentSQL(ctx, sql), args...)
+	}
+
+	key, ok := cacheKey(sql, args)
+	if !ok {
+		return c.DB.Query(ctx, commentSQL(ctx, sql), args...)
+	}
+	if e := c.get(key); e != nil {
+		observeCache(ctx, sql, true)
+		return &cachedRows{entry: e, current: -1}, nil
+	}
+	observeCache(ctx, sql, false)
+
+	rows, err := c.DB.Query(ctx, commentSQL(ctx, sql), args...)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingRows{Rows: rows, cache: c, key: key, sql: sql}, nil
+}
+
+// Exec runs the statement on the underlying DB and invalidates the cache
+// as configured.
+func (c *CachedDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tag, err := c.DB.Exec(ctx, commentSQL(ctx, sql), args...)
+	c.invalidate(sql)
+	return tag, err
+}
+
+// Invalidate drops every cached result.
+func (c *CachedDB) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// Len returns the number of results in the cache, including any that have
+// expired but not yet been evicted.
+func (c *CachedDB) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// get returns the fresh entry for key, or nil.
+func (c *CachedDB) get(key string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*cacheEntry)
+	if time.Now().After(e.expires) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	return e
+}
+
+// put stores e, evicting the least recently used entries over the limit.
+func (c *CachedDB) put(e *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[e.key]; ok {
+		c.lru.Remove(el)
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+	for c.lru.Len() > c.opts.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// invalidate drops the entries a write of sql may have made stale.
+func (c *CachedDB) invalidate(sql string) {
+	switch c.opts.Invalidate {
+	case InvalidateNone:
+		return
+	case InvalidateTables:
+		if table := writtenTable(sql); table != "" {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			for el := c.lru.Front(); el != nil; {
+				next := el.Next()
+				if e := el.Value.(*cacheEntry); strings.Contains(strings.ToLower(e.sql), table) {
+					c.lru.Remove(el)
+					delete(c.entries, e.key)
+				}
+				el = next
+			}
+			return
+		}
+	}
+	c.Invalidate()
+}
+
+// cacheable reports whether sql is a query whose result may be cached: a
+// SELECT, or a WITH query without data-modifying statements, that takes no
+// row locks.
+func cacheable(sql string) bool {
+	switch sqlVerb(sql) {
+	case "SELECT":
+	case "WITH":
+		if containsKeyword(sql, "INSERT") || containsKeyword(sql, "DELETE") || containsKeyword(sql, "MERGE") {
+			return false
+		}
+	default:
+		return false
+	}
+	return !containsKeyword(sql, "UPDATE") && !containsKeyword(sql, "SHARE")
+}
+
+// cacheKey identifies a query and its arguments. ok is false if an
+// argument can't be keyed by its value.
+func cacheKey(sql string, args []any) (key string, ok bool) {
+	keyed := make([]any, len(args))
+	for i, arg := range args {
+		if keyed[i], ok = cacheArg(arg); !ok {
+			return "", false
+		}
+	}
+	return fmt.Sprintf("%s\x00%#v", sql, keyed), true
+}
+
+// cacheArg returns the value arg is keyed by: the value it points to if it
+// is a pointer, or the value it gives if it is a driver.Valuer. ok is false
+// if that value still holds pointers, which %#v would print as addresses.
+func cacheArg(arg any) (value any, ok bool) {
+	for {
+		v := reflect.ValueOf(arg)
+		if !v.IsValid() || v.Kind() == reflect.Pointer && v.IsNil() {
+			return nil, true
+		}
+		if valuer, isValuer := arg.(driver.Valuer); isValuer {
+			value, err := valuer.Value()
+			if err != nil {
+				return nil, false
+			}
+			return value, !holdsPointers(reflect.ValueOf(value))
+		}
+		if v.Kind() != reflect.Pointer {
+			return arg, !holdsPointers(v)
+		}
+		arg = v.Elem().Interface()
+	}
+}
+
+// holdsPointers reports whether v is or contains a pointer, or another
+// value that %#v doesn't print by content. Values that print themselves
+// with GoString, such as time.Time, don't count.
+func holdsPointers(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	if v.CanInterface() {
+		if _, ok := v.Interface().(fmt.GoStringer); ok {
+			return false
+		}
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.UnsafePointer, reflect.Chan, reflect.Func:
+		return true
+	case reflect.Interface:
+		return !v.IsNil() && holdsPointers(v.Elem())
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if holdsPointers(v.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if holdsPointers(iter.Key()) || holdsPointers(iter.Value()) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if holdsPointers(v.Field(i)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writtenTable returns the lower-cased, unqualified, unquoted name of the
+// table sql writes to, or "" if it can't tell.
+func writtenTable(sql string) string {
+	fields := strings.Fields(sql)
+	for i, f := range fields {
+		switch strings.ToUpper(f) {
+		case "INTO", "UPDATE", "TRUNCATE":
+		case "FROM":
+			if i == 0 || !strings.EqualFold(fields[i-1], "DELETE") {
+				continue
+			}
+		default:
+			continue
+		}
+		rest := fields[i+1:]
+		for len(rest) > 0 && (strings.EqualFold(rest[0], "ONLY") || strings.EqualFold(rest[0], "TABLE")) {
+			rest = rest[1:]
+		}
+		if len(rest) == 0 {
+			return ""
+		}
+		name, _, _ := strings.Cut(rest[0], "(")
+		name = strings.TrimRight(name, ";")
+		if j := strings.LastIndexByte(name, '.'); j >= 0 {
+			name = name[j+1:]
+		}
+		return strings.ToLower(strings.Trim(name, `"`))
+	}
+	return ""
+}
+
+// recordingRows passes rows through while keeping a copy of their values,
+// which are stored in the cache if the rows are read to the end without
+// error.
+type recordingRows struct {
+	pgx.Rows
+	cache  *CachedDB
+	key    string
+	sql    string
+	rows   [][]any
+	failed bool
+	done   bool
+}
+
+func (r *recordingRows) Next() bool {
+	if !r.Rows.Next() {
+		r.finish()
+		return false
+	}
+	if !r.failed {
+		values, err := r.Rows.Values()
+		if err != nil {
+			r.failed = true
+		} else {
+			r.rows = append(r.rows, copyValues(values))
+		}
+	}
+	return true
+}
+
+// finish stores the rows, once they are exhausted.
+func (r *recordingRows) finish() {
+	if r.done {
+		return
+	}
+	r.done = true
+	if r.failed || r.Rows.Err() != nil {
+		return
+	}
+	r.cache.put(&cacheEntry{
+		key:     r.key,
+		sql:     r.sql,
+		fields:  append([]pgconn.FieldDescription(nil), r.Rows.FieldDescriptions()...),
+		rows:    r.rows,
+		tag:     r.Rows.CommandTag(),
+		expires: time.Now().Add(r.cache.opts.TTL),
+	})
+}
+
+// cachedRows replays a cached result set.
+type cachedRows struct {
+	entry   *cacheEntry
+	current int
+	closed  bool
+}
+
+func (r *cachedRows) Close() {
+	r.closed = true
+}
+
+func (r *cachedRows) Err() error {
+	return nil
+}
+
+func (r *cachedRows) CommandTag() pgconn.CommandTag {
+	return r.entry.tag
+}
+
+func (r *cachedRows) FieldDescriptions() []pgconn.FieldDescription {
+	return r.entry.fields
+}
+
+func (r *cachedRows) Next() bool {
+	if r.closed || r.current+1 >= len(r.entry.rows) {
+		r.closed = true
+		return false
+	}
+	r.current++
+	return true
+}
+
+func (r *cachedRows) Values() ([]any, error) {
+	if r.current < 0 || r.current >= len(r.entry.rows) {
+		return nil, fmt.Errorf("no current row")
+	}
+	return copyValues(r.entry.rows[r.current]), nil
+}
+
+func (r *cachedRows) Scan(dest ...any) error {
+	values, err := r.Values()
+	if err != nil {
+		return err
+	}
+	if len(dest) != len(values) {
+		return fmt.Errorf("number of field descriptions must equal number of destinations, got %d and %d", len(values), len(dest))
+	}
+	opts := defaultOptions.Load()
+	for i, d := range dest {
+		ptr := reflect.ValueOf(d)
+		if ptr.Kind() != reflect.Pointer || ptr.IsNil() {
+			return fmt.Errorf("destination %d must be a non-nil pointer, got %T", i, d)
+		}
+		if err := assignValue(ptr.Elem(), values[i], opts); err != nil {
+			return fmt.Errorf("failed to scan column %s: %w", r.entry.fields[i].Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *cachedRows) RawValues() [][]byte {
+	return nil
+}
+
+func (r *cachedRows) Conn() *pgx.Conn {
+	return nil
+}
+
+// copyValues returns a deep copy of values, so that callers can't change
+// the cached ones. Only the mutable types pgx returns are copied.
+func copyValues(values []any) []any {
+	copied := make([]any, len(values))
+	for i, v := range values {
+		copied[i] = copyValue(v)
+	}
+	return copied
+}
+
+func copyValue(v any) any {
+	switch v := v.(type) {
+	case []byte:
+		return append([]byte(nil), v...)
+	case []any:
+		return copyValues(v)
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = copyValue(e)
+		}
+		return m
+	}
+	return v
+}
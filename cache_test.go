@@ -0,0 +1,370 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// countingQueryer counts the queries that reach the mock.
+type countingQueryer struct {
+	mockQueryer
+	queries int
+}
+
+func (c *countingQueryer) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	c.queries++
+	return c.mockQueryer.Query(ctx, sql, args...)
+}
+
+func newCountingQueryer() *countingQueryer {
+	return &countingQueryer{mockQueryer: mockQueryer{rows: []mockRow{
+		{values: []interface{}{int64(1), "John", []byte("john@example.com")}},
+		{values: []interface{}{int64(2), "Jane", []byte("jane@example.com")}},
+	}}}
+}
+
+func TestCachedDBServesRepeatedQueries(t *testing.T) {
+	ctx := context.Background()
+	mock := newCountingQueryer()
+	db := NewCachedDB(mock, CacheOpts{TTL: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		rows, err := QueryMaps(ctx, db, "SELECT * FROM users WHERE id > $1", 0)
+		if err != nil {
+			t.Fatalf("QueryMaps failed: %v", err)
+		}
+		if len(rows) != 2 || rows[1]["name"] != "Jane" {
+			t.Fatalf("Unexpected rows on query %d: %v", i, rows)
+		}
+	}
+	if mock.queries != 1 {
+		t.Errorf("Expected 1 query to reach the database, got %d", mock.queries)
+	}
+
+	if _, err := QueryMaps(ctx, db, "SELECT * FROM users WHERE id > $1", 1); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if mock.queries != 2 {
+		t.Errorf("Expected different arguments to miss the cache, got %d queries", mock.queries)
+	}
+
+	type TestUser struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+	var users []TestUser
+	if err := QueryStructs(ctx, db, "SELECT * FROM users WHERE id > $1", &users, 0); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if len(users) != 2 || users[0].Name != "John" {
+		t.Errorf("Unexpected structs from the cache: %+v", users)
+	}
+	if mock.queries != 2 {
+		t.Errorf("Expected QueryStructs to be served from the cache, got %d queries", mock.queries)
+	}
+}
+
+func TestCachedDBReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	db := NewCachedDB(newCountingQueryer(), CacheOpts{TTL: time.Minute})
+
+	first, err := QueryMaps(ctx, db, "SELECT * FROM users")
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	first[0]["name"] = "Mallory"
+	first[0]["email"].([]byte)[0] = 'X'
+
+	second, err := QueryMaps(ctx, db, "SELECT * FROM users")
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	second[0]["email"].([]byte)[0] = 'Y'
+
+	third, err := QueryMaps(ctx, db, "SELECT * FROM users")
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if third[0]["name"] != "John" || string(third[0]["email"].([]byte)) != "john@example.com" {
+		t.Errorf("Expected the cached row to be unchanged, got %v", third[0])
+	}
+}
+
+func TestCachedDBExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	mock := newCountingQueryer()
+	db := NewCachedDB(mock, CacheOpts{TTL: 10 * time.Millisecond})
+
+	if _, err := QueryMaps(ctx, db, "SELECT * FROM users"); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := QueryMaps(ctx, db, "SELECT * FROM users"); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if mock.queries != 2 {
+		t.Errorf("Expected the expired entry to be queried again, got %d queries", mock.queries)
+	}
+}
+
+func TestCachedDBEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	mock := newCountingQueryer()
+	db := NewCachedDB(mock, CacheOpts{TTL: time.Minute, MaxEntries: 2})
+
+	for _, sql := range []string{"SELECT 1", "SELECT 2", "SELECT 1", "SELECT 3"} {
+		if _, err := QueryMaps(ctx, db, sql); err != nil {
+			t.Fatalf("QueryMaps failed: %v", err)
+		}
+	}
+	if db.Len() != 2 {
+		t.Errorf("Expected 2 cached entries, got %d", db.Len())
+	}
+
+	mock.queries = 0
+	if _, err := QueryMaps(ctx, db, "SELECT 1"); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if _, err := QueryMaps(ctx, db, "SELECT 2"); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if mock.queries != 1 {
+		t.Errorf("Expected only the least recently used entry to be evicted, got %d queries", mock.queries)
+	}
+}
+
+func TestCachedDBIgnoresCommentTags(t *testing.T) {
+	useCommentTags(t, CommentTag{Key: "trace_id", Value: func(ctx context.Context) string {
+		id, _ := ctx.Value(traceKey{}).(string)
+		return id
+	}})
+	mock := newCountingQueryer()
+	db := NewCachedDB(mock, CacheOpts{TTL: time.Minute})
+
+	for _, id := range []string{"abc", "def"} {
+		ctx := context.WithValue(context.Background(), traceKey{}, id)
+		if _, err := QueryMaps(ctx, db, "SELECT * FROM users"); err != nil {
+			t.Fatalf("QueryMaps failed: %v", err)
+		}
+	}
+	if mock.queries != 1 || db.Len() != 1 {
+		t.Errorf("Expected requests with different tags to share one entry, got %d queries and %d entries", mock.queries, db.Len())
+	}
+	if want := "SELECT * FROM users\n/* trace_id=abc */"; mock.lastSQL != want {
+		t.Errorf("Expected the database to get %q, got %q", want, mock.lastSQL)
+	}
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "ghi")
+	if _, err := Exec(ctx, db, "DELETE FROM users"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if want := "DELETE FROM users\n/* trace_id=ghi */"; mock.lastSQL != want {
+		t.Errorf("Expected the database to get %q, got %q", want, mock.lastSQL)
+	}
+}
+
+func TestCachedDBSkipsUncacheableResults(t *testing.T) {
+	ctx := context.Background()
+	mock := newCountingQueryer()
+	db := NewCachedDB(mock, CacheOpts{TTL: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if _, err := QueryMaps(ctx, db, "SELECT * FROM jobs FOR UPDATE SKIP LOCKED"); err != nil {
+			t.Fatalf("QueryMaps failed: %v", err)
+		}
+	}
+	if mock.queries != 2 {
+		t.Errorf("Expected locking queries not to be cached, got %d queries", mock.queries)
+	}
+
+	mock.err = errors.New("boom")
+	if _, err := QueryMaps(ctx, db, "SELECT * FROM users"); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if db.Len() != 0 {
+		t.Errorf("Expected failed queries not to be cached, got %d entries", db.Len())
+	}
+}
+
+func TestCachedDBKeysPointerArgsByValue(t *testing.T) {
+	ctx := context.Background()
+	mock := newCountingQueryer()
+	db := NewCachedDB(mock, CacheOpts{TTL: time.Minute})
+
+	minID := int64(0)
+	for _, v := range []int64{0, 1} {
+		minID = v
+		if _, err := QueryMaps(ctx, db, "SELECT * FROM users WHERE id > $1", &minID); err != nil {
+			t.Fatalf("QueryMaps failed: %v", err)
+		}
+	}
+	if mock.queries != 2 {
+		t.Errorf("Expected a changed pointer target to miss the cache, got %d queries", mock.queries)
+	}
+
+	other := int64(1)
+	if _, err := QueryMaps(ctx, db, "SELECT * FROM users WHERE id > $1", &other); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if _, err := QueryMaps(ctx, db, "SELECT * FROM users WHERE id > $1", pgtype.Int8{Int64: 1, Valid: true}); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if mock.queries != 2 {
+		t.Errorf("Expected equal values behind pointers and Valuers to hit the cache, got %d queries", mock.queries)
+	}
+
+	type filter struct{ IDs *[]int64 }
+	for i := 0; i < 2; i++ {
+		if _, err := QueryMaps(ctx, db, "SELECT * FROM users WHERE id = ANY($1)", filter{}); err != nil {
+			t.Fatalf("QueryMaps failed: %v", err)
+		}
+	}
+	if mock.queries != 4 || db.Len() != 2 {
+		t.Errorf("Expected args holding pointers to run uncached, got %d queries and %d entries", mock.queries, db.Len())
+	}
+}
+
+func TestCachedDBCachesWithQueries(t *testing.T) {
+	ctx := context.Background()
+	mock := newCountingQueryer()
+	db := NewCachedDB(mock, CacheOpts{TTL: time.Minute})
+
+	if _, err := QueryMaps(ctx, db, "SELECT * FROM countries"); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	const read = "WITH active AS (SELECT * FROM users WHERE active) SELECT * FROM active"
+	for i := 0; i < 2; i++ {
+		if _, err := QueryMaps(ctx, db, read); err != nil {
+			t.Fatalf("QueryMaps failed: %v", err)
+		}
+	}
+	if mock.queries != 2 || db.Len() != 2 {
+		t.Errorf("Expected the WITH query cached without invalidating, got %d queries and %d entries", mock.queries, db.Len())
+	}
+
+	if _, err := QueryMaps(ctx, db, "WITH gone AS (DELETE FROM users RETURNING id) SELECT * FROM gone"); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if db.Len() != 0 {
+		t.Errorf("Expected a data-modifying WITH query to invalidate the cache, got %d entries", db.Len())
+	}
+}
+
+func TestCachedDBInvalidation(t *testing.T) {
+	ctx := context.Background()
+	populate := func(t *testing.T, db *CachedDB) {
+		for _, sql := range []string{"SELECT * FROM users", "SELECT * FROM public.plans", "SELECT * FROM countries"} {
+			if _, err := QueryMaps(ctx, db, sql); err != nil {
+				t.Fatalf("QueryMaps failed: %v", err)
+			}
+		}
+	}
+
+	t.Run("all", func(t *testing.T) {
+		db := NewCachedDB(newCountingQueryer(), CacheOpts{TTL: time.Minute})
+		populate(t, db)
+		if _, err := Exec(ctx, db, "UPDATE users SET name = $1", "x"); err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		if db.Len() != 0 {
+			t.Errorf("Expected the cache to be cleared, got %d entries", db.Len())
+		}
+	})
+
+	t.Run("tables", func(t *testing.T) {
+		db := NewCachedDB(newCountingQueryer(), CacheOpts{TTL: time.Minute, Invalidate: InvalidateTables})
+		populate(t, db)
+		if _, err := Exec(ctx, db, `INSERT INTO public."Plans"(name) VALUES ($1)`, "x"); err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		if db.Len() != 2 {
+			t.Errorf("Expected only the plans entry to be dropped, got %d entries", db.Len())
+		}
+		if _, err := QueryMaps(ctx, db, "DELETE FROM ONLY users RETURNING id"); err != nil {
+			t.Fatalf("QueryMaps failed: %v", err)
+		}
+		if db.Len() != 1 {
+			t.Errorf("Expected a DELETE through Query to drop the users entry, got %d entries", db.Len())
+		}
+		if _, err := Exec(ctx, db, "CREATE INDEX ON countries (code)"); err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		if db.Len() != 0 {
+			t.Errorf("Expected DDL to clear the cache, got %d entries", db.Len())
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		db := NewCachedDB(newCountingQueryer(), CacheOpts{TTL: time.Minute, Invalidate: InvalidateNone})
+		populate(t, db)
+		if _, err := Exec(ctx, db, "TRUNCATE users"); err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		if db.Len() != 3 {
+			t.Errorf("Expected the cache to be kept, got %d entries", db.Len())
+		}
+		db.Invalidate()
+		if db.Len() != 0 {
+			t.Errorf("Expected Invalidate to clear the cache, got %d entries", db.Len())
+		}
+	})
+}
+
+func TestWrittenTable(t *testing.T) {
+	tests := map[string]string{
+		"INSERT INTO users (name) VALUES ($1)":     "users",
+		`insert into "Users"(name) values ($1)`:    "users",
+		"UPDATE ONLY app.accounts SET x = 1":       "accounts",
+		"DELETE FROM sessions WHERE expired":       "sessions",
+		"TRUNCATE TABLE audit_log;":                "audit_log",
+		"MERGE INTO stock USING deliveries ON ...": "stock",
+		"VACUUM users": "",
+	}
+	for sql, want := range tests {
+		if got := writtenTable(sql); got != want {
+			t.Errorf("writtenTable(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+type cacheRecordingCollector struct {
+	recordingCollector
+	mu      sync.Mutex
+	lookups []string
+}
+
+func (c *cacheRecordingCollector) ObserveCache(op string, hit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	c.lookups = append(c.lookups, op+" "+result)
+}
+
+func TestCachedDBObservesLookups(t *testing.T) {
+	collector := &cacheRecordingCollector{}
+	SetMetricsCollector(collector)
+	t.Cleanup(func() { SetMetricsCollector(nil) })
+
+	ctx := context.Background()
+	db := NewCachedDB(newCountingQueryer(), CacheOpts{TTL: time.Minute})
+	if _, err := QueryMaps(ctx, db, "SELECT * FROM plans"); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if _, err := QueryMaps(WithQueryName(ctx, "ListPlans"), db, "SELECT * FROM plans"); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+
+	expected := []string{"SELECT miss", "ListPlans hit"}
+	if len(collector.lookups) != len(expected) || collector.lookups[0] != expected[0] || collector.lookups[1] != expected[1] {
+		t.Errorf("Expected lookups %v, got %v", expected, collector.lookups)
+	}
+}
@@ -0,0 +1,143 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// CheckStruct compares the tagged fields of model, a struct or pointer to
+// one, with the columns of table as InspectTable reports them, and returns
+// every problem it finds joined into one error:
+//
+//   - a field whose column does not exist
+//   - a nullable column mapped to a field that cannot hold NULL, which would
+//     silently read NULL as the zero value
+//   - a column whose type cannot be read into the field, such as text into
+//     an int
+//
+// Types dbx cannot judge, such as fields implementing sql.Scanner or
+// Postgres types without an obvious Go counterpart, are accepted. table may
+// be schema-qualified; fields tagged with another table, as in join
+// structs, and fields of nested structs are skipped. Call it from a startup
+// self-test so schema drift fails loudly:
+//
+//	if err := dbx.CheckStruct(ctx, db, "users", User{}); err != nil {
+//		log.Fatal(err)
+//	}
+func CheckStruct(ctx context.Context, db DB, table string, model any) error {
+	t := reflect.TypeOf(model)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("model must be a struct or pointer to struct, got %T", model)
+	}
+
+	schema, name, ok := strings.Cut(table, ".")
+	if !ok {
+		schema, name = "", table
+	}
+	info, err := InspectTable(ctx, db, schema, name)
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]ColumnInfo, len(info.Columns))
+	for _, col := range info.Columns {
+		columns[col.Name] = col
+	}
+
+	meta := getStructMeta(t, defaults())
+	if meta.Err != nil {
+		return meta.Err
+	}
+
+	var errs []error
+	for _, f := range meta.Fields {
+		if f.Nested() || (f.HasTable() && !strings.EqualFold(tagTable(&f), name)) {
+			continue
+		}
+
+		col, ok := columns[f.Column]
+		if !ok {
+			errs = append(errs, fmt.Errorf("field %s: column %q does not exist in %s", f.Name, f.Column, table))
+			continue
+		}
+
+		fieldType := t.FieldByIndex(f.Index).Type
+		if f.Options.Contains("json") {
+			continue
+		}
+		if col.Nullable && !canHoldNull(fieldType) {
+			errs = append(errs, fmt.Errorf("field %s: column %q is nullable but the field is %s; use a pointer", f.Name, f.Column, fieldType))
+		}
+		if !typeFits(col.Type, fieldType) {
+			errs = append(errs, fmt.Errorf("field %s: column %q is %s, which cannot be read into %s", f.Name, f.Column, col.Type, fieldType))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s does not match table %s:\n%w", t, table, errors.Join(errs...))
+	}
+	return nil
+}
+
+// tagTable returns the unqualified table name of a field tagged in the
+// table.column form.
+func tagTable(f *fieldMeta) string {
+	table := strings.TrimSuffix(f.Tag, "."+f.Column)
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		table = table[i+1:]
+	}
+	return table
+}
+
+// canHoldNull reports whether a field of type t can represent NULL.
+func canHoldNull(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	}
+	return reflect.PointerTo(t).Implements(scannerType)
+}
+
+// typeFits reports whether a column of the Postgres type pgType, as
+// format_type prints it, can be read into a field of type t. It errs on the
+// side of accepting types it doesn't know.
+func typeFits(pgType string, t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface || reflect.PointerTo(t).Implements(scannerType) {
+		return true
+	}
+
+	kind := t.Kind()
+	isInt := kind >= reflect.Int && kind <= reflect.Uint64
+	isFloat := kind == reflect.Float32 || kind == reflect.Float64
+	isBytes := kind == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+
+	base, _, _ := strings.Cut(pgType, "(")
+	switch {
+	case strings.HasSuffix(pgType, "[]"):
+		return kind == reflect.Slice || kind == reflect.Array
+	case base == "boolean":
+		return kind == reflect.Bool
+	case base == "smallint" || base == "integer" || base == "bigint":
+		return isInt || isFloat
+	case base == "real" || base == "double precision":
+		return isFloat
+	case base == "numeric":
+		return isInt || isFloat || kind == reflect.String || kind == reflect.Struct
+	case base == "text" || base == "character varying" || base == "character" || base == "citext" || base == "name":
+		return kind == reflect.String || isBytes
+	case base == "uuid":
+		return kind == reflect.String || isBytes || isUUIDType(t)
+	case base == "bytea":
+		return isBytes || kind == reflect.String
+	case base == "date" || strings.HasPrefix(base, "timestamp"):
+		return t == timeType || kind == reflect.String
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package dbx
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// usersTable is a mock returning the catalog rows InspectTable reads for a
+// users table.
+func usersTable() *mockQueryer {
+	return &mockQueryer{
+		fields: []pgconn.FieldDescription{
+			{Name: "schema"}, {Name: "view"}, {Name: "name"}, {Name: "type"},
+			{Name: "nullable"}, {Name: "column_default"}, {Name: "primary_key"},
+		},
+		rows: []mockRow{
+			{values: []interface{}{"public", false, "id", "bigint", false, "", true}},
+			{values: []interface{}{"public", false, "name", "text", false, "", false}},
+			{values: []interface{}{"public", false, "bio", "text", true, "", false}},
+			{values: []interface{}{"public", false, "age", "text", false, "", false}},
+			{values: []interface{}{"public", false, "created_at", "timestamp with time zone", false, "now()", false}},
+			{values: []interface{}{"public", false, "born_on", "date", false, "", false}},
+		},
+	}
+}
+
+func TestCheckStruct(t *testing.T) {
+	type User struct {
+		ID        int64          `db:"users.id"`
+		Name      string         `db:"name"`
+		Bio       sql.NullString `db:"bio"`
+		Age       string         `db:"age"`
+		CreatedAt time.Time      `db:"created_at"`
+		BornOn    string         `db:"born_on"`
+		Team      string         `db:"teams.name"`
+	}
+
+	if err := CheckStruct(context.Background(), usersTable(), "public.users", &User{}); err != nil {
+		t.Errorf("CheckStruct failed: %v", err)
+	}
+
+	// Times are read into strings as RFC 3339, and dates as YYYY-MM-DD
+	type UserText struct {
+		CreatedAt string  `db:"created_at"`
+		BornOn    *string `db:"born_on"`
+	}
+	if err := CheckStruct(context.Background(), usersTable(), "users", UserText{}); err != nil {
+		t.Errorf("CheckStruct failed for string time fields: %v", err)
+	}
+}
+
+func TestCheckStructMismatches(t *testing.T) {
+	type User struct {
+		ID    int64  `db:"id"`
+		Name  string `db:"nmae"`
+		Bio   string `db:"bio"`
+		Age   int    `db:"age"`
+		Born  int    `db:"born_on"`
+		Extra string `db:"-"`
+	}
+
+	err := CheckStruct(context.Background(), usersTable(), "users", User{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		`field Name: column "nmae" does not exist in users`,
+		`field Bio: column "bio" is nullable but the field is string`,
+		`field Age: column "age" is text, which cannot be read into int`,
+		`field Born: column "born_on" is date, which cannot be read into int`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "field ID") {
+		t.Errorf("unexpected problem with ID:\n%v", err)
+	}
+}
@@ -0,0 +1,72 @@
+// Command dbxgen writes a Go file with a dbx-tagged struct for each of the
+// named tables, using dbx.GenerateStruct. It is meant for go:generate:
+//
+//	//go:generate go run github.com/JoeFinlinson/dbx/cmd/dbxgen -package models users invoice line_item
+//
+// The database is given by -dsn or, failing that, the DATABASE_URL
+// environment variable. Each table is written to <table>.go in -out.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JoeFinlinson/dbx"
+	"github.com/jackc/pgx/v5"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("dbxgen: ")
+
+	dsn := flag.String("dsn", os.Getenv("DATABASE_URL"), "PostgreSQL connection string (default $DATABASE_URL)")
+	schema := flag.String("schema", "", "schema of the tables (default the connection's current schema)")
+	pkg := flag.String("package", "", "package name of the generated files (default the output directory's name)")
+	out := flag.String("out", ".", "directory to write the generated files to")
+	numericString := flag.Bool("numeric-string", false, "map numeric columns to string instead of float64")
+	bare := flag.Bool("bare", false, "tag fields with the column alone instead of table.column")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: dbxgen [flags] table...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *dsn == "" {
+		log.Fatal("no database given; set -dsn or DATABASE_URL")
+	}
+	if *pkg == "" {
+		abs, err := filepath.Abs(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*pkg = strings.ReplaceAll(filepath.Base(abs), "-", "_")
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, *dsn)
+	if err != nil {
+		log.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	opts := dbx.GenOpts{Package: *pkg, NumericAsString: *numericString, BareColumns: *bare}
+	for _, table := range flag.Args() {
+		src, err := dbx.GenerateStruct(ctx, conn, *schema, table, opts)
+		if err != nil {
+			log.Fatalf("%s: %v", table, err)
+		}
+		path := filepath.Join(*out, table+".go")
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
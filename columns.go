@@ -0,0 +1,181 @@
+package dbx
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// duplicateColumns returns the names that occur more than once in names, in
+// order of first appearance. Empty names are ignored.
+func duplicateColumns(names []string) []string {
+	seen := make(map[string]int, len(names))
+	var dups []string
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if seen[name]++; seen[name] == 2 {
+			dups = append(dups, name)
+		}
+	}
+	return dups
+}
+
+// checkColumns fails if opts asks for StrictColumns and names holds
+// duplicates.
+func checkColumns(names []string, opts *options) error {
+	if !opts.strictColumns {
+		return nil
+	}
+	dups := duplicateColumns(names)
+	if len(dups) == 0 {
+		return nil
+	}
+
+	quoted := make([]string, len(dups))
+	for i, name := range dups {
+		quoted[i] = strconv.Quote(name)
+	}
+	return fmt.Errorf("duplicate column names in result: %s", strings.Join(quoted, ", "))
+}
+
+// checkAllFields fails if a field of meta, other than a nested struct or
+// one tagged optional, has no column in fieldMap. The error lists the
+// missing tags and the columns of the result.
+func checkAllFields(meta *structMeta, fieldMap map[int]int, names []string) error {
+	missing := missingFields(meta, fieldMap)
+	if len(missing) == 0 {
+		return nil
+	}
+	for i, tag := range missing {
+		missing[i] = strconv.Quote(tag)
+	}
+
+	var columns []string
+	for _, name := range names {
+		if name != "" {
+			columns = append(columns, strconv.Quote(name))
+		}
+	}
+	return fmt.Errorf("no column for fields tagged %s; result has columns %s",
+		strings.Join(missing, ", "), strings.Join(columns, ", "))
+}
+
+// missingFields returns the tags of the fields of meta that have no column
+// in fieldMap, leaving out nested structs and fields tagged optional.
+func missingFields(meta *structMeta, fieldMap map[int]int) []string {
+	mapped := make(map[int]bool, len(fieldMap))
+	for _, i := range fieldMap {
+		mapped[i] = true
+	}
+
+	containers := make(map[string]bool, len(meta.Groups))
+	for _, g := range meta.Groups {
+		containers[fmt.Sprint(g.Index)] = true
+	}
+
+	var missing []string
+	for i, f := range meta.Fields {
+		if mapped[i] || f.Options.Contains("optional") || containers[fmt.Sprint(f.Index)] {
+			continue
+		}
+		missing = append(missing, f.Tag)
+	}
+	return missing
+}
+
+// uniqueColumns returns names with each repeat of a name suffixed _2, _3,
+// and so on, skipping suffixed names that are already columns, so that no
+// value is lost when the names key a map. names is returned as is when it
+// holds no duplicates.
+func uniqueColumns(names []string) []string {
+	if len(duplicateColumns(names)) == 0 {
+		return names
+	}
+
+	taken := make(map[string]bool, len(names))
+	for _, name := range names {
+		taken[name] = true
+	}
+
+	unique := make([]string, len(names))
+	count := make(map[string]int, len(names))
+	for i, name := range names {
+		count[name]++
+		if name == "" || count[name] == 1 {
+			unique[i] = name
+			continue
+		}
+		for n := count[name]; ; n++ {
+			candidate := name + "_" + strconv.Itoa(n)
+			if !taken[candidate] {
+				taken[candidate] = true
+				unique[i] = candidate
+				count[name] = n
+				break
+			}
+		}
+	}
+	return unique
+}
+
+// ColumnsOf returns the select list for struct type T with its columns
+// qualified by tableAlias, and aliased to their tags where those differ
+// from the column, so that the result maps back onto T. For
+//
+//	type Invoice struct {
+//		ID     int    `db:"invoice.id"`
+//		Amount string `db:"invoice.amount"`
+//		Status string `db:"status"`
+//	}
+//
+// ColumnsOf[Invoice]("i") returns
+//
+//	"i"."id" AS "invoice.id", "i"."amount" AS "invoice.amount", "i"."status"
+//
+// Fields of nested structs are qualified by their prefix rather than by
+// tableAlias, as they come from another table, and rest and group fields
+// are left out. With an empty tableAlias, table.column tags are qualified
+// by their own table. Options such as TagName change the tags read.
+// ColumnsOf panics if T is not a struct or its tags are invalid.
+func ColumnsOf[T any](tableAlias string, opts ...Option) string {
+	o, _ := splitArgs(withOptions(nil, opts))
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("dbx.ColumnsOf: %s is not a struct", typ))
+	}
+	meta := getStructMeta(typ, o)
+	if meta.Err != nil {
+		panic("dbx.ColumnsOf: " + meta.Err.Error())
+	}
+	return strings.Join(selectList(meta, tableAlias), ", ")
+}
+
+// selectList returns a select list entry for each field of meta that takes
+// a column, as described for ColumnsOf.
+func selectList(meta *structMeta, alias string) []string {
+	containers := make(map[string]bool, len(meta.Groups))
+	for _, g := range meta.Groups {
+		containers[fmt.Sprint(g.Index)] = true
+	}
+
+	var list []string
+	for _, f := range meta.Fields {
+		if containers[fmt.Sprint(f.Index)] {
+			continue
+		}
+		switch {
+		case f.Nested() || (alias == "" && f.HasTable()):
+			list = append(list, Ident(strings.Split(f.Tag, ".")...)+" AS "+quoteIdent(f.Tag))
+		case alias == "":
+			list = append(list, quoteIdent(f.Tag))
+		case f.HasTable():
+			list = append(list, Ident(alias, f.Column)+" AS "+quoteIdent(f.Tag))
+		default:
+			list = append(list, Ident(alias, f.Tag))
+		}
+	}
+	return list
+}
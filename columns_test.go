@@ -0,0 +1,188 @@
+package dbx
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUniqueColumns(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  []string
+	}{
+		{[]string{"id", "name"}, []string{"id", "name"}},
+		{[]string{"id", "id", "id"}, []string{"id", "id_2", "id_3"}},
+		{[]string{"id", "id", "id_2"}, []string{"id", "id_3", "id_2"}},
+		{[]string{"", "", "id"}, []string{"", "", "id"}},
+	}
+
+	for _, tt := range tests {
+		if got := uniqueColumns(tt.names); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("uniqueColumns(%q) = %q, want %q", tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestDuplicateColumnsQueryMaps(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "name", "id"),
+		rows:   []mockRow{{values: []interface{}{1, "Acme", 2}}},
+	}
+
+	rows, err := QueryMaps(ctx, mock, "SELECT a.id, a.name, b.id FROM a JOIN b ON ...")
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	expected := []RowMap{{"id": 1, "name": "Acme", "id_2": 2}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected %v, got %v", expected, rows)
+	}
+
+	data, err := QueryJSON(ctx, mock, "SELECT a.id, a.name, b.id FROM a JOIN b ON ...")
+	if err != nil {
+		t.Fatalf("QueryJSON failed: %v", err)
+	}
+	if want := `[{"id":1,"name":"Acme","id_2":2}]`; string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+}
+
+func TestDuplicateColumnsStrict(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "name", "id", "name", "email"),
+		rows:   []mockRow{{values: []interface{}{1, "a", 2, "b", "c"}}},
+	}
+
+	_, err := QueryMaps(ctx, mock, "SELECT ...", StrictColumns())
+	if err == nil || !strings.Contains(err.Error(), `"id", "name"`) {
+		t.Errorf("Expected duplicate column error, got %v", err)
+	}
+
+	type Row struct {
+		ID int `db:"id"`
+	}
+	var rows []Row
+	if err := QueryStructs(ctx, mock, "SELECT ...", &rows, StrictColumns()); err == nil || !strings.Contains(err.Error(), "duplicate column") {
+		t.Errorf("Expected duplicate column error, got %v", err)
+	}
+	if _, err := QueryJSON(ctx, mock, "SELECT ...", StrictColumns()); err == nil {
+		t.Error("Expected duplicate column error from QueryJSON")
+	}
+}
+
+func TestDuplicateColumnsQueryStructs(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "id"),
+		rows:   []mockRow{{values: []interface{}{1, 2}}},
+	}
+
+	type Row struct {
+		InvoiceID  int `db:"invoice.id"`
+		CustomerID int `db:"customer.id"`
+	}
+
+	var rows []Row
+	if err := QueryStructs(ctx, mock, "SELECT invoice.id, customer.id FROM invoice JOIN customer ON ...", &rows); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	expected := []Row{{InvoiceID: 1, CustomerID: 2}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, rows)
+	}
+}
+
+func TestRequireAllFields(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "emial"),
+		rows:   []mockRow{{values: []interface{}{1, "a@example.com"}}},
+	}
+
+	type User struct {
+		ID       int    `db:"users.id"`
+		Email    string `db:"email"`
+		Nickname string `db:"nickname,optional"`
+	}
+
+	var users []User
+	if err := QueryStructs(ctx, mock, "SELECT id, email AS emial FROM users", &users); err != nil {
+		t.Fatalf("QueryStructs without the option failed: %v", err)
+	}
+
+	err := QueryStructs(ctx, mock, "SELECT id, email AS emial FROM users", &users, RequireAllFields())
+	if err == nil {
+		t.Fatal("Expected error for the unmatched field")
+	}
+	if msg := err.Error(); !strings.Contains(msg, `tagged "email"`) || !strings.Contains(msg, `"id", "emial"`) || strings.Contains(msg, "nickname") {
+		t.Errorf("Expected error listing the missing tag and the columns, got %v", err)
+	}
+
+	type Customer struct {
+		ID    int    `db:"id"`
+		Email string `db:"email"`
+	}
+	type Row struct {
+		ID       int       `db:"id"`
+		Customer *Customer `db:"customer"`
+	}
+	mock.fields = mockFields("id", "customer.id", "customer.email")
+	mock.rows = []mockRow{{values: []interface{}{1, 2, "b@example.com"}}}
+	var rows []Row
+	if err := QueryStructs(ctx, mock, "SELECT ...", &rows, RequireAllFields()); err != nil {
+		t.Errorf("Expected nested struct fields to be satisfied by their columns, got %v", err)
+	}
+}
+
+func TestColumnsOf(t *testing.T) {
+	type Customer struct {
+		Email string `db:"email"`
+	}
+	type Invoice struct {
+		ID       int       `db:"invoice.id"`
+		Amount   string    `db:"invoice.amount"`
+		Status   string    `db:"status"`
+		Customer *Customer `db:"customer"`
+		Extra    RowMap    `db:",rest"`
+	}
+
+	want := `"i"."id" AS "invoice.id", "i"."amount" AS "invoice.amount", "i"."status", "customer"."email" AS "customer.email"`
+	if got := ColumnsOf[Invoice]("i"); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+	want = `"invoice"."id" AS "invoice.id", "invoice"."amount" AS "invoice.amount", "status", "customer"."email" AS "customer.email"`
+	if got := ColumnsOf[Invoice](""); got != want {
+		t.Errorf("Expected %s without an alias, got %s", want, got)
+	}
+
+	type Odd struct {
+		Name string `json:"we\"ird"`
+	}
+	if got, want := ColumnsOf[Odd](`t"`, TagName("json")), `"t"""."we""ird"`; got != want {
+		t.Errorf("Expected quoted identifiers %s, got %s", want, got)
+	}
+
+	// The list maps back onto the struct
+	mock := &mockQueryer{
+		fields: mockFields("invoice.id", "invoice.amount", "status", "customer.email"),
+		rows:   []mockRow{{values: []interface{}{1, "9.99", "paid", "a@example.com"}}},
+	}
+	var invoices []Invoice
+	if err := QueryStructs(context.Background(), mock, "SELECT "+ColumnsOf[Invoice]("i")+" FROM invoice i", &invoices, RequireAllFields()); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if inv := invoices[0]; inv.ID != 1 || inv.Status != "paid" || inv.Customer == nil || inv.Customer.Email != "a@example.com" {
+		t.Errorf("Unexpected invoice: %+v", inv)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected ColumnsOf to panic for a non-struct type")
+		}
+	}()
+	ColumnsOf[int]("i")
+}
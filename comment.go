@@ -0,0 +1,87 @@
+package dbx
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+)
+
+// CommentTag is a key=value pair appended to every statement dbx sends, so
+// that entries in pg_stat_activity and the server log can be traced back
+// to the request that ran them. Value is called with the statement's
+// context; an empty result leaves the tag out.
+type CommentTag struct {
+	Key   string
+	Value func(ctx context.Context) string
+}
+
+var currentCommentTags atomic.Pointer[[]CommentTag]
+
+// SetCommentTags makes dbx append a comment built from tags to each
+// statement it runs, replacing any tags set previously:
+//
+//	dbx.SetCommentTags(
+//		dbx.CommentTag{Key: "app", Value: func(context.Context) string { return "billing" }},
+//		dbx.CommentTag{Key: "trace_id", Value: traceID},
+//	)
+//
+// turns SELECT 1 into
+//
+//	SELECT 1
+//	/* app=billing, trace_id=abc123 */
+//
+// Calling it with no tags turns comments off. Statements run with the
+// Prepared option are sent by name and get no comment. Values that vary per
+// request make each statement's text unique, so pgx's statement cache no
+// longer helps with them.
+func SetCommentTags(tags ...CommentTag) {
+	if len(tags) == 0 {
+		currentCommentTags.Store(nil)
+		return
+	}
+	tags = append([]CommentTag(nil), tags...)
+	currentCommentTags.Store(&tags)
+}
+
+// commentSQL appends the comment built from the comment tags, if any, to
+// sql.
+func commentSQL(ctx context.Context, sql string) string {
+	tags := currentCommentTags.Load()
+	if tags == nil {
+		return sql
+	}
+
+	var b strings.Builder
+	for _, tag := range *tags {
+		value := tag.Value(ctx)
+		if value == "" {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString(sql)
+			b.WriteString("\n/* ")
+		} else {
+			b.WriteString(", ")
+		}
+		b.WriteString(escapeComment(tag.Key))
+		b.WriteByte('=')
+		b.WriteString(escapeComment(value))
+	}
+	if b.Len() == 0 {
+		return sql
+	}
+	b.WriteString(" */")
+	return b.String()
+}
+
+// escapeComment makes s safe to place inside a block comment by breaking
+// up the sequences that would end the comment early or, since Postgres
+// nests block comments, open one that never ends. Breaking one can form
+// another, as in "*/*", so it repeats until none are left.
+func escapeComment(s string) string {
+	for strings.Contains(s, "*/") || strings.Contains(s, "/*") {
+		s = strings.ReplaceAll(s, "*/", "* /")
+		s = strings.ReplaceAll(s, "/*", "/ *")
+	}
+	return s
+}
@@ -0,0 +1,75 @@
+package dbx
+
+import (
+	"context"
+	"testing"
+)
+
+type traceKey struct{}
+
+func useCommentTags(t *testing.T, tags ...CommentTag) {
+	t.Helper()
+	SetCommentTags(tags...)
+	t.Cleanup(func() { SetCommentTags() })
+}
+
+func TestCommentTags(t *testing.T) {
+	useCommentTags(t,
+		CommentTag{Key: "app", Value: func(context.Context) string { return "billing" }},
+		CommentTag{Key: "trace_id", Value: func(ctx context.Context) string {
+			id, _ := ctx.Value(traceKey{}).(string)
+			return id
+		}},
+	)
+
+	mock := &mockQueryer{}
+	ctx := context.WithValue(context.Background(), traceKey{}, "abc123")
+	if _, err := Exec(ctx, mock, "DELETE FROM users"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if want := "DELETE FROM users\n/* app=billing, trace_id=abc123 */"; mock.lastSQL != want {
+		t.Errorf("SQL = %q, want %q", mock.lastSQL, want)
+	}
+
+	if _, err := QueryMaps(context.Background(), mock, "SELECT 1"); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if want := "SELECT 1\n/* app=billing */"; mock.lastSQL != want {
+		t.Errorf("SQL = %q, want %q", mock.lastSQL, want)
+	}
+
+	preparer := &mockPreparer{}
+	if _, err := Exec(ctx, preparer, "DELETE FROM users", Prepared("delete_users")); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if preparer.lastSQL != "delete_users" || preparer.prepared["delete_users"] != "DELETE FROM users" {
+		t.Errorf("prepared statement got a comment: sent %q, prepared %q", preparer.lastSQL, preparer.prepared["delete_users"])
+	}
+}
+
+func TestCommentTagsEmpty(t *testing.T) {
+	useCommentTags(t, CommentTag{Key: "trace_id", Value: func(context.Context) string { return "" }})
+
+	mock := &mockQueryer{}
+	if _, err := Exec(context.Background(), mock, "DELETE FROM users"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if mock.lastSQL != "DELETE FROM users" {
+		t.Errorf("SQL = %q, want no comment", mock.lastSQL)
+	}
+}
+
+func TestEscapeComment(t *testing.T) {
+	tests := map[string]string{
+		"abc":         "abc",
+		"a*/b":        "a* /b",
+		"a/*b":        "a/ *b",
+		"*/*":         "* / *",
+		"x*/; DROP t": "x* /; DROP t",
+	}
+	for in, want := range tests {
+		if got := escapeComment(in); got != want {
+			t.Errorf("escapeComment(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
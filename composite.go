@@ -0,0 +1,102 @@
+package dbx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// isCompositeTarget reports whether a map[string]any value should be mapped
+// into a field of type t member by member, as pgx decodes a registered
+// composite type, rather than decoded as a JSON object. Values from json
+// and jsonb columns never get here from struct mapping, which sends them to
+// assignJSON by their column type; see isDocumentTarget. For values whose
+// column type isn't known, as in MapToStruct, structs whose fields carry
+// json tags and no db tags are left to JSON, since their names follow the
+// document rather than the type.
+func isCompositeTarget(t reflect.Type, opts *options) bool {
+	if t.Kind() != reflect.Struct || t == timeType || t == addrType || t == prefixType {
+		return false
+	}
+	var jsonTagged bool
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag
+		if _, ok := tag.Lookup(opts.tagName); ok {
+			return true
+		}
+		if _, ok := tag.Lookup("json"); ok {
+			jsonTagged = true
+		}
+	}
+	return !jsonTagged
+}
+
+// isJSONColumn reports whether oid is the type of a json or jsonb column,
+// or an array of either.
+func isJSONColumn(oid uint32) bool {
+	switch oid {
+	case pgtype.JSONOID, pgtype.JSONBOID, pgtype.JSONArrayOID, pgtype.JSONBArrayOID:
+		return true
+	}
+	return false
+}
+
+// isDocumentTarget reports whether a value from a json or jsonb column is
+// decoded into a field of type t as a JSON document, by the json tags of
+// its structs, rather than converted by assignValue: t is a struct or a
+// slice of structs, or a pointer to either, that doesn't scan itself.
+func isDocumentTarget(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+	return t.Kind() == reflect.Struct && !reflect.PointerTo(t).Implements(scannerType)
+}
+
+// assignComposite sets struct field from the members of a composite value.
+// Each member goes to the field tagged with its name or, failing that, the
+// untagged exported field of the same name ignoring case, and is converted
+// with assignValue, so nested composites fill nested structs. Members with
+// no field are ignored and fields with no member keep their zero value.
+func assignComposite(field reflect.Value, members map[string]any, opts *options) error {
+	t := field.Type()
+	meta := getStructMeta(t, opts)
+	if meta.Err != nil {
+		return meta.Err
+	}
+
+	byColumn := make(map[string]*fieldMeta, len(meta.Fields))
+	for i := range meta.Fields {
+		if f := &meta.Fields[i]; !f.Nested() {
+			byColumn[f.Column] = f
+		}
+	}
+
+	v := reflect.New(t).Elem()
+	for name, member := range members {
+		var target reflect.Value
+		if f, ok := byColumn[name]; ok {
+			target = f.settable(v)
+		} else if sf, ok := t.FieldByNameFunc(func(s string) bool { return strings.EqualFold(s, name) }); ok &&
+			sf.IsExported() && sf.Tag.Get(opts.tagName) == "" {
+			target = fieldByIndexAlloc(v, sf.Index)
+		} else {
+			continue
+		}
+		if err := assignValue(target, member, opts); err != nil {
+			if err == errNotConvertible {
+				return fmt.Errorf("member %q: cannot convert %T to %s", name, member, target.Type())
+			}
+			return fmt.Errorf("member %q: %w", name, err)
+		}
+	}
+	field.Set(v)
+	return nil
+}
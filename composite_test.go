@@ -0,0 +1,177 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type compositeGeo struct {
+	Lat float64 `db:"lat"`
+	Lng float64 `db:"lng"`
+}
+
+type compositeAddress struct {
+	Street string        `db:"street"`
+	City   string        `db:"city"`
+	Zip    *string       `db:"zip"`
+	Geo    *compositeGeo `db:"geo"`
+}
+
+func TestQueryStructsComposite(t *testing.T) {
+	type Customer struct {
+		ID       int64              `db:"id"`
+		Address  compositeAddress   `db:"address"`
+		Billing  *compositeAddress  `db:"billing"`
+		Previous []compositeAddress `db:"previous"`
+	}
+	mock := &mockQueryer{
+		fields: mockFields("id", "address", "billing", "previous"),
+		rows: []mockRow{
+			{values: []interface{}{
+				int64(1),
+				map[string]any{"street": "1 Main St", "city": "Springfield", "zip": "12345", "geo": map[string]any{"lat": 39.8, "lng": -89.6}},
+				map[string]any{"street": "PO Box 7", "city": "Springfield", "zip": nil, "geo": nil},
+				[]any{map[string]any{"street": "9 Elm St", "city": "Shelbyville"}},
+			}},
+			{values: []interface{}{int64(2), nil, nil, nil}},
+		},
+	}
+
+	var customers []Customer
+	if err := QueryStructs(context.Background(), mock, "SELECT * FROM customers", &customers); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	got := customers[0]
+	if got.Address.Street != "1 Main St" || got.Address.City != "Springfield" || got.Address.Zip == nil || *got.Address.Zip != "12345" {
+		t.Errorf("Unexpected address: %+v", got.Address)
+	}
+	if got.Address.Geo == nil || *got.Address.Geo != (compositeGeo{Lat: 39.8, Lng: -89.6}) {
+		t.Errorf("Expected the nested composite in Geo, got %+v", got.Address.Geo)
+	}
+	if got.Billing == nil || got.Billing.Street != "PO Box 7" || got.Billing.Zip != nil || got.Billing.Geo != nil {
+		t.Errorf("Unexpected billing address: %+v", got.Billing)
+	}
+	if len(got.Previous) != 1 || got.Previous[0].City != "Shelbyville" {
+		t.Errorf("Unexpected previous addresses: %+v", got.Previous)
+	}
+	if customers[1].Address != (compositeAddress{}) || customers[1].Billing != nil || customers[1].Previous != nil {
+		t.Errorf("Expected NULLs to give zero values, got %+v", customers[1])
+	}
+}
+
+func TestAssignCompositeMembers(t *testing.T) {
+	type Period struct {
+		Label    string
+		StartsAt time.Time `db:"starts_at"`
+		Amount   string    `db:"amount"`
+		Skipped  string    `db:"-"`
+	}
+	type Event struct {
+		Period Period `db:"period"`
+	}
+	starts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock := &mockQueryer{
+		fields: mockFields("period"),
+		rows: []mockRow{{values: []interface{}{map[string]any{
+			"label":     "Q1",
+			"starts_at": starts,
+			"amount":    numeric(1050, -2),
+			"skipped":   "ignored",
+			"extra":     true,
+		}}}},
+	}
+
+	var events []Event
+	if err := QueryStructs(context.Background(), mock, "SELECT period FROM events", &events); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	want := Period{Label: "Q1", StartsAt: starts, Amount: "10.50"}
+	if events[0].Period != want {
+		t.Errorf("Expected %+v, got %+v", want, events[0].Period)
+	}
+}
+
+func TestAssignCompositeError(t *testing.T) {
+	type Customer struct {
+		Address compositeAddress `db:"address"`
+	}
+	mock := &mockQueryer{
+		fields: mockFields("address"),
+		rows:   []mockRow{{values: []interface{}{map[string]any{"street": "1 Main St", "geo": map[string]any{"lat": "north"}}}}},
+	}
+
+	var customers []Customer
+	var convErr *ConversionError
+	err := QueryStructs(context.Background(), mock, "SELECT address FROM customers", &customers)
+	if !errors.As(err, &convErr) || convErr.Field != "Address" || !strings.Contains(err.Error(), `member "geo": member "lat"`) {
+		t.Errorf("Expected a ConversionError naming the member, got %v", err)
+	}
+}
+
+func TestAssignCompositeLeavesJSONStructs(t *testing.T) {
+	type Settings struct {
+		DarkMode bool `json:"dark_mode"`
+	}
+	type User struct {
+		Settings Settings `db:"settings"`
+	}
+	mock := &mockQueryer{
+		fields: mockFields("settings"),
+		rows:   []mockRow{{values: []interface{}{map[string]any{"dark_mode": true}}}},
+	}
+
+	var users []User
+	if err := QueryStructs(context.Background(), mock, "SELECT settings FROM users", &users); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if !users[0].Settings.DarkMode {
+		t.Errorf("Expected the jsonb document decoded by its json tags, got %+v", users[0].Settings)
+	}
+}
+
+func TestQueryStructsJSONColumnNotComposite(t *testing.T) {
+	type Profile struct {
+		DisplayName string `db:"display_name" json:"displayName"`
+		Age         int    `db:"age" json:"age"`
+	}
+	type User struct {
+		Profile  Profile   `db:"profile"`
+		History  []Profile `db:"history"`
+		Previous *Profile  `db:"previous"`
+		Address  Profile   `db:"address"`
+	}
+	fields := mockFields("profile", "history", "previous", "address")
+	fields[0].DataTypeOID = pgtype.JSONBOID
+	fields[1].DataTypeOID = pgtype.JSONBOID
+	fields[2].DataTypeOID = pgtype.JSONOID
+	fields[3].DataTypeOID = 16500 // a registered composite type
+	mock := &mockQueryer{
+		fields: fields,
+		rows: []mockRow{{values: []interface{}{
+			map[string]any{"displayName": "Alice", "age": float64(30)},
+			[]any{map[string]any{"displayName": "Al"}},
+			map[string]any{"displayName": "Ally"},
+			map[string]any{"display_name": "Main St", "age": int32(1)},
+		}}},
+	}
+
+	var users []User
+	if err := QueryStructs(context.Background(), mock, "SELECT * FROM users", &users); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	got := users[0]
+	if got.Profile != (Profile{DisplayName: "Alice", Age: 30}) {
+		t.Errorf("Expected the jsonb document decoded by its json tags, got %+v", got.Profile)
+	}
+	if len(got.History) != 1 || got.History[0].DisplayName != "Al" || got.Previous == nil || got.Previous.DisplayName != "Ally" {
+		t.Errorf("Unexpected history and previous: %+v, %+v", got.History, got.Previous)
+	}
+	if got.Address != (Profile{DisplayName: "Main St", Age: 1}) {
+		t.Errorf("Expected the composite mapped by its db tags, got %+v", got.Address)
+	}
+}
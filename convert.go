@@ -0,0 +1,357 @@
+package dbx
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// errNotConvertible is returned by assignValue when a value cannot be
+// represented in the target field.
+var errNotConvertible = errors.New("value not convertible")
+
+// assignValue sets field from a database value. Fields implementing
+// sql.Scanner (such as sql.NullString) are populated through Scan, including
+// for NULL. Otherwise NULL resets the field to its zero value, which is nil
+// for pointer fields, and pointer fields are allocated with the value
+// assigned to the pointed-to value using the same rules. Array values are
+// converted element by element into slice fields, composite values are
+// mapped into struct fields member by member, and JSON objects and
+// documents are decoded into struct, map, and slice fields. Intervals,
+// network addresses, composites, and ranges are converted as described for
+// assignInterval, assignNetwork, assignComposite, and Range.
+func assignValue(field reflect.Value, value any, opts *options) error {
+	val := reflect.ValueOf(value)
+
+	if n, ok := value.(pgtype.Numeric); ok && field.Type() != val.Type() {
+		if _, registered := numericTypes.Load(field.Type()); registered {
+			return assignNumeric(field, n, opts)
+		}
+	}
+
+	if u, ok := value.(pgtype.UUID); ok && field.Type() != val.Type() {
+		if !u.Valid {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		value, val = u.Bytes, reflect.ValueOf(u.Bytes)
+	}
+
+	// Handled ahead of sql.Scanner because uuid types such as uuid.UUID
+	// implement it but do not accept [16]byte
+	if u, ok := uuidValue(val); ok && isUUIDType(field.Type()) {
+		field.Set(reflect.ValueOf(u).Convert(field.Type()))
+		return nil
+	}
+
+	if u, ok := uuidValue(val); ok && field.Kind() == reflect.String {
+		field.SetString(formatUUID(u))
+		return nil
+	}
+
+	if s, ok := value.(string); ok && isUUIDType(field.Type()) {
+		u, err := parseUUID(s)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(u).Convert(field.Type()))
+		return nil
+	}
+
+	if field.Kind() != reflect.Pointer && field.CanAddr() && field.Addr().Type().Implements(scannerType) {
+		if val.IsValid() && val.Type().AssignableTo(field.Type()) {
+			field.Set(val)
+			return nil
+		}
+		// Hand numerics to scanners as exact decimal text, which decimal
+		// types understand
+		if n, ok := value.(pgtype.Numeric); ok && n.Valid {
+			text, err := numericText(n)
+			if err != nil {
+				return err
+			}
+			value = text
+		}
+		return field.Addr().Interface().(sql.Scanner).Scan(value)
+	}
+
+	if !val.IsValid() || (val.Kind() == reflect.Ptr && val.IsNil()) {
+		// Set zero value for the field if DB value is NULL
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	if field.Kind() == reflect.Pointer {
+		if val.Type().AssignableTo(field.Type()) {
+			field.Set(val)
+			return nil
+		}
+		ptr := reflect.New(field.Type().Elem())
+		if err := assignValue(ptr.Elem(), value, opts); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	if n, ok := value.(pgtype.Numeric); ok && field.Type() != val.Type() {
+		return assignNumeric(field, n, opts)
+	}
+
+	if handled, err := assignNetwork(field, value); handled {
+		return err
+	}
+
+	if r, ok := value.(pgtype.Range[any]); ok {
+		if handled, err := assignRange(field, r, opts); handled {
+			return err
+		}
+	}
+
+	if iv, ok := value.(pgtype.Interval); ok && field.Type() != val.Type() {
+		if !iv.Valid {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		return assignInterval(field, iv)
+	}
+
+	if field.Type() == timeType {
+		t, ok, err := toTime(value)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errNotConvertible
+		}
+		if opts.timesInUTC {
+			t = t.UTC()
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	if t, ok := value.(time.Time); ok && field.Kind() == reflect.String {
+		if opts.timesInUTC {
+			t = t.UTC()
+		}
+		field.SetString(t.Format(time.RFC3339Nano))
+		return nil
+	}
+
+	if val.Type().AssignableTo(field.Type()) {
+		field.Set(val)
+		return nil
+	}
+
+	if m, ok := value.(map[string]any); ok && isCompositeTarget(field.Type(), opts) {
+		return assignComposite(field, m, opts)
+	}
+
+	if isJSONTarget(field.Type()) {
+		switch value.(type) {
+		case map[string]any, []byte, string:
+			return assignJSON(field, value)
+		}
+	}
+
+	if isArrayField(field.Type()) && val.Kind() == reflect.Slice {
+		return assignSlice(field, val, opts)
+	}
+
+	// Go allows converting integers to strings, but the result is a rune,
+	// not the decimal text, so treat it as incompatible.
+	if field.Kind() == reflect.String && isIntegerKind(val.Kind()) {
+		return errNotConvertible
+	}
+
+	// Named string and integer types, as used for enums, take text and
+	// integers however the driver wraps them
+	if field.Kind() == reflect.String {
+		if text, ok, valid := textValue(value); ok {
+			if !valid {
+				field.Set(reflect.Zero(field.Type()))
+				return nil
+			}
+			field.SetString(text)
+			return nil
+		}
+	}
+	if isIntegerKind(field.Kind()) {
+		if i, ok, valid := integerValue(val); ok {
+			if !valid {
+				field.Set(reflect.Zero(field.Type()))
+				return nil
+			}
+			return assignInteger(field, i)
+		}
+	}
+
+	if val.Type().ConvertibleTo(field.Type()) {
+		field.Set(val.Convert(field.Type()))
+		return nil
+	}
+
+	return errNotConvertible
+}
+
+// timeLayouts are the string formats accepted for time.Time fields, tried in order.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	time.DateOnly,
+}
+
+// toTime converts a database value to a time.Time. It accepts time.Time,
+// pgtype timestamp and date values, and strings in RFC 3339, Postgres
+// timestamp, or date-only form. ok is false for unsupported types.
+func toTime(value any) (t time.Time, ok bool, err error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true, nil
+	case pgtype.Timestamptz:
+		return v.Time, true, finiteTime(v.Valid, v.InfinityModifier)
+	case pgtype.Timestamp:
+		return v.Time, true, finiteTime(v.Valid, v.InfinityModifier)
+	case pgtype.Date:
+		return v.Time, true, finiteTime(v.Valid, v.InfinityModifier)
+	case string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true, nil
+			}
+		}
+		return time.Time{}, true, fmt.Errorf("cannot parse %q as a time", v)
+	}
+	return time.Time{}, false, nil
+}
+
+// finiteTime reports an error for pgtype time values that cannot be
+// represented as a time.Time. Invalid (NULL) values map to the zero time.
+func finiteTime(valid bool, modifier pgtype.InfinityModifier) error {
+	if valid && modifier != pgtype.Finite {
+		return fmt.Errorf("cannot represent %s as a time", modifier)
+	}
+	return nil
+}
+
+// textValue returns the text of a string, byte slice, or pgtype.Text
+// value. ok is false for other types, and valid false for a NULL Text.
+func textValue(value any) (text string, ok, valid bool) {
+	switch v := value.(type) {
+	case []byte:
+		return string(v), true, true
+	case pgtype.Text:
+		return v.String, true, v.Valid
+	}
+	if val := reflect.ValueOf(value); val.Kind() == reflect.String {
+		return val.String(), true, true
+	}
+	return "", false, false
+}
+
+// integerValue returns val if it holds an integer of any kind, or the
+// value of a pgtype.Int2, Int4, or Int8. ok is false for other types, and
+// valid false for a NULL wrapper.
+func integerValue(val reflect.Value) (i reflect.Value, ok, valid bool) {
+	switch v := val.Interface().(type) {
+	case pgtype.Int2:
+		return reflect.ValueOf(v.Int16), true, v.Valid
+	case pgtype.Int4:
+		return reflect.ValueOf(v.Int32), true, v.Valid
+	case pgtype.Int8:
+		return reflect.ValueOf(v.Int64), true, v.Valid
+	}
+	if isIntegerKind(val.Kind()) {
+		return val, true, true
+	}
+	return reflect.Value{}, false, false
+}
+
+// assignInteger sets the integer field from the integer i, failing if the
+// value doesn't fit rather than wrapping around.
+func assignInteger(field, i reflect.Value) error {
+	if i.CanInt() {
+		n := i.Int()
+		switch {
+		case field.CanInt() && !field.OverflowInt(n):
+			field.SetInt(n)
+		case field.CanUint() && n >= 0 && !field.OverflowUint(uint64(n)):
+			field.SetUint(uint64(n))
+		default:
+			return fmt.Errorf("value %d overflows %s", n, field.Type())
+		}
+		return nil
+	}
+
+	n := i.Uint()
+	switch {
+	case field.CanUint() && !field.OverflowUint(n):
+		field.SetUint(n)
+	case field.CanInt() && n <= math.MaxInt64 && !field.OverflowInt(int64(n)):
+		field.SetInt(int64(n))
+	default:
+		return fmt.Errorf("value %d overflows %s", n, field.Type())
+	}
+	return nil
+}
+
+// isIntegerKind reports whether k is a signed or unsigned integer kind.
+func isIntegerKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// encodeValue returns the value to send to the database for a struct field.
+// Nil pointers are sent as SQL NULL rather than as a typed nil, and fields
+// implementing driver.Valuer (such as sql.NullString) are sent as the
+// result of Value. Fields tagged with the json option are sent as their JSON
+// encoding, and time.Duration fields as intervals.
+func encodeValue(field reflect.Value, opts tagOptions) (any, error) {
+	if opts.Contains("json") {
+		return encodeJSON(field)
+	}
+
+	if field.Kind() == reflect.Pointer && field.IsNil() {
+		return nil, nil
+	}
+
+	if valuer, ok := field.Interface().(driver.Valuer); ok {
+		return valuer.Value()
+	}
+	if field.CanAddr() {
+		if valuer, ok := field.Addr().Interface().(driver.Valuer); ok {
+			return valuer.Value()
+		}
+	}
+
+	// Send durations as intervals rather than as bigint nanoseconds
+	if field.Type() == durationType {
+		return durationInterval(time.Duration(field.Int())), nil
+	}
+
+	// Send named [16]byte types such as uuid.UUID without a Valuer as
+	// plain [16]byte, which pgx encodes as uuid
+	if isUUIDType(field.Type()) && field.Type() != uuidBytesType {
+		return field.Convert(uuidBytesType).Interface(), nil
+	}
+
+	return field.Interface(), nil
+}
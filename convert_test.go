@@ -0,0 +1,389 @@
+package dbx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestQueryStructsPointerFields(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{int32(1), "John", nil}},
+			{values: []interface{}{int32(2), "", "jane@example.com"}},
+		},
+	}
+
+	type TestUser struct {
+		ID    *int64  `db:"id"`
+		Name  *string `db:"name"`
+		Email *string `db:"email"`
+	}
+
+	var users []TestUser
+	if err := QueryStructs(ctx, mock, "SELECT * FROM users", &users); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	if users[0].ID == nil || *users[0].ID != 1 {
+		t.Errorf("Expected ID 1, got %v", users[0].ID)
+	}
+	if users[0].Email != nil {
+		t.Errorf("Expected NULL email to be nil, got %q", *users[0].Email)
+	}
+	if users[1].Name == nil || *users[1].Name != "" {
+		t.Errorf("Expected empty name to be a non-nil pointer to \"\", got %v", users[1].Name)
+	}
+	if users[1].Email == nil || *users[1].Email != "jane@example.com" {
+		t.Errorf("Expected email jane@example.com, got %v", users[1].Email)
+	}
+}
+
+func TestAssignValuePointerToTime(t *testing.T) {
+	now := time.Now()
+
+	var field *time.Time
+	if err := assignValue(reflect.ValueOf(&field).Elem(), now, defaults()); err != nil {
+		t.Fatalf("assignValue failed: %v", err)
+	}
+	if field == nil || !field.Equal(now) {
+		t.Errorf("Expected %v, got %v", now, field)
+	}
+}
+
+func TestExtractStructFieldsNilPointer(t *testing.T) {
+	type TestUser struct {
+		Name       string  `db:"name"`
+		MiddleName *string `db:"middle_name"`
+		Nickname   *string `db:"nickname"`
+	}
+
+	nickname := "JJ"
+	_, values, err := extractStructFields(TestUser{Name: "John", Nickname: &nickname})
+	if err != nil {
+		t.Fatalf("extractStructFields failed: %v", err)
+	}
+
+	if values[1] != nil {
+		t.Errorf("Expected nil pointer to be sent as untyped nil, got %#v", values[1])
+	}
+	if values[2] != &nickname {
+		t.Errorf("Expected non-nil pointer to be passed through, got %#v", values[2])
+	}
+}
+
+// upperString is a domain type that implements sql.Scanner and driver.Valuer.
+type upperString string
+
+func (u *upperString) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("upperString: unsupported type %T", src)
+	}
+	*u = upperString(strings.ToUpper(s))
+	return nil
+}
+
+func (u upperString) Value() (driver.Value, error) {
+	return strings.ToLower(string(u)), nil
+}
+
+func TestQueryStructsSQLNullTypes(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{int32(1), "John", nil}},
+		},
+	}
+
+	type TestUser struct {
+		ID    sql.NullInt64  `db:"id"`
+		Name  upperString    `db:"name"`
+		Email sql.NullString `db:"email"`
+	}
+
+	var users []TestUser
+	if err := QueryStructs(ctx, mock, "SELECT * FROM users", &users); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	expected := TestUser{
+		ID:    sql.NullInt64{Int64: 1, Valid: true},
+		Name:  "JOHN",
+		Email: sql.NullString{},
+	}
+	if users[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, users[0])
+	}
+}
+
+func TestQueryStructsScannerError(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, 42, "john@example.com"}},
+		},
+	}
+
+	type TestUser struct {
+		Name upperString `db:"name"`
+	}
+
+	var users []TestUser
+	err := QueryStructs(ctx, mock, "SELECT * FROM users", &users)
+
+	var convErr *ConversionError
+	if !errors.As(err, &convErr) || convErr.Err == nil {
+		t.Fatalf("Expected *ConversionError wrapping the Scan error, got %v", err)
+	}
+}
+
+func TestExtractStructFieldsValuer(t *testing.T) {
+	type TestUser struct {
+		Name  upperString    `db:"name"`
+		Email sql.NullString `db:"email"`
+		Phone sql.NullString `db:"phone"`
+	}
+
+	_, values, err := extractStructFields(TestUser{
+		Name:  "JOHN",
+		Phone: sql.NullString{String: "555-0100", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("extractStructFields failed: %v", err)
+	}
+
+	expected := []any{"john", nil, "555-0100"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected values %#v, got %#v", expected, values)
+	}
+}
+
+func TestAssignValueTime(t *testing.T) {
+	want := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value any
+		want  time.Time
+	}{
+		{"time.Time", want, want},
+		{"RFC3339 string", "2024-03-15T10:30:00Z", want},
+		{"postgres timestamp string", "2024-03-15 10:30:00", want},
+		{"date-only string", "2024-03-15", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"pgtype.Timestamptz", pgtype.Timestamptz{Time: want, Valid: true}, want},
+		{"pgtype.Timestamp", pgtype.Timestamp{Time: want, Valid: true}, want},
+		{"pgtype.Date", pgtype.Date{Time: want, Valid: true}, want},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got time.Time
+			if err := assignValue(reflect.ValueOf(&got).Elem(), tt.value, defaults()); err != nil {
+				t.Fatalf("assignValue failed: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAssignValueTimeErrors(t *testing.T) {
+	var got time.Time
+	field := reflect.ValueOf(&got).Elem()
+
+	if err := assignValue(field, "not a time", defaults()); err == nil {
+		t.Error("Expected error for unparseable string")
+	}
+	if err := assignValue(field, pgtype.Timestamptz{InfinityModifier: pgtype.Infinity, Valid: true}, defaults()); err == nil {
+		t.Error("Expected error for infinite timestamp")
+	}
+}
+
+func TestQueryStructsTimesInUTC(t *testing.T) {
+	ctx := context.Background()
+	local := time.Date(2024, 3, 15, 10, 30, 0, 0, time.FixedZone("EST", -5*3600))
+	mock := &mockQueryer{
+		fields: mockFields("created_at"),
+		rows:   []mockRow{{values: []interface{}{local}}},
+	}
+
+	type Event struct {
+		CreatedAt time.Time `db:"created_at"`
+	}
+
+	var events []Event
+	if err := QueryStructs(ctx, mock, "SELECT created_at FROM events", &events, TimesInUTC()); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	if events[0].CreatedAt.Location() != time.UTC || !events[0].CreatedAt.Equal(local) {
+		t.Errorf("Expected %v in UTC, got %v", local, events[0].CreatedAt)
+	}
+}
+
+func TestSetDefaultsTimesInUTC(t *testing.T) {
+	SetDefaults(TimesInUTC())
+	t.Cleanup(func() { SetDefaults() })
+
+	ctx := context.Background()
+	local := time.Date(2024, 3, 15, 10, 30, 0, 0, time.FixedZone("EST", -5*3600))
+	mock := &mockQueryer{
+		fields: mockFields("created_at"),
+		rows:   []mockRow{{values: []interface{}{local}}},
+	}
+
+	rows, err := QueryMaps(ctx, mock, "SELECT created_at FROM events")
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+
+	if got := rows[0]["created_at"].(time.Time); got.Location() != time.UTC {
+		t.Errorf("Expected time in UTC, got %v", got)
+	}
+}
+
+func TestQueryStructsDateIntoString(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: []pgconn.FieldDescription{
+			{Name: "birthday", DataTypeOID: pgtype.DateOID},
+			{Name: "created_at", DataTypeOID: pgtype.TimestamptzOID},
+		},
+		rows: []mockRow{{values: []interface{}{
+			time.Date(1990, 6, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC),
+		}}},
+	}
+
+	type Person struct {
+		Birthday  string `db:"birthday"`
+		CreatedAt string `db:"created_at"`
+	}
+
+	var people []Person
+	if err := QueryStructs(ctx, mock, "SELECT birthday, created_at FROM people", &people); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	expected := Person{Birthday: "1990-06-01", CreatedAt: "2024-03-15T10:30:00Z"}
+	if people[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, people[0])
+	}
+}
+
+type orderStatus string
+
+const orderShipped orderStatus = "shipped"
+
+type priority int8
+
+type level uint16
+
+func TestAssignValueEnums(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  orderStatus
+	}{
+		{"string", "shipped", orderShipped},
+		{"[]byte", []byte("shipped"), orderShipped},
+		{"pgtype.Text", pgtype.Text{String: "shipped", Valid: true}, orderShipped},
+		{"NULL pgtype.Text", pgtype.Text{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := orderStatus("stale")
+			if err := assignValue(reflect.ValueOf(&got).Elem(), tt.value, defaults()); err != nil {
+				t.Fatalf("assignValue failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAssignValueNamedIntegers(t *testing.T) {
+	var p priority
+	field := reflect.ValueOf(&p).Elem()
+	for _, value := range []any{int64(-3), int32(-3), pgtype.Int2{Int16: -3, Valid: true}} {
+		p = 0
+		if err := assignValue(field, value, defaults()); err != nil || p != -3 {
+			t.Errorf("assignValue(%#v) = %v, %v; want -3", value, p, err)
+		}
+	}
+	for _, value := range []any{int64(128), uint8(253)} {
+		if err := assignValue(field, value, defaults()); err == nil || !strings.Contains(err.Error(), "overflows") {
+			t.Errorf("Expected %#v to overflow priority, got %v", value, err)
+		}
+	}
+	if err := assignValue(field, pgtype.Int8{}, defaults()); err != nil || p != 0 {
+		t.Errorf("Expected NULL pgtype.Int8 to give 0, got %v, %v", p, err)
+	}
+
+	var l level
+	field = reflect.ValueOf(&l).Elem()
+	if err := assignValue(field, int32(65535), defaults()); err != nil || l != 65535 {
+		t.Errorf("Expected 65535, got %v, %v", l, err)
+	}
+	for _, value := range []any{int64(-1), int64(65536), pgtype.Int4{Int32: -1, Valid: true}} {
+		if err := assignValue(field, value, defaults()); err == nil {
+			t.Errorf("Expected %#v to overflow level", value)
+		}
+	}
+}
+
+func TestQueryStructsEnums(t *testing.T) {
+	type Order struct {
+		ID       int64       `db:"id"`
+		Status   orderStatus `db:"status"`
+		Priority priority    `db:"priority"`
+		Level    *level      `db:"level"`
+	}
+	mock := &mockQueryer{
+		fields: mockFields("id", "status", "priority", "level"),
+		rows:   []mockRow{{values: []interface{}{int64(1), []byte("shipped"), int16(2), int32(7)}}},
+	}
+
+	var orders []Order
+	if err := QueryStructs(context.Background(), mock, "SELECT * FROM orders", &orders); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if got := orders[0]; got.Status != orderShipped || got.Priority != 2 || got.Level == nil || *got.Level != 7 {
+		t.Errorf("Unexpected order: %+v", got)
+	}
+
+	mock.rows = []mockRow{{values: []interface{}{int64(1), "new", int16(300), nil}}}
+	var convErr *ConversionError
+	err := QueryStructs(context.Background(), mock, "SELECT * FROM orders", &orders)
+	if !errors.As(err, &convErr) || convErr.Column != "priority" {
+		t.Errorf("Expected a ConversionError for the overflowing priority, got %v", err)
+	}
+}
+
+func TestInsertStructEnums(t *testing.T) {
+	mock := &mockQueryer{}
+	type Order struct {
+		Status   orderStatus `db:"status"`
+		Priority priority    `db:"priority"`
+	}
+
+	if err := InsertStruct(context.Background(), mock, "orders", Order{Status: orderShipped, Priority: 2}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if len(mock.lastArgs) != 2 || mock.lastArgs[0] != orderShipped || mock.lastArgs[1] != priority(2) {
+		t.Errorf("Expected the enum values sent as they are, got %#v", mock.lastArgs)
+	}
+}
@@ -0,0 +1,94 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Copier is implemented by database handles that support the Postgres COPY
+// protocol. *pgxpool.Pool, *pgx.Conn and pgx.Tx all satisfy it.
+type Copier interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// CopyStructs bulk loads a slice of structs into the specified table using COPY.
+// Column names come from db tags in the same way as InsertStruct, and every
+// element must produce the same column set. Schema-qualified table names such
+// as "billing.invoice" are supported, and names are validated and folded to
+// lower case as for InsertStruct. The BeforeInsert hook of each element
+// runs before any row is sent. It returns the number of rows copied.
+func CopyStructs(ctx context.Context, conn Copier, table string, data any) (int64, error) {
+	sliceValue := reflect.ValueOf(data)
+	if sliceValue.Kind() == reflect.Pointer {
+		sliceValue = sliceValue.Elem()
+	}
+	if sliceValue.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("data must be a slice of structs, got %T", data)
+	}
+
+	if sliceValue.Len() == 0 {
+		return 0, nil
+	}
+
+	items := make([]any, sliceValue.Len())
+	for i := range items {
+		elem := sliceValue.Index(i)
+		item := elem.Interface()
+		if elem.Kind() == reflect.Struct && elem.CanAddr() {
+			item = elem.Addr().Interface()
+		}
+		item, err := beforeInsert(ctx, item)
+		if err != nil {
+			return 0, fmt.Errorf("element %d: %w", i, err)
+		}
+		items[i] = item
+	}
+
+	fields, _, err := extractStructFields(items[0])
+	if err != nil {
+		return 0, fmt.Errorf("failed to extract struct fields: %w", err)
+	}
+
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("no valid fields found for copy")
+	}
+
+	// Names are validated and folded as InsertStruct does, but left
+	// unquoted, since pgx quotes them itself
+	tableParts, err := nameParts("table", table)
+	if err != nil {
+		return 0, err
+	}
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		parts, err := nameParts("column", field)
+		if err != nil {
+			return 0, err
+		}
+		if len(parts) != 1 {
+			return 0, fmt.Errorf("invalid column name %q: COPY takes unqualified column names", field)
+		}
+		columns[i] = parts[0]
+	}
+
+	src := pgx.CopyFromSlice(len(items), func(i int) ([]any, error) {
+		rowFields, rowValues, err := extractStructFields(items[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract struct fields for element %d: %w", i, err)
+		}
+		if !reflect.DeepEqual(fields, rowFields) {
+			return nil, fmt.Errorf("element %d has columns %v, expected %v", i, rowFields, fields)
+		}
+		return rowValues, nil
+	})
+
+	copied, err := conn.CopyFrom(ctx, pgx.Identifier(tableParts), columns, src)
+	if err != nil {
+		return copied, fmt.Errorf("copy failed: %w", err)
+	}
+
+	return copied, nil
+}
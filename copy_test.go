@@ -0,0 +1,99 @@
+package dbx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type mockCopier struct {
+	table   pgx.Identifier
+	columns []string
+	rows    [][]any
+}
+
+func (m *mockCopier) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	m.table, m.columns = tableName, columnNames
+	for rowSrc.Next() {
+		values, err := rowSrc.Values()
+		if err != nil {
+			return int64(len(m.rows)), err
+		}
+		m.rows = append(m.rows, values)
+	}
+	return int64(len(m.rows)), rowSrc.Err()
+}
+
+func TestCopyStructs(t *testing.T) {
+	ctx := context.Background()
+	copier := &mockCopier{}
+
+	users := []insertUser{
+		{Name: "John", Email: "john@example.com"},
+		{Name: "Jane", Email: "jane@example.com"},
+	}
+
+	copied, err := CopyStructs(ctx, copier, "app.users", users)
+	if err != nil {
+		t.Fatalf("CopyStructs failed: %v", err)
+	}
+
+	if copied != 2 {
+		t.Errorf("Expected 2 rows copied, got %d", copied)
+	}
+
+	if !reflect.DeepEqual(copier.table, pgx.Identifier{"app", "users"}) {
+		t.Errorf("Expected identifier [app users], got %v", copier.table)
+	}
+
+	if !reflect.DeepEqual(copier.columns, []string{"name", "email"}) {
+		t.Errorf("Expected columns [name email], got %v", copier.columns)
+	}
+
+	expectedRows := [][]any{{"John", "john@example.com"}, {"Jane", "jane@example.com"}}
+	if !reflect.DeepEqual(copier.rows, expectedRows) {
+		t.Errorf("Expected rows %v, got %v", expectedRows, copier.rows)
+	}
+}
+
+func TestCopyStructsEmpty(t *testing.T) {
+	ctx := context.Background()
+	copier := &mockCopier{}
+
+	copied, err := CopyStructs(ctx, copier, "users", []insertUser{})
+	if err != nil || copied != 0 {
+		t.Errorf("Expected no-op, got %d rows and error %v", copied, err)
+	}
+	if copier.table != nil {
+		t.Error("Expected CopyFrom not to be called")
+	}
+}
+
+func TestCopyStructsTableName(t *testing.T) {
+	ctx := context.Background()
+	users := []insertUser{{Name: "John", Email: "john@example.com"}}
+
+	for table, want := range map[string]pgx.Identifier{
+		"Users":              {"users"},
+		"App.Users":          {"app", "users"},
+		`app."Legacy Users"`: {"app", "Legacy Users"},
+	} {
+		copier := &mockCopier{}
+		if _, err := CopyStructs(ctx, copier, table, users); err != nil {
+			t.Fatalf("CopyStructs(%q) failed: %v", table, err)
+		}
+		if !reflect.DeepEqual(copier.table, want) {
+			t.Errorf("CopyStructs(%q) copied into %v, want %v", table, copier.table, want)
+		}
+	}
+
+	copier := &mockCopier{}
+	if _, err := CopyStructs(ctx, copier, "users; DROP TABLE users", users); err == nil {
+		t.Error("Expected an error for an invalid table name")
+	}
+	if copier.table != nil {
+		t.Error("Expected CopyFrom not to be called")
+	}
+}
@@ -0,0 +1,49 @@
+package dbx
+
+import (
+	"context"
+	"strings"
+)
+
+// Count returns the number of rows in table matching where, which uses $1,
+// $2, ... placeholders for args. An empty where counts the whole table.
+// The table name may be schema-qualified and is validated and quoted; the
+// where clause is written into the SQL as given.
+//
+//	n, err := dbx.Count(ctx, db, "users", "active = $1", true)
+func Count(ctx context.Context, db DB, table, where string, args ...any) (int64, error) {
+	opts, args := splitArgs(args)
+
+	from, err := fromWhere(db, table, where)
+	if err != nil {
+		return 0, err
+	}
+	return queryScalar[int64](ctx, db, "Count", "SELECT count(*) FROM "+from, opts, args)
+}
+
+// Exists reports whether table has any row matching where, using
+// SELECT EXISTS so the database can stop at the first match. An empty
+// where checks whether the table has any rows at all. The table name is
+// handled as by Count.
+func Exists(ctx context.Context, db DB, table, where string, args ...any) (bool, error) {
+	opts, args := splitArgs(args)
+
+	from, err := fromWhere(db, table, where)
+	if err != nil {
+		return false, err
+	}
+	return queryScalar[bool](ctx, db, "Exists", "SELECT EXISTS (SELECT 1 FROM "+from+")", opts, args)
+}
+
+// fromWhere builds the "table WHERE ..." tail of a statement, omitting
+// WHERE when where is blank.
+func fromWhere(db DB, table, where string) (string, error) {
+	quoted, err := qualifyTable(db, table)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(where) == "" {
+		return quoted, nil
+	}
+	return quoted + " WHERE " + where, nil
+}
@@ -0,0 +1,128 @@
+package dbx
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("count"),
+		rows:   []mockRow{{values: []interface{}{int64(7)}}},
+	}
+
+	n, err := Count(ctx, mock, "users", "active = $1", true)
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if n != 7 {
+		t.Errorf("Expected 7, got %d", n)
+	}
+	if mock.lastSQL != `SELECT count(*) FROM "users" WHERE active = $1` {
+		t.Errorf("Unexpected SQL: %s", mock.lastSQL)
+	}
+	if len(mock.lastArgs) != 1 || mock.lastArgs[0] != true {
+		t.Errorf("Unexpected args: %v", mock.lastArgs)
+	}
+
+	if _, err := Count(ctx, mock, "billing.Invoice", ""); err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if mock.lastSQL != `SELECT count(*) FROM "billing"."invoice"` {
+		t.Errorf("Unexpected SQL: %s", mock.lastSQL)
+	}
+}
+
+func TestExists(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("exists"),
+		rows:   []mockRow{{values: []interface{}{true}}},
+	}
+
+	ok, err := Exists(ctx, mock, "users", "email = $1", "john@example.com")
+	if err != nil {
+		t.Fatalf("Exists failed: %v", err)
+	}
+	if !ok {
+		t.Error("Expected true")
+	}
+	if mock.lastSQL != `SELECT EXISTS (SELECT 1 FROM "users" WHERE email = $1)` {
+		t.Errorf("Unexpected SQL: %s", mock.lastSQL)
+	}
+}
+
+func TestCountRejectsBadTable(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	_, err := Count(ctx, mock, "users; DROP TABLE users", "")
+	if err == nil || !strings.Contains(err.Error(), "invalid table name") {
+		t.Errorf("Expected invalid table name error, got %v", err)
+	}
+	if mock.lastSQL != "" {
+		t.Errorf("Expected no query, got %s", mock.lastSQL)
+	}
+}
+
+func TestQuoteTable(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "users", want: `"users"`},
+		{in: "Users", want: `"users"`},
+		{in: "billing.invoice", want: `"billing"."invoice"`},
+		{in: `"Order Items"`, want: `"Order Items"`},
+		{in: `public."My""Table"`, want: `"public"."My""Table"`},
+		{in: "tbl_$1", want: `"tbl_$1"`},
+		{in: "", wantErr: true},
+		{in: "1users", wantErr: true},
+		{in: "users x", wantErr: true},
+		{in: "billing.", wantErr: true},
+		{in: `"unterminated`, wantErr: true},
+		{in: `"a"b`, wantErr: true},
+		{in: `""`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := quoteTable(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("quoteTable(%q): expected error, got %s", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("quoteTable(%q) failed: %v", tt.in, err)
+		} else if got != tt.want {
+			t.Errorf("quoteTable(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIdent(t *testing.T) {
+	tests := []struct {
+		parts []string
+		want  string
+	}{
+		{[]string{"users"}, `"users"`},
+		{[]string{"billing", "Invoice Lines"}, `"billing"."Invoice Lines"`},
+		{[]string{`say "hi"`}, `"say ""hi"""`},
+		{[]string{"a.b"}, `"a.b"`},
+	}
+
+	for _, tt := range tests {
+		got := Ident(tt.parts...)
+		if got != tt.want {
+			t.Errorf("Ident(%q) = %s, want %s", tt.parts, got, tt.want)
+		}
+		// Identifiers from Ident pass validation unchanged
+		if quoted, err := quoteTable(got); err != nil || quoted != got {
+			t.Errorf("quoteTable(%s) = %s, %v", got, quoted, err)
+		}
+	}
+}
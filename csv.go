@@ -0,0 +1,127 @@
+package dbx
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// QueryCSV executes a query and writes the results to w as CSV: a header
+// row of column names in result order, then one record per row. Values are
+// formatted for spreadsheets and other tools rather than for Go: times as
+// RFC 3339 (dates as YYYY-MM-DD), numerics as their exact decimal text,
+// uuids in canonical form, byte slices hex-encoded unless BinaryAs says
+// otherwise, and arrays and json as JSON text. NULL is written as an empty
+// field unless the CSVNull option is passed, and CSVDelimiter switches to
+// another separator, e.g. '\t' for TSV.
+func QueryCSV(ctx context.Context, db DB, w io.Writer, sql string, args ...any) error {
+	opts, args := splitArgs(args)
+
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.csvDelimiter
+
+	rows, err := query(ctx, db, "QueryCSV", sql, args, opts)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	fieldDescs := rows.FieldDescriptions()
+	kept := keptColumns(fieldDescs, opts)
+	record := make([]string, len(kept))
+	for j, i := range kept {
+		record[j] = fieldDescs[i].Name
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return fmt.Errorf("failed to get row values: %w", err)
+		}
+
+		for j, i := range kept {
+			if record[j], err = csvValue(inetHost(values[i], fieldDescs[i].DataTypeOID), fieldDescs[i].DataTypeOID, opts); err != nil {
+				return fmt.Errorf("failed to format column %q: %w", fieldDescs[i].Name, err)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("row iteration error: %w", err)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvValue formats a value returned by the driver as a CSV field.
+func csvValue(v any, oid uint32, opts *options) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return opts.csvNull, nil
+	case string:
+		return v, nil
+	case time.Time:
+		if oid == pgtype.DateOID {
+			return v.Format(time.DateOnly), nil
+		}
+		if opts.timesInUTC {
+			v = v.UTC()
+		}
+		return v.Format(time.RFC3339Nano), nil
+	case pgtype.Numeric:
+		if !v.Valid {
+			return opts.csvNull, nil
+		}
+		return numericText(v)
+	case [16]byte:
+		return formatUUID(v), nil
+	case []byte:
+		return binaryText(v, binaryDigits, opts), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case pgtype.Range[any]:
+		if !v.Valid {
+			return opts.csvNull, nil
+		}
+		return formatRange(v, func(e any) (string, error) { return csvValue(e, rangeElemOID(oid), opts) })
+	case pgtype.Multirange[pgtype.Range[any]]:
+		ranges := make([]string, len(v))
+		for i, r := range v {
+			text, err := csvValue(r, oid, opts)
+			if err != nil {
+				return "", err
+			}
+			ranges[i] = text
+		}
+		return "{" + strings.Join(ranges, ",") + "}", nil
+	case []any, map[string]any:
+		data, err := json.Marshal(jsonValue(v, opts))
+		return string(data), err
+	}
+	return fmt.Sprint(v), nil
+}
+
+// rangeElemOID returns the type of the endpoints of a range column of type
+// oid, as far as csvValue needs it: date for daterange columns.
+func rangeElemOID(oid uint32) uint32 {
+	if oid == pgtype.DaterangeOID || oid == pgtype.DatemultirangeOID {
+		return pgtype.DateOID
+	}
+	return 0
+}
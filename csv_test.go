@@ -0,0 +1,75 @@
+package dbx
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestQueryCSV(t *testing.T) {
+	ctx := context.Background()
+	fields := mockFields("id", "amount", "created_at", "due", "note", "raw", "ref", "tags")
+	fields[3].DataTypeOID = pgtype.DateOID
+	mock := &mockQueryer{
+		fields: fields,
+		rows: []mockRow{
+			{values: []interface{}{
+				int64(1),
+				pgtype.Numeric{Int: big.NewInt(123456), Exp: -2, Valid: true},
+				time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC),
+				time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+				"hello, world",
+				[]byte{0xde, 0xad},
+				testUUIDBytes,
+				[]any{"a", "b"},
+			}},
+			{values: []interface{}{int64(2), nil, nil, nil, nil, nil, nil, nil}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := QueryCSV(ctx, mock, &buf, "SELECT * FROM invoices"); err != nil {
+		t.Fatalf("QueryCSV failed: %v", err)
+	}
+
+	expected := "id,amount,created_at,due,note,raw,ref,tags\n" +
+		"1,1234.56,2024-03-15T10:30:00Z,2024-04-01,\"hello, world\",dead," + testUUIDString + ",\"[\"\"a\"\",\"\"b\"\"]\"\n" +
+		"2,,,,,,,\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestQueryCSVOptions(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "name"),
+		rows:   []mockRow{{values: []interface{}{1.5, nil}}},
+	}
+
+	var buf bytes.Buffer
+	if err := QueryCSV(ctx, mock, &buf, "SELECT id, name FROM t", CSVDelimiter('\t'), CSVNull(`\N`)); err != nil {
+		t.Fatalf("QueryCSV failed: %v", err)
+	}
+
+	if expected := "id\tname\n1.5\t\\N\n"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestQueryCSVHeaderOnly(t *testing.T) {
+	mock := &mockQueryer{fields: []pgconn.FieldDescription{{Name: "id"}}}
+
+	var buf bytes.Buffer
+	if err := QueryCSV(context.Background(), mock, &buf, "SELECT id FROM t WHERE false"); err != nil {
+		t.Fatalf("QueryCSV failed: %v", err)
+	}
+	if buf.String() != "id\n" {
+		t.Errorf("Expected header only, got %q", buf.String())
+	}
+}
@@ -0,0 +1,67 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// cursorID numbers the cursors declared by QueryCursor, so that cursors
+// opened in the same transaction get different names.
+var cursorID atomic.Uint64
+
+// QueryCursor runs a query through a server-side cursor, fetching
+// fetchSize rows at a time and passing each batch to fn as QueryMaps would
+// return it, so that result sets too large to hold in memory, on either
+// side, can be processed in pieces. Returning an error from fn stops the
+// fetching and is returned.
+//
+// Cursors only live inside a transaction, so db must be able to begin one:
+// on a pool or connection QueryCursor runs in a transaction of its own, and
+// on a pgx.Tx in a savepoint. The cursor is closed when QueryCursor
+// returns, including when fn fails.
+//
+//	err := dbx.QueryCursor(ctx, pool, "SELECT * FROM events", 10000, func(rows []dbx.RowMap) error {
+//		return export(rows)
+//	})
+func QueryCursor(ctx context.Context, db DB, sql string, fetchSize int, fn func([]RowMap) error, args ...any) error {
+	opts, args := splitArgs(args)
+	// The cursor's statements differ from sql, so they can't share its name
+	opts.prepared = ""
+
+	if fetchSize <= 0 {
+		return fmt.Errorf("fetch size must be positive, got %d", fetchSize)
+	}
+
+	return RunNested(ctx, db, func(tx DB) error {
+		name := fmt.Sprintf("dbx_cursor_%d", cursorID.Add(1))
+		declare := "DECLARE " + name + " NO SCROLL CURSOR FOR " + strings.TrimRight(sql, " \t\n;")
+		if _, err := exec(ctx, tx, "QueryCursor", declare, args, opts); err != nil {
+			return fmt.Errorf("declare cursor failed: %w", err)
+		}
+
+		fetch := fmt.Sprintf("FETCH FORWARD %d FROM %s", fetchSize, name)
+		for {
+			rows, err := queryMaps(ctx, tx, "QueryCursor", fetch, opts, nil, mapValue)
+			if err != nil {
+				return err
+			}
+			if len(rows) > 0 {
+				if err := fn(rows); err != nil {
+					// Rolling back the transaction or savepoint closes the
+					// cursor
+					return err
+				}
+			}
+			if len(rows) < fetchSize {
+				break
+			}
+		}
+
+		if _, err := exec(ctx, tx, "QueryCursor", "CLOSE "+name, nil, opts); err != nil {
+			return fmt.Errorf("close cursor failed: %w", err)
+		}
+		return nil
+	})
+}
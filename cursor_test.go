@@ -0,0 +1,130 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// cursorTx is a transaction holding a cursor over rows, which FETCH
+// statements read from in order. Begin returns the same transaction.
+type cursorTx struct {
+	pgx.Tx
+	rows       []mockRow
+	statements []string
+	args       []any
+	committed  bool
+	rolledBack bool
+}
+
+func (c *cursorTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return c, nil
+}
+
+func (c *cursorTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	c.statements = append(c.statements, sql)
+	if strings.HasPrefix(sql, "DECLARE") {
+		c.args = args
+	}
+	return pgconn.NewCommandTag(strings.Fields(sql)[0]), nil
+}
+
+func (c *cursorTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	c.statements = append(c.statements, sql)
+	var n int
+	var name string
+	if _, err := fmt.Sscanf(sql, "FETCH FORWARD %d FROM %s", &n, &name); err != nil {
+		return nil, err
+	}
+	n = min(n, len(c.rows))
+	batch := c.rows[:n]
+	c.rows = c.rows[n:]
+	return &mockRows{rows: batch, current: -1}, nil
+}
+
+func (c *cursorTx) Commit(ctx context.Context) error {
+	c.committed = true
+	return nil
+}
+
+func (c *cursorTx) Rollback(ctx context.Context) error {
+	if c.committed {
+		return pgx.ErrTxClosed
+	}
+	c.rolledBack = true
+	return nil
+}
+
+func TestQueryCursor(t *testing.T) {
+	tx := &cursorTx{}
+	for i := 1; i <= 5; i++ {
+		tx.rows = append(tx.rows, mockRow{values: []interface{}{i, "user", "user@example.com"}})
+	}
+
+	var batches []int
+	var ids []any
+	err := QueryCursor(context.Background(), tx, "SELECT * FROM users WHERE active = $1;", 2, func(rows []RowMap) error {
+		batches = append(batches, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row["id"])
+		}
+		return nil
+	}, true)
+	if err != nil {
+		t.Fatalf("QueryCursor failed: %v", err)
+	}
+
+	if len(batches) != 3 || batches[0] != 2 || batches[2] != 1 || len(ids) != 5 {
+		t.Errorf("batches = %v, ids = %v", batches, ids)
+	}
+	if len(tx.args) != 1 || tx.args[0] != true {
+		t.Errorf("DECLARE args = %v, want [true]", tx.args)
+	}
+
+	first, last := tx.statements[0], tx.statements[len(tx.statements)-1]
+	name := strings.Fields(first)[1]
+	if want := "DECLARE " + name + " NO SCROLL CURSOR FOR SELECT * FROM users WHERE active = $1"; first != want {
+		t.Errorf("declared %q, want %q", first, want)
+	}
+	if tx.statements[1] != "FETCH FORWARD 2 FROM "+name {
+		t.Errorf("fetched with %q", tx.statements[1])
+	}
+	if last != "CLOSE "+name {
+		t.Errorf("last statement %q, want CLOSE", last)
+	}
+	if !tx.committed {
+		t.Error("savepoint was not released")
+	}
+}
+
+func TestQueryCursorCallbackError(t *testing.T) {
+	tx := &cursorTx{rows: []mockRow{{values: []interface{}{1, "a", "b"}}, {values: []interface{}{2, "a", "b"}}}}
+	stop := errors.New("stop")
+
+	calls := 0
+	err := QueryCursor(context.Background(), tx, "SELECT * FROM users", 1, func(rows []RowMap) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("err = %v after %d calls, want stop after 1", err, calls)
+	}
+	if !tx.rolledBack {
+		t.Error("expected rollback, which closes the cursor")
+	}
+}
+
+func TestQueryCursorNeedsTransactions(t *testing.T) {
+	noop := func([]RowMap) error { return nil }
+	if err := QueryCursor(context.Background(), &mockQueryer{}, "SELECT 1", 10, noop); err == nil || !strings.Contains(err.Error(), "cannot begin transactions") {
+		t.Errorf("expected an error for a DB without transactions, got %v", err)
+	}
+	if err := QueryCursor(context.Background(), &cursorTx{}, "SELECT 1", 0, noop); err == nil {
+		t.Error("expected an error for a zero fetch size")
+	}
+}
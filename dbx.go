@@ -3,9 +3,49 @@
 //
 // Key features:
 //   - QueryMaps: Get results as []map[string]interface{}
+//   - RowMap.String, RowMap.Int, ..., RowValue: Typed access to RowMap values
+//   - MapToStruct, StructToMap: Convert between RowMaps and tagged structs
+//   - Range: Map range and multirange columns, or just their endpoints, into struct fields
+//   - QueryRows: Get column names and rows in query order
 //   - QueryStructs: Map results into structs using db:"table.column" tags
+//   - QueryStruct: Map a single-row result into a struct
+//   - GetByID, GetBy: Load a struct by its key or another column
+//   - QueryStructsT: Generic variant of QueryStructs returning []T
+//   - ColumnsOf: Generate aliased SELECT column lists from struct tags
+//   - NewMapper: Precompiled struct mapping for queries run repeatedly
+//   - ScanRowsInto, MappingFor: Map rows from elsewhere, or inspect how columns map to fields
+//   - QueryStructsMap: Map results into a map[K]V keyed by a column
+//   - QueryScalar, QueryColumn: Read a single value, or a single column as []T
+//   - Count, Exists: Count or test for matching rows in a table
+//   - QueryMapsIter, QueryStructsIter: Stream results one row at a time
+//   - QueryStructsEach, QueryStructsChunks: Pass streamed rows to a function singly or in batches
+//   - QueryCursor: Process huge results in batches through a server-side cursor
+//   - QueryNested: Collect joined child rows into slices on their parents
+//   - QueryPage: Fetch a LIMIT/OFFSET page of structs along with the total count
+//   - QueryMapsNamed, QueryStructsNamed, ExecNamed: Bind :name placeholders from maps or structs
 //   - InsertStruct: Insert structs into tables automatically
-//   - QueryJSON: Get results as JSON bytes
+//   - InsertStructColumns, InsertStructExcept: Insert a subset of a struct's columns
+//   - Exec: Run a statement and get the number of rows affected
+//   - InsertStructs: Bulk insert slices of structs with multi-row VALUES
+//   - InsertMap, InsertMaps: Insert RowMaps using their keys as columns
+//   - CopyStructs: Bulk load slices of structs via COPY
+//   - UpdateStruct: Update rows from structs with a caller-supplied WHERE clause
+//   - UpdateWhere, DeleteWhere: Update or delete rows matching a WHERE clause
+//   - BeforeInserter, BeforeUpdater, AfterScanner: Struct lifecycle hooks
+//   - InspectTable, ListTables: Read the columns of tables and views from the catalog
+//   - CheckStruct: Verify a struct's tags and field types against a live table
+//   - GenerateStruct: Generate a tagged Go struct from a table (see cmd/dbxgen)
+//   - LoadQueries: Load named statements from .sql files and run them by name
+//   - Explain: Get the EXPLAIN plan of a query with its cost and timings
+//   - Prepared, PrepareAll: Run queries as named prepared statements
+//   - Listen, ListenChannels, ListenJSON: Receive NOTIFY payloads, reconnecting as needed
+//   - Parallel, ParallelN: Run independent queries concurrently on a pool
+//   - NewCachedDB: Serve repeated SELECTs from an in-memory cache with a TTL
+//   - RunInTx: Run a function in a transaction, committing or rolling back automatically
+//   - WithSchema, SetSearchPath: Work in a default schema
+//   - QueryJSON, QueryJSONIndent, QueryJSONObject: Get results as JSON bytes
+//   - QueryJSONStream, QueryNDJSON, QueryCSV: Stream results to an io.Writer
+//   - Where, Select: Build dynamic WHERE clauses and SELECT statements with numbered placeholders
 //
 // Example:
 //
@@ -18,9 +58,9 @@ package dbx
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
-	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -35,10 +75,27 @@ type DB interface {
 // RowMap represents a single database row as a map
 type RowMap map[string]interface{}
 
+// ErrNoRows is returned by single-row helpers such as QueryStruct when the
+// query produces no rows.
+var ErrNoRows = errors.New("dbx: no rows in result set")
+
+// ErrTooManyRows is returned by single-row helpers such as QueryStruct when
+// the query produces more than one row.
+var ErrTooManyRows = errors.New("dbx: more than one row in result set")
+
 // QueryMaps executes a query and returns results as a slice of maps.
 // Each map represents a row with column names as keys.
+// Numeric columns are returned as float64, or as exact decimal strings
+// with ExactNumerics.
 func QueryMaps(ctx context.Context, db DB, sql string, args ...any) ([]RowMap, error) {
-	rows, err := db.Query(ctx, sql, args...)
+	opts, args := splitArgs(args)
+	return queryMaps(ctx, db, "QueryMaps", sql, opts, args, mapValue)
+}
+
+// queryMaps implements QueryMaps on behalf of the helper named by op.
+// Each value returned by the driver is passed through convert.
+func queryMaps(ctx context.Context, db DB, op, sql string, opts *options, args []any, convert func(any, *options) any) ([]RowMap, error) {
+	rows, err := query(ctx, db, op, sql, args, opts)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
@@ -47,10 +104,17 @@ func QueryMaps(ctx context.Context, db DB, sql string, args ...any) ([]RowMap, e
 	fieldDescs := rows.FieldDescriptions()
 	fieldNames := make([]string, len(fieldDescs))
 	for i, fd := range fieldDescs {
-		fieldNames[i] = string(fd.Name)
+		fieldNames[i] = fd.Name
 	}
+	if err := checkColumns(fieldNames, opts); err != nil {
+		return nil, err
+	}
+	fieldNames = uniqueColumns(fieldNames)
 
 	var result []RowMap
+	if opts.capacity > 0 {
+		result = make([]RowMap, 0, opts.capacity)
+	}
 	for rows.Next() {
 		values, err := rows.Values()
 		if err != nil {
@@ -59,7 +123,7 @@ func QueryMaps(ctx context.Context, db DB, sql string, args ...any) ([]RowMap, e
 
 		row := make(RowMap, len(values))
 		for i, v := range values {
-			row[fieldNames[i]] = v
+			row[fieldNames[i]] = convert(inetHost(v, fieldDescs[i].DataTypeOID), opts)
 		}
 		result = append(result, row)
 	}
@@ -73,126 +137,360 @@ func QueryMaps(ctx context.Context, db DB, sql string, args ...any) ([]RowMap, e
 
 // QueryJSON executes a query and returns results as JSON bytes.
 // This is useful for APIs or when you need JSON output directly.
+// Object keys follow the column order of the query.
+// Times are rendered with the JSONTimeFormat layout, RFC 3339 by default,
+// and numerics as JSON numbers carrying their exact digits, or as JSON
+// strings with ExactNumerics.
 func QueryJSON(ctx context.Context, db DB, sql string, args ...any) ([]byte, error) {
-	rows, err := QueryMaps(ctx, db, sql, args...)
+	opts, args := splitArgs(args)
+	rows, err := queryJSONRows(ctx, db, "QueryJSON", sql, opts, args)
 	if err != nil {
 		return nil, err
 	}
 	return json.Marshal(rows)
 }
 
+// queryJSONRows collects the rows of a query as rendered in JSON output,
+// reporting the query as op.
+func queryJSONRows(ctx context.Context, db DB, op, sql string, opts *options, args []any) ([]jsonObject, error) {
+	var rows []jsonObject
+	_, err := eachRow(ctx, db, op, sql, args, opts, jsonRows(opts, func(row jsonObject) error {
+		rows = append(rows, row)
+		return nil
+	}))
+	return rows, err
+}
+
+// QueryJSONIndent is like QueryJSON but indents the output, placing each
+// element on its own line and using indent for each level of nesting.
+// This is handy for CLI tools and debugging output.
+func QueryJSONIndent(ctx context.Context, db DB, sql string, indent string, args ...any) ([]byte, error) {
+	opts, args := splitArgs(args)
+	rows, err := queryJSONRows(ctx, db, "QueryJSONIndent", sql, opts, args)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(rows, "", indent)
+}
+
+// QueryJSONObject executes a query expected to produce exactly one row and
+// returns it as a single JSON object rather than a one-element array. Values
+// are rendered as by QueryJSON. ErrNoRows is returned when the query produces
+// no rows and ErrTooManyRows when it produces more than one.
+func QueryJSONObject(ctx context.Context, db DB, sql string, args ...any) ([]byte, error) {
+	opts, args := splitArgs(args)
+
+	var row jsonObject
+	count, err := eachRow(ctx, db, "QueryJSONObject", sql, args, opts, jsonRows(opts, func(r jsonObject) error {
+		if row.keys != nil {
+			return ErrTooManyRows
+		}
+		row = r
+		return nil
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrNoRows
+	}
+	return json.Marshal(row)
+}
+
 // InsertStruct inserts a struct into the specified table.
 // It uses db:"column" tags to map struct fields to table columns.
-// Fields without db tags or with db:"-" are ignored.
+// Fields without db tags, with db:"-", or tagged readonly, as for generated
+// columns, are ignored, and fields tagged omitempty or omitzero, as in
+// db:"middle_name,omitempty", are left out when empty so the column default
+// applies. Likewise a pk-tagged field holding its zero value is left out so
+// that a serial or identity column generates the key; non-zero keys are
+// inserted as given. Zero fields tagged createdAt or updatedAt, as in
+// db:"created_at,createdAt", are set to the database's now(), while values
+// set by the caller are inserted as given. InsertStructColumns and
+// InsertStructExcept insert a subset.
+// Table and column names are validated and quoted; see Ident.
 func InsertStruct(ctx context.Context, db DB, table string, data any) error {
-	fields, values, err := extractStructFields(data)
+	_, err := insertStruct(ctx, db, "InsertStruct", table, data)
+	return err
+}
+
+// InsertStructResult is like InsertStruct but also returns the number of
+// rows inserted.
+func InsertStructResult(ctx context.Context, db DB, table string, data any) (int64, error) {
+	return insertStruct(ctx, db, "InsertStructResult", table, data)
+}
+
+// insertStruct implements InsertStruct, reporting the statement as op.
+func insertStruct(ctx context.Context, db DB, op, table string, data any) (int64, error) {
+	data, err := beforeInsert(ctx, data)
 	if err != nil {
-		return fmt.Errorf("failed to extract struct fields: %w", err)
+		return 0, err
 	}
 
-	if len(fields) == 0 {
-		return fmt.Errorf("no valid fields found for insertion")
+	sql, values, err := buildInsertStruct(db, table, data)
+	if err != nil {
+		return 0, err
 	}
 
-	placeholders := make([]string, len(fields))
-	for i := range fields {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	tag, err := exec(ctx, db, op, sql, values, nil)
+	if err != nil {
+		return 0, fmt.Errorf("insert failed: %w", err)
 	}
 
-	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-		table,
-		strings.Join(fields, ", "),
-		strings.Join(placeholders, ", "),
-	)
+	return tag.RowsAffected(), nil
+}
+
+// Exec executes a statement that returns no rows, such as an INSERT,
+// UPDATE, or DDL statement, and returns the number of rows affected.
+func Exec(ctx context.Context, db DB, sql string, args ...any) (int64, error) {
+	opts, args := splitArgs(args)
 
-	_, err = db.Exec(ctx, sql, values...)
+	tag, err := exec(ctx, db, "Exec", sql, args, opts)
 	if err != nil {
-		return fmt.Errorf("insert failed: %w", err)
+		return 0, fmt.Errorf("exec failed: %w", err)
 	}
 
-	return nil
+	return tag.RowsAffected(), nil
 }
 
 // QueryStructs executes a query and maps results into the provided struct slice.
 // It uses db:"table.column" tags to map columns to struct fields.
-// The dest parameter must be a pointer to a slice of structs.
+// The dest parameter must be a pointer to a slice of structs or of pointers
+// to structs, in which case a new struct is allocated for each row. The
+// slice is truncated before scanning, keeping its capacity, unless the
+// Append option is passed; a pointer to a nil slice is fine.
+// A value that cannot be converted to its field's type produces a
+// *ConversionError unless the Lenient option is passed among the args.
 func QueryStructs(ctx context.Context, db DB, sql string, dest any, args ...any) error {
-	fmt.Printf("[dbx] QueryStructs called with dest type: %T, value: %#v\n", dest, dest)
+	opts, args := splitArgs(args)
+	getLogger().Debugf("[dbx] QueryStructs called with dest type: %T", dest)
+	return queryStructs(ctx, db, "QueryStructs", sql, dest, opts, args)
+}
+
+// queryStructs implements QueryStructs, reporting the query as op.
+func queryStructs(ctx context.Context, db DB, op, sql string, dest any, opts *options, args []any) error {
+	sliceValue, elemType, err := structSliceDest(dest)
+	if err != nil {
+		return err
+	}
+
+	// Execute the query
+	rows, err := query(ctx, db, op, sql, args, opts)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	// Build field mapping
+	scanner, err := newStructScanner(rows, elemType, opts)
+	if err != nil {
+		return err
+	}
+
+	return collectStructs(ctx, rows, scanner, sliceValue, opts)
+}
+
+// collectStructs reads the remaining rows into sliceValue, a slice of
+// structs or struct pointers, with scanner, as QueryStructs does.
+func collectStructs(ctx context.Context, rows pgx.Rows, scanner *structScanner, sliceValue reflect.Value, opts *options) error {
+	elemType, isPtr, err := structElemType(sliceValue.Type())
+	if err != nil {
+		return err
+	}
+
+	// Reuse the slice's capacity but not its rows unless asked to append
+	if !opts.appendRows {
+		sliceValue.SetLen(0)
+	}
+	if opts.capacity > 0 {
+		sliceValue.Grow(opts.capacity)
+	}
+
+	// Rows are scanned in place into the next element of the slice, which
+	// grows by doubling when full, rather than into a new struct that is
+	// then copied by reflect.Append
+	if opts.directScan {
+		scanner.planDirect(rows)
+	}
+	for row := 0; rows.Next(); row++ {
+		n := sliceValue.Len()
+		if n == sliceValue.Cap() {
+			sliceValue.Grow(1)
+		}
+		sliceValue.SetLen(n + 1)
+		slot := sliceValue.Index(n)
+
+		// Reused capacity may hold an earlier row
+		var elem reflect.Value
+		if isPtr {
+			elem = reflect.New(elemType)
+			slot.Set(elem)
+		} else {
+			slot.SetZero()
+			elem = slot.Addr()
+		}
+
+		if err := scanner.scanRow(rows, elem.Elem()); err != nil {
+			sliceValue.SetLen(n)
+			return err
+		}
+		if err := afterScan(ctx, elem); err != nil {
+			sliceValue.SetLen(n)
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return nil
+}
+
+// structSliceDest checks that dest is a non-nil pointer to a slice of
+// structs or struct pointers, returning the slice and its struct type.
+func structSliceDest(dest any) (reflect.Value, reflect.Type, error) {
 	destValue := reflect.ValueOf(dest)
 	if dest == nil {
-		return fmt.Errorf("dest cannot be nil; must be a pointer to a slice of structs")
+		return reflect.Value{}, nil, fmt.Errorf("dest cannot be nil; must be a pointer to a slice of structs")
 	}
 	if destValue.Kind() != reflect.Pointer {
-		return fmt.Errorf("dest must be a pointer to a slice of structs, got %T", dest)
+		return reflect.Value{}, nil, fmt.Errorf("dest must be a pointer to a slice of structs, got %T", dest)
 	}
 	if destValue.IsNil() {
-		return fmt.Errorf("dest pointer is nil; must be a pointer to a slice of structs")
+		return reflect.Value{}, nil, fmt.Errorf("dest pointer is nil; must be a pointer to a slice of structs")
 	}
 
 	sliceValue := destValue.Elem()
 	if sliceValue.Kind() != reflect.Slice {
-		return fmt.Errorf("dest must be a pointer to a slice of structs, got pointer to %s", sliceValue.Kind())
+		return reflect.Value{}, nil, fmt.Errorf("dest must be a pointer to a slice of structs, got pointer to %s", sliceValue.Kind())
 	}
 
 	// Get the element type of the slice
-	elemType := sliceValue.Type().Elem()
+	elemType, _, err := structElemType(sliceValue.Type())
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+	return sliceValue, elemType, nil
+}
+
+// structElemType returns the struct type of the elements of sliceType,
+// which may be structs or pointers to structs, and whether they are
+// pointers.
+func structElemType(sliceType reflect.Type) (reflect.Type, bool, error) {
+	elemType := sliceType.Elem()
+	isPtr := elemType.Kind() == reflect.Pointer
+	if isPtr {
+		elemType = elemType.Elem()
+	}
 	if elemType.Kind() != reflect.Struct {
-		return fmt.Errorf("slice elements must be structs, got %s", elemType.Kind())
+		return nil, false, fmt.Errorf("slice elements must be structs or pointers to structs, got %s", sliceType.Elem())
 	}
+	return elemType, isPtr, nil
+}
 
-	// Execute the query
-	rows, err := db.Query(ctx, sql, args...)
+// QueryStructsT executes a query and returns the results as a freshly allocated []T.
+// It is a generic convenience over QueryStructs and shares its db tag matching;
+// T must be a struct type.
+func QueryStructsT[T any](ctx context.Context, db DB, sql string, args ...any) ([]T, error) {
+	var result []T
+	if err := QueryStructs(ctx, db, sql, &result, args...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// QueryStruct executes a query and maps the single resulting row into dest.
+// It uses the same db tag matching as QueryStructs.
+// The dest parameter must be a pointer to a struct. ErrNoRows is returned when
+// the query produces no rows and ErrTooManyRows when it produces more than one;
+// in both cases dest is left untouched. Options such as Lenient may be passed
+// among the args.
+func QueryStruct(ctx context.Context, db DB, sql string, dest any, args ...any) error {
+	opts, args := splitArgs(args)
+	return queryStruct(ctx, db, "QueryStruct", sql, dest, opts, args)
+}
+
+// queryStruct implements QueryStruct, reporting the query as op.
+func queryStruct(ctx context.Context, db DB, op, sql string, dest any, opts *options, args []any) error {
+	if dest == nil {
+		return fmt.Errorf("dest cannot be nil; must be a pointer to a struct")
+	}
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Pointer {
+		return fmt.Errorf("dest must be a pointer to a struct, got %T", dest)
+	}
+	if destValue.IsNil() {
+		return fmt.Errorf("dest pointer is nil; must be a pointer to a struct")
+	}
+
+	structValue := destValue.Elem()
+	if structValue.Kind() != reflect.Struct {
+		return fmt.Errorf("dest must be a pointer to a struct, got pointer to %s", structValue.Kind())
+	}
+
+	rows, err := query(ctx, db, op, sql, args, opts)
 	if err != nil {
 		return fmt.Errorf("query failed: %w", err)
 	}
 	defer rows.Close()
 
-	// Build field mapping
-	fieldMap, err := buildFieldMapping(rows, elemType)
+	scanner, err := newStructScanner(rows, structValue.Type(), opts)
 	if err != nil {
-		return fmt.Errorf("failed to build field mapping: %w", err)
+		return err
 	}
 
-	// Process each row
-	for rows.Next() {
-		values, err := rows.Values()
-		if err != nil {
-			return fmt.Errorf("failed to get row values: %w", err)
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("row iteration error: %w", err)
 		}
+		return ErrNoRows
+	}
 
-		// Create a new struct instance
-		elem := reflect.New(elemType).Elem()
-
-		// Map values to struct fields
-		for colIndex, fieldIndex := range fieldMap {
-			if colIndex < len(values) && fieldIndex >= 0 {
-				field := elem.Field(fieldIndex)
-				if field.CanSet() {
-					val := reflect.ValueOf(values[colIndex])
-					if !val.IsValid() || (val.Kind() == reflect.Ptr && val.IsNil()) {
-						// Set zero value for the field if DB value is NULL
-						field.Set(reflect.Zero(field.Type()))
-					} else if val.Type().ConvertibleTo(field.Type()) {
-						field.Set(val.Convert(field.Type()))
-					}
-				}
-			}
-		}
+	values, err := rows.Values()
+	if err != nil {
+		return fmt.Errorf("failed to get row values: %w", err)
+	}
 
-		// Append to the slice
-		sliceValue.Set(reflect.Append(sliceValue, elem))
+	// Map into a fresh value so dest is only written on success
+	elem := reflect.New(structValue.Type()).Elem()
+	if err := scanner.scan(values, elem); err != nil {
+		return err
+	}
+	if err := afterScan(ctx, elem.Addr()); err != nil {
+		return err
 	}
 
+	if rows.Next() {
+		return ErrTooManyRows
+	}
 	if err := rows.Err(); err != nil {
 		return fmt.Errorf("row iteration error: %w", err)
 	}
 
+	structValue.Set(elem)
 	return nil
 }
 
 // extractStructFields extracts field names and values from a struct for insertion.
-// It uses db tags to determine column names and skips fields with db:"-".
+// It uses db tags, or the tag and name mapper set with SetDefaults, to
+// determine column names and skips fields with db:"-" and fields tagged
+// readonly. Other tag options such as ",pk" are ignored.
 func extractStructFields(data any) ([]string, []any, error) {
+	return structFields(data, false)
+}
+
+// extractInsertFields is like extractStructFields but prepares a single-row
+// insert: fields tagged omitempty or omitzero that hold an empty value are
+// left out, so the column default applies, and zero createdAt and updatedAt
+// fields are set to the database's now().
+func extractInsertFields(data any) ([]string, []any, error) {
+	return structFields(data, true)
+}
+
+// structFields implements extractStructFields and, when insert is true,
+// extractInsertFields.
+func structFields(data any, insert bool) ([]string, []any, error) {
 	v := reflect.ValueOf(data)
 	if v.Kind() == reflect.Pointer {
 		v = v.Elem()
@@ -202,72 +500,136 @@ func extractStructFields(data any) ([]string, []any, error) {
 		return nil, nil, fmt.Errorf("data must be a struct or pointer to struct")
 	}
 
-	t := v.Type()
-	var fields []string
-	var values []any
+	meta := getStructMeta(v.Type(), defaults())
+	if meta.Err != nil {
+		return nil, nil, meta.Err
+	}
+	fields := make([]string, 0, len(meta.Fields))
+	values := make([]any, 0, len(meta.Fields))
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		dbTag := field.Tag.Get("db")
+	for _, f := range meta.Fields {
+		if f.Nested() {
+			continue
+		}
 
-		// Skip fields with no db tag or explicitly ignored
-		if dbTag == "" || dbTag == "-" {
+		if f.Options.Contains("readonly") {
 			continue
 		}
+		if insert && len(f.Options) > 0 {
+			field, ok := f.value(v)
+			if f.Options.timestamp() && (!ok || field.IsZero()) {
+				fields = append(fields, f.Column)
+				values = append(values, sqlNow)
+				continue
+			}
+			if !ok || f.Options.omits(field) {
+				continue
+			}
+		}
 
-		// Extract the column name from the tag
 		// Support both "column" and "table.column" formats
-		columnName := dbTag
-		if dotIndex := strings.Index(dbTag, "."); dotIndex != -1 {
-			columnName = dbTag[dotIndex+1:]
+		value, err := f.encode(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode field %s: %w", f.Name, err)
 		}
 
-		fields = append(fields, columnName)
-		values = append(values, v.Field(i).Interface())
+		fields = append(fields, f.Column)
+		values = append(values, value)
 	}
 
 	return fields, values, nil
 }
 
-// buildFieldMapping creates a mapping from column indices to struct field indices.
-// It uses db tags to match columns to fields, with fallback to field names.
-func buildFieldMapping(rows pgx.Rows, structType reflect.Type) (map[int]int, error) {
+// buildFieldMapping creates a mapping from column indices to positions in the
+// struct's field metadata (see getStructMeta). It uses the tags named by
+// opts to match columns to fields, with fallback to field names. Tag
+// parsing is cached per struct type and tag name, so per-call work is only
+// matching the cached names against the result columns.
+func buildFieldMapping(rows pgx.Rows, structType reflect.Type, opts *options) (map[int]int, error) {
 	fieldDescs := rows.FieldDescriptions()
+	names := make([]string, len(fieldDescs))
+	for i, fd := range fieldDescs {
+		names[i] = fd.Name
+	}
+	return mapColumns(names, structType, opts)
+}
+
+// mapColumns matches column names against the fields of structType, as
+// described for buildFieldMapping. Empty names never match. Fields matching
+// a name shared by several columns take them in order, so the first such
+// field gets the first column. It fails if two fields resolve to the same
+// column, or, with RequireAllFields, if a field has no column.
+func mapColumns(names []string, structType reflect.Type, opts *options) (map[int]int, error) {
+	meta := getStructMeta(structType, opts)
+	if meta.Err != nil {
+		return nil, meta.Err
+	}
 	fieldMap := make(map[int]int)
 
 	// Build a map of column names to their indices
-	colMap := make(map[string]int)
-	for i, fd := range fieldDescs {
-		colMap[string(fd.Name)] = i
+	colMap := make(map[string][]int, len(names))
+	for i, name := range names {
+		if name != "" {
+			colMap[name] = append(colMap[name], i)
+		}
 	}
 
-	// Map struct fields to columns
-	for i := 0; i < structType.NumField(); i++ {
-		field := structType.Field(i)
-		dbTag := field.Tag.Get("db")
+	// column returns the first column called name not yet mapped to a
+	// field. Once all are taken the last is returned again, so that
+	// assign reports the conflict.
+	column := func(name string) (int, bool) {
+		indices, exists := colMap[name]
+		if !exists {
+			return 0, false
+		}
+		for _, i := range indices {
+			if _, taken := fieldMap[i]; !taken {
+				return i, true
+			}
+		}
+		return indices[len(indices)-1], true
+	}
 
-		if dbTag == "" || dbTag == "-" {
-			continue
+	// assign maps the column to field i unless another field has it
+	assign := func(colIndex, i int) error {
+		if prev, taken := fieldMap[colIndex]; taken {
+			return fmt.Errorf("fields %s and %s of %s both map to column %q",
+				meta.Fields[prev].Name, meta.Fields[i].Name, structType, names[colIndex])
 		}
+		fieldMap[colIndex] = i
+		return nil
+	}
+
+	// Map struct fields to columns
+	for i, f := range meta.Fields {
+		colIndex, exists := column(f.Tag)
 
-		// Try to find the column by the full tag first
-		if colIndex, exists := colMap[dbTag]; exists {
-			fieldMap[colIndex] = i
+		// Nested struct fields only match their prefixed column, so that
+		// columns of the same name from different tables don't collide
+		if !exists && f.Nested() {
 			continue
 		}
 
 		// If it's a table.column format, try just the column name
-		if dotIndex := strings.Index(dbTag, "."); dotIndex != -1 {
-			columnName := dbTag[dotIndex+1:]
-			if colIndex, exists := colMap[columnName]; exists {
-				fieldMap[colIndex] = i
-				continue
-			}
+		if !exists && f.HasTable() {
+			colIndex, exists = column(f.Column)
 		}
 
 		// Fallback to field name
-		if colIndex, exists := colMap[field.Name]; exists {
-			fieldMap[colIndex] = i
+		if !exists {
+			colIndex, exists = column(f.Name)
+		}
+
+		if exists {
+			if err := assign(colIndex, i); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if opts.requireAll {
+		if err := checkAllFields(meta, fieldMap, names); err != nil {
+			return nil, err
 		}
 	}
 
@@ -3,7 +3,9 @@ package dbx
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/jackc/pgx/v5"
@@ -13,6 +15,22 @@ import (
 // Mock implementation for testing
 type mockQueryer struct {
 	rows []mockRow
+
+	// Result columns; defaults to id, name, email when nil
+	fields []pgconn.FieldDescription
+
+	// Recorded from the most recent Query or Exec call
+	lastSQL  string
+	lastArgs []interface{}
+
+	// Command tag returned by Exec
+	execTag pgconn.CommandTag
+
+	// Number of Exec calls made
+	execCount int
+
+	// Error returned by Query and Exec
+	err error
 }
 
 type mockRow struct {
@@ -20,15 +38,25 @@ type mockRow struct {
 }
 
 func (m *mockQueryer) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
-	return &mockRows{rows: m.rows, current: -1}, nil
+	m.lastSQL, m.lastArgs = sql, args
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &mockRows{rows: m.rows, fields: m.fields, current: -1}, nil
 }
 
 func (m *mockQueryer) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
-	return pgconn.CommandTag{}, nil
+	m.lastSQL, m.lastArgs = sql, args
+	m.execCount++
+	if m.err != nil {
+		return pgconn.CommandTag{}, m.err
+	}
+	return m.execTag, nil
 }
 
 type mockRows struct {
 	rows    []mockRow
+	fields  []pgconn.FieldDescription
 	current int
 }
 
@@ -49,6 +77,9 @@ func (m *mockRows) Values() ([]interface{}, error) {
 }
 
 func (m *mockRows) FieldDescriptions() []pgconn.FieldDescription {
+	if m.fields != nil {
+		return m.fields
+	}
 	return []pgconn.FieldDescription{
 		{Name: "id"},
 		{Name: "name"},
@@ -144,6 +175,47 @@ func TestInsertStruct(t *testing.T) {
 	}
 }
 
+func TestInsertStructResult(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("INSERT 0 1")}
+
+	type TestUser struct {
+		Name string `db:"name"`
+	}
+
+	inserted, err := InsertStructResult(ctx, mock, "users", TestUser{Name: "Test User"})
+	if err != nil {
+		t.Fatalf("InsertStructResult failed: %v", err)
+	}
+	if inserted != 1 {
+		t.Errorf("Expected 1 row inserted, got %d", inserted)
+	}
+	if mock.lastSQL != `INSERT INTO "users" ("name") VALUES ($1)` {
+		t.Errorf("Unexpected SQL: %s", mock.lastSQL)
+	}
+}
+
+func TestExec(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("UPDATE 4")}
+
+	affected, err := Exec(ctx, mock, "UPDATE users SET active = $1 WHERE team_id = $2", false, 3)
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if affected != 4 {
+		t.Errorf("Expected 4 rows affected, got %d", affected)
+	}
+	if !reflect.DeepEqual(mock.lastArgs, []interface{}{false, 3}) {
+		t.Errorf("Unexpected args: %v", mock.lastArgs)
+	}
+
+	mock.err = errors.New("connection refused")
+	if _, err := Exec(ctx, mock, "DELETE FROM users"); err == nil || !strings.Contains(err.Error(), "exec failed") {
+		t.Errorf("Expected exec error, got %v", err)
+	}
+}
+
 func TestQueryStructs(t *testing.T) {
 	ctx := context.Background()
 	mock := &mockQueryer{
@@ -174,6 +246,104 @@ func TestQueryStructs(t *testing.T) {
 	}
 }
 
+func TestQueryStructsPointerElements(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, "John", "john@example.com"}},
+			{values: []interface{}{2, "Jane", "jane@example.com"}},
+		},
+	}
+
+	type TestUser struct {
+		ID    int    `db:"id"`
+		Name  string `db:"name"`
+		Email string `db:"email"`
+	}
+
+	var users []TestUser
+	if err := QueryStructs(ctx, mock, "SELECT * FROM users", &users); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	var ptrs []*TestUser
+	if err := QueryStructs(ctx, mock, "SELECT * FROM users", &ptrs); err != nil {
+		t.Fatalf("QueryStructs with []*T failed: %v", err)
+	}
+
+	if len(ptrs) != len(users) {
+		t.Fatalf("Expected %d users, got %d", len(users), len(ptrs))
+	}
+	for i := range users {
+		if ptrs[i] == nil || *ptrs[i] != users[i] {
+			t.Errorf("Row %d: expected %+v, got %+v", i, users[i], ptrs[i])
+		}
+	}
+	if ptrs[0] == ptrs[1] {
+		t.Error("Expected a separate struct per row")
+	}
+
+	generic, err := QueryStructsT[*TestUser](ctx, mock, "SELECT * FROM users")
+	if err != nil {
+		t.Fatalf("QueryStructsT failed: %v", err)
+	}
+	if len(generic) != 2 || *generic[1] != users[1] {
+		t.Errorf("Unexpected QueryStructsT result: %+v", generic)
+	}
+}
+
+func TestQueryStructsResetsDestination(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, "John", "john@example.com"}},
+		},
+	}
+
+	type TestUser struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	users := make([]TestUser, 0, 8)
+	users = append(users, TestUser{ID: 99, Name: "stale"})
+
+	if err := QueryStructs(ctx, mock, "SELECT * FROM users", &users); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if len(users) != 1 || users[0].ID != 1 {
+		t.Errorf("Expected stale rows to be dropped, got %+v", users)
+	}
+	if cap(users) != 8 {
+		t.Errorf("Expected capacity to be kept, got %d", cap(users))
+	}
+
+	if err := QueryStructs(ctx, mock, "SELECT * FROM users", &users, Append()); err != nil {
+		t.Fatalf("QueryStructs with Append failed: %v", err)
+	}
+	if len(users) != 2 {
+		t.Errorf("Expected rows to accumulate with Append, got %+v", users)
+	}
+
+	var fresh []TestUser
+	if err := QueryStructs(ctx, mock, "SELECT * FROM users", &fresh); err != nil {
+		t.Fatalf("QueryStructs into nil slice failed: %v", err)
+	}
+	if len(fresh) != 1 {
+		t.Errorf("Expected 1 row in nil slice target, got %+v", fresh)
+	}
+}
+
+func TestQueryStructsRejectsNonStructElements(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	var ids []*int
+	if err := QueryStructs(ctx, mock, "SELECT id FROM users", &ids); err == nil {
+		t.Error("Expected error for slice of non-struct pointers")
+	}
+}
+
 func TestQueryStructsWithTableColumnTags(t *testing.T) {
 	ctx := context.Background()
 	mock := &mockQueryer{
@@ -268,7 +438,7 @@ func TestBuildFieldMapping(t *testing.T) {
 		Email string `db:"email"`
 	}
 
-	fieldMap, err := buildFieldMapping(rows, reflect.TypeOf(TestUser{}))
+	fieldMap, err := buildFieldMapping(rows, reflect.TypeOf(TestUser{}), defaults())
 	if err != nil {
 		t.Fatalf("buildFieldMapping failed: %v", err)
 	}
@@ -296,7 +466,7 @@ func TestBuildFieldMappingWithTableColumn(t *testing.T) {
 		Users_Email string `db:"users.email"`
 	}
 
-	fieldMap, err := buildFieldMapping(rows, reflect.TypeOf(TestUser{}))
+	fieldMap, err := buildFieldMapping(rows, reflect.TypeOf(TestUser{}), defaults())
 	if err != nil {
 		t.Fatalf("buildFieldMapping with table.column failed: %v", err)
 	}
@@ -307,3 +477,232 @@ func TestBuildFieldMappingWithTableColumn(t *testing.T) {
 		t.Errorf("Expected field map %v, got %v", expectedMap, fieldMap)
 	}
 }
+
+func TestQueryStruct(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, "John", "john@example.com"}},
+		},
+	}
+
+	type TestUser struct {
+		ID    int    `db:"users.id"`
+		Name  string `db:"users.name"`
+		Email string `db:"users.email"`
+	}
+
+	var user TestUser
+	err := QueryStruct(ctx, mock, "SELECT * FROM users WHERE id = $1", &user, 1)
+	if err != nil {
+		t.Fatalf("QueryStruct failed: %v", err)
+	}
+
+	expected := TestUser{ID: 1, Name: "John", Email: "john@example.com"}
+	if user != expected {
+		t.Errorf("Expected %+v, got %+v", expected, user)
+	}
+}
+
+func TestQueryStructNoRows(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	type TestUser struct {
+		ID int `db:"id"`
+	}
+
+	var user TestUser
+	err := QueryStruct(ctx, mock, "SELECT * FROM users WHERE id = $1", &user, 1)
+	if !errors.Is(err, ErrNoRows) {
+		t.Fatalf("Expected ErrNoRows, got %v", err)
+	}
+}
+
+func TestQueryStructTooManyRows(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, "John", "john@example.com"}},
+			{values: []interface{}{2, "Jane", "jane@example.com"}},
+		},
+	}
+
+	type TestUser struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	var user TestUser
+	err := QueryStruct(ctx, mock, "SELECT * FROM users", &user)
+	if !errors.Is(err, ErrTooManyRows) {
+		t.Fatalf("Expected ErrTooManyRows, got %v", err)
+	}
+	if user != (TestUser{}) {
+		t.Errorf("Expected dest to be untouched, got %+v", user)
+	}
+}
+
+func TestQueryStructInvalidDest(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	var users []struct{}
+	if err := QueryStruct(ctx, mock, "SELECT 1", &users); err == nil {
+		t.Error("Expected error for pointer to slice dest")
+	}
+	if err := QueryStruct(ctx, mock, "SELECT 1", nil); err == nil {
+		t.Error("Expected error for nil dest")
+	}
+}
+
+func TestQueryStructsT(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, "John", "john@example.com"}},
+			{values: []interface{}{2, "Jane", "jane@example.com"}},
+		},
+	}
+
+	type TestUser struct {
+		ID    int    `db:"users.id"`
+		Name  string `db:"users.name"`
+		Email string `db:"email"`
+	}
+
+	users, err := QueryStructsT[TestUser](ctx, mock, "SELECT * FROM users")
+	if err != nil {
+		t.Fatalf("QueryStructsT failed: %v", err)
+	}
+
+	var expected []TestUser
+	if err := QueryStructs(ctx, mock, "SELECT * FROM users", &expected); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if !reflect.DeepEqual(users, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, users)
+	}
+}
+
+func TestQueryStructsTNonStruct(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	if _, err := QueryStructsT[int](ctx, mock, "SELECT 1"); err == nil {
+		t.Error("Expected error for non-struct type parameter")
+	}
+}
+
+func TestExtractStructFieldsIgnoresTagOptions(t *testing.T) {
+	type TestStruct struct {
+		ID   int    `db:"users.id,pk"`
+		Name string `db:"name"`
+	}
+
+	fields, _, err := extractStructFields(TestStruct{ID: 1, Name: "John"})
+	if err != nil {
+		t.Fatalf("extractStructFields failed: %v", err)
+	}
+
+	expectedFields := []string{"id", "name"}
+	if !reflect.DeepEqual(fields, expectedFields) {
+		t.Errorf("Expected fields %v, got %v", expectedFields, fields)
+	}
+}
+
+// mockFields builds field descriptions with the given column names.
+func mockFields(names ...string) []pgconn.FieldDescription {
+	fields := make([]pgconn.FieldDescription, len(names))
+	for i, name := range names {
+		fields[i] = pgconn.FieldDescription{Name: name}
+	}
+	return fields
+}
+
+func TestQueryJSONIndent(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "name"),
+		rows: []mockRow{
+			{values: []interface{}{1, "John"}},
+		},
+	}
+
+	jsonData, err := QueryJSONIndent(ctx, mock, "SELECT id, name FROM users", "  ")
+	if err != nil {
+		t.Fatalf("QueryJSONIndent failed: %v", err)
+	}
+
+	expected := "[\n  {\n    \"id\": 1,\n    \"name\": \"John\"\n  }\n]"
+	if string(jsonData) != expected {
+		t.Errorf("Expected %s, got %s", expected, jsonData)
+	}
+}
+
+func TestQueryJSONObject(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "name"),
+		rows: []mockRow{
+			{values: []interface{}{1, "John"}},
+		},
+	}
+
+	jsonData, err := QueryJSONObject(ctx, mock, "SELECT id, name FROM users WHERE id = $1", 1)
+	if err != nil {
+		t.Fatalf("QueryJSONObject failed: %v", err)
+	}
+	if string(jsonData) != `{"id":1,"name":"John"}` {
+		t.Errorf("Unexpected JSON: %s", jsonData)
+	}
+
+	mock.rows = nil
+	if _, err := QueryJSONObject(ctx, mock, "SELECT id, name FROM users WHERE id = $1", 2); !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows, got %v", err)
+	}
+
+	mock.rows = []mockRow{
+		{values: []interface{}{1, "John"}},
+		{values: []interface{}{2, "Jane"}},
+	}
+	if _, err := QueryJSONObject(ctx, mock, "SELECT id, name FROM users"); !errors.Is(err, ErrTooManyRows) {
+		t.Errorf("Expected ErrTooManyRows, got %v", err)
+	}
+}
+
+func TestQueryStructsCapacity(t *testing.T) {
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, "Alice", "alice@example.com"}},
+			{values: []interface{}{2, "Bob", "bob@example.com"}},
+		},
+	}
+	type User struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	var users []User
+	if err := QueryStructs(context.Background(), mock, "SELECT * FROM users LIMIT 10", &users, Capacity(10)); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if len(users) != 2 || cap(users) < 10 || users[1].Name != "Bob" {
+		t.Errorf("Expected 2 users with capacity for 10, got %+v (cap %d)", users, cap(users))
+	}
+
+	// Reused capacity must not leak fields from earlier rows
+	users = append(users[:0], User{ID: 9, Name: "stale"}, User{ID: 8, Name: "stale"}, User{ID: 7, Name: "stale"})
+	mock.rows = []mockRow{{values: []interface{}{3, nil, "carol@example.com"}}}
+	if err := QueryStructs(context.Background(), mock, "SELECT * FROM users", &users); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if len(users) != 1 || users[0] != (User{ID: 3}) {
+		t.Errorf("Expected only the new row, got %+v", users)
+	}
+
+	maps, err := QueryMaps(context.Background(), mock, "SELECT * FROM users", Capacity(5))
+	if err != nil || len(maps) != 1 || cap(maps) < 5 {
+		t.Errorf("Expected 1 row with capacity for 5, got %v (cap %d), %v", maps, cap(maps), err)
+	}
+}
@@ -0,0 +1,178 @@
+// Package dbxotel adds OpenTelemetry tracing to dbx.
+//
+// Wrap a dbx.DB to emit one span per Query or Exec:
+//
+//	db := dbxotel.Wrap(dbpool)
+//	users, err := dbx.QueryStructsT[User](ctx, db, "SELECT * FROM users")
+//
+// The wrapper itself satisfies dbx.DB, so it can be passed to every dbx helper.
+// Keeping it in a separate package means the core dbx package does not depend
+// on OpenTelemetry.
+package dbxotel
+
+import (
+	"context"
+	"strings"
+
+	"github.com/JoeFinlinson/dbx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/JoeFinlinson/dbx/dbxotel"
+
+// defaultMaxStatementLength is the default limit on the db.statement attribute.
+const defaultMaxStatementLength = 2048
+
+// DB wraps a dbx.DB and records a span for each statement.
+type DB struct {
+	db                 dbx.DB
+	tracer             trace.Tracer
+	maxStatementLength int
+}
+
+// Option configures a DB.
+type Option func(*DB)
+
+// WithTracerProvider sets the tracer provider used to create spans.
+// The global provider is used by default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(d *DB) {
+		d.tracer = tp.Tracer(instrumentationName)
+	}
+}
+
+// WithMaxStatementLength limits the length of the db.statement attribute.
+// Longer statements are truncated. Zero or negative disables the attribute.
+func WithMaxStatementLength(n int) Option {
+	return func(d *DB) {
+		d.maxStatementLength = n
+	}
+}
+
+// Wrap returns a DB that traces every statement sent to db.
+func Wrap(db dbx.DB, opts ...Option) *DB {
+	d := &DB{
+		db:                 db,
+		tracer:             otel.GetTracerProvider().Tracer(instrumentationName),
+		maxStatementLength: defaultMaxStatementLength,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Query starts a span that ends when the returned rows are fully read or closed.
+func (d *DB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	ctx, span := d.start(ctx, sql)
+
+	rows, err := d.db.Query(ctx, sql, args...)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+// Exec records a span around the statement.
+func (d *DB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	ctx, span := d.start(ctx, sql)
+
+	tag, err := d.db.Exec(ctx, sql, args...)
+	if err == nil {
+		span.SetAttributes(attribute.Int64("db.rows_affected", tag.RowsAffected()))
+	}
+	endSpan(span, err)
+	return tag, err
+}
+
+func (d *DB) start(ctx context.Context, sql string) (context.Context, trace.Span) {
+	op := operation(sql)
+
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation", op),
+	}
+	if d.maxStatementLength > 0 {
+		attrs = append(attrs, attribute.String("db.statement", truncate(sql, d.maxStatementLength)))
+	}
+
+	name := "dbx"
+	if op != "" {
+		name = "dbx " + op
+	}
+
+	return d.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// operation returns the upper-cased leading keyword of sql, e.g. "SELECT".
+func operation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !isRuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// tracedRows ends its span when iteration finishes or the rows are closed.
+type tracedRows struct {
+	pgx.Rows
+	span     trace.Span
+	rowCount int64
+	ended    bool
+}
+
+func (r *tracedRows) Next() bool {
+	if r.Rows.Next() {
+		r.rowCount++
+		return true
+	}
+	r.end()
+	return false
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	r.end()
+}
+
+func (r *tracedRows) end() {
+	if r.ended {
+		return
+	}
+	r.ended = true
+	r.span.SetAttributes(attribute.Int64("db.rows_returned", r.rowCount))
+	endSpan(r.span, r.Rows.Err())
+}
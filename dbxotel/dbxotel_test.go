@@ -0,0 +1,144 @@
+package dbxotel
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/JoeFinlinson/dbx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type fakeDB struct {
+	values [][]any
+	err    error
+}
+
+func (f *fakeDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &fakeRows{values: f.values, current: -1}, nil
+}
+
+func (f *fakeDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if f.err != nil {
+		return pgconn.CommandTag{}, f.err
+	}
+	return pgconn.NewCommandTag("UPDATE 3"), nil
+}
+
+type fakeRows struct {
+	pgx.Rows
+	values  [][]any
+	current int
+}
+
+func (r *fakeRows) Next() bool {
+	r.current++
+	return r.current < len(r.values)
+}
+
+func (r *fakeRows) Values() ([]any, error) { return r.values[r.current], nil }
+func (r *fakeRows) Close()                 {}
+func (r *fakeRows) Err() error             { return nil }
+
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription {
+	return []pgconn.FieldDescription{{Name: "id"}}
+}
+
+func newRecorder() (*tracetest.SpanRecorder, Option) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return recorder, WithTracerProvider(tp)
+}
+
+func attr(attrs []attribute.KeyValue, key string) (attribute.Value, bool) {
+	for _, kv := range attrs {
+		if string(kv.Key) == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestQuerySpan(t *testing.T) {
+	recorder, opt := newRecorder()
+	var db dbx.DB = Wrap(&fakeDB{values: [][]any{{1}, {2}}}, opt)
+
+	rows, err := dbx.QueryMaps(context.Background(), db, "select id from users")
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "dbx SELECT" {
+		t.Errorf("Expected span name %q, got %q", "dbx SELECT", span.Name())
+	}
+	if v, _ := attr(span.Attributes(), "db.statement"); v.AsString() != "select id from users" {
+		t.Errorf("Unexpected db.statement %q", v.AsString())
+	}
+	if v, _ := attr(span.Attributes(), "db.operation"); v.AsString() != "SELECT" {
+		t.Errorf("Unexpected db.operation %q", v.AsString())
+	}
+	if v, _ := attr(span.Attributes(), "db.rows_returned"); v.AsInt64() != 2 {
+		t.Errorf("Expected db.rows_returned 2, got %d", v.AsInt64())
+	}
+}
+
+func TestExecSpan(t *testing.T) {
+	recorder, opt := newRecorder()
+	db := Wrap(&fakeDB{}, opt)
+
+	if _, err := db.Exec(context.Background(), "UPDATE users SET active = false"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+
+	span := recorder.Ended()[0]
+	if v, _ := attr(span.Attributes(), "db.rows_affected"); v.AsInt64() != 3 {
+		t.Errorf("Expected db.rows_affected 3, got %d", v.AsInt64())
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	recorder, opt := newRecorder()
+	db := Wrap(&fakeDB{err: errors.New("boom")}, opt)
+
+	if _, err := db.Query(context.Background(), "SELECT 1"); err == nil {
+		t.Fatal("Expected error")
+	}
+
+	span := recorder.Ended()[0]
+	if span.Status().Code != codes.Error {
+		t.Errorf("Expected error status, got %v", span.Status())
+	}
+}
+
+func TestStatementTruncation(t *testing.T) {
+	recorder, opt := newRecorder()
+	db := Wrap(&fakeDB{}, opt, WithMaxStatementLength(10))
+
+	_, _ = db.Exec(context.Background(), "UPDATE users SET name = 'a very long value'")
+
+	v, _ := attr(recorder.Ended()[0].Attributes(), "db.statement")
+	if v.AsString() != "UPDATE use..." {
+		t.Errorf("Expected truncated statement, got %q", v.AsString())
+	}
+	if !strings.HasPrefix("UPDATE users", strings.TrimSuffix(v.AsString(), "...")) {
+		t.Errorf("Truncated statement is not a prefix: %q", v.AsString())
+	}
+}
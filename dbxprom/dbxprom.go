@@ -0,0 +1,116 @@
+// Package dbxprom provides a Prometheus implementation of dbx.MetricsCollector.
+//
+//	collector := dbxprom.NewCollector()
+//	prometheus.MustRegister(collector)
+//	dbx.SetMetricsCollector(collector)
+//
+// Statements are labelled by operation: the name given with dbx.WithQueryName,
+// or the SQL verb when no name is set. Lookups in a dbx.CachedDB are counted
+// by operation and result, hit or miss.
+package dbxprom
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector records statement counts, error counts, and latency histograms.
+// It implements dbx.MetricsCollector, dbx.CacheCollector, and
+// prometheus.Collector.
+type Collector struct {
+	queries  *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	cache    *prometheus.CounterVec
+}
+
+// Option configures a Collector.
+type Option func(*config)
+
+type config struct {
+	namespace string
+	buckets   []float64
+}
+
+// WithNamespace sets the metric namespace. The default is "dbx".
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithBuckets sets the latency histogram buckets, in seconds.
+// The default is prometheus.DefBuckets.
+func WithBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// NewCollector creates a Collector. Register it with a prometheus.Registerer
+// and install it with dbx.SetMetricsCollector.
+func NewCollector(opts ...Option) *Collector {
+	cfg := config{namespace: "dbx", buckets: prometheus.DefBuckets}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return &Collector{
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace,
+			Name:      "queries_total",
+			Help:      "Total number of statements executed.",
+		}, []string{"op"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace,
+			Name:      "query_errors_total",
+			Help:      "Total number of statements that returned an error.",
+		}, []string{"op"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: cfg.namespace,
+			Name:      "query_duration_seconds",
+			Help:      "Statement latency in seconds.",
+			Buckets:   cfg.buckets,
+		}, []string{"op"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace,
+			Name:      "cache_requests_total",
+			Help:      "Total number of result cache lookups.",
+		}, []string{"op", "result"}),
+	}
+}
+
+// ObserveQuery implements dbx.MetricsCollector.
+func (c *Collector) ObserveQuery(op string, duration time.Duration, err error) {
+	c.queries.WithLabelValues(op).Inc()
+	if err != nil {
+		c.errors.WithLabelValues(op).Inc()
+	}
+	c.duration.WithLabelValues(op).Observe(duration.Seconds())
+}
+
+// ObserveCache implements dbx.CacheCollector.
+func (c *Collector) ObserveCache(op string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	c.cache.WithLabelValues(op, result).Inc()
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.queries.Describe(ch)
+	c.errors.Describe(ch)
+	c.duration.Describe(ch)
+	c.cache.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.queries.Collect(ch)
+	c.errors.Collect(ch)
+	c.duration.Collect(ch)
+	c.cache.Collect(ch)
+}
@@ -0,0 +1,62 @@
+package dbxprom
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JoeFinlinson/dbx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var (
+	_ dbx.MetricsCollector = (*Collector)(nil)
+	_ dbx.CacheCollector   = (*Collector)(nil)
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector(WithNamespace("test"))
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	c.ObserveQuery("SELECT", 10*time.Millisecond, nil)
+	c.ObserveQuery("SELECT", 20*time.Millisecond, errors.New("boom"))
+	c.ObserveQuery("INSERT", 5*time.Millisecond, nil)
+
+	if got := testutil.ToFloat64(c.queries.WithLabelValues("SELECT")); got != 2 {
+		t.Errorf("Expected 2 SELECT queries, got %v", got)
+	}
+	if got := testutil.ToFloat64(c.errors.WithLabelValues("SELECT")); got != 1 {
+		t.Errorf("Expected 1 SELECT error, got %v", got)
+	}
+	if got := testutil.ToFloat64(c.errors.WithLabelValues("INSERT")); got != 0 {
+		t.Errorf("Expected 0 INSERT errors, got %v", got)
+	}
+
+	count, err := testutil.GatherAndCount(reg, "test_query_duration_seconds")
+	if err != nil {
+		t.Fatalf("GatherAndCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 histogram series, got %d", count)
+	}
+}
+
+func TestCollectorCache(t *testing.T) {
+	c := NewCollector()
+
+	c.ObserveCache("SELECT", false)
+	c.ObserveCache("SELECT", true)
+	c.ObserveCache("SELECT", true)
+
+	if got := testutil.ToFloat64(c.cache.WithLabelValues("SELECT", "hit")); got != 2 {
+		t.Errorf("Expected 2 hits, got %v", got)
+	}
+	if got := testutil.ToFloat64(c.cache.WithLabelValues("SELECT", "miss")); got != 1 {
+		t.Errorf("Expected 1 miss, got %v", got)
+	}
+}
@@ -0,0 +1,331 @@
+// Package dbxtest provides a fake dbx.DB for unit tests.
+//
+// Register the statements the code under test is expected to run, along
+// with their results, then check that they all ran:
+//
+//	fake := dbxtest.NewFakeDB()
+//	fake.On("SELECT * FROM users WHERE id = $1").
+//		WithArgs(42).
+//		Returns([]string{"id", "name"}, []any{42, "John"})
+//
+//	user, err := LoadUser(ctx, fake, 42)
+//	fake.AssertExpectations(t)
+//
+// A statement matches an expectation when it contains the expectation's SQL,
+// after runs of whitespace in both are collapsed to single spaces.
+// Expectations are tried in the order they were registered and each may
+// match any number of calls. Statements matching no expectation fail with an
+// error and are reported by AssertExpectations.
+//
+// For tests too involved to script by hand, Record wraps a real database and
+// saves every statement and its results to a JSON fixture, and Replay serves
+// a saved fixture without a database.
+package dbxtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/JoeFinlinson/dbx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Call records a statement sent to a FakeDB.
+type Call struct {
+	SQL  string
+	Args []any
+}
+
+// FakeDB is a dbx.DB that answers statements from registered expectations.
+// It is safe for concurrent use.
+type FakeDB struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+	unexpected   []Call
+}
+
+var _ dbx.DB = (*FakeDB)(nil)
+
+// NewFakeDB returns a FakeDB with no expectations.
+func NewFakeDB() *FakeDB {
+	return &FakeDB{}
+}
+
+// On registers an expectation for statements containing sql and returns it
+// so its result can be configured. Without further configuration it
+// matches with an empty result set and, for Exec, zero rows affected.
+func (f *FakeDB) On(sql string) *Expectation {
+	e := &Expectation{sql: normalize(sql)}
+	f.mu.Lock()
+	f.expectations = append(f.expectations, e)
+	f.mu.Unlock()
+	return e
+}
+
+// Calls returns every statement sent to f so far, in order, including
+// unexpected ones.
+func (f *FakeDB) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// TestingT is the subset of testing.TB used by AssertExpectations.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertExpectations fails t for every expectation that no statement
+// matched and every statement that matched no expectation.
+func (f *FakeDB) AssertExpectations(t TestingT) {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, e := range f.expectations {
+		if e.calls == 0 {
+			t.Errorf("dbxtest: expected statement was not run: %s", e.sql)
+		}
+	}
+	for _, c := range f.unexpected {
+		t.Errorf("dbxtest: unexpected statement: %s (args %v)", c.SQL, c.Args)
+	}
+}
+
+// Query implements dbx.DB.
+func (f *FakeDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	e, err := f.match(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return newRows(e), nil
+}
+
+// Exec implements dbx.DB.
+func (f *FakeDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	e, err := f.match(sql, args)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	if e.err != nil {
+		return pgconn.CommandTag{}, e.err
+	}
+
+	verb, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	return pgconn.NewCommandTag(fmt.Sprintf("%s %d", strings.ToUpper(verb), e.rowsAffected)), nil
+}
+
+// match records a call and returns the first expectation it satisfies.
+func (f *FakeDB) match(sql string, args []any) (*Expectation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	call := Call{SQL: sql, Args: args}
+	f.calls = append(f.calls, call)
+
+	normalized := normalize(sql)
+	for _, e := range f.expectations {
+		if e.matches(normalized, args) {
+			e.calls++
+			return e, nil
+		}
+	}
+
+	f.unexpected = append(f.unexpected, call)
+	return nil, fmt.Errorf("dbxtest: unexpected statement: %s", sql)
+}
+
+// Expectation describes a statement a FakeDB expects and the result it
+// gives. Its methods return the Expectation so they can be chained.
+type Expectation struct {
+	sql          string
+	args         []any
+	matchArgs    bool
+	columns      []string
+	rows         [][]any
+	rowsAffected int64
+	err          error
+	valuesErrRow int
+	valuesErr    error
+	iterErrRow   int
+	iterErr      error
+	calls        int
+}
+
+// WithArgs restricts the expectation to statements sent with exactly args.
+func (e *Expectation) WithArgs(args ...any) *Expectation {
+	e.args, e.matchArgs = args, true
+	return e
+}
+
+// Returns sets the result set of a query: its column names and rows. Use
+// table.column names to exercise table.column struct tags.
+func (e *Expectation) Returns(columns []string, rows ...[]any) *Expectation {
+	e.columns, e.rows = columns, rows
+	return e
+}
+
+// ReturnsRowsAffected sets the number of rows an Exec reports as affected.
+func (e *Expectation) ReturnsRowsAffected(n int64) *Expectation {
+	e.rowsAffected = n
+	return e
+}
+
+// ReturnsError makes the statement itself fail with err.
+func (e *Expectation) ReturnsError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// ValuesError makes reading the values of the given row, counting from
+// zero, fail with err.
+func (e *Expectation) ValuesError(row int, err error) *Expectation {
+	e.valuesErrRow, e.valuesErr = row, err
+	return e
+}
+
+// IterError makes iteration stop after the given number of rows and the
+// rows' Err method report err, as when the connection fails mid-result.
+func (e *Expectation) IterError(afterRows int, err error) *Expectation {
+	e.iterErrRow, e.iterErr = afterRows, err
+	return e
+}
+
+// matches reports whether a statement satisfies the expectation.
+func (e *Expectation) matches(normalizedSQL string, args []any) bool {
+	if !strings.Contains(normalizedSQL, e.sql) {
+		return false
+	}
+	if !e.matchArgs {
+		return true
+	}
+	if len(args) == 0 && len(e.args) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(args, e.args)
+}
+
+// normalize collapses runs of whitespace in sql to single spaces.
+func normalize(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
+
+// rows serves a fixed result set through pgx.Rows.
+type rows struct {
+	fields       []pgconn.FieldDescription
+	values       [][]any
+	tag          pgconn.CommandTag
+	valuesErrRow int
+	valuesErr    error
+	iterErrRow   int
+	iterErr      error
+	current      int
+	err          error
+	closed       bool
+}
+
+var _ pgx.Rows = (*rows)(nil)
+
+func newRows(e *Expectation) *rows {
+	fields := make([]pgconn.FieldDescription, len(e.columns))
+	for i, name := range e.columns {
+		fields[i] = pgconn.FieldDescription{Name: name}
+	}
+	return &rows{
+		fields:       fields,
+		values:       e.rows,
+		tag:          pgconn.NewCommandTag(fmt.Sprintf("SELECT %d", len(e.rows))),
+		valuesErrRow: e.valuesErrRow,
+		valuesErr:    e.valuesErr,
+		iterErrRow:   e.iterErrRow,
+		iterErr:      e.iterErr,
+		current:      -1,
+	}
+}
+
+func (r *rows) Close() {
+	r.closed = true
+}
+
+func (r *rows) Err() error {
+	return r.err
+}
+
+func (r *rows) CommandTag() pgconn.CommandTag {
+	return r.tag
+}
+
+func (r *rows) FieldDescriptions() []pgconn.FieldDescription {
+	return r.fields
+}
+
+func (r *rows) Next() bool {
+	if r.closed || r.err != nil {
+		return false
+	}
+	if r.iterErr != nil && r.current+1 == r.iterErrRow {
+		r.err = r.iterErr
+		return false
+	}
+	r.current++
+	if r.current >= len(r.values) {
+		r.closed = true
+		return false
+	}
+	return true
+}
+
+func (r *rows) Scan(dest ...any) error {
+	values, err := r.Values()
+	if err != nil {
+		return err
+	}
+	if len(dest) != len(values) {
+		return fmt.Errorf("dbxtest: scan got %d destinations for %d columns", len(dest), len(values))
+	}
+
+	for i, d := range dest {
+		target := reflect.ValueOf(d)
+		if target.Kind() != reflect.Pointer || target.IsNil() {
+			return fmt.Errorf("dbxtest: scan destination %d is not a non-nil pointer", i)
+		}
+		target = target.Elem()
+		if values[i] == nil {
+			target.Set(reflect.Zero(target.Type()))
+			continue
+		}
+		v := reflect.ValueOf(values[i])
+		if !v.Type().AssignableTo(target.Type()) {
+			return fmt.Errorf("dbxtest: cannot scan %T into %s", values[i], target.Type())
+		}
+		target.Set(v)
+	}
+	return nil
+}
+
+func (r *rows) Values() ([]any, error) {
+	if r.current < 0 || r.current >= len(r.values) {
+		return nil, fmt.Errorf("dbxtest: no current row")
+	}
+	if r.valuesErr != nil && r.current == r.valuesErrRow {
+		return nil, r.valuesErr
+	}
+	return append([]any(nil), r.values[r.current]...), nil
+}
+
+func (r *rows) RawValues() [][]byte {
+	return nil
+}
+
+func (r *rows) Conn() *pgx.Conn {
+	return nil
+}
@@ -0,0 +1,116 @@
+package dbxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JoeFinlinson/dbx"
+)
+
+// recorder captures AssertExpectations failures.
+type recorder struct {
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestQueryStructs(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeDB()
+	fake.On("SELECT * FROM users WHERE team_id = $1").
+		WithArgs(4).
+		Returns([]string{"id", "name"}, []any{1, "John"}, []any{2, "Jane"})
+
+	type User struct {
+		ID   int    `db:"users.id"`
+		Name string `db:"users.name"`
+	}
+
+	users, err := dbx.QueryStructsT[User](ctx, fake, "SELECT *\n\tFROM users\n\tWHERE team_id = $1", 4)
+	if err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	expected := []User{{ID: 1, Name: "John"}, {ID: 2, Name: "Jane"}}
+	if !reflect.DeepEqual(users, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, users)
+	}
+	fake.AssertExpectations(t)
+}
+
+func TestExec(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeDB()
+	fake.On("DELETE FROM sessions").ReturnsRowsAffected(3)
+
+	affected, err := dbx.Exec(ctx, fake, "DELETE FROM sessions WHERE expires_at < now()")
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if affected != 3 {
+		t.Errorf("Expected 3 rows affected, got %d", affected)
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 1 || calls[0].SQL != "DELETE FROM sessions WHERE expires_at < now()" {
+		t.Errorf("Unexpected calls: %+v", calls)
+	}
+	fake.AssertExpectations(t)
+}
+
+func TestInjectedErrors(t *testing.T) {
+	ctx := context.Background()
+	queryErr := errors.New("connection refused")
+	valuesErr := errors.New("cannot decode")
+	iterErr := errors.New("connection reset")
+
+	fake := NewFakeDB()
+	fake.On("FROM a").ReturnsError(queryErr)
+	fake.On("FROM b").Returns([]string{"id"}, []any{1}, []any{2}).ValuesError(1, valuesErr)
+	fake.On("FROM c").Returns([]string{"id"}, []any{1}, []any{2}).IterError(1, iterErr)
+
+	if _, err := dbx.QueryMaps(ctx, fake, "SELECT id FROM a"); !errors.Is(err, queryErr) {
+		t.Errorf("Expected query error, got %v", err)
+	}
+	if _, err := dbx.QueryMaps(ctx, fake, "SELECT id FROM b"); !errors.Is(err, valuesErr) {
+		t.Errorf("Expected values error, got %v", err)
+	}
+	if _, err := dbx.QueryMaps(ctx, fake, "SELECT id FROM c"); !errors.Is(err, iterErr) {
+		t.Errorf("Expected iteration error, got %v", err)
+	}
+	fake.AssertExpectations(t)
+}
+
+func TestAssertExpectations(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeDB()
+	fake.On("SELECT id FROM users").WithArgs(1)
+	fake.On("UPDATE users")
+
+	if _, err := dbx.QueryMaps(ctx, fake, "SELECT id FROM users", 2); err == nil {
+		t.Error("Expected error for a statement with unexpected args")
+	}
+	if _, err := dbx.Exec(ctx, fake, "UPDATE users SET active = false"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+
+	r := &recorder{}
+	fake.AssertExpectations(r)
+	if len(r.errors) != 2 {
+		t.Fatalf("Expected 2 failures, got %v", r.errors)
+	}
+	if !strings.Contains(r.errors[0], "not run: SELECT id FROM users") {
+		t.Errorf("Expected unmatched expectation, got %s", r.errors[0])
+	}
+	if !strings.Contains(r.errors[1], "unexpected statement: SELECT id FROM users") {
+		t.Errorf("Expected unexpected statement, got %s", r.errors[1])
+	}
+}
@@ -0,0 +1,447 @@
+package dbxtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"os"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/JoeFinlinson/dbx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fixture is the file format written by Recorder.Save and read by Replay.
+type fixture struct {
+	Statements []statement `json:"statements"`
+}
+
+// statement is one recorded Query or Exec and its outcome.
+type statement struct {
+	SQL        string                    `json:"sql"`
+	Exec       bool                      `json:"exec,omitempty"`
+	Args       json.RawMessage           `json:"args"`
+	Error      *recordedError            `json:"error,omitempty"`
+	Fields     []pgconn.FieldDescription `json:"fields,omitempty"`
+	Rows       [][]*recordedValue        `json:"rows,omitempty"`
+	IterError  *recordedError            `json:"iterError,omitempty"`
+	CommandTag string                    `json:"commandTag,omitempty"`
+}
+
+// recordedError keeps enough of an error to rebuild it, including the
+// SQLSTATE of a Postgres error so predicates like dbx.IsUniqueViolation
+// behave the same on replay.
+type recordedError struct {
+	Message  string `json:"message"`
+	Severity string `json:"severity,omitempty"`
+	Code     string `json:"code,omitempty"`
+}
+
+func recordError(err error) *recordedError {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return &recordedError{Message: pgErr.Message, Severity: pgErr.Severity, Code: pgErr.Code}
+	}
+	return &recordedError{Message: err.Error()}
+}
+
+func (e *recordedError) err() error {
+	if e.Code != "" {
+		return &pgconn.PgError{Message: e.Message, Severity: e.Severity, Code: e.Code}
+	}
+	return errors.New(e.Message)
+}
+
+// recordedValue is a value tagged with its Go type so it decodes back to
+// the same type. A nil *recordedValue is SQL NULL.
+type recordedValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// jsonTypes lists the value types that survive a round trip through
+// encoding/json unchanged, keyed by the name stored in fixtures.
+var jsonTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, v := range []any{
+		false, "", []byte(nil), int8(0), int16(0), int32(0), int64(0), int(0),
+		uint8(0), uint16(0), uint32(0), uint64(0), time.Time{}, [16]byte{},
+		netip.Addr{}, netip.Prefix{}, pgtype.Numeric{}, pgtype.UUID{},
+		pgtype.Date{}, pgtype.Timestamp{}, pgtype.Timestamptz{},
+	} {
+		t := reflect.TypeOf(v)
+		jsonTypes[t.String()] = t
+	}
+}
+
+// encodeValue converts a value returned by pgx.Rows.Values to its fixture
+// form. Arrays and JSON objects are encoded element by element.
+func encodeValue(v any) (*recordedValue, error) {
+	var (
+		typ = fmt.Sprintf("%T", v)
+		val any
+	)
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		val = formatFloat(v, 64)
+	case float32:
+		val = formatFloat(float64(v), 32)
+	case []any:
+		elems := make([]*recordedValue, len(v))
+		for i, e := range v {
+			enc, err := encodeValue(e)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = enc
+		}
+		val = elems
+	case map[string]any:
+		fields := make(map[string]*recordedValue, len(v))
+		for k, e := range v {
+			enc, err := encodeValue(e)
+			if err != nil {
+				return nil, err
+			}
+			fields[k] = enc
+		}
+		val = fields
+	default:
+		if _, ok := jsonTypes[typ]; !ok {
+			return nil, fmt.Errorf("dbxtest: cannot record value of type %T", v)
+		}
+		val = v
+	}
+
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return nil, fmt.Errorf("dbxtest: cannot record %T value: %w", v, err)
+	}
+	return &recordedValue{Type: typ, Value: raw}, nil
+}
+
+// formatFloat writes floats as strings so NaN and infinities survive.
+func formatFloat(f float64, bitSize int) string {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
+
+// decodeValue reverses encodeValue.
+func decodeValue(rv *recordedValue) (any, error) {
+	if rv == nil {
+		return nil, nil
+	}
+
+	switch rv.Type {
+	case "float64", "float32":
+		var s string
+		if err := json.Unmarshal(rv.Value, &s); err != nil {
+			return nil, err
+		}
+		bitSize := 64
+		if rv.Type == "float32" {
+			bitSize = 32
+		}
+		f, err := strconv.ParseFloat(s, bitSize)
+		if err != nil {
+			return nil, err
+		}
+		if bitSize == 32 {
+			return float32(f), nil
+		}
+		return f, nil
+	case "[]interface {}":
+		var elems []*recordedValue
+		if err := json.Unmarshal(rv.Value, &elems); err != nil {
+			return nil, err
+		}
+		out := make([]any, len(elems))
+		for i, e := range elems {
+			v, err := decodeValue(e)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	case "map[string]interface {}":
+		var fields map[string]*recordedValue
+		if err := json.Unmarshal(rv.Value, &fields); err != nil {
+			return nil, err
+		}
+		out := make(map[string]any, len(fields))
+		for k, e := range fields {
+			v, err := decodeValue(e)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = v
+		}
+		return out, nil
+	}
+
+	t, ok := jsonTypes[rv.Type]
+	if !ok {
+		return nil, fmt.Errorf("dbxtest: unknown recorded type %q", rv.Type)
+	}
+	p := reflect.New(t)
+	if err := json.Unmarshal(rv.Value, p.Interface()); err != nil {
+		return nil, fmt.Errorf("dbxtest: cannot decode recorded %s: %w", rv.Type, err)
+	}
+	return p.Elem().Interface(), nil
+}
+
+// encodeArgs converts statement arguments to the form they are matched in.
+// Arguments of types that cannot be recorded, such as custom driver.Valuer
+// types, are compared by type and %v formatting.
+func encodeArgs(args []any) json.RawMessage {
+	enc := make([]*recordedValue, len(args))
+	for i, a := range args {
+		v, err := encodeValue(a)
+		if err != nil {
+			raw, _ := json.Marshal(fmt.Sprintf("%v", a))
+			v = &recordedValue{Type: fmt.Sprintf("%T", a), Value: raw}
+		}
+		enc[i] = v
+	}
+	raw, _ := json.Marshal(enc)
+	return raw
+}
+
+// Recorder is a dbx.DB that runs statements on another DB and records them,
+// with their results, for Replay. It is safe for concurrent use.
+type Recorder struct {
+	db         dbx.DB
+	mu         sync.Mutex
+	statements []statement
+}
+
+var _ dbx.DB = (*Recorder)(nil)
+
+// Record returns a Recorder that runs statements on db.
+func Record(db dbx.DB) *Recorder {
+	return &Recorder{db: db}
+}
+
+// Query runs sql on the underlying DB and records its rows. The rows are
+// read in full before Query returns. It fails if a row holds a value of a
+// type that cannot be recorded.
+func (r *Recorder) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	st := statement{SQL: sql, Args: encodeArgs(args)}
+
+	src, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		st.Error = recordError(err)
+		r.add(st)
+		return nil, err
+	}
+	defer src.Close()
+
+	result := &rows{fields: src.FieldDescriptions(), current: -1}
+	for src.Next() {
+		values, err := src.Values()
+		if err != nil {
+			result.iterErr = err
+			break
+		}
+
+		enc := make([]*recordedValue, len(values))
+		for i, v := range values {
+			if enc[i], err = encodeValue(v); err != nil {
+				return nil, err
+			}
+		}
+		result.values = append(result.values, values)
+		st.Rows = append(st.Rows, enc)
+	}
+	src.Close()
+	if result.iterErr == nil {
+		result.iterErr = src.Err()
+	}
+	result.iterErrRow = len(result.values)
+	result.tag = src.CommandTag()
+
+	st.Fields = result.fields
+	st.CommandTag = result.tag.String()
+	if result.iterErr != nil {
+		st.IterError = recordError(result.iterErr)
+	}
+	r.add(st)
+	return result, nil
+}
+
+// Exec runs sql on the underlying DB and records its command tag.
+func (r *Recorder) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	st := statement{SQL: sql, Exec: true, Args: encodeArgs(args)}
+
+	tag, err := r.db.Exec(ctx, sql, args...)
+	if err != nil {
+		st.Error = recordError(err)
+	} else {
+		st.CommandTag = tag.String()
+	}
+	r.add(st)
+	return tag, err
+}
+
+func (r *Recorder) add(st statement) {
+	r.mu.Lock()
+	r.statements = append(r.statements, st)
+	r.mu.Unlock()
+}
+
+// Save writes the statements recorded so far to path as indented JSON.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(fixture{Statements: r.statements}, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("dbxtest: failed to encode fixture: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Replayer is a dbx.DB that serves statements recorded by a Recorder,
+// without a database. Each recorded statement is served once, in recorded
+// order among statements that match, so a query run twice may return
+// different results each time. It is safe for concurrent use.
+type Replayer struct {
+	statements       []statement
+	used             []bool
+	ignoreWhitespace bool
+	mu               sync.Mutex
+}
+
+var _ dbx.DB = (*Replayer)(nil)
+
+// ReplayOption configures a Replayer.
+type ReplayOption func(*Replayer)
+
+// IgnoreWhitespace matches statements whose SQL differs from the recording
+// only in whitespace. By default SQL must match exactly.
+func IgnoreWhitespace() ReplayOption {
+	return func(r *Replayer) {
+		r.ignoreWhitespace = true
+	}
+}
+
+// Replay loads a fixture written by Recorder.Save. A statement matches a
+// recorded one with the same SQL and arguments.
+func Replay(path string, opts ...ReplayOption) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("dbxtest: invalid fixture %s: %w", path, err)
+	}
+
+	r := &Replayer{statements: f.Statements, used: make([]bool, len(f.Statements))}
+	for _, opt := range opts {
+		opt(r)
+	}
+	for i := range r.statements {
+		st := &r.statements[i]
+		if st.Args, err = compactJSON(st.Args); err != nil {
+			return nil, fmt.Errorf("dbxtest: invalid fixture %s: %w", path, err)
+		}
+		if r.ignoreWhitespace {
+			st.SQL = normalize(st.SQL)
+		}
+	}
+	return r, nil
+}
+
+// Query serves the rows recorded for sql and args.
+func (r *Replayer) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	st, err := r.next(sql, false, args)
+	if err != nil {
+		return nil, err
+	}
+	if st.Error != nil {
+		return nil, st.Error.err()
+	}
+
+	result := &rows{
+		fields:     st.Fields,
+		values:     make([][]any, len(st.Rows)),
+		tag:        pgconn.NewCommandTag(st.CommandTag),
+		iterErrRow: len(st.Rows),
+		current:    -1,
+	}
+	for i, enc := range st.Rows {
+		values := make([]any, len(enc))
+		for j, v := range enc {
+			if values[j], err = decodeValue(v); err != nil {
+				return nil, err
+			}
+		}
+		result.values[i] = values
+	}
+	if st.IterError != nil {
+		result.iterErr = st.IterError.err()
+	}
+	return result, nil
+}
+
+// Exec serves the command tag recorded for sql and args.
+func (r *Replayer) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	st, err := r.next(sql, true, args)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	if st.Error != nil {
+		return pgconn.CommandTag{}, st.Error.err()
+	}
+	return pgconn.NewCommandTag(st.CommandTag), nil
+}
+
+// next claims the first unused recorded statement matching a call.
+func (r *Replayer) next(sql string, exec bool, args []any) (*statement, error) {
+	if r.ignoreWhitespace {
+		sql = normalize(sql)
+	}
+	encArgs := string(encodeArgs(args))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.statements {
+		st := &r.statements[i]
+		if r.used[i] || st.Exec != exec || st.SQL != sql || string(st.Args) != encArgs {
+			continue
+		}
+		r.used[i] = true
+		return st, nil
+	}
+	return nil, fmt.Errorf("dbxtest: no recorded result for statement: %s (args %v)", sql, args)
+}
+
+// compactJSON strips insignificant whitespace so recorded arguments compare
+// equal to freshly encoded ones.
+func compactJSON(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return json.RawMessage("[]"), nil
+	}
+	var b bytes.Buffer
+	if err := json.Compact(&b, raw); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
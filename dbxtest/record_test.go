@@ -0,0 +1,153 @@
+package dbxtest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/netip"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JoeFinlinson/dbx"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestRecordReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "users.json")
+
+	created := time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.UTC)
+	var balance pgtype.Numeric
+	if err := balance.Scan("1234.50"); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := NewFakeDB()
+	fake.On("SELECT * FROM users WHERE id = $1").WithArgs(int64(1)).Returns(
+		[]string{"id", "name", "avatar", "created_at", "balance", "score", "tags", "prefs", "uid", "net", "deleted_at"},
+		[]any{
+			int32(1), "John", []byte{0xde, 0xad}, created, balance, math.NaN(),
+			[]any{"admin", "ops"}, map[string]any{"theme": "dark", "size": float64(12)},
+			[16]byte{1, 2, 3}, netip.MustParsePrefix("10.0.0.0/8"), nil,
+		},
+	)
+	fake.On("UPDATE users").ReturnsRowsAffected(1)
+	fake.On("INSERT INTO users").ReturnsError(&pgconn.PgError{Severity: "ERROR", Code: "23505", Message: "duplicate key"})
+
+	rec := Record(fake)
+	recorded, err := dbx.QueryMaps(ctx, rec, "SELECT * FROM users WHERE id = $1", int64(1))
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if _, err := dbx.Exec(ctx, rec, "UPDATE users SET name = $1", "Jane"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if _, err := dbx.Exec(ctx, rec, "INSERT INTO users (name) VALUES ($1)", "Jane"); !dbx.IsUniqueViolation(err) {
+		t.Fatalf("Expected unique violation, got %v", err)
+	}
+	if err := rec.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	db, err := Replay(path)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	replayed, err := dbx.QueryMaps(ctx, db, "SELECT * FROM users WHERE id = $1", int64(1))
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+
+	// NaN never equals itself, so compare it separately
+	if score, ok := replayed[0]["score"].(float64); !ok || !math.IsNaN(score) {
+		t.Errorf("Expected NaN score, got %v", replayed[0]["score"])
+	}
+	delete(recorded[0], "score")
+	delete(replayed[0], "score")
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("Expected %#v, got %#v", recorded, replayed)
+	}
+
+	affected, err := dbx.Exec(ctx, db, "UPDATE users SET name = $1", "Jane")
+	if err != nil || affected != 1 {
+		t.Errorf("Expected 1 row affected, got %d, %v", affected, err)
+	}
+	if _, err := dbx.Exec(ctx, db, "INSERT INTO users (name) VALUES ($1)", "Jane"); !dbx.IsUniqueViolation(err) {
+		t.Errorf("Expected unique violation on replay, got %v", err)
+	}
+}
+
+func TestReplayMatching(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "count.json")
+
+	fake := NewFakeDB()
+	fake.On(`SELECT count(*) FROM "users"`).Returns([]string{"count"}, []any{int64(2)})
+	rec := Record(fake)
+	if _, err := dbx.Count(ctx, rec, "users", ""); err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if err := rec.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	db, err := Replay(path)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if _, err := dbx.QueryMaps(ctx, db, `SELECT  count(*)  FROM "users"`); err == nil {
+		t.Error("Expected whitespace differences to prevent a match")
+	}
+	if n, err := dbx.Count(ctx, db, "users", ""); err != nil || n != 2 {
+		t.Errorf("Expected count 2, got %d, %v", n, err)
+	}
+	if _, err := dbx.Count(ctx, db, "users", ""); err == nil || !strings.Contains(err.Error(), "no recorded result") {
+		t.Errorf("Expected each recording to be served once, got %v", err)
+	}
+
+	db, err = Replay(path, IgnoreWhitespace())
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if _, err := dbx.QueryMaps(ctx, db, "SELECT count(*)\n\tFROM \"users\""); err != nil {
+		t.Errorf("Expected match ignoring whitespace, got %v", err)
+	}
+}
+
+func TestRecordIterError(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "iter.json")
+	iterErr := errors.New("connection reset")
+
+	fake := NewFakeDB()
+	fake.On("SELECT id FROM users").Returns([]string{"id"}, []any{1}, []any{2}).IterError(1, iterErr)
+	rec := Record(fake)
+	if _, err := dbx.QueryMaps(ctx, rec, "SELECT id FROM users"); !errors.Is(err, iterErr) {
+		t.Fatalf("Expected iteration error, got %v", err)
+	}
+	if err := rec.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	db, err := Replay(path)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if _, err := dbx.QueryMaps(ctx, db, "SELECT id FROM users"); err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("Expected replayed iteration error, got %v", err)
+	}
+}
+
+func TestRecordUnsupportedValue(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeDB()
+	fake.On("SELECT").Returns([]string{"d"}, []any{time.Second})
+
+	if _, err := dbx.QueryMaps(ctx, Record(fake), "SELECT interval '1 second' AS d"); err == nil || !strings.Contains(err.Error(), "time.Duration") {
+		t.Errorf("Expected unsupported type error, got %v", err)
+	}
+}
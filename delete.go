@@ -0,0 +1,111 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DeleteStruct deletes the row identified by the primary key fields of a struct.
+// Primary key fields are marked with the pk tag option, e.g. db:"users.id,pk".
+// Multiple pk fields form a composite key and are ANDed together.
+// It returns the number of rows affected, and refuses to run when the struct
+// has no pk fields rather than issuing an unfiltered DELETE.
+func DeleteStruct(ctx context.Context, db DB, table string, data any) (int64, error) {
+	keys, values, err := extractPrimaryKey(data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to extract primary key: %w", err)
+	}
+
+	if len(keys) == 0 {
+		return 0, fmt.Errorf("no primary key fields found; tag them with the pk option, e.g. db:\"id,pk\"")
+	}
+
+	quotedTable, err := qualifyTable(db, table)
+	if err != nil {
+		return 0, err
+	}
+	quotedKeys, err := quoteColumns(keys)
+	if err != nil {
+		return 0, err
+	}
+
+	conditions := make([]string, len(quotedKeys))
+	for i, key := range quotedKeys {
+		conditions[i] = fmt.Sprintf("%s = $%d", key, i+1)
+	}
+
+	sql := fmt.Sprintf("DELETE FROM %s WHERE %s",
+		quotedTable,
+		strings.Join(conditions, " AND "),
+	)
+
+	tag, err := exec(ctx, db, "DeleteStruct", sql, values, nil)
+	if err != nil {
+		return 0, fmt.Errorf("delete failed: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// DeleteWhere deletes the rows of table matching where, which uses $1, $2,
+// ... placeholders for args, and returns the number of rows affected. An
+// empty where is refused unless the AllRows option is passed among the
+// args. The table name is validated and quoted.
+//
+//	n, err := dbx.DeleteWhere(ctx, db, "sessions", "expires_at < $1", time.Now())
+func DeleteWhere(ctx context.Context, db DB, table, where string, args ...any) (int64, error) {
+	opts, args := splitArgs(args)
+
+	quoted, err := qualifyTable(db, table)
+	if err != nil {
+		return 0, err
+	}
+
+	sql := "DELETE FROM " + quoted
+	if strings.TrimSpace(where) != "" {
+		sql += " WHERE " + where
+	} else if !opts.allRows {
+		return 0, errWhereRequired
+	}
+
+	tag, err := exec(ctx, db, "DeleteWhere", sql, args, opts)
+	if err != nil {
+		return 0, fmt.Errorf("delete failed: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// extractPrimaryKey extracts the column names and values of the pk-tagged
+// fields of a struct.
+func extractPrimaryKey(data any) ([]string, []any, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("data must be a struct or pointer to struct")
+	}
+
+	var keys []string
+	var values []any
+
+	for _, f := range getStructMeta(v.Type(), defaults()).Fields {
+		if !f.Options.Contains("pk") || f.Nested() {
+			continue
+		}
+
+		value, err := f.encode(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode field %s: %w", f.Name, err)
+		}
+
+		keys = append(keys, f.Column)
+		values = append(values, value)
+	}
+
+	return keys, values, nil
+}
@@ -0,0 +1,118 @@
+package dbx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestDeleteStruct(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("DELETE 1")}
+
+	type TestUser struct {
+		ID   int    `db:"users.id,pk"`
+		Name string `db:"users.name"`
+	}
+
+	affected, err := DeleteStruct(ctx, mock, "users", &TestUser{ID: 7, Name: "John"})
+	if err != nil {
+		t.Fatalf("DeleteStruct failed: %v", err)
+	}
+
+	if affected != 1 {
+		t.Errorf("Expected 1 row affected, got %d", affected)
+	}
+
+	expectedSQL := `DELETE FROM "users" WHERE "id" = $1`
+	if mock.lastSQL != expectedSQL {
+		t.Errorf("Expected SQL %q, got %q", expectedSQL, mock.lastSQL)
+	}
+
+	if !reflect.DeepEqual(mock.lastArgs, []interface{}{7}) {
+		t.Errorf("Expected args [7], got %v", mock.lastArgs)
+	}
+}
+
+func TestDeleteStructCompositeKey(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	type Membership struct {
+		UserID  int    `db:"user_id,pk"`
+		GroupID int    `db:"group_id,pk"`
+		Role    string `db:"role"`
+	}
+
+	_, err := DeleteStruct(ctx, mock, "memberships", Membership{UserID: 1, GroupID: 2, Role: "admin"})
+	if err != nil {
+		t.Fatalf("DeleteStruct failed: %v", err)
+	}
+
+	expectedSQL := `DELETE FROM "memberships" WHERE "user_id" = $1 AND "group_id" = $2`
+	if mock.lastSQL != expectedSQL {
+		t.Errorf("Expected SQL %q, got %q", expectedSQL, mock.lastSQL)
+	}
+}
+
+func TestDeleteStructWithoutPrimaryKey(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	type TestUser struct {
+		Name string `db:"name"`
+	}
+
+	if _, err := DeleteStruct(ctx, mock, "users", TestUser{Name: "John"}); err == nil {
+		t.Error("Expected error for struct without pk fields")
+	}
+	if mock.lastSQL != "" {
+		t.Errorf("Expected no statement to be executed, got %q", mock.lastSQL)
+	}
+}
+
+func TestDeleteWhere(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("DELETE 3")}
+
+	affected, err := DeleteWhere(ctx, mock, "sessions", "user_id = $1", 7)
+	if err != nil {
+		t.Fatalf("DeleteWhere failed: %v", err)
+	}
+	if affected != 3 {
+		t.Errorf("Expected 3 rows affected, got %d", affected)
+	}
+
+	expectedSQL := `DELETE FROM "sessions" WHERE user_id = $1`
+	if mock.lastSQL != expectedSQL {
+		t.Errorf("Expected SQL %q, got %q", expectedSQL, mock.lastSQL)
+	}
+	if !reflect.DeepEqual(mock.lastArgs, []interface{}{7}) {
+		t.Errorf("Expected args [7], got %v", mock.lastArgs)
+	}
+}
+
+func TestDeleteWhereRequiresWhere(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("DELETE 5")}
+
+	if _, err := DeleteWhere(ctx, mock, "sessions", " "); err == nil {
+		t.Fatal("Expected error for empty where clause")
+	}
+	if mock.execCount != 0 {
+		t.Errorf("Expected no exec, got %d", mock.execCount)
+	}
+
+	affected, err := DeleteWhere(ctx, mock, "sessions", "", AllRows())
+	if err != nil {
+		t.Fatalf("DeleteWhere failed: %v", err)
+	}
+	if affected != 5 {
+		t.Errorf("Expected 5 rows affected, got %d", affected)
+	}
+	if mock.lastSQL != `DELETE FROM "sessions"` {
+		t.Errorf("Unexpected SQL: %q", mock.lastSQL)
+	}
+}
@@ -0,0 +1,279 @@
+package dbx
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var bytesType = reflect.TypeOf([]byte(nil))
+
+// DirectScan makes QueryStructs and QueryStructsT read columns of common
+// types straight into struct fields with pgx's Rows.Scan, instead of
+// decoding every column of a row into an interface value with Rows.Values
+// and converting it. Integer, float, text, bool, timestamp, date, and bytea
+// columns in fields of the matching kind, or pointers to them, take the
+// direct path; other columns in the same row are read as before. Results
+// are the same either way.
+//
+// The direct path is skipped, leaving the query to the usual path, with
+// the Lenient option, for structs with a rest field or nested struct
+// pointers, and for rows not read from a pgx connection, such as those of
+// stdsql, dbxtest, and CachedDB.
+func DirectScan() Option {
+	return func(o *options) {
+		o.directScan = true
+	}
+}
+
+// directDest is a destination for one column reused across rows by the
+// direct path. target is passed to Rows.Scan; assign then copies the
+// scanned value into a non-pointer field.
+type directDest interface {
+	target() any
+	null() bool
+	assign(field reflect.Value, opts *options) error
+	valueType() reflect.Type
+}
+
+// directColumn is a column read by the direct path.
+type directColumn struct {
+	col   int        // column index
+	field *fieldMeta // field the column is mapped to
+	dest  directDest
+}
+
+// planDirect sets up the direct path for rows, reporting whether it
+// applies. Columns without a direct destination are left to Values.
+func (s *structScanner) planDirect(rows pgx.Rows) bool {
+	if s.opts.lenient || s.rest != nil || len(s.nullable) > 0 || rows.Conn() == nil {
+		return false
+	}
+
+	targets := make([]any, len(s.columns))
+	var direct []directColumn
+	var slow []int
+	for colIndex, fieldPos := range s.fieldMap {
+		meta := &s.fields[fieldPos]
+		var dest directDest
+		if meta.Endpoints == nil && !meta.Options.Contains("json") {
+			dest = newDirectDest(s.oids[colIndex], meta.Index, s.structType)
+		}
+		if dest == nil {
+			slow = append(slow, colIndex)
+			continue
+		}
+		targets[colIndex] = dest.target()
+		direct = append(direct, directColumn{col: colIndex, field: meta, dest: dest})
+	}
+	if len(direct) == 0 {
+		return false
+	}
+	s.direct, s.targets, s.slow = direct, targets, slow
+	return true
+}
+
+// scanDirect reads the current row of rows into elem through the direct
+// path set up by planDirect, falling back to Values for the other mapped
+// columns.
+func (s *structScanner) scanDirect(rows pgx.Rows, elem reflect.Value) error {
+	if err := rows.Scan(s.targets...); err != nil {
+		return fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	for i := range s.direct {
+		c := &s.direct[i]
+		field := c.field.settable(elem)
+		if !field.CanSet() {
+			continue
+		}
+		if err := setDirect(field, c.dest, s.opts); err != nil {
+			return s.conversionError(c.col, c.field, c.dest.valueType(), field, err)
+		}
+	}
+
+	if len(s.slow) > 0 {
+		values, err := rows.Values()
+		if err != nil {
+			return fmt.Errorf("failed to get row values: %w", err)
+		}
+		for _, colIndex := range s.slow {
+			if err := s.assign(elem, colIndex, &s.fields[s.fieldMap[colIndex]], values[colIndex]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setDirect sets field, which may be a pointer, from the value scanned
+// into d, as assignValue would from the same value.
+func setDirect(field reflect.Value, d directDest, opts *options) error {
+	if d.null() {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	if field.Kind() != reflect.Pointer {
+		return d.assign(field, opts)
+	}
+	ptr := reflect.New(field.Type().Elem())
+	if err := d.assign(ptr.Elem(), opts); err != nil {
+		return err
+	}
+	field.Set(ptr)
+	return nil
+}
+
+// newDirectDest returns a destination for a column of type oid mapped to
+// the field at index in structType, or nil if the column must go through
+// Values, because the pair has conversion rules of its own in assignValue
+// or the field is a scanner.
+func newDirectDest(oid uint32, index []int, structType reflect.Type) directDest {
+	t := structType.FieldByIndex(index).Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(scannerType) || reflect.PointerTo(t).Implements(scannerType) {
+		return nil
+	}
+
+	switch oid {
+	case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID:
+		if isIntegerKind(t.Kind()) && t.Kind() != reflect.Uintptr {
+			return &directInt{}
+		}
+	case pgtype.Float4OID, pgtype.Float8OID:
+		if t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64 {
+			return &directFloat{}
+		}
+	case pgtype.TextOID, pgtype.VarcharOID, pgtype.BPCharOID, pgtype.NameOID:
+		if t.Kind() == reflect.String {
+			return &directText{}
+		}
+	case pgtype.BoolOID:
+		if t.Kind() == reflect.Bool {
+			return &directBool{}
+		}
+	case pgtype.TimestamptzOID:
+		if t == timeType {
+			return &directTimestamptz{}
+		}
+	case pgtype.TimestampOID:
+		if t == timeType {
+			return &directTimestamp{}
+		}
+	case pgtype.DateOID:
+		if t == timeType {
+			return &directDate{}
+		}
+	case pgtype.ByteaOID:
+		if t == bytesType {
+			return &directBytes{}
+		}
+	}
+	return nil
+}
+
+type directInt struct{ v pgtype.Int8 }
+
+func (d *directInt) target() any             { return &d.v }
+func (d *directInt) null() bool              { return !d.v.Valid }
+func (d *directInt) valueType() reflect.Type { return reflect.TypeOf(int64(0)) }
+
+func (d *directInt) assign(field reflect.Value, opts *options) error {
+	if field.CanInt() && !field.OverflowInt(d.v.Int64) {
+		field.SetInt(d.v.Int64)
+		return nil
+	}
+	return assignInteger(field, reflect.ValueOf(d.v.Int64))
+}
+
+type directFloat struct{ v pgtype.Float8 }
+
+func (d *directFloat) target() any             { return &d.v }
+func (d *directFloat) null() bool              { return !d.v.Valid }
+func (d *directFloat) valueType() reflect.Type { return reflect.TypeOf(float64(0)) }
+
+func (d *directFloat) assign(field reflect.Value, opts *options) error {
+	field.SetFloat(d.v.Float64)
+	return nil
+}
+
+type directText struct{ v pgtype.Text }
+
+func (d *directText) target() any             { return &d.v }
+func (d *directText) null() bool              { return !d.v.Valid }
+func (d *directText) valueType() reflect.Type { return reflect.TypeOf("") }
+
+func (d *directText) assign(field reflect.Value, opts *options) error {
+	field.SetString(d.v.String)
+	return nil
+}
+
+type directBool struct{ v pgtype.Bool }
+
+func (d *directBool) target() any             { return &d.v }
+func (d *directBool) null() bool              { return !d.v.Valid }
+func (d *directBool) valueType() reflect.Type { return reflect.TypeOf(false) }
+
+func (d *directBool) assign(field reflect.Value, opts *options) error {
+	field.SetBool(d.v.Bool)
+	return nil
+}
+
+type directTimestamptz struct{ v pgtype.Timestamptz }
+
+func (d *directTimestamptz) target() any             { return &d.v }
+func (d *directTimestamptz) null() bool              { return !d.v.Valid }
+func (d *directTimestamptz) valueType() reflect.Type { return timeType }
+
+func (d *directTimestamptz) assign(field reflect.Value, opts *options) error {
+	return setTime(field, d.v.Time, d.v.InfinityModifier, opts)
+}
+
+type directTimestamp struct{ v pgtype.Timestamp }
+
+func (d *directTimestamp) target() any             { return &d.v }
+func (d *directTimestamp) null() bool              { return !d.v.Valid }
+func (d *directTimestamp) valueType() reflect.Type { return timeType }
+
+func (d *directTimestamp) assign(field reflect.Value, opts *options) error {
+	return setTime(field, d.v.Time, d.v.InfinityModifier, opts)
+}
+
+type directDate struct{ v pgtype.Date }
+
+func (d *directDate) target() any             { return &d.v }
+func (d *directDate) null() bool              { return !d.v.Valid }
+func (d *directDate) valueType() reflect.Type { return timeType }
+
+func (d *directDate) assign(field reflect.Value, opts *options) error {
+	return setTime(field, d.v.Time, d.v.InfinityModifier, opts)
+}
+
+// setTime sets a time.Time field from a scanned timestamp or date, which
+// must be finite.
+func setTime(field reflect.Value, t time.Time, inf pgtype.InfinityModifier, opts *options) error {
+	if inf != pgtype.Finite {
+		return errNotConvertible
+	}
+	if opts.timesInUTC {
+		t = t.UTC()
+	}
+	*field.Addr().Interface().(*time.Time) = t
+	return nil
+}
+
+type directBytes struct{ v []byte }
+
+func (d *directBytes) target() any             { return &d.v }
+func (d *directBytes) null() bool              { return d.v == nil }
+func (d *directBytes) valueType() reflect.Type { return bytesType }
+
+func (d *directBytes) assign(field reflect.Value, opts *options) error {
+	field.SetBytes(d.v)
+	return nil
+}
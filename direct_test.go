@@ -0,0 +1,204 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// pgxQueryer serves mockQueryer results as rows that look as if they came
+// from a pgx connection: Scan and Values decode the values with pgx's own
+// codecs by round-tripping them through their binary encoding.
+type pgxQueryer struct {
+	*mockQueryer
+	scans int
+	types *pgtype.Map
+}
+
+func (q *pgxQueryer) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	rows, err := q.mockQueryer.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	if q.types == nil {
+		q.types = pgtype.NewMap()
+	}
+	return &pgxRows{mockRows: rows.(*mockRows), q: q}, nil
+}
+
+type pgxRows struct {
+	*mockRows
+	q *pgxQueryer
+}
+
+func (r *pgxRows) Conn() *pgx.Conn {
+	return new(pgx.Conn)
+}
+
+func (r *pgxRows) Scan(dest ...any) error {
+	r.q.scans++
+	m := r.q.types
+	for i, d := range dest {
+		if d == nil {
+			continue
+		}
+		oid, buf, err := r.encode(m, i)
+		if err != nil {
+			return err
+		}
+		if err := m.PlanScan(oid, pgtype.BinaryFormatCode, d).Scan(buf, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *pgxRows) Values() ([]any, error) {
+	m := r.q.types
+	values := make([]any, len(r.FieldDescriptions()))
+	for i := range values {
+		oid, buf, err := r.encode(m, i)
+		if err != nil {
+			return nil, err
+		}
+		typ, _ := m.TypeForOID(oid)
+		if values[i], err = typ.Codec.DecodeValue(m, oid, pgtype.BinaryFormatCode, buf); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// encode returns the type and binary encoding of column i of the current
+// row, which is nil for NULL.
+func (r *pgxRows) encode(m *pgtype.Map, i int) (uint32, []byte, error) {
+	oid := r.FieldDescriptions()[i].DataTypeOID
+	value := r.rows[r.current].values[i]
+	if value == nil {
+		return oid, nil, nil
+	}
+	buf, err := m.Encode(oid, pgtype.BinaryFormatCode, value, nil)
+	return oid, buf, err
+}
+
+// typedFields returns field descriptions for name, type OID pairs.
+func typedFields(columns ...any) []pgconn.FieldDescription {
+	var fields []pgconn.FieldDescription
+	for i := 0; i < len(columns); i += 2 {
+		fields = append(fields, pgconn.FieldDescription{Name: columns[i].(string), DataTypeOID: uint32(columns[i+1].(int))})
+	}
+	return fields
+}
+
+type directStatus string
+
+type directAccount struct {
+	ID        int64          `db:"id"`
+	Small     int8           `db:"small"`
+	Count     *int32         `db:"count"`
+	Missing   int            `db:"missing"`
+	Rate      float64        `db:"rate"`
+	Name      string         `db:"name"`
+	Status    directStatus   `db:"status"`
+	Nickname  *string        `db:"nickname"`
+	Active    bool           `db:"active"`
+	CreatedAt time.Time      `db:"created_at"`
+	Birthday  *time.Time     `db:"birthday"`
+	Avatar    []byte         `db:"avatar"`
+	Balance   string         `db:"balance"`
+	Settings  map[string]any `db:"settings"`
+}
+
+func directMock() *mockQueryer {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	return &mockQueryer{
+		fields: typedFields(
+			"id", pgtype.Int8OID, "small", pgtype.Int2OID, "count", pgtype.Int4OID, "missing", pgtype.Int4OID,
+			"rate", pgtype.Float8OID, "name", pgtype.TextOID, "status", pgtype.VarcharOID, "nickname", pgtype.TextOID,
+			"active", pgtype.BoolOID, "created_at", pgtype.TimestamptzOID, "birthday", pgtype.DateOID,
+			"avatar", pgtype.ByteaOID, "balance", pgtype.NumericOID, "settings", pgtype.JSONBOID,
+		),
+		rows: []mockRow{
+			{values: []any{
+				int64(1), int16(7), int32(42), nil, 1.5, "Alice", "active", "Al", true, created,
+				time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC), []byte{1, 2}, numeric(1050, -2), map[string]any{"theme": "dark"},
+			}},
+			{values: []any{
+				int64(2), int16(-3), nil, int32(5), -0.25, "Bob", "closed", nil, false, created,
+				nil, nil, nil, nil,
+			}},
+		},
+	}
+}
+
+func TestQueryStructsDirectScan(t *testing.T) {
+	ctx := context.Background()
+
+	var want []directAccount
+	if err := QueryStructs(ctx, &pgxQueryer{mockQueryer: directMock()}, "SELECT * FROM accounts", &want); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	q := &pgxQueryer{mockQueryer: directMock()}
+	var got []directAccount
+	if err := QueryStructs(ctx, q, "SELECT * FROM accounts", &got, DirectScan()); err != nil {
+		t.Fatalf("QueryStructs with DirectScan failed: %v", err)
+	}
+	if q.scans != 2 {
+		t.Errorf("Expected the direct path to scan each row, got %d scans", q.scans)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the same result as without DirectScan:\n got %+v\nwant %+v", got, want)
+	}
+	if got[0].Count == nil || *got[0].Count != 42 || got[1].Nickname != nil || got[0].Balance != "10.50" {
+		t.Errorf("Unexpected values: %+v", got)
+	}
+}
+
+func TestQueryStructsDirectScanOverflow(t *testing.T) {
+	type Row struct {
+		Small int8 `db:"small"`
+	}
+	mock := &mockQueryer{
+		fields: typedFields("small", pgtype.Int2OID),
+		rows:   []mockRow{{values: []any{int16(300)}}},
+	}
+
+	var rows []Row
+	var convErr *ConversionError
+	err := QueryStructs(context.Background(), &pgxQueryer{mockQueryer: mock}, "SELECT small FROM t", &rows, DirectScan())
+	if !errors.As(err, &convErr) || convErr.Field != "Small" || convErr.Column != "small" {
+		t.Errorf("Expected a ConversionError for the overflow, got %v", err)
+	}
+	if len(rows) != 0 {
+		t.Errorf("Expected the failed row left out, got %+v", rows)
+	}
+}
+
+func TestQueryStructsDirectScanSkipped(t *testing.T) {
+	type Row struct {
+		ID   int64  `db:"id"`
+		Rest RowMap `db:",rest"`
+	}
+	mock := &mockQueryer{
+		fields: typedFields("id", pgtype.Int8OID, "name", pgtype.TextOID),
+		rows:   []mockRow{{values: []any{int64(1), "Alice"}}},
+	}
+
+	for name, opts := range map[string][]any{"lenient": {DirectScan(), Lenient()}, "rest field": {DirectScan()}} {
+		q := &pgxQueryer{mockQueryer: mock}
+		var rows []Row
+		if err := QueryStructs(context.Background(), q, "SELECT * FROM t", &rows, opts...); err != nil {
+			t.Fatalf("%s: QueryStructs failed: %v", name, err)
+		}
+		if q.scans != 0 || rows[0].ID != 1 {
+			t.Errorf("%s: expected the Values path, got %d scans and %+v", name, q.scans, rows)
+		}
+	}
+}
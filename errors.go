@@ -0,0 +1,91 @@
+package dbx
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// maxErrorSQL is the longest SQL text, in bytes, kept in a QueryError.
+const maxErrorSQL = 500
+
+// QueryError reports a statement that failed in the database, along with
+// the statement itself. It wraps the driver's error, so errors.As still
+// finds a *pgconn.PgError and errors.Is still matches context.Canceled.
+type QueryError struct {
+	Op   string // dbx helper that ran the statement, e.g. "QueryStructs"
+	SQL  string // statement text, truncated to 500 bytes
+	Args int    // number of arguments sent with the statement
+	Err  error  // underlying error
+}
+
+// newQueryError wraps err, a failure of the statement sql run on behalf of
+// op.
+func newQueryError(op, sql string, args []any, err error) *QueryError {
+	return &QueryError{Op: op, SQL: truncateSQL(sql, maxErrorSQL), Args: len(args), Err: err}
+}
+
+// truncateSQL shortens sql to at most max bytes, without splitting a UTF-8
+// sequence, and marks the cut with "...". A max of zero or less leaves sql
+// whole.
+func truncateSQL(sql string, max int) string {
+	if max <= 0 || len(sql) <= max {
+		return sql
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(sql[cut]) {
+		cut--
+	}
+	return sql[:cut] + "..."
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s %q (%d args): %v", e.Op, e.SQL, e.Args, e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// SQLSTATE codes recognized by the Is* predicates and RunInTxRetry.
+const (
+	codeNotNullViolation     = "23502"
+	codeForeignKeyViolation  = "23503"
+	codeUniqueViolation      = "23505"
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+// IsUniqueViolation reports whether err is a Postgres unique_violation
+// (SQLSTATE 23505), such as inserting a duplicate key.
+func IsUniqueViolation(err error) bool {
+	return hasSQLState(err, codeUniqueViolation)
+}
+
+// IsForeignKeyViolation reports whether err is a Postgres
+// foreign_key_violation (SQLSTATE 23503).
+func IsForeignKeyViolation(err error) bool {
+	return hasSQLState(err, codeForeignKeyViolation)
+}
+
+// IsNotNullViolation reports whether err is a Postgres not_null_violation
+// (SQLSTATE 23502).
+func IsNotNullViolation(err error) bool {
+	return hasSQLState(err, codeNotNullViolation)
+}
+
+// IsSerializationFailure reports whether err is a Postgres
+// serialization_failure (SQLSTATE 40001), after which a serializable
+// transaction should be retried.
+func IsSerializationFailure(err error) bool {
+	return hasSQLState(err, codeSerializationFailure)
+}
+
+// hasSQLState reports whether err wraps a *pgconn.PgError with the given
+// SQLSTATE code.
+func hasSQLState(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
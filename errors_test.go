@@ -0,0 +1,98 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestQueryError(t *testing.T) {
+	ctx := context.Background()
+	pgErr := &pgconn.PgError{Code: "23505", Message: `duplicate key value violates unique constraint "users_email_key"`}
+	mock := &mockQueryer{err: pgErr}
+
+	type TestUser struct {
+		Email string `db:"email"`
+	}
+
+	err := InsertStruct(ctx, mock, "users", TestUser{Email: "john@example.com"})
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) {
+		t.Fatalf("Expected *QueryError, got %T: %v", err, err)
+	}
+	if queryErr.Op != "InsertStruct" || queryErr.SQL != `INSERT INTO "users" ("email") VALUES ($1)` || queryErr.Args != 1 {
+		t.Errorf("Unexpected QueryError: %+v", queryErr)
+	}
+	if !strings.Contains(err.Error(), `InsertStruct "INSERT INTO \"users\" (\"email\") VALUES ($1)" (1 args)`) {
+		t.Errorf("Unexpected message: %v", err)
+	}
+
+	var found *pgconn.PgError
+	if !errors.As(err, &found) || found != pgErr {
+		t.Error("Expected errors.As to find the *pgconn.PgError")
+	}
+	if !IsUniqueViolation(err) {
+		t.Error("Expected IsUniqueViolation to be true")
+	}
+	if IsForeignKeyViolation(err) || IsNotNullViolation(err) || IsSerializationFailure(err) {
+		t.Error("Expected other predicates to be false")
+	}
+}
+
+func TestQueryErrorFromQuery(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{err: context.Canceled}
+
+	_, err := QueryMaps(ctx, mock, "SELECT * FROM users")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) || queryErr.Op != "QueryMaps" {
+		t.Errorf("Expected *QueryError from QueryMaps, got %v", err)
+	}
+}
+
+func TestQueryErrorTruncatesSQL(t *testing.T) {
+	sql := "SELECT " + strings.Repeat("é", 400)
+	err := newQueryError("QueryMaps", sql, nil, errors.New("boom"))
+
+	if !strings.HasSuffix(err.SQL, "...") {
+		t.Errorf("Expected truncated SQL, got %q", err.SQL)
+	}
+	if len(err.SQL) > maxErrorSQL+len("...") {
+		t.Errorf("SQL too long: %d bytes", len(err.SQL))
+	}
+	if !strings.HasSuffix(strings.TrimSuffix(err.SQL, "..."), "é") {
+		t.Errorf("Expected truncation on a rune boundary, got %q", err.SQL)
+	}
+}
+
+func TestSQLStatePredicates(t *testing.T) {
+	tests := []struct {
+		code string
+		is   func(error) bool
+	}{
+		{"23505", IsUniqueViolation},
+		{"23503", IsForeignKeyViolation},
+		{"23502", IsNotNullViolation},
+		{"40001", IsSerializationFailure},
+	}
+
+	for _, tt := range tests {
+		err := newQueryError("Exec", "UPDATE t SET x = 1", nil, &pgconn.PgError{Code: tt.code})
+		if !tt.is(err) {
+			t.Errorf("Expected predicate for %s to match", tt.code)
+		}
+		if tt.is(errors.New(tt.code)) {
+			t.Errorf("Expected predicate for %s not to match a plain error", tt.code)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 
@@ -19,6 +18,12 @@ type User struct {
 	Users_Active bool   `db:"users.active"`
 }
 
+// UserFilter holds optional filters for listing users
+type UserFilter struct {
+	Active    *bool   `db:"active"`
+	EmailLike *string `db:"email,like"`
+}
+
 // Invoice represents an invoice with customer information
 // Demonstrates joining multiple tables
 type Invoice struct {
@@ -58,11 +63,11 @@ func main() {
 
 	// Example 2: QueryJSON - Get results as JSON
 	fmt.Println("\n=== QueryJSON Example ===")
-	jsonData, err := dbx.QueryJSON(ctx, db, "SELECT name, email FROM users LIMIT 3")
+	jsonData, err := dbx.QueryJSONIndent(ctx, db, "SELECT name, email FROM users LIMIT 3", "  ")
 	if err != nil {
 		log.Printf("QueryJSON failed: %v", err)
 	} else {
-		fmt.Printf("JSON result: %s\n", string(jsonData))
+		fmt.Printf("JSON result:\n%s\n", string(jsonData))
 	}
 
 	// Example 3: InsertStruct - Insert a struct into a table
@@ -119,30 +124,16 @@ func main() {
 
 	// Example 6: Dynamic query with maps
 	fmt.Println("\n=== Dynamic Query Example ===")
-	// Simulate a dynamic filter
-	filterActive := true
-	filterEmail := "%@example.com"
-
-	var query string
-	var args []any
+	// Simulate a dynamic filter; nil fields are left out of the WHERE clause
+	active := true
+	emailPattern := "%@example.com"
+	filter := UserFilter{Active: &active, EmailLike: &emailPattern}
 
-	if filterActive {
-		query = "SELECT * FROM users WHERE active = $1"
-		args = append(args, filterActive)
-	} else {
-		query = "SELECT * FROM users"
-	}
-
-	if filterEmail != "" {
-		if len(args) > 0 {
-			query += " AND email LIKE $2"
-		} else {
-			query += " WHERE email LIKE $1"
-		}
-		args = append(args, filterEmail)
+	where, args, err := dbx.FilterToWhere(filter, 1)
+	if err != nil {
+		log.Fatalf("Failed to build filter: %v", err)
 	}
-
-	query += " LIMIT 10"
+	query := "SELECT * FROM users " + where + " LIMIT 10"
 
 	dynamicRows, err := dbx.QueryMaps(ctx, db, query, args...)
 	if err != nil {
@@ -154,20 +145,3 @@ func main() {
 		}
 	}
 }
-
-// Helper function to pretty print JSON
-func prettyPrintJSON(data []byte) {
-	var prettyJSON interface{}
-	if err := json.Unmarshal(data, &prettyJSON); err != nil {
-		fmt.Printf("Error parsing JSON: %v\n", err)
-		return
-	}
-
-	prettyData, err := json.MarshalIndent(prettyJSON, "", "  ")
-	if err != nil {
-		fmt.Printf("Error formatting JSON: %v\n", err)
-		return
-	}
-
-	fmt.Println(string(prettyData))
-}
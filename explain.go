@@ -0,0 +1,138 @@
+package dbx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExplainResult is the plan Postgres reports for a statement, as returned
+// by Explain.
+type ExplainResult struct {
+	TotalCost     float64         // planner's estimated total cost of the root node
+	ActualTime    time.Duration   // actual total time of the root node; zero without ANALYZE
+	PlanningTime  time.Duration   // time spent planning; zero without ANALYZE
+	ExecutionTime time.Duration   // time spent executing; zero without ANALYZE
+	Plan          json.RawMessage // the plan exactly as Postgres returned it
+}
+
+// Explain runs sql prefixed with EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON),
+// with the same args, and returns the resulting plan. ANALYZE executes the
+// statement, so for an INSERT, UPDATE, or DELETE pass Analyze(false) to get
+// the estimated plan only, or run Explain in a transaction that is rolled
+// back.
+//
+//	plan, err := dbx.Explain(ctx, db, "SELECT * FROM users WHERE email = $1", email)
+//	fmt.Println(plan.TotalCost, plan.ExecutionTime)
+//
+// sql must be a single statement.
+func Explain(ctx context.Context, db DB, sql string, args ...any) (ExplainResult, error) {
+	opts, args := splitArgs(args)
+
+	sql = strings.TrimRight(strings.TrimSpace(sql), ";")
+	if sql == "" {
+		return ExplainResult{}, errors.New("cannot explain an empty statement")
+	}
+	if multipleStatements(sql) {
+		return ExplainResult{}, errors.New("cannot explain multiple statements; pass one at a time")
+	}
+
+	prefix := "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) "
+	if opts.planOnly {
+		prefix = "EXPLAIN (FORMAT JSON) "
+	}
+
+	rows, err := query(ctx, db, "Explain", prefix+sql, args, opts)
+	if err != nil {
+		return ExplainResult{}, fmt.Errorf("explain failed: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return ExplainResult{}, fmt.Errorf("explain failed: %w", err)
+		}
+		return ExplainResult{}, errors.New("explain returned no plan")
+	}
+	values, err := rows.Values()
+	if err != nil {
+		return ExplainResult{}, fmt.Errorf("failed to get row values: %w", err)
+	}
+	if len(values) != 1 {
+		return ExplainResult{}, fmt.Errorf("explain returned %d columns", len(values))
+	}
+
+	var raw []byte
+	switch v := values[0].(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		// pgx decodes json columns into maps and slices
+		if raw, err = json.Marshal(v); err != nil {
+			return ExplainResult{}, fmt.Errorf("failed to read plan: %w", err)
+		}
+	}
+	return parseExplain(raw)
+}
+
+// parseExplain reads the figures of an ExplainResult from a JSON plan.
+func parseExplain(raw []byte) (ExplainResult, error) {
+	var plans []struct {
+		Plan struct {
+			TotalCost       float64 `json:"Total Cost"`
+			ActualTotalTime float64 `json:"Actual Total Time"`
+		} `json:"Plan"`
+		PlanningTime  float64 `json:"Planning Time"`
+		ExecutionTime float64 `json:"Execution Time"`
+	}
+	if err := json.Unmarshal(raw, &plans); err != nil {
+		return ExplainResult{}, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	if len(plans) != 1 {
+		return ExplainResult{}, fmt.Errorf("explain returned %d plans", len(plans))
+	}
+
+	p := plans[0]
+	return ExplainResult{
+		TotalCost:     p.Plan.TotalCost,
+		ActualTime:    milliseconds(p.Plan.ActualTotalTime),
+		PlanningTime:  milliseconds(p.PlanningTime),
+		ExecutionTime: milliseconds(p.ExecutionTime),
+		Plan:          json.RawMessage(raw),
+	}, nil
+}
+
+// milliseconds converts a time in fractional milliseconds, as EXPLAIN
+// reports them, to a time.Duration.
+func milliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// multipleStatements reports whether sql contains a semicolon followed by
+// anything other than whitespace and comments, outside string literals,
+// quoted identifiers, and comments.
+func multipleStatements(sql string) bool {
+	ended := false
+	for i := 0; i < len(sql); {
+		if n := skipQuotedOrComment(sql, i); n > i {
+			if ended && sql[i] != '-' && sql[i] != '/' {
+				return true
+			}
+			i = n
+			continue
+		}
+		switch c := sql[i]; {
+		case c == ';':
+			ended = true
+		case ended && c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			return true
+		}
+		i++
+	}
+	return false
+}
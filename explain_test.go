@@ -0,0 +1,91 @@
+package dbx
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const testPlan = `[{"Plan": {"Node Type": "Seq Scan", "Total Cost": 35.5, "Actual Total Time": 0.25}, "Planning Time": 0.1, "Execution Time": 0.5}]`
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: []pgconn.FieldDescription{{Name: "QUERY PLAN"}},
+		rows:   []mockRow{{values: []interface{}{testPlan}}},
+	}
+
+	plan, err := Explain(ctx, mock, "SELECT * FROM users WHERE id = $1;", 1)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+
+	if want := "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) SELECT * FROM users WHERE id = $1"; mock.lastSQL != want {
+		t.Errorf("SQL = %q, want %q", mock.lastSQL, want)
+	}
+	if len(mock.lastArgs) != 1 || mock.lastArgs[0] != 1 {
+		t.Errorf("args = %v, want [1]", mock.lastArgs)
+	}
+	if plan.TotalCost != 35.5 {
+		t.Errorf("TotalCost = %v, want 35.5", plan.TotalCost)
+	}
+	if plan.ActualTime != 250*time.Microsecond {
+		t.Errorf("ActualTime = %v, want 250µs", plan.ActualTime)
+	}
+	if plan.PlanningTime != 100*time.Microsecond || plan.ExecutionTime != 500*time.Microsecond {
+		t.Errorf("PlanningTime, ExecutionTime = %v, %v, want 100µs, 500µs", plan.PlanningTime, plan.ExecutionTime)
+	}
+	if string(plan.Plan) != testPlan {
+		t.Errorf("Plan = %s, want the raw plan", plan.Plan)
+	}
+}
+
+func TestExplainPlanOnly(t *testing.T) {
+	mock := &mockQueryer{
+		fields: []pgconn.FieldDescription{{Name: "QUERY PLAN"}},
+		rows: []mockRow{{values: []interface{}{
+			[]interface{}{map[string]interface{}{"Plan": map[string]interface{}{"Total Cost": 1.5}}},
+		}}},
+	}
+
+	plan, err := Explain(context.Background(), mock, "DELETE FROM users WHERE id = $1", 1, Analyze(false))
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if want := "EXPLAIN (FORMAT JSON) DELETE FROM users WHERE id = $1"; mock.lastSQL != want {
+		t.Errorf("SQL = %q, want %q", mock.lastSQL, want)
+	}
+	if plan.TotalCost != 1.5 || plan.ExecutionTime != 0 {
+		t.Errorf("plan = %+v, want cost 1.5 and no timings", plan)
+	}
+}
+
+func TestExplainMultipleStatements(t *testing.T) {
+	for _, sql := range []string{
+		"SELECT 1; SELECT 2",
+		"DELETE FROM users; DROP TABLE users;",
+		"SELECT 1; 'x'",
+	} {
+		mock := &mockQueryer{}
+		_, err := Explain(context.Background(), mock, sql)
+		if err == nil || !strings.Contains(err.Error(), "multiple statements") {
+			t.Errorf("%q: expected multiple statements error, got %v", sql, err)
+		}
+		if mock.lastSQL != "" {
+			t.Errorf("%q: statement was sent", sql)
+		}
+	}
+
+	for _, sql := range []string{
+		"SELECT ';' FROM users",
+		"SELECT 1; -- trailing comment",
+		`SELECT "a;b" FROM t /* ; */`,
+	} {
+		if multipleStatements(sql) {
+			t.Errorf("%q reported as multiple statements", sql)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package dbx
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// filterOps maps the operator tag options recognised by FilterToWhere to
+// their condition constructors.
+var filterOps = map[string]func(column string, value any) Cond{
+	"eq":  Eq,
+	"neq": Neq,
+	"gt":  Gt,
+	"gte": Gte,
+	"lt":  Lt,
+	"lte": Lte,
+	"in":  In,
+	"like": func(column string, value any) Cond {
+		return comparison{column, "LIKE", value}
+	},
+}
+
+// FilterToWhere renders a WHERE clause from the db-tagged fields of filter,
+// a struct or pointer to struct, with placeholders numbered from
+// startPlaceholder. Nil pointers, zero values, and empty slices are
+// skipped, so optional filters are best declared as pointers:
+//
+//	type UserFilter struct {
+//		Active    *bool    `db:"active"`
+//		EmailLike *string  `db:"email,like"`
+//		Roles     []string `db:"role,in"`
+//	}
+//
+// An operator may be given as a tag option: eq (the default), neq, like,
+// gt, gte, lt, lte, or in. Slice fields default to in. The conditions are
+// combined with AND, and the clause is empty when every field is skipped.
+func FilterToWhere(filter any, startPlaceholder int) (string, []any, error) {
+	if startPlaceholder < 1 {
+		return "", nil, fmt.Errorf("startPlaceholder must be at least 1, got %d", startPlaceholder)
+	}
+
+	v := reflect.ValueOf(filter)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("filter must be a struct or pointer to struct, got %T", filter)
+	}
+
+	var conds []Cond
+	for _, f := range getStructMeta(v.Type(), defaults()).Fields {
+		if f.Nested() {
+			continue
+		}
+
+		field, ok := f.value(v)
+		if !ok || field.IsZero() {
+			continue
+		}
+		if field.Kind() == reflect.Pointer {
+			field = field.Elem()
+		}
+		if field.Kind() == reflect.Slice && field.Len() == 0 {
+			continue
+		}
+
+		op := "eq"
+		if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+			op = "in"
+		}
+		found := false
+		for _, opt := range f.Options {
+			if _, known := filterOps[opt]; !known {
+				continue
+			}
+			if found {
+				return "", nil, fmt.Errorf("field %s has more than one filter operator", f.Name)
+			}
+			op, found = opt, true
+		}
+
+		conds = append(conds, filterOps[op](f.Tag, field.Interface()))
+	}
+
+	sql, args := Where(conds...).Build(startPlaceholder - 1)
+	return sql, args, nil
+}
@@ -0,0 +1,91 @@
+package dbx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFilterToWhere(t *testing.T) {
+	type UserFilter struct {
+		Active    *bool    `db:"active"`
+		EmailLike *string  `db:"email,like"`
+		MinAge    int      `db:"age,gte"`
+		Roles     []string `db:"role"`
+		IDs       []int    `db:"users.id,in"`
+		Name      string   `db:"name,neq"`
+	}
+
+	active := false
+	email := "%@example.com"
+
+	tests := []struct {
+		name     string
+		filter   any
+		start    int
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:    "empty",
+			filter:  UserFilter{},
+			start:   1,
+			wantSQL: "",
+		},
+		{
+			name:     "pointer to false is kept",
+			filter:   &UserFilter{Active: &active, EmailLike: &email},
+			start:    1,
+			wantSQL:  "WHERE active = $1 AND email LIKE $2",
+			wantArgs: []any{false, "%@example.com"},
+		},
+		{
+			name:     "operators and slices",
+			filter:   UserFilter{MinAge: 18, Roles: []string{"admin"}, IDs: []int{}, Name: "root"},
+			start:    3,
+			wantSQL:  "WHERE age >= $3 AND role = ANY($4) AND name <> $5",
+			wantArgs: []any{18, []string{"admin"}, "root"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := FilterToWhere(tt.filter, tt.start)
+			if err != nil {
+				t.Fatalf("FilterToWhere failed: %v", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("Expected SQL %q, got %q", tt.wantSQL, sql)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Expected args %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}
+
+func TestFilterToWhereErrors(t *testing.T) {
+	type Conflicting struct {
+		Age int `db:"age,gt,lt"`
+	}
+
+	tests := []struct {
+		name    string
+		filter  any
+		start   int
+		wantErr string
+	}{
+		{"not a struct", 42, 1, "filter must be a struct"},
+		{"bad start", Conflicting{}, 0, "startPlaceholder must be at least 1"},
+		{"two operators", Conflicting{Age: 1}, 1, "more than one filter operator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := FilterToWhere(tt.filter, tt.start)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GenOpts controls the Go source produced by GenerateStruct.
+type GenOpts struct {
+	// Package, if set, makes the output a complete file: a package clause,
+	// the imports the struct needs, and the struct. Otherwise only the type
+	// declaration is produced.
+	Package string
+
+	// StructName names the struct; it defaults to the table name in
+	// CamelCase, such as LineItem for line_item.
+	StructName string
+
+	// NumericAsString maps numeric columns to string, keeping their exact
+	// decimal value, instead of float64.
+	NumericAsString bool
+
+	// BareColumns tags fields with the column alone, db:"email", instead
+	// of the table.column form, db:"users.email".
+	BareColumns bool
+}
+
+// GenerateStruct reads the columns of a table or view with InspectTable and
+// returns gofmt-formatted Go source for a struct with a tagged field for
+// each. Columns are mapped to Go types as dbx reads them:
+// integers to int16, int32, or int64, timestamps and dates to time.Time,
+// numeric to float64 or, with NumericAsString, string, and arrays to
+// slices. Nullable columns become pointers, except those mapped to slices
+// or any, which can hold NULL as they are. Primary key columns are tagged
+// pk. Types without a Go counterpart are mapped to any.
+//
+//	src, err := dbx.GenerateStruct(ctx, db, "public", "users", dbx.GenOpts{Package: "models"})
+func GenerateStruct(ctx context.Context, db DB, schema, table string, opts GenOpts) (string, error) {
+	info, err := InspectTable(ctx, db, schema, table)
+	if err != nil {
+		return "", err
+	}
+	return generateStruct(info, opts)
+}
+
+// generateStruct renders the struct for info.
+func generateStruct(info *TableInfo, opts GenOpts) (string, error) {
+	name := opts.StructName
+	if name == "" {
+		name = goName(info.Name)
+	}
+
+	imports := make(map[string]bool)
+	seen := make(map[string]string, len(info.Columns))
+	var fields strings.Builder
+	for _, col := range info.Columns {
+		field := goName(col.Name)
+		if other, ok := seen[field]; ok {
+			return "", fmt.Errorf("columns %q and %q both map to field %s", other, col.Name, field)
+		}
+		seen[field] = col.Name
+
+		goType, pkg := goTypeFor(col.Type, opts)
+		if pkg != "" {
+			imports[pkg] = true
+		}
+		if col.Nullable && !strings.HasPrefix(goType, "[]") && goType != "any" {
+			goType = "*" + goType
+		}
+
+		tag := col.Name
+		if !opts.BareColumns {
+			tag = info.Name + "." + col.Name
+		}
+		if col.PrimaryKey {
+			tag += ",pk"
+		}
+		fmt.Fprintf(&fields, "\t%s %s `db:%q`\n", field, goType, tag)
+	}
+
+	var b strings.Builder
+	if opts.Package != "" {
+		b.WriteString("// Code generated by dbx. DO NOT EDIT.\n\n")
+		fmt.Fprintf(&b, "package %s\n\n", opts.Package)
+		if len(imports) > 0 {
+			pkgs := make([]string, 0, len(imports))
+			for pkg := range imports {
+				pkgs = append(pkgs, pkg)
+			}
+			sort.Strings(pkgs)
+			b.WriteString("import (\n")
+			for _, pkg := range pkgs {
+				fmt.Fprintf(&b, "\t%q\n", pkg)
+			}
+			b.WriteString(")\n\n")
+		}
+	}
+	kind := "table"
+	if info.View {
+		kind = "view"
+	}
+	fmt.Fprintf(&b, "// %s is a row of the %s.%s %s.\n", name, info.Schema, info.Name, kind)
+	fmt.Fprintf(&b, "type %s struct {\n%s}\n", name, fields.String())
+
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format struct for %s: %w", info.Name, err)
+	}
+	return string(src), nil
+}
+
+// goTypeFor returns the Go type for a Postgres type as format_type prints
+// it, along with the package it needs imported, if any.
+func goTypeFor(pgType string, opts GenOpts) (goType, pkg string) {
+	if elem, ok := strings.CutSuffix(pgType, "[]"); ok {
+		goType, pkg = goTypeFor(elem, opts)
+		if goType == "any" || goType == "[]byte" {
+			return "any", ""
+		}
+		return "[]" + goType, pkg
+	}
+
+	base, _, _ := strings.Cut(pgType, "(")
+	switch {
+	case base == "boolean":
+		return "bool", ""
+	case base == "smallint":
+		return "int16", ""
+	case base == "integer":
+		return "int32", ""
+	case base == "bigint":
+		return "int64", ""
+	case base == "real":
+		return "float32", ""
+	case base == "double precision":
+		return "float64", ""
+	case base == "numeric":
+		if opts.NumericAsString {
+			return "string", ""
+		}
+		return "float64", ""
+	case base == "text" || base == "character varying" || base == "character" || base == "citext" || base == "name" || base == "uuid":
+		return "string", ""
+	case base == "bytea":
+		return "[]byte", ""
+	case base == "date" || strings.HasPrefix(base, "timestamp"):
+		return "time.Time", "time"
+	}
+	return "any", ""
+}
+
+// goInitialisms are the column name parts written in upper case in Go
+// names, following the Go convention for initialisms.
+var goInitialisms = map[string]bool{
+	"api": true, "id": true, "ip": true, "json": true, "html": true, "http": true,
+	"sql": true, "uid": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// goName converts a snake_case column or table name into an exported Go
+// name, such as UserID for user_id.
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	}) {
+		if goInitialisms[strings.ToLower(part)] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(part)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(part[size:])
+	}
+
+	s := b.String()
+	if r, _ := utf8.DecodeRuneInString(s); s == "" || !unicode.IsLetter(r) {
+		s = "X" + s
+	}
+	return s
+}
@@ -0,0 +1,89 @@
+package dbx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateStruct(t *testing.T) {
+	info := &TableInfo{
+		Schema: "public",
+		Name:   "line_item",
+		Columns: []ColumnInfo{
+			{Name: "id", Type: "bigint", PrimaryKey: true},
+			{Name: "invoice_id", Type: "integer"},
+			{Name: "amount", Type: "numeric(10,2)"},
+			{Name: "note", Type: "text", Nullable: true},
+			{Name: "tags", Type: "text[]", Nullable: true},
+			{Name: "created_at", Type: "timestamp with time zone"},
+			{Name: "shipped_on", Type: "date", Nullable: true},
+			{Name: "attrs", Type: "jsonb", Nullable: true},
+		},
+	}
+
+	src, err := generateStruct(info, GenOpts{Package: "models"})
+	if err != nil {
+		t.Fatalf("generateStruct failed: %v", err)
+	}
+
+	want := "// Code generated by dbx. DO NOT EDIT.\n\n" +
+		"package models\n\n" +
+		"import (\n\t\"time\"\n)\n\n" +
+		"// LineItem is a row of the public.line_item table.\n" +
+		"type LineItem struct {\n" +
+		"\tID        int64      `db:\"line_item.id,pk\"`\n" +
+		"\tInvoiceID int32      `db:\"line_item.invoice_id\"`\n" +
+		"\tAmount    float64    `db:\"line_item.amount\"`\n" +
+		"\tNote      *string    `db:\"line_item.note\"`\n" +
+		"\tTags      []string   `db:\"line_item.tags\"`\n" +
+		"\tCreatedAt time.Time  `db:\"line_item.created_at\"`\n" +
+		"\tShippedOn *time.Time `db:\"line_item.shipped_on\"`\n" +
+		"\tAttrs     any        `db:\"line_item.attrs\"`\n" +
+		"}\n"
+	if src != want {
+		t.Errorf("generated:\n%s\nwant:\n%s", src, want)
+	}
+}
+
+func TestGenerateStructOptions(t *testing.T) {
+	info := &TableInfo{
+		Schema:  "public",
+		Name:    "active_users",
+		View:    true,
+		Columns: []ColumnInfo{{Name: "balance", Type: "numeric"}},
+	}
+
+	src, err := generateStruct(info, GenOpts{StructName: "ActiveUser", NumericAsString: true, BareColumns: true})
+	if err != nil {
+		t.Fatalf("generateStruct failed: %v", err)
+	}
+	for _, want := range []string{"// ActiveUser is a row of the public.active_users view.", "Balance string `db:\"balance\"`"} {
+		if !strings.Contains(src, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "package") {
+		t.Errorf("expected only the type declaration, got:\n%s", src)
+	}
+
+	info.Columns = append(info.Columns, ColumnInfo{Name: "Balance", Type: "text"})
+	if _, err := generateStruct(info, GenOpts{}); err == nil || !strings.Contains(err.Error(), "both map to field Balance") {
+		t.Errorf("expected a field collision error, got %v", err)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"id":          "ID",
+		"user_id":     "UserID",
+		"api_url":     "APIURL",
+		"created_at":  "CreatedAt",
+		"2fa_enabled": "X2faEnabled",
+		"line item":   "LineItem",
+	}
+	for in, want := range tests {
+		if got := goName(in); got != want {
+			t.Errorf("goName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
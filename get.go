@@ -0,0 +1,58 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// GetByID loads the row of table whose primary key equals id into dest,
+// which must be a pointer to a struct. The key column is taken from the
+// struct's pk-tagged field, or is id if it has none. Columns are mapped as
+// by QueryStruct, so table.column tags work with the unqualified columns of
+// SELECT *. ErrNoRows is returned when there is no such row.
+//
+//	var user User
+//	err := dbx.GetByID(ctx, db, "users", &user, 42)
+func GetByID(ctx context.Context, db DB, table string, dest any, id any, opts ...Option) error {
+	column := "id"
+	if t := reflect.TypeOf(dest); t != nil && t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct {
+		o, _ := splitArgs(withOptions(nil, opts))
+		keys := primaryKeyFields(getStructMeta(t.Elem(), o))
+		if len(keys) > 1 {
+			return fmt.Errorf("%s has a composite primary key; use GetBy or QueryStruct", t.Elem())
+		}
+		if len(keys) == 1 {
+			column = keys[0].Column
+		}
+	}
+	return getBy(ctx, db, "GetByID", table, dest, column, id, opts)
+}
+
+// GetBy loads the first row of table whose column equals value into dest,
+// which must be a pointer to a struct, mapping columns as GetByID does.
+// ErrNoRows is returned when no row matches. The table and column names are
+// validated and quoted.
+func GetBy(ctx context.Context, db DB, table string, dest any, column string, value any, opts ...Option) error {
+	return getBy(ctx, db, "GetBy", table, dest, column, value, opts)
+}
+
+// getBy implements GetBy, reporting the query as op.
+func getBy(ctx context.Context, db DB, op, table string, dest any, column string, value any, opts []Option) error {
+	quotedTable, err := qualifyTable(db, table)
+	if err != nil {
+		return err
+	}
+	quotedColumn, err := quoteColumn(column)
+	if err != nil {
+		return err
+	}
+
+	o, args := splitArgs(withOptions([]any{value}, opts))
+	sql := fmt.Sprintf("SELECT * FROM %s WHERE %s = $1 LIMIT 1", quotedTable, quotedColumn)
+	if o.forUpdate {
+		sql += " FOR UPDATE"
+	}
+
+	return queryStruct(ctx, db, op, sql, dest, o, args)
+}
@@ -0,0 +1,87 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGetByID(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "name", "email"),
+		rows:   []mockRow{{values: []interface{}{42, "John", "john@example.com"}}},
+	}
+
+	type User struct {
+		ID    int    `db:"users.id"`
+		Name  string `db:"users.name"`
+		Email string `db:"users.email"`
+	}
+
+	var user User
+	if err := GetByID(ctx, mock, "users", &user, 42); err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if user.ID != 42 || user.Name != "John" {
+		t.Errorf("Unexpected user: %+v", user)
+	}
+	if mock.lastSQL != `SELECT * FROM "users" WHERE "id" = $1 LIMIT 1` {
+		t.Errorf("Unexpected SQL: %s", mock.lastSQL)
+	}
+	if len(mock.lastArgs) != 1 || mock.lastArgs[0] != 42 {
+		t.Errorf("Unexpected args: %v", mock.lastArgs)
+	}
+}
+
+func TestGetByIDPrimaryKeyTag(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("user_id", "name"),
+		rows:   []mockRow{{values: []interface{}{7, "Jane"}}},
+	}
+
+	type Account struct {
+		UserID int    `db:"user_id,pk"`
+		Name   string `db:"name"`
+	}
+
+	var account Account
+	if err := GetByID(ctx, mock, "accounts", &account, 7, ForUpdate()); err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if mock.lastSQL != `SELECT * FROM "accounts" WHERE "user_id" = $1 LIMIT 1 FOR UPDATE` {
+		t.Errorf("Unexpected SQL: %s", mock.lastSQL)
+	}
+
+	type Membership struct {
+		UserID int `db:"user_id,pk"`
+		TeamID int `db:"team_id,pk"`
+	}
+	if err := GetByID(ctx, mock, "memberships", &Membership{}, 7); err == nil {
+		t.Error("Expected error for composite primary key")
+	}
+}
+
+func TestGetBy(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{fields: mockFields("id", "name", "email")}
+
+	type User struct {
+		ID    int    `db:"id"`
+		Email string `db:"email"`
+	}
+
+	var user User
+	err := GetBy(ctx, mock, "users", &user, "email", "nobody@example.com")
+	if !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows, got %v", err)
+	}
+	if mock.lastSQL != `SELECT * FROM "users" WHERE "email" = $1 LIMIT 1` {
+		t.Errorf("Unexpected SQL: %s", mock.lastSQL)
+	}
+
+	if err := GetBy(ctx, mock, "users", &user, "email = '' OR 1=1 --", "x"); err == nil {
+		t.Error("Expected error for invalid column name")
+	}
+}
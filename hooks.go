@@ -0,0 +1,53 @@
+package dbx
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Hooks are invoked around every statement dbx executes. Either function may
+// be nil. Hooks run on the goroutine issuing the statement and must be safe
+// for concurrent use.
+type Hooks struct {
+	// Before is called before the statement is sent. The returned context is
+	// used for the statement and passed to After, so values stored in it can
+	// be used to correlate the two calls. Returning nil keeps ctx unchanged.
+	Before func(ctx context.Context, sql string, args []any) context.Context
+
+	// After is called once the statement has completed; for queries this is
+	// when the rows have been fully read or closed.
+	After func(ctx context.Context, sql string, args []any, err error, duration time.Duration)
+}
+
+var currentHooks atomic.Pointer[Hooks]
+
+// SetHooks installs package-level hooks, replacing any set previously.
+// Passing the zero Hooks removes them.
+func SetHooks(h Hooks) {
+	if h.Before == nil && h.After == nil {
+		currentHooks.Store(nil)
+		return
+	}
+	currentHooks.Store(&h)
+}
+
+// hooksBefore runs the Before hook, if any, and returns the hooks in effect
+// for the statement along with the context to use.
+func hooksBefore(ctx context.Context, sql string, args []any) (*Hooks, context.Context) {
+	h := currentHooks.Load()
+	if h == nil || h.Before == nil {
+		return h, ctx
+	}
+	if hookCtx := h.Before(ctx, sql, args); hookCtx != nil {
+		ctx = hookCtx
+	}
+	return h, ctx
+}
+
+// after runs the After hook, if any.
+func (h *Hooks) after(ctx context.Context, sql string, args []any, err error, duration time.Duration) {
+	if h != nil && h.After != nil {
+		h.After(ctx, sql, args, err, duration)
+	}
+}
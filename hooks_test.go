@@ -0,0 +1,84 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type hookKey struct{}
+
+func TestHooksCorrelateBeforeAndAfter(t *testing.T) {
+	t.Cleanup(func() { SetHooks(Hooks{}) })
+
+	var mu sync.Mutex
+	var seen []string
+	SetHooks(Hooks{
+		Before: func(ctx context.Context, sql string, args []any) context.Context {
+			return context.WithValue(ctx, hookKey{}, "request-1")
+		},
+		After: func(ctx context.Context, sql string, args []any, err error, duration time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			id, _ := ctx.Value(hookKey{}).(string)
+			seen = append(seen, id+":"+sql)
+		},
+	})
+
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, "John", "john@example.com"}},
+		},
+	}
+
+	if _, err := QueryJSON(ctx, mock, "SELECT * FROM users"); err != nil {
+		t.Fatalf("QueryJSON failed: %v", err)
+	}
+
+	type TestUser struct {
+		Name string `db:"name"`
+	}
+	var users []TestUser
+	if err := QueryStructs(ctx, mock, "SELECT name FROM users", &users); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if err := InsertStruct(ctx, mock, "users", TestUser{Name: "Jane"}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+
+	expected := []string{
+		"request-1:SELECT * FROM users",
+		"request-1:SELECT name FROM users",
+		`request-1:INSERT INTO "users" ("name") VALUES ($1)`,
+	}
+	if len(seen) != len(expected) {
+		t.Fatalf("Expected %d After calls, got %v", len(expected), seen)
+	}
+	for i := range expected {
+		if seen[i] != expected[i] {
+			t.Errorf("After call %d: expected %q, got %q", i, expected[i], seen[i])
+		}
+	}
+}
+
+func TestHooksReceiveErrors(t *testing.T) {
+	t.Cleanup(func() { SetHooks(Hooks{}) })
+
+	var got error
+	SetHooks(Hooks{
+		After: func(ctx context.Context, sql string, args []any, err error, duration time.Duration) {
+			got = err
+		},
+	})
+
+	failure := errors.New("boom")
+	mock := &mockQueryer{err: failure}
+	_, _ = QueryMaps(context.Background(), mock, "SELECT 1")
+
+	if !errors.Is(got, failure) {
+		t.Errorf("Expected After to receive %v, got %v", failure, got)
+	}
+}
@@ -0,0 +1,156 @@
+package dbx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// quoteIdent double-quotes a single identifier, doubling any embedded
+// quotes.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Ident quotes parts as a single identifier, such as a table name, joining
+// them with dots: Ident("billing", "Invoice Lines") gives
+// "billing"."Invoice Lines". Each part is used exactly, without validation or
+// case folding, and embedded double quotes are escaped. The result can be
+// passed as a table or column name to the helpers that generate SQL, which
+// otherwise accept only plain identifiers, or spliced into SQL by hand.
+func Ident(parts ...string) string {
+	quoted := make([]string, len(parts))
+	for i, part := range parts {
+		quoted[i] = quoteIdent(part)
+	}
+	return strings.Join(quoted, ".")
+}
+
+// quoteTable validates a possibly schema-qualified table name such as
+// billing.invoice and returns it with each part quoted separately. Unquoted
+// parts must be plain identifiers and are folded to lower case, as
+// PostgreSQL would fold them; parts already wrapped in double quotes are
+// kept exactly.
+func quoteTable(table string) (string, error) {
+	return quoteName("table", table)
+}
+
+// quoteColumn validates and quotes a column name, possibly qualified by its
+// table, in the same way as quoteTable.
+func quoteColumn(column string) (string, error) {
+	return quoteName("column", column)
+}
+
+// quoteColumns applies quoteColumn to each of columns.
+func quoteColumns(columns []string) ([]string, error) {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		var err error
+		if quoted[i], err = quoteColumn(column); err != nil {
+			return nil, err
+		}
+	}
+	return quoted, nil
+}
+
+// quoteName implements quoteTable and quoteColumn. kind names the sort of
+// identifier in errors.
+func quoteName(kind, name string) (string, error) {
+	parts, err := splitName(kind, name)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// splitName validates a dotted name as described for quoteTable and returns
+// its parts, each quoted.
+func splitName(kind, name string) ([]string, error) {
+	parts, err := nameParts(kind, name)
+	if err != nil {
+		return nil, err
+	}
+	for i, part := range parts {
+		parts[i] = quoteIdent(part)
+	}
+	return parts, nil
+}
+
+// nameParts is like splitName but returns the parts unquoted, folded as
+// PostgreSQL would fold them, for APIs such as pgx.Identifier that quote
+// names themselves.
+func nameParts(kind, name string) ([]string, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%s name is required", kind)
+	}
+
+	var parts []string
+	for rest := name; ; {
+		var part string
+		if strings.HasPrefix(rest, `"`) {
+			end := closingQuote(rest)
+			if end < 0 {
+				return nil, fmt.Errorf("invalid %s name %q: unterminated quoted identifier", kind, name)
+			}
+			part = strings.ReplaceAll(rest[1:end], `""`, `"`)
+			rest = rest[end+1:]
+		} else {
+			i := strings.IndexByte(rest, '.')
+			if i < 0 {
+				i = len(rest)
+			}
+			if !isPlainIdent(rest[:i]) {
+				return nil, fmt.Errorf("invalid %s name %q: quote identifiers that are not plain names", kind, name)
+			}
+			part = strings.ToLower(rest[:i])
+			rest = rest[i:]
+		}
+		if part == "" {
+			return nil, fmt.Errorf("invalid %s name %q: empty identifier", kind, name)
+		}
+		parts = append(parts, part)
+
+		if rest == "" {
+			break
+		}
+		if rest[0] != '.' {
+			return nil, fmt.Errorf("invalid %s name %q: unexpected text after quoted identifier", kind, name)
+		}
+		rest = rest[1:]
+	}
+
+	return parts, nil
+}
+
+// closingQuote returns the index of the quote closing the quoted identifier
+// at the start of s, skipping doubled quotes, or -1 if there is none.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		if s[i] != '"' {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '"' {
+			i++
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// isPlainIdent reports whether s is an identifier that needs no quoting:
+// a letter or underscore followed by letters, digits, underscores, or
+// dollar signs.
+func isPlainIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r == '$' || r >= '0' && r <= '9'):
+		default:
+			return false
+		}
+	}
+	return true
+}
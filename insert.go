@@ -0,0 +1,437 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// sqlExpr is a value written into generated SQL as is instead of being
+// bound to a placeholder.
+type sqlExpr string
+
+// sqlNow sets a column to the database's current time.
+const sqlNow sqlExpr = "now()"
+
+// maxBindParams is the maximum number of bind parameters Postgres accepts in
+// a single statement.
+const maxBindParams = 65535
+
+// InsertStructs inserts a slice of structs into the specified table using
+// multi-row INSERT statements. Every element must produce the same column set.
+// Statements are chunked automatically so no single statement exceeds the
+// Postgres bind parameter limit. It returns the total number of rows inserted;
+// an empty slice is a no-op. The omitempty and omitzero tag options are
+// ignored, and zero primary keys are sent as they are, since every row
+// shares one column list. Zero fields tagged createdAt or updatedAt are
+// set to the database's now(), as by InsertStruct.
+func InsertStructs(ctx context.Context, db DB, table string, data any) (int64, error) {
+	sliceValue := reflect.ValueOf(data)
+	if sliceValue.Kind() == reflect.Pointer {
+		sliceValue = sliceValue.Elem()
+	}
+	if sliceValue.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("data must be a slice of structs, got %T", data)
+	}
+
+	if sliceValue.Len() == 0 {
+		return 0, nil
+	}
+
+	var fields []string
+	var values []any
+	for i := 0; i < sliceValue.Len(); i++ {
+		elem := sliceValue.Index(i)
+		item := elem.Interface()
+		if elem.Kind() == reflect.Struct && elem.CanAddr() {
+			item = elem.Addr().Interface()
+		}
+		item, err := beforeInsert(ctx, item)
+		if err != nil {
+			return 0, fmt.Errorf("element %d: %w", i, err)
+		}
+
+		rowFields, rowValues, err := extractStructFields(item)
+		if err != nil {
+			return 0, fmt.Errorf("failed to extract struct fields for element %d: %w", i, err)
+		}
+		fillTimestamps(item, rowFields, rowValues)
+
+		if i == 0 {
+			fields = rowFields
+		} else if !reflect.DeepEqual(fields, rowFields) {
+			return 0, fmt.Errorf("element %d has columns %v, expected %v", i, rowFields, fields)
+		}
+		values = append(values, rowValues...)
+	}
+
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("no valid fields found for insertion")
+	}
+
+	quotedTable, err := qualifyTable(db, table)
+	if err != nil {
+		return 0, err
+	}
+	quotedFields, err := quoteColumns(fields)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsPerStatement := maxBindParams / len(fields)
+	total := sliceValue.Len()
+	var inserted int64
+
+	for start := 0; start < total; start += rowsPerStatement {
+		end := min(start+rowsPerStatement, total)
+
+		sql, args := insertSQL(quotedTable, quotedFields, values[start*len(fields):end*len(fields)])
+		tag, err := exec(ctx, db, "InsertStructs", sql, args, nil)
+		if err != nil {
+			return inserted, fmt.Errorf("insert failed: %w", err)
+		}
+		inserted += tag.RowsAffected()
+	}
+
+	return inserted, nil
+}
+
+// InsertMap inserts a RowMap, such as one returned by QueryMaps, into the
+// specified table. The keys are used as column names in sorted order, with
+// any "table." prefix dropped as for struct tags; the table and column
+// names are validated and quoted.
+func InsertMap(ctx context.Context, db DB, table string, row RowMap) error {
+	_, err := insertMaps(ctx, db, "InsertMap", table, []RowMap{row})
+	return err
+}
+
+// InsertMaps inserts a slice of RowMaps into the specified table using
+// multi-row INSERT statements, chunked like InsertStructs. Every map must
+// have the same keys. It returns the total number of rows inserted; an
+// empty slice is a no-op.
+func InsertMaps(ctx context.Context, db DB, table string, rows []RowMap) (int64, error) {
+	return insertMaps(ctx, db, "InsertMaps", table, rows)
+}
+
+// insertMaps implements InsertMap and InsertMaps, reporting the statements
+// as op.
+func insertMaps(ctx context.Context, db DB, op, table string, rows []RowMap) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	quotedTable, err := qualifyTable(db, table)
+	if err != nil {
+		return 0, err
+	}
+
+	keys := make([]string, 0, len(rows[0]))
+	for key := range rows[0] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 0 {
+		return 0, fmt.Errorf("no columns found for insertion")
+	}
+
+	fields := make([]string, len(keys))
+	seen := make(map[string]string, len(keys))
+	for i, key := range keys {
+		column := columnFromTag(key)
+		if other, ok := seen[column]; ok {
+			return 0, fmt.Errorf("keys %q and %q both name column %q", other, key, column)
+		}
+		seen[column] = key
+
+		if fields[i], err = quoteColumn(column); err != nil {
+			return 0, err
+		}
+	}
+
+	values := make([]any, 0, len(rows)*len(keys))
+	for i, row := range rows {
+		if len(row) != len(keys) {
+			return 0, fmt.Errorf("row %d has %d keys, expected %d", i, len(row), len(keys))
+		}
+		for _, key := range keys {
+			value, ok := row[key]
+			if !ok {
+				return 0, fmt.Errorf("row %d is missing key %q", i, key)
+			}
+			values = append(values, value)
+		}
+	}
+
+	rowsPerStatement := maxBindParams / len(fields)
+	var inserted int64
+
+	for start := 0; start < len(rows); start += rowsPerStatement {
+		end := min(start+rowsPerStatement, len(rows))
+
+		sql, args := insertSQL(quotedTable, fields, values[start*len(fields):end*len(fields)])
+		tag, err := exec(ctx, db, op, sql, args, nil)
+		if err != nil {
+			return inserted, fmt.Errorf("insert failed: %w", err)
+		}
+		inserted += tag.RowsAffected()
+	}
+
+	return inserted, nil
+}
+
+// InsertStructReturning inserts a struct into the specified table and writes
+// the row returned by the database back into it, so generated ids, column
+// defaults, and trigger-populated values show up on the struct.
+// The data parameter must be a pointer to a struct. Columns are matched to
+// fields with the same db tag logic as QueryStructs. Columns left out by
+// omitempty or omitzero, and zero primary keys, are read back with their
+// generated or default values. The BeforeInsert hook runs before the
+// fields are read, and the AfterScan hook once the row is written back.
+func InsertStructReturning(ctx context.Context, db DB, table string, data any) error {
+	destValue := reflect.ValueOf(data)
+	if destValue.Kind() != reflect.Pointer || destValue.IsNil() {
+		return fmt.Errorf("data must be a non-nil pointer to a struct, got %T", data)
+	}
+
+	structValue := destValue.Elem()
+	if structValue.Kind() != reflect.Struct {
+		return fmt.Errorf("data must be a pointer to a struct, got pointer to %s", structValue.Kind())
+	}
+	if _, err := beforeInsert(ctx, data); err != nil {
+		return err
+	}
+
+	sql, values, err := buildInsertStruct(db, table, data)
+	if err != nil {
+		return err
+	}
+	sql += " RETURNING *"
+
+	rows, err := query(ctx, db, "InsertStructReturning", sql, values, nil)
+	if err != nil {
+		return fmt.Errorf("insert failed: %w", err)
+	}
+	defer rows.Close()
+
+	scanner, err := newStructScanner(rows, structValue.Type(), nil)
+	if err != nil {
+		return err
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("insert failed: %w", err)
+		}
+		return ErrNoRows
+	}
+
+	returned, err := rows.Values()
+	if err != nil {
+		return fmt.Errorf("failed to get row values: %w", err)
+	}
+	if err := scanner.scan(returned, structValue); err != nil {
+		return err
+	}
+	if err := afterScan(ctx, destValue); err != nil {
+		return err
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("insert failed: %w", err)
+	}
+
+	return nil
+}
+
+// InsertStructColumns is like InsertStruct but only inserts the named
+// columns, leaving the rest of the table's columns to their defaults.
+// Columns are matched against the column part of the tags, so "name"
+// selects a field tagged db:"users.name". Fields tagged omitempty or
+// omitzero are inserted even when empty if they are named. It fails if a
+// column has no corresponding field.
+//
+//	err := dbx.InsertStructColumns(ctx, db, "users", user, "name", "email")
+func InsertStructColumns(ctx context.Context, db DB, table string, data any, columns ...string) error {
+	data, err := beforeInsert(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	fields, values, err := extractStructFields(data)
+	if err != nil {
+		return fmt.Errorf("failed to extract struct fields: %w", err)
+	}
+	if err := checkFieldColumns(fields, columns); err != nil {
+		return err
+	}
+	fields, values = filterColumns(fields, values, columns, true)
+	return insertColumns(ctx, db, "InsertStructColumns", table, fields, values)
+}
+
+// InsertStructExcept is like InsertStruct but leaves out the named columns,
+// matched as for InsertStructColumns, so they take their defaults. It fails
+// if a column has no corresponding field.
+func InsertStructExcept(ctx context.Context, db DB, table string, data any, columns ...string) error {
+	data, err := beforeInsert(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	all, _, err := extractStructFields(data)
+	if err != nil {
+		return fmt.Errorf("failed to extract struct fields: %w", err)
+	}
+	if err := checkFieldColumns(all, columns); err != nil {
+		return err
+	}
+
+	fields, values, err := extractInsertFields(data)
+	if err != nil {
+		return fmt.Errorf("failed to extract struct fields: %w", err)
+	}
+	fields, values = filterColumns(fields, values, columns, false)
+	return insertColumns(ctx, db, "InsertStructExcept", table, fields, values)
+}
+
+// insertColumns inserts a single row of the given columns and values,
+// reporting the statement as op.
+func insertColumns(ctx context.Context, db DB, op, table string, fields []string, values []any) error {
+	sql, values, err := buildInsert(db, table, fields, values)
+	if err != nil {
+		return err
+	}
+
+	if _, err := exec(ctx, db, op, sql, values, nil); err != nil {
+		return fmt.Errorf("insert failed: %w", err)
+	}
+	return nil
+}
+
+// checkFieldColumns fails if any of columns is not in fields.
+func checkFieldColumns(fields, columns []string) error {
+	for _, column := range columns {
+		if !containsString(fields, column) {
+			return fmt.Errorf("column %q has no corresponding struct field", column)
+		}
+	}
+	return nil
+}
+
+// filterColumns returns the fields, and their values, that are in columns
+// when keep is true, or that are not in columns when keep is false.
+func filterColumns(fields []string, values []any, columns []string, keep bool) ([]string, []any) {
+	var keptFields []string
+	var keptValues []any
+	for i, field := range fields {
+		if containsString(columns, field) == keep {
+			keptFields = append(keptFields, field)
+			keptValues = append(keptValues, values[i])
+		}
+	}
+	return keptFields, keptValues
+}
+
+// buildInsertStruct builds the INSERT statement and arguments for a single
+// struct. db supplies the default schema, if any, and may be nil.
+func buildInsertStruct(db DB, table string, data any) (string, []any, error) {
+	fields, values, err := extractInsertFields(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to extract struct fields: %w", err)
+	}
+	return buildInsert(db, table, fields, values)
+}
+
+// buildInsert builds a single-row INSERT statement for the given columns
+// and values.
+func buildInsert(db DB, table string, fields []string, values []any) (string, []any, error) {
+	if len(fields) == 0 {
+		return "", nil, fmt.Errorf("no valid fields found for insertion")
+	}
+
+	quotedTable, err := qualifyTable(db, table)
+	if err != nil {
+		return "", nil, err
+	}
+	quotedFields, err := quoteColumns(fields)
+	if err != nil {
+		return "", nil, err
+	}
+
+	placeholders := make([]string, len(values))
+	args := make([]any, 0, len(values))
+	for i, value := range values {
+		if expr, ok := value.(sqlExpr); ok {
+			placeholders[i] = string(expr)
+			continue
+		}
+		args = append(args, value)
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quotedTable,
+		strings.Join(quotedFields, ", "),
+		strings.Join(placeholders, ", "),
+	)
+	return sql, args, nil
+}
+
+// insertSQL builds an INSERT statement for the given columns with a row of
+// numbered placeholders for every len(fields) values, and returns it with
+// the values to bind to them. sqlExpr values, such as sqlNow, are written
+// into the statement instead. The table and column names must already be
+// quoted.
+func insertSQL(table string, fields []string, values []any) (string, []any) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES ", table, strings.Join(fields, ", "))
+
+	args := make([]any, 0, len(values))
+	for i, value := range values {
+		switch {
+		case i == 0:
+			b.WriteByte('(')
+		case i%len(fields) == 0:
+			b.WriteString("), (")
+		default:
+			b.WriteString(", ")
+		}
+		if expr, ok := value.(sqlExpr); ok {
+			b.WriteString(string(expr))
+			continue
+		}
+		args = append(args, value)
+		fmt.Fprintf(&b, "$%d", len(args))
+	}
+	b.WriteByte(')')
+
+	return b.String(), args
+}
+
+// fillTimestamps replaces the values of the zero fields tagged createdAt or
+// updatedAt of the struct in data with the database's now(). fields and
+// values are the struct's columns and values, as returned by
+// extractStructFields.
+func fillTimestamps(data any, fields []string, values []any) {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	for _, f := range getStructMeta(v.Type(), defaults()).Fields {
+		if f.Nested() || !f.Options.timestamp() {
+			continue
+		}
+		if field, ok := f.value(v); ok && !field.IsZero() {
+			continue
+		}
+		for i, column := range fields {
+			if column == f.Column {
+				values[i] = sqlNow
+			}
+		}
+	}
+}
@@ -0,0 +1,479 @@
+package dbx
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type insertUser struct {
+	Name  string `db:"users.name"`
+	Email string `db:"users.email"`
+}
+
+func TestInsertStructs(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("INSERT 0 2")}
+
+	users := []insertUser{
+		{Name: "John", Email: "john@example.com"},
+		{Name: "Jane", Email: "jane@example.com"},
+	}
+
+	inserted, err := InsertStructs(ctx, mock, "users", users)
+	if err != nil {
+		t.Fatalf("InsertStructs failed: %v", err)
+	}
+
+	if inserted != 2 {
+		t.Errorf("Expected 2 rows inserted, got %d", inserted)
+	}
+
+	expectedSQL := `INSERT INTO "users" ("name", "email") VALUES ($1, $2), ($3, $4)`
+	if mock.lastSQL != expectedSQL {
+		t.Errorf("Expected SQL %q, got %q", expectedSQL, mock.lastSQL)
+	}
+
+	expectedArgs := []interface{}{"John", "john@example.com", "Jane", "jane@example.com"}
+	if !reflect.DeepEqual(mock.lastArgs, expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, mock.lastArgs)
+	}
+}
+
+func TestInsertStructsChunksByBindLimit(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("INSERT 0 1")}
+
+	// Two columns per row allows 32767 rows per statement
+	users := make([]insertUser, 40000)
+
+	inserted, err := InsertStructs(ctx, mock, "users", users)
+	if err != nil {
+		t.Fatalf("InsertStructs failed: %v", err)
+	}
+
+	if mock.execCount != 2 {
+		t.Errorf("Expected 2 statements, got %d", mock.execCount)
+	}
+	if inserted != 2 {
+		t.Errorf("Expected rows affected summed across statements (2), got %d", inserted)
+	}
+	if len(mock.lastArgs) != (40000-32767)*2 {
+		t.Errorf("Expected %d args in final chunk, got %d", (40000-32767)*2, len(mock.lastArgs))
+	}
+}
+
+func TestInsertStructsEmpty(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	inserted, err := InsertStructs(ctx, mock, "users", []insertUser{})
+	if err != nil {
+		t.Fatalf("InsertStructs failed: %v", err)
+	}
+	if inserted != 0 || mock.execCount != 0 {
+		t.Errorf("Expected no-op, got %d rows and %d statements", inserted, mock.execCount)
+	}
+}
+
+func TestInsertStructsMismatchedColumns(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	type other struct {
+		Name string `db:"name"`
+	}
+
+	_, err := InsertStructs(ctx, mock, "users", []any{insertUser{Name: "John"}, other{Name: "Jane"}})
+	if err == nil {
+		t.Error("Expected error for elements with different column sets")
+	}
+}
+
+func TestInsertStructQuotesIdentifiers(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("INSERT 0 1")}
+
+	type Order struct {
+		Order int    `db:"order"`
+		User  string `db:"user"`
+	}
+
+	if err := InsertStruct(ctx, mock, "billing.invoice", Order{Order: 1, User: "john"}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	expectedSQL := `INSERT INTO "billing"."invoice" ("order", "user") VALUES ($1, $2)`
+	if mock.lastSQL != expectedSQL {
+		t.Errorf("Expected SQL %q, got %q", expectedSQL, mock.lastSQL)
+	}
+
+	if err := InsertStruct(ctx, mock, Ident("Order Items"), Order{}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if !strings.HasPrefix(mock.lastSQL, `INSERT INTO "Order Items" (`) {
+		t.Errorf("Unexpected SQL: %s", mock.lastSQL)
+	}
+
+	mock.execCount = 0
+	if err := InsertStruct(ctx, mock, "users; DROP TABLE users", Order{}); err == nil {
+		t.Error("Expected error for an invalid table name")
+	}
+	if mock.execCount != 0 {
+		t.Error("Expected no statement to run")
+	}
+}
+
+func TestInsertStructReturning(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{42, "John", "john@example.com"}},
+		},
+	}
+
+	type TestUser struct {
+		ID    int    `db:"users.id"`
+		Name  string `db:"users.name"`
+		Email string `db:"users.email"`
+	}
+
+	user := TestUser{Name: "John", Email: "john@example.com"}
+	if err := InsertStructReturning(ctx, mock, "users", &user); err != nil {
+		t.Fatalf("InsertStructReturning failed: %v", err)
+	}
+
+	if user.ID != 42 {
+		t.Errorf("Expected generated ID 42 to be written back, got %d", user.ID)
+	}
+
+	expectedSQL := `INSERT INTO "users" ("id", "name", "email") VALUES ($1, $2, $3) RETURNING *`
+	if mock.lastSQL != expectedSQL {
+		t.Errorf("Expected SQL %q, got %q", expectedSQL, mock.lastSQL)
+	}
+}
+
+func TestInsertStructOmitEmpty(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("INSERT 0 1")}
+
+	type TestUser struct {
+		ID         int       `db:"users.id,pk,omitempty"`
+		Name       string    `db:"users.name"`
+		MiddleName string    `db:"users.middle_name,omitempty"`
+		Nickname   *string   `db:"nickname,omitempty"`
+		Tags       []string  `db:"tags,omitempty"`
+		CreatedAt  time.Time `db:"created_at,omitzero"`
+	}
+
+	if err := InsertStruct(ctx, mock, "users", TestUser{Name: "John"}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("name") VALUES ($1)`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+
+	// A pointer to a zero value is not empty
+	empty := ""
+	if err := InsertStruct(ctx, mock, "users", TestUser{ID: 1, Name: "John", MiddleName: "Q", Nickname: &empty}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("id", "name", "middle_name", "nickname") VALUES ($1, $2, $3, $4)`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+	if expected := []any{1, "John", "Q", &empty}; !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected args %#v, got %#v", expected, mock.lastArgs)
+	}
+
+	// InsertStructs keeps every column so rows line up
+	if _, err := InsertStructs(ctx, mock, "users", []TestUser{{Name: "John"}, {ID: 2, Name: "Jane"}}); err != nil {
+		t.Fatalf("InsertStructs failed: %v", err)
+	}
+	if !strings.HasPrefix(mock.lastSQL, `INSERT INTO "users" ("id", "name", "middle_name", "nickname", "tags", "created_at")`) {
+		t.Errorf("Expected all columns, got %q", mock.lastSQL)
+	}
+}
+
+func TestInsertStructReturningOmitEmpty(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock := &mockQueryer{
+		fields: mockFields("id", "name", "created_at"),
+		rows:   []mockRow{{values: []interface{}{42, "John", created}}},
+	}
+
+	type TestUser struct {
+		ID        int       `db:"id,omitempty"`
+		Name      string    `db:"name"`
+		CreatedAt time.Time `db:"created_at,omitzero"`
+	}
+
+	user := TestUser{Name: "John"}
+	if err := InsertStructReturning(ctx, mock, "users", &user); err != nil {
+		t.Fatalf("InsertStructReturning failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("name") VALUES ($1) RETURNING *`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+	if expected := (TestUser{ID: 42, Name: "John", CreatedAt: created}); !reflect.DeepEqual(user, expected) {
+		t.Errorf("Expected defaults to be read back, got %+v", user)
+	}
+}
+
+func TestInsertStructReadonly(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "name", "full_name"),
+		rows:   []mockRow{{values: []interface{}{42, "John", "John Smith"}}},
+	}
+
+	type TestUser struct {
+		ID       int    `db:"users.id,readonly"`
+		Name     string `db:"users.name"`
+		FullName string `db:"full_name,readonly"`
+	}
+
+	user := TestUser{ID: 1, Name: "John", FullName: "ignored"}
+	if err := InsertStructReturning(ctx, mock, "users", &user); err != nil {
+		t.Fatalf("InsertStructReturning failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("name") VALUES ($1) RETURNING *`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+	if expected := (TestUser{ID: 42, Name: "John", FullName: "John Smith"}); user != expected {
+		t.Errorf("Expected readonly columns to be read back, got %+v", user)
+	}
+
+	if _, err := InsertStructs(ctx, mock, "users", []TestUser{user}); err != nil {
+		t.Fatalf("InsertStructs failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("name") VALUES ($1)`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+}
+
+func TestInsertStructColumns(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("INSERT 0 1")}
+
+	type TestUser struct {
+		ID     int    `db:"users.id"`
+		Name   string `db:"users.name"`
+		Email  string `db:"users.email"`
+		Status string `db:"status,omitempty"`
+	}
+	user := TestUser{ID: 1, Name: "John", Email: "john@example.com"}
+
+	if err := InsertStructColumns(ctx, mock, "users", user, "email", "name", "status"); err != nil {
+		t.Fatalf("InsertStructColumns failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("name", "email", "status") VALUES ($1, $2, $3)`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+	if expected := []any{"John", "john@example.com", ""}; !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected args %#v, got %#v", expected, mock.lastArgs)
+	}
+
+	if err := InsertStructExcept(ctx, mock, "users", user, "id"); err != nil {
+		t.Fatalf("InsertStructExcept failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("name", "email") VALUES ($1, $2)`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+
+	mock.lastSQL = ""
+	if err := InsertStructColumns(ctx, mock, "users", user, "name", "nickname"); err == nil || !strings.Contains(err.Error(), "nickname") {
+		t.Errorf("Expected error for unknown column, got %v", err)
+	}
+	if err := InsertStructExcept(ctx, mock, "users", user, "users.id"); err == nil {
+		t.Error("Expected error for unknown column")
+	}
+	if mock.lastSQL != "" {
+		t.Error("Expected no statement to run")
+	}
+}
+
+func TestInsertStructZeroPrimaryKey(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		execTag: pgconn.NewCommandTag("INSERT 0 1"),
+		fields:  mockFields("id", "name"),
+		rows:    []mockRow{{values: []interface{}{42, "John"}}},
+	}
+
+	type TestUser struct {
+		ID   int    `db:"users.id,pk"`
+		Name string `db:"users.name"`
+	}
+
+	if err := InsertStruct(ctx, mock, "users", TestUser{Name: "John"}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("name") VALUES ($1)`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+
+	// Fixed ids, as in a data migration, are still inserted
+	if err := InsertStruct(ctx, mock, "users", TestUser{ID: 7, Name: "John"}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("id", "name") VALUES ($1, $2)`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+
+	user := TestUser{Name: "John"}
+	if err := InsertStructReturning(ctx, mock, "users", &user); err != nil {
+		t.Fatalf("InsertStructReturning failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("name") VALUES ($1) RETURNING *`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+	if user.ID != 42 {
+		t.Errorf("Expected generated ID 42, got %d", user.ID)
+	}
+}
+
+func TestInsertStructTimestamps(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock := &mockQueryer{
+		fields: mockFields("id", "name", "created_at", "updated_at"),
+		rows:   []mockRow{{values: []interface{}{1, "John", now, now}}},
+	}
+
+	type TestUser struct {
+		ID        int        `db:"id,pk"`
+		Name      string     `db:"name"`
+		CreatedAt time.Time  `db:"created_at,createdAt"`
+		UpdatedAt *time.Time `db:"updated_at,updatedAt"`
+	}
+
+	user := TestUser{Name: "John"}
+	if err := InsertStructReturning(ctx, mock, "users", &user); err != nil {
+		t.Fatalf("InsertStructReturning failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("name", "created_at", "updated_at") VALUES ($1, now(), now()) RETURNING *`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+	if expected := []any{"John"}; !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected args %#v, got %#v", expected, mock.lastArgs)
+	}
+	if !user.CreatedAt.Equal(now) || user.UpdatedAt == nil || !user.UpdatedAt.Equal(now) {
+		t.Errorf("Expected timestamps to be read back, got %+v", user)
+	}
+
+	// Values set by the caller win
+	mock.execTag = pgconn.NewCommandTag("INSERT 0 1")
+	if err := InsertStruct(ctx, mock, "users", TestUser{Name: "John", CreatedAt: now}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("name", "created_at", "updated_at") VALUES ($1, $2, now())`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+	if expected := []any{"John", now}; !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected args %#v, got %#v", expected, mock.lastArgs)
+	}
+}
+
+func TestInsertStructsTimestamps(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("INSERT 0 2")}
+
+	type TestUser struct {
+		Name      string    `db:"name"`
+		CreatedAt time.Time `db:"created_at,createdAt"`
+	}
+	set := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	users := []TestUser{{Name: "John"}, {Name: "Jane", CreatedAt: set}}
+	if _, err := InsertStructs(ctx, mock, "users", users); err != nil {
+		t.Fatalf("InsertStructs failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("name", "created_at") VALUES ($1, now()), ($2, $3)`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+	if expected := []any{"John", "Jane", set}; !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected args %#v, got %#v", expected, mock.lastArgs)
+	}
+}
+
+func TestInsertStructReturningRequiresPointer(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	if err := InsertStructReturning(ctx, mock, "users", insertUser{Name: "John"}); err == nil {
+		t.Error("Expected error for non-pointer data")
+	}
+}
+
+func TestInsertMap(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("INSERT 0 1")}
+
+	row := RowMap{"users.name": "John", "email": "john@example.com", "order": 2}
+	if err := InsertMap(ctx, mock, "users", row); err != nil {
+		t.Fatalf("InsertMap failed: %v", err)
+	}
+
+	expectedSQL := `INSERT INTO "users" ("email", "order", "name") VALUES ($1, $2, $3)`
+	if mock.lastSQL != expectedSQL {
+		t.Errorf("Expected SQL %q, got %q", expectedSQL, mock.lastSQL)
+	}
+	if !reflect.DeepEqual(mock.lastArgs, []interface{}{"john@example.com", 2, "John"}) {
+		t.Errorf("Unexpected args: %v", mock.lastArgs)
+	}
+}
+
+func TestInsertMaps(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("INSERT 0 2")}
+
+	rows := []RowMap{
+		{"id": 1, "name": "John"},
+		{"id": 2, "name": "Jane"},
+	}
+	inserted, err := InsertMaps(ctx, mock, "archive.users", rows)
+	if err != nil {
+		t.Fatalf("InsertMaps failed: %v", err)
+	}
+	if inserted != 2 {
+		t.Errorf("Expected 2 rows inserted, got %d", inserted)
+	}
+
+	expectedSQL := `INSERT INTO "archive"."users" ("id", "name") VALUES ($1, $2), ($3, $4)`
+	if mock.lastSQL != expectedSQL {
+		t.Errorf("Expected SQL %q, got %q", expectedSQL, mock.lastSQL)
+	}
+	if !reflect.DeepEqual(mock.lastArgs, []interface{}{1, "John", 2, "Jane"}) {
+		t.Errorf("Unexpected args: %v", mock.lastArgs)
+	}
+}
+
+func TestInsertMapsErrors(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	tests := []struct {
+		name string
+		rows []RowMap
+	}{
+		{"mismatched keys", []RowMap{{"id": 1, "name": "John"}, {"id": 2, "email": "jane@example.com"}}},
+		{"missing key", []RowMap{{"id": 1, "name": "John"}, {"id": 2}}},
+		{"duplicate column", []RowMap{{"users.id": 1, "id": 1}}},
+		{"invalid column", []RowMap{{"id) VALUES (1); --": 1}}},
+		{"no columns", []RowMap{{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := InsertMaps(ctx, mock, "users", tt.rows); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+	if mock.execCount != 0 {
+		t.Errorf("Expected no exec, got %d", mock.execCount)
+	}
+}
@@ -0,0 +1,118 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+)
+
+// TableInfo describes a table or view, as reported by InspectTable and
+// ListTables.
+type TableInfo struct {
+	Schema  string
+	Name    string
+	View    bool         // a view or materialized view rather than a table
+	Columns []ColumnInfo // in table order; nil from ListTables
+}
+
+// ColumnInfo describes a column of a table or view.
+type ColumnInfo struct {
+	Name       string
+	Type       string // as Postgres formats it, e.g. "integer" or "character varying(255)"
+	Nullable   bool
+	Default    string // default expression, e.g. "now()"; empty if there is none
+	PrimaryKey bool   // part of the table's primary key
+}
+
+// relationKinds lists the pg_class kinds reported: ordinary, partitioned,
+// and foreign tables, views, and materialized views.
+const relationKinds = "('r', 'p', 'f', 'v', 'm')"
+
+// inspectSchema returns schema, or the default schema of db if schema is
+// empty and db is a SchemaDB. An empty result stands for current_schema().
+func inspectSchema(db DB, schema string) string {
+	if s, ok := db.(interface{ Schema() string }); ok && schema == "" {
+		return s.Schema()
+	}
+	return schema
+}
+
+// catalogOptions returns the package defaults with the struct mapping
+// settings reset, so the catalog rows map by their db tags whatever
+// SetDefaults installed.
+func catalogOptions() *options {
+	opts := defaults()
+	opts.tagName, opts.nameMapper = "db", nil
+	return opts
+}
+
+// ListTables returns the tables and views in schema, sorted by name,
+// without their columns. An empty schema means the default schema of a
+// SchemaDB, or else the connection's current schema.
+func ListTables(ctx context.Context, db DB, schema string) ([]TableInfo, error) {
+	sql := `SELECT n.nspname AS schema, c.relname AS name, c.relkind IN ('v', 'm') AS view
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = coalesce(nullif($1, ''), current_schema()) AND c.relkind IN ` + relationKinds + `
+ORDER BY c.relname`
+
+	var rows []struct {
+		Schema string `db:"schema"`
+		Name   string `db:"name"`
+		View   bool   `db:"view"`
+	}
+	if err := queryStructs(ctx, db, "ListTables", sql, &rows, catalogOptions(), []any{inspectSchema(db, schema)}); err != nil {
+		return nil, err
+	}
+
+	tables := make([]TableInfo, len(rows))
+	for i, row := range rows {
+		tables[i] = TableInfo{Schema: row.Schema, Name: row.Name, View: row.View}
+	}
+	return tables, nil
+}
+
+// InspectTable returns the columns of a table or view in schema, in table
+// order. An empty schema is treated as by ListTables. ErrNoRows is returned
+// if there is no such table or view.
+func InspectTable(ctx context.Context, db DB, schema, table string) (*TableInfo, error) {
+	sql := `SELECT n.nspname AS schema, c.relkind IN ('v', 'm') AS view,
+	a.attname AS name, format_type(a.atttypid, a.atttypmod) AS type, NOT a.attnotnull AS nullable,
+	coalesce(pg_get_expr(d.adbin, d.adrelid), '') AS column_default,
+	coalesce(a.attnum = ANY(i.indkey), false) AS primary_key
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = c.oid AND d.adnum = a.attnum
+LEFT JOIN pg_catalog.pg_index i ON i.indrelid = c.oid AND i.indisprimary
+WHERE n.nspname = coalesce(nullif($1, ''), current_schema()) AND c.relname = $2 AND c.relkind IN ` + relationKinds + `
+ORDER BY a.attnum`
+
+	var rows []struct {
+		Schema     string `db:"schema"`
+		View       bool   `db:"view"`
+		Name       string `db:"name"`
+		Type       string `db:"type"`
+		Nullable   bool   `db:"nullable"`
+		Default    string `db:"column_default"`
+		PrimaryKey bool   `db:"primary_key"`
+	}
+	if err := queryStructs(ctx, db, "InspectTable", sql, &rows, catalogOptions(), []any{inspectSchema(db, schema), table}); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("table %q: %w", table, ErrNoRows)
+	}
+
+	info := &TableInfo{Schema: rows[0].Schema, Name: table, View: rows[0].View}
+	info.Columns = make([]ColumnInfo, len(rows))
+	for i, row := range rows {
+		info.Columns[i] = ColumnInfo{
+			Name:       row.Name,
+			Type:       row.Type,
+			Nullable:   row.Nullable,
+			Default:    row.Default,
+			PrimaryKey: row.PrimaryKey,
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,73 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestInspectTable(t *testing.T) {
+	mock := &mockQueryer{
+		fields: []pgconn.FieldDescription{
+			{Name: "schema"}, {Name: "view"}, {Name: "name"}, {Name: "type"},
+			{Name: "nullable"}, {Name: "column_default"}, {Name: "primary_key"},
+		},
+		rows: []mockRow{
+			{values: []interface{}{"public", false, "id", "bigint", false, "nextval('users_id_seq'::regclass)", true}},
+			{values: []interface{}{"public", false, "email", "character varying(255)", true, "", false}},
+		},
+	}
+
+	info, err := InspectTable(context.Background(), WithSchema(mock, "app"), "", "users")
+	if err != nil {
+		t.Fatalf("InspectTable failed: %v", err)
+	}
+	if !reflect.DeepEqual(mock.lastArgs, []interface{}{"app", "users"}) {
+		t.Errorf("args = %v, want the SchemaDB's schema and the table", mock.lastArgs)
+	}
+
+	want := &TableInfo{
+		Schema: "public",
+		Name:   "users",
+		Columns: []ColumnInfo{
+			{Name: "id", Type: "bigint", Default: "nextval('users_id_seq'::regclass)", PrimaryKey: true},
+			{Name: "email", Type: "character varying(255)", Nullable: true},
+		},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("InspectTable = %+v, want %+v", info, want)
+	}
+
+	mock.rows = nil
+	if _, err := InspectTable(context.Background(), mock, "public", "missing"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("expected ErrNoRows, got %v", err)
+	}
+}
+
+func TestListTables(t *testing.T) {
+	SetDefaults(TagName("json"))
+	defer SetDefaults()
+
+	mock := &mockQueryer{
+		fields: []pgconn.FieldDescription{{Name: "schema"}, {Name: "name"}, {Name: "view"}},
+		rows: []mockRow{
+			{values: []interface{}{"public", "active_users", true}},
+			{values: []interface{}{"public", "users", false}},
+		},
+	}
+
+	tables, err := ListTables(context.Background(), mock, "public")
+	if err != nil {
+		t.Fatalf("ListTables failed: %v", err)
+	}
+	want := []TableInfo{
+		{Schema: "public", Name: "active_users", View: true},
+		{Schema: "public", Name: "users"},
+	}
+	if !reflect.DeepEqual(tables, want) {
+		t.Errorf("ListTables = %+v, want %+v", tables, want)
+	}
+}
@@ -0,0 +1,102 @@
+package dbx
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// assignInterval sets a time.Duration or string field from an interval
+// value. A day is taken to be 24 hours. Intervals with months can't be
+// converted to a Duration, since months vary in length, and are an error
+// rather than an approximation; a string field takes any interval.
+func assignInterval(field reflect.Value, iv pgtype.Interval) error {
+	switch {
+	case field.Type() == durationType:
+		d, err := intervalDuration(iv)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	case field.Kind() == reflect.String:
+		field.SetString(formatInterval(iv))
+		return nil
+	}
+	return errNotConvertible
+}
+
+// intervalDuration converts an interval without months to a Duration.
+func intervalDuration(iv pgtype.Interval) (time.Duration, error) {
+	if iv.Months != 0 {
+		return 0, fmt.Errorf("cannot convert interval %q to time.Duration: months have no fixed length; convert it in the query, such as with EXTRACT(epoch FROM ...)", formatInterval(iv))
+	}
+	const (
+		maxMicros    = math.MaxInt64 / int64(time.Microsecond)
+		microsPerDay = int64(24 * time.Hour / time.Microsecond)
+	)
+	days := int64(iv.Days)
+	overflow := iv.Microseconds > maxMicros || iv.Microseconds < -maxMicros || days > maxMicros/microsPerDay || days < -maxMicros/microsPerDay
+	micros := days*microsPerDay + iv.Microseconds
+	if overflow || micros > maxMicros || micros < -maxMicros {
+		return 0, fmt.Errorf("interval %q overflows time.Duration", formatInterval(iv))
+	}
+	return time.Duration(micros) * time.Microsecond, nil
+}
+
+// durationInterval converts a Duration to an interval, dropping any
+// fraction of a microsecond, which intervals can't hold.
+func durationInterval(d time.Duration) pgtype.Interval {
+	return pgtype.Interval{Microseconds: d.Microseconds(), Valid: true}
+}
+
+// formatInterval renders an interval as Postgres does with the default
+// IntervalStyle, such as "1 year 2 mons 3 days 04:05:06.5". As there, a
+// positive part following a negative one carries an explicit sign, as in
+// "-3 days +04:00:00".
+func formatInterval(iv pgtype.Interval) string {
+	var parts []string
+	negative := false // whether the last part written was negative
+	unit := func(n int64, singular, plural string) {
+		if n == 0 {
+			return
+		}
+		sign := ""
+		if negative && n > 0 {
+			sign = "+"
+		}
+		if n == 1 {
+			parts = append(parts, sign+"1 "+singular)
+		} else {
+			parts = append(parts, sign+strconv.FormatInt(n, 10)+" "+plural)
+		}
+		negative = n < 0
+	}
+	unit(int64(iv.Months/12), "year", "years")
+	unit(int64(iv.Months%12), "mon", "mons")
+	unit(int64(iv.Days), "day", "days")
+
+	if iv.Microseconds != 0 || len(parts) == 0 {
+		micros := iv.Microseconds
+		sign := ""
+		if micros < 0 {
+			sign, micros = "-", -micros
+		} else if negative {
+			sign = "+"
+		}
+		secs := micros / 1e6
+		clock := fmt.Sprintf("%s%02d:%02d:%02d", sign, secs/3600, secs/60%60, secs%60)
+		if frac := micros % 1e6; frac != 0 {
+			clock += strings.TrimRight(fmt.Sprintf(".%06d", frac), "0")
+		}
+		parts = append(parts, clock)
+	}
+	return strings.Join(parts, " ")
+}
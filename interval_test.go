@@ -0,0 +1,129 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestFormatInterval(t *testing.T) {
+	tests := []struct {
+		iv   pgtype.Interval
+		want string
+	}{
+		{pgtype.Interval{Valid: true}, "00:00:00"},
+		{pgtype.Interval{Microseconds: 1500000, Valid: true}, "00:00:01.5"},
+		{pgtype.Interval{Microseconds: 90061000001, Valid: true}, "25:01:01.000001"},
+		{pgtype.Interval{Microseconds: -3600000000, Valid: true}, "-01:00:00"},
+		{pgtype.Interval{Days: 1, Valid: true}, "1 day"},
+		{pgtype.Interval{Days: -3, Microseconds: 60000000, Valid: true}, "-3 days +00:01:00"},
+		{pgtype.Interval{Days: -3, Microseconds: 14400000000, Valid: true}, "-3 days +04:00:00"},
+		{pgtype.Interval{Days: 1, Microseconds: -14400000000, Valid: true}, "1 day -04:00:00"},
+		{pgtype.Interval{Days: -1, Microseconds: -14400000000, Valid: true}, "-1 days -04:00:00"},
+		{pgtype.Interval{Months: -14, Days: 3, Valid: true}, "-1 years -2 mons +3 days"},
+		{pgtype.Interval{Months: 2, Days: -3, Microseconds: 1000000, Valid: true}, "2 mons -3 days +00:00:01"},
+		{pgtype.Interval{Months: 14, Days: 3, Microseconds: 14706000000, Valid: true}, "1 year 2 mons 3 days 04:05:06"},
+		{pgtype.Interval{Months: 1, Valid: true}, "1 mon"},
+	}
+	for _, tt := range tests {
+		if got := formatInterval(tt.iv); got != tt.want {
+			t.Errorf("formatInterval(%+v) = %q, want %q", tt.iv, got, tt.want)
+		}
+	}
+}
+
+func TestIntervalDuration(t *testing.T) {
+	tests := []struct {
+		iv   pgtype.Interval
+		want time.Duration
+	}{
+		{pgtype.Interval{Microseconds: 1500, Valid: true}, 1500 * time.Microsecond},
+		{pgtype.Interval{Days: 2, Microseconds: 3600000000, Valid: true}, 49 * time.Hour},
+		{pgtype.Interval{Days: -1, Microseconds: 60000000, Valid: true}, -23*time.Hour - 59*time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := intervalDuration(tt.iv)
+		if err != nil || got != tt.want {
+			t.Errorf("intervalDuration(%+v) = %v, %v; want %v", tt.iv, got, err, tt.want)
+		}
+	}
+
+	if _, err := intervalDuration(pgtype.Interval{Months: 1, Valid: true}); err == nil || !strings.Contains(err.Error(), "months") {
+		t.Errorf("Expected an error for an interval with months, got %v", err)
+	}
+	for _, iv := range []pgtype.Interval{
+		{Days: 200000, Valid: true},
+		{Days: math.MinInt32, Valid: true},
+		{Microseconds: math.MaxInt64, Valid: true},
+		{Days: 106751, Microseconds: 86400000000, Valid: true},
+	} {
+		if _, err := intervalDuration(iv); err == nil || !strings.Contains(err.Error(), "overflows") {
+			t.Errorf("Expected an overflow error for %+v, got %v", iv, err)
+		}
+	}
+}
+
+func TestQueryStructsIntervals(t *testing.T) {
+	type SLA struct {
+		Target  time.Duration  `db:"target"`
+		Actual  *time.Duration `db:"actual"`
+		Window  string         `db:"window"`
+		Elapsed time.Duration  `db:"elapsed"`
+	}
+	mock := &mockQueryer{
+		fields: mockFields("target", "actual", "window", "elapsed"),
+		rows: []mockRow{{values: []interface{}{
+			pgtype.Interval{Microseconds: 4 * 3600000000, Valid: true},
+			pgtype.Interval{Days: 1, Microseconds: 1800000000, Valid: true},
+			pgtype.Interval{Months: 1, Valid: true},
+			nil,
+		}}},
+	}
+
+	var slas []SLA
+	if err := QueryStructs(context.Background(), mock, "SELECT * FROM slas", &slas); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	got := slas[0]
+	if got.Target != 4*time.Hour {
+		t.Errorf("Expected target 4h, got %v", got.Target)
+	}
+	if got.Actual == nil || *got.Actual != 24*time.Hour+30*time.Minute {
+		t.Errorf("Expected actual 24h30m, got %v", got.Actual)
+	}
+	if got.Window != "1 mon" {
+		t.Errorf("Expected window %q, got %q", "1 mon", got.Window)
+	}
+	if got.Elapsed != 0 {
+		t.Errorf("Expected NULL to give 0, got %v", got.Elapsed)
+	}
+
+	mock.fields = mockFields("target")
+	mock.rows = []mockRow{{values: []interface{}{pgtype.Interval{Months: 1, Valid: true}}}}
+	var convErr *ConversionError
+	err := QueryStructs(context.Background(), mock, "SELECT target FROM slas", &slas)
+	if !errors.As(err, &convErr) || convErr.Field != "Target" {
+		t.Errorf("Expected a ConversionError for months, got %v", err)
+	}
+}
+
+func TestInsertStructDuration(t *testing.T) {
+	mock := &mockQueryer{}
+	type SLA struct {
+		Name   string        `db:"name"`
+		Target time.Duration `db:"target"`
+	}
+
+	if err := InsertStruct(context.Background(), mock, "slas", SLA{Name: "api", Target: 90*time.Second + 1500*time.Nanosecond}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	want := pgtype.Interval{Microseconds: 90000001, Valid: true}
+	if len(mock.lastArgs) != 2 || mock.lastArgs[1] != want {
+		t.Errorf("Expected the duration sent as %+v, got %#v", want, mock.lastArgs)
+	}
+}
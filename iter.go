@@ -0,0 +1,273 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrStopIteration can be returned by a QueryStructsEach callback to stop
+// iterating early without QueryStructsEach returning an error.
+var ErrStopIteration = errors.New("dbx: stop iteration")
+
+// MapRows iterates over query results one RowMap at a time, holding the
+// underlying rows open until they are exhausted or closed. Use it like
+// pgx.Rows:
+//
+//	it, err := dbx.QueryMapsIter(ctx, db, "SELECT * FROM events")
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//	for it.Next() {
+//		process(it.Row())
+//	}
+//	return it.Err()
+type MapRows struct {
+	rows  pgx.Rows
+	names []string
+	opts  *options
+	row   RowMap
+	err   error
+}
+
+// QueryMapsIter is a streaming variant of QueryMaps: rows are converted
+// as the caller reads them instead of being collected into a slice.
+// Repeated column names are made unique as for QueryMaps, or rejected with
+// StrictColumns. Close must be called if the caller stops before Next
+// returns false.
+func QueryMapsIter(ctx context.Context, db DB, sql string, args ...any) (*MapRows, error) {
+	opts, args := splitArgs(args)
+
+	rows, err := query(ctx, db, "QueryMapsIter", sql, args, opts)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	fieldDescs := rows.FieldDescriptions()
+	names := make([]string, len(fieldDescs))
+	for i, fd := range fieldDescs {
+		names[i] = fd.Name
+	}
+	if err := checkColumns(names, opts); err != nil {
+		rows.Close()
+		return nil, err
+	}
+
+	return &MapRows{rows: rows, names: uniqueColumns(names), opts: opts}, nil
+}
+
+// Next advances to the next row, returning false when there are no more
+// rows or an error occurred. The rows are closed when it returns false.
+func (r *MapRows) Next() bool {
+	r.row = nil
+	if r.err != nil || !r.rows.Next() {
+		r.Close()
+		return false
+	}
+
+	values, err := r.rows.Values()
+	if err != nil {
+		r.err = fmt.Errorf("failed to get row values: %w", err)
+		r.Close()
+		return false
+	}
+
+	fieldDescs := r.rows.FieldDescriptions()
+	r.row = make(RowMap, len(values))
+	for i, v := range values {
+		r.row[r.names[i]] = mapValue(inetHost(v, fieldDescs[i].DataTypeOID), r.opts)
+	}
+	return true
+}
+
+// Row returns the current row.
+func (r *MapRows) Row() RowMap {
+	return r.row
+}
+
+// Err returns the error, if any, that ended the iteration, including
+// errors reported by the underlying rows.
+func (r *MapRows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	if err := r.rows.Err(); err != nil {
+		return fmt.Errorf("row iteration error: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying rows. It is safe to call more than once.
+func (r *MapRows) Close() {
+	r.rows.Close()
+}
+
+// StructRows iterates over query results one T at a time, where T is a
+// struct or pointer to struct mapped as by QueryStructs. It is used like
+// MapRows.
+type StructRows[T any] struct {
+	ctx      context.Context
+	rows     pgx.Rows
+	scanner  *structScanner
+	elemType reflect.Type
+	isPtr    bool
+	row      T
+	n        int // rows read so far
+	err      error
+}
+
+// QueryStructsIter is a streaming variant of QueryStructsT: each row is
+// mapped into a T as the caller reads it. Close must be called if the
+// caller stops before Next returns false.
+func QueryStructsIter[T any](ctx context.Context, db DB, sql string, args ...any) (*StructRows[T], error) {
+	opts, args := splitArgs(args)
+
+	elemType, isPtr, err := structElemType(reflect.TypeOf([]T(nil)))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query(ctx, db, "QueryStructsIter", sql, args, opts)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	scanner, err := newStructScanner(rows, elemType, opts)
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+
+	return &StructRows[T]{ctx: ctx, rows: rows, scanner: scanner, elemType: elemType, isPtr: isPtr}, nil
+}
+
+// Next advances to the next row, returning false when there are no more
+// rows or an error occurred. The rows are closed when it returns false.
+func (r *StructRows[T]) Next() bool {
+	var zero T
+	r.row = zero
+	if r.err != nil || !r.rows.Next() {
+		r.Close()
+		return false
+	}
+
+	values, err := r.rows.Values()
+	if err != nil {
+		r.err = fmt.Errorf("failed to get row values: %w", err)
+		r.Close()
+		return false
+	}
+
+	elem := reflect.New(r.elemType)
+	if err := r.scanner.scan(values, elem.Elem()); err != nil {
+		r.err = err
+		r.Close()
+		return false
+	}
+	if err := afterScan(r.ctx, elem); err != nil {
+		r.err = fmt.Errorf("row %d: %w", r.n, err)
+		r.Close()
+		return false
+	}
+	r.n++
+
+	if r.isPtr {
+		r.row = elem.Interface().(T)
+	} else {
+		r.row = elem.Elem().Interface().(T)
+	}
+	return true
+}
+
+// Row returns the current row.
+func (r *StructRows[T]) Row() T {
+	return r.row
+}
+
+// Err returns the error, if any, that ended the iteration, including
+// errors reported by the underlying rows.
+func (r *StructRows[T]) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	if err := r.rows.Err(); err != nil {
+		return fmt.Errorf("row iteration error: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying rows. It is safe to call more than once.
+func (r *StructRows[T]) Close() {
+	r.rows.Close()
+}
+
+// QueryStructsEach maps each row into a fresh T, as QueryStructsIter does,
+// and passes it to fn. Iteration stops, closing the rows, when fn returns
+// an error: ErrStopIteration makes QueryStructsEach return nil, and any
+// other error is returned as is.
+func QueryStructsEach[T any](ctx context.Context, db DB, sql string, fn func(T) error, args ...any) error {
+	it, err := QueryStructsIter[T](ctx, db, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for it.Next() {
+		if err := fn(it.Row()); err != nil {
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// QueryStructsChunks maps rows into Ts as QueryStructsIter does and passes
+// them to fn in slices of chunkSize, the last of which may be shorter.
+// Each chunk is a new slice, so fn may keep it. As with QueryStructsEach,
+// an error from fn stops the query: ErrStopIteration makes
+// QueryStructsChunks return nil, and any other error is returned as is.
+//
+//	err := dbx.QueryStructsChunks(ctx, db, "SELECT * FROM products", 500, func(batch []Product) error {
+//		return index.Bulk(ctx, batch)
+//	})
+func QueryStructsChunks[T any](ctx context.Context, db DB, sql string, chunkSize int, fn func([]T) error, args ...any) error {
+	if chunkSize <= 0 {
+		return fmt.Errorf("chunk size must be positive, got %d", chunkSize)
+	}
+
+	it, err := QueryStructsIter[T](ctx, db, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	chunk := make([]T, 0, chunkSize)
+	for it.Next() {
+		if chunk = append(chunk, it.Row()); len(chunk) < chunkSize {
+			continue
+		}
+		if err := fn(chunk); err != nil {
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+			return err
+		}
+		chunk = make([]T, 0, chunkSize)
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	if len(chunk) > 0 {
+		if err := fn(chunk); err != nil && !errors.Is(err, ErrStopIteration) {
+			return err
+		}
+	}
+	return nil
+}
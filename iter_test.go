@@ -0,0 +1,265 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// iterQueryer returns rows that record Close and report err from Err.
+type iterQueryer struct {
+	mockQueryer
+	closed  int
+	iterErr error
+}
+
+type iterRows struct {
+	*mockRows
+	q *iterQueryer
+}
+
+func (r *iterRows) Close()     { r.q.closed++ }
+func (r *iterRows) Err() error { return r.q.iterErr }
+
+func (m *iterQueryer) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	rows, err := m.mockQueryer.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &iterRows{mockRows: rows.(*mockRows), q: m}, nil
+}
+
+func newIterQueryer() *iterQueryer {
+	return &iterQueryer{mockQueryer: mockQueryer{rows: []mockRow{
+		{values: []interface{}{1, "John", "john@example.com"}},
+		{values: []interface{}{2, "Jane", "jane@example.com"}},
+		{values: []interface{}{3, "Jim", "jim@example.com"}},
+	}}}
+}
+
+func TestQueryMapsIter(t *testing.T) {
+	ctx := context.Background()
+	mock := newIterQueryer()
+
+	it, err := QueryMapsIter(ctx, mock, "SELECT * FROM users")
+	if err != nil {
+		t.Fatalf("QueryMapsIter failed: %v", err)
+	}
+	defer it.Close()
+
+	var names []any
+	for it.Next() {
+		names = append(names, it.Row()["name"])
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(names) != 3 || names[2] != "Jim" {
+		t.Errorf("Unexpected rows: %v", names)
+	}
+	if mock.closed == 0 {
+		t.Error("Expected rows to be closed once exhausted")
+	}
+}
+
+func TestQueryMapsIterDuplicateColumns(t *testing.T) {
+	ctx := context.Background()
+	mock := newIterQueryer()
+	mock.fields = mockFields("id", "name", "id")
+	mock.rows = []mockRow{{values: []interface{}{1, "Acme", 2}}}
+
+	it, err := QueryMapsIter(ctx, mock, "SELECT a.id, a.name, b.id FROM a JOIN b ON ...")
+	if err != nil {
+		t.Fatalf("QueryMapsIter failed: %v", err)
+	}
+	defer it.Close()
+	if !it.Next() {
+		t.Fatalf("Expected a row, got %v", it.Err())
+	}
+	if expected := (RowMap{"id": 1, "name": "Acme", "id_2": 2}); !reflect.DeepEqual(it.Row(), expected) {
+		t.Errorf("Expected %v, got %v", expected, it.Row())
+	}
+
+	mock.closed = 0
+	if _, err := QueryMapsIter(ctx, mock, "SELECT ...", StrictColumns()); err == nil || !strings.Contains(err.Error(), `"id"`) {
+		t.Errorf("Expected duplicate column error, got %v", err)
+	}
+	if mock.closed == 0 {
+		t.Error("Expected rows to be closed after the column check failed")
+	}
+}
+
+func TestQueryStructsIterEarlyStop(t *testing.T) {
+	ctx := context.Background()
+	mock := newIterQueryer()
+
+	type TestUser struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	func() {
+		it, err := QueryStructsIter[*TestUser](ctx, mock, "SELECT * FROM users")
+		if err != nil {
+			t.Fatalf("QueryStructsIter failed: %v", err)
+		}
+		defer it.Close()
+
+		for it.Next() {
+			if it.Row().ID == 2 {
+				if it.Row().Name != "Jane" {
+					t.Errorf("Unexpected row: %+v", it.Row())
+				}
+				break
+			}
+		}
+	}()
+
+	if mock.closed == 0 {
+		t.Error("Expected rows to be closed after stopping early")
+	}
+}
+
+func TestQueryStructsIterErr(t *testing.T) {
+	ctx := context.Background()
+	mock := newIterQueryer()
+	mock.iterErr = errors.New("connection reset")
+
+	type TestUser struct {
+		ID int `db:"id"`
+	}
+
+	it, err := QueryStructsIter[TestUser](ctx, mock, "SELECT * FROM users")
+	if err != nil {
+		t.Fatalf("QueryStructsIter failed: %v", err)
+	}
+	defer it.Close()
+
+	count := 0
+	for it.Next() {
+		count++
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 rows, got %d", count)
+	}
+	if err := it.Err(); !errors.Is(err, mock.iterErr) {
+		t.Errorf("Expected rows error to be surfaced, got %v", err)
+	}
+}
+
+func TestQueryStructsIterRejectsNonStruct(t *testing.T) {
+	if _, err := QueryStructsIter[int](context.Background(), newIterQueryer(), "SELECT 1"); err == nil {
+		t.Error("Expected error for non-struct element type")
+	}
+}
+
+func TestQueryStructsEach(t *testing.T) {
+	ctx := context.Background()
+
+	type TestUser struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	t.Run("all rows", func(t *testing.T) {
+		var names []string
+		err := QueryStructsEach(ctx, newIterQueryer(), "SELECT * FROM users", func(u TestUser) error {
+			names = append(names, u.Name)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("QueryStructsEach failed: %v", err)
+		}
+		if len(names) != 3 {
+			t.Errorf("Expected 3 rows, got %v", names)
+		}
+	})
+
+	t.Run("stop iteration", func(t *testing.T) {
+		mock := newIterQueryer()
+		calls := 0
+		err := QueryStructsEach(ctx, mock, "SELECT * FROM users", func(u TestUser) error {
+			calls++
+			return ErrStopIteration
+		})
+		if err != nil {
+			t.Fatalf("Expected nil error after ErrStopIteration, got %v", err)
+		}
+		if calls != 1 || mock.closed == 0 {
+			t.Errorf("Expected one call and closed rows, got calls=%d closed=%d", calls, mock.closed)
+		}
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		mock := newIterQueryer()
+		failure := errors.New("kafka unavailable")
+		err := QueryStructsEach(ctx, mock, "SELECT * FROM users", func(u TestUser) error {
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Errorf("Expected callback error, got %v", err)
+		}
+		if mock.closed == 0 {
+			t.Error("Expected rows to be closed")
+		}
+	})
+}
+
+func TestQueryStructsChunks(t *testing.T) {
+	ctx := context.Background()
+
+	type TestUser struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	t.Run("chunks", func(t *testing.T) {
+		var chunks [][]TestUser
+		err := QueryStructsChunks(ctx, newIterQueryer(), "SELECT * FROM users", 2, func(chunk []TestUser) error {
+			chunks = append(chunks, chunk)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("QueryStructsChunks failed: %v", err)
+		}
+		if len(chunks) != 2 || len(chunks[0]) != 2 || len(chunks[1]) != 1 {
+			t.Fatalf("Expected chunks of 2 and 1, got %v", chunks)
+		}
+		if chunks[0][0].Name == chunks[1][0].Name {
+			t.Errorf("Expected each chunk to keep its own rows, got %v", chunks)
+		}
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		mock := newIterQueryer()
+		failure := errors.New("index unavailable")
+		calls := 0
+		err := QueryStructsChunks(ctx, mock, "SELECT * FROM users", 1, func(chunk []TestUser) error {
+			calls++
+			return failure
+		})
+		if !errors.Is(err, failure) || calls != 1 || mock.closed == 0 {
+			t.Errorf("Expected failure after one call with closed rows, got err=%v calls=%d closed=%d", err, calls, mock.closed)
+		}
+	})
+
+	t.Run("stop iteration", func(t *testing.T) {
+		err := QueryStructsChunks(ctx, newIterQueryer(), "SELECT * FROM users", 5, func(chunk []TestUser) error {
+			return ErrStopIteration
+		})
+		if err != nil {
+			t.Errorf("Expected nil error after ErrStopIteration, got %v", err)
+		}
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		if err := QueryStructsChunks(ctx, newIterQueryer(), "SELECT 1", 0, func([]TestUser) error { return nil }); err == nil {
+			t.Error("Expected error for a zero chunk size")
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package dbx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// mapValue converts a value returned by the driver into the form stored in a
+// RowMap, applying options such as TimesInUTC and NormalizeTypes. Numerics
+// become float64, or strings with ExactNumerics, uuids their canonical
+// string form, and ranges Range[any] values, including inside arrays.
+func mapValue(v any, opts *options) any {
+	if opts.normalize {
+		v = normalizeValue(v)
+	}
+	switch v := v.(type) {
+	case time.Time:
+		if opts.timesInUTC {
+			return v.UTC()
+		}
+	case pgtype.Numeric:
+		if opts.exactNumerics {
+			return numericString(v)
+		}
+		return numericFloat(v)
+	case [16]byte:
+		return formatUUID(v)
+	case pgtype.Range[any]:
+		return outputRange(v, opts, mapValue)
+	case pgtype.Multirange[pgtype.Range[any]]:
+		return outputMultirange(v, opts, mapValue)
+	case []any:
+		return convertArray(v, opts, mapValue)
+	}
+	return v
+}
+
+// jsonValue converts a value returned by the driver into the form used in
+// JSON output. Numerics keep their exact digits, and MAC addresses are
+// written as text rather than base64; inet and cidr values already are.
+func jsonValue(v any, opts *options) any {
+	if opts.normalize {
+		v = normalizeValue(v)
+	}
+	switch v := v.(type) {
+	case time.Time:
+		if opts.timesInUTC {
+			v = v.UTC()
+		}
+		return v.Format(opts.timeFormat)
+	case pgtype.Numeric:
+		return numericJSON(v, opts)
+	case net.HardwareAddr:
+		return v.String()
+	case []byte:
+		if opts.binaryFormat != BinaryDefault || opts.maxBinary > 0 {
+			return binaryText(v, BinaryBase64, opts)
+		}
+	case [16]byte:
+		return formatUUID(v)
+	case pgtype.Range[any]:
+		return outputRange(v, opts, jsonValue)
+	case pgtype.Multirange[pgtype.Range[any]]:
+		return outputMultirange(v, opts, jsonValue)
+	case []any:
+		return convertArray(v, opts, jsonValue)
+	}
+	return v
+}
+
+// CamelCase converts a snake_case column name such as created_at into
+// camelCase (createdAt), for use with JSONKeys. Leading underscores and
+// text other than underscores are left alone.
+func CamelCase(name string) string {
+	trimmed := strings.TrimLeft(name, "_")
+	parts := strings.Split(trimmed, "_")
+	var b strings.Builder
+	b.WriteString(name[:len(name)-len(trimmed)])
+	b.WriteString(parts[0])
+	for _, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(part)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(part[size:])
+	}
+	return b.String()
+}
+
+// StripTablePrefix removes the table qualifier from a column alias such as
+// invoice.amount, leaving amount, for use with JSONKeys.
+func StripTablePrefix(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// isJSONTarget reports whether t is a struct, map, or slice type that json
+// and jsonb values can be decoded into. []byte is excluded so raw JSON can
+// still be read as bytes.
+func isJSONTarget(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+// assignJSON decodes a json or jsonb value into field. Raw JSON arrives as
+// []byte or string; values pgx has already decoded, such as
+// map[string]any, are re-encoded first. NULL resets the field.
+func assignJSON(field reflect.Value, value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+
+	ptr := reflect.New(field.Type())
+	if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+	field.Set(ptr.Elem())
+	return nil
+}
+
+// encodeJSON returns the JSON encoding of a field tagged with the json
+// option. Nil maps, slices, and pointers are sent as SQL NULL rather than
+// the JSON null literal.
+func encodeJSON(field reflect.Value) (any, error) {
+	switch field.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Pointer, reflect.Interface:
+		if field.IsNil() {
+			return nil, nil
+		}
+	}
+
+	data, err := json.Marshal(field.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,208 @@
+package dbx
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryJSONTimeFormat(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("created_at"),
+		rows: []mockRow{{values: []interface{}{
+			time.Date(2024, 3, 15, 10, 30, 0, 0, time.FixedZone("EST", -5*3600)),
+		}}},
+	}
+
+	tests := []struct {
+		name string
+		args []any
+		want string
+	}{
+		{"default", nil, `[{"created_at":"2024-03-15T10:30:00-05:00"}]`},
+		{"custom layout", []any{JSONTimeFormat(time.DateOnly)}, `[{"created_at":"2024-03-15"}]`},
+		{"utc", []any{TimesInUTC()}, `[{"created_at":"2024-03-15T15:30:00Z"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := QueryJSON(ctx, mock, "SELECT created_at FROM events", tt.args...)
+			if err != nil {
+				t.Fatalf("QueryJSON failed: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, data)
+			}
+		})
+	}
+}
+
+func TestQueryJSONKeys(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("invoice.id", "customer_name"),
+		rows:   []mockRow{{values: []interface{}{1, "Acme"}}},
+	}
+
+	tests := []struct {
+		name string
+		fn   func(string) string
+		want string
+	}{
+		{"camel case", CamelCase, `[{"invoice.id":1,"customerName":"Acme"}]`},
+		{"strip table prefix", StripTablePrefix, `[{"id":1,"customer_name":"Acme"}]`},
+		{"both", func(s string) string { return CamelCase(StripTablePrefix(s)) }, `[{"id":1,"customerName":"Acme"}]`},
+		{"custom", strings.ToUpper, `[{"INVOICE.ID":1,"CUSTOMER_NAME":"Acme"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := QueryJSON(ctx, mock, "SELECT * FROM invoices", JSONKeys(tt.fn))
+			if err != nil {
+				t.Fatalf("QueryJSON failed: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, data)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if _, err := QueryNDJSON(ctx, mock, &buf, "SELECT * FROM invoices", JSONKeys(StripTablePrefix)); err != nil {
+		t.Fatalf("QueryNDJSON failed: %v", err)
+	}
+	if buf.String() != `{"id":1,"customer_name":"Acme"}`+"\n" {
+		t.Errorf("Unexpected NDJSON: %s", buf.String())
+	}
+}
+
+func TestQueryJSONKeysCollision(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("invoice.id", "customer.id"),
+		rows:   []mockRow{{values: []interface{}{1, 2}}},
+	}
+
+	_, err := QueryJSON(ctx, mock, "SELECT * FROM invoices", JSONKeys(StripTablePrefix))
+	if err == nil {
+		t.Fatal("Expected error for colliding keys")
+	}
+	if !strings.Contains(err.Error(), `columns "invoice.id" and "customer.id" both map to JSON key "id"`) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	tests := map[string]string{
+		"id":             "id",
+		"created_at":     "createdAt",
+		"user_id_hash":   "userIdHash",
+		"_private_field": "_privateField",
+		"double__under":  "doubleUnder",
+		"trailing_":      "trailing",
+		"alreadyCamel":   "alreadyCamel",
+	}
+	for in, want := range tests {
+		if got := CamelCase(in); got != want {
+			t.Errorf("CamelCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+type testSettings struct {
+	Theme  string   `json:"theme"`
+	Alerts bool     `json:"alerts"`
+	Tags   []string `json:"tags"`
+}
+
+func TestQueryStructsJSONColumns(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("settings", "raw", "text", "meta", "items", "missing"),
+		rows: []mockRow{{values: []interface{}{
+			map[string]any{"theme": "dark", "alerts": true, "tags": []any{"a"}},
+			[]byte(`{"theme":"light"}`),
+			`{"theme":"blue"}`,
+			map[string]any{"k": "v"},
+			[]any{map[string]any{"theme": "x"}},
+			nil,
+		}}},
+	}
+
+	type Row struct {
+		Settings testSettings      `db:"settings"`
+		Raw      testSettings      `db:"raw"`
+		Text     *testSettings     `db:"text"`
+		Meta     map[string]string `db:"meta"`
+		Items    []testSettings    `db:"items"`
+		Missing  *testSettings     `db:"missing"`
+	}
+
+	var rows []Row
+	if err := QueryStructs(ctx, mock, "SELECT * FROM accounts", &rows); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	got := rows[0]
+	if !reflect.DeepEqual(got.Settings, testSettings{Theme: "dark", Alerts: true, Tags: []string{"a"}}) {
+		t.Errorf("Settings = %+v", got.Settings)
+	}
+	if got.Raw.Theme != "light" {
+		t.Errorf("Raw = %+v", got.Raw)
+	}
+	if got.Text == nil || got.Text.Theme != "blue" {
+		t.Errorf("Text = %+v", got.Text)
+	}
+	if got.Meta["k"] != "v" {
+		t.Errorf("Meta = %v", got.Meta)
+	}
+	if len(got.Items) != 1 || got.Items[0].Theme != "x" {
+		t.Errorf("Items = %+v", got.Items)
+	}
+	if got.Missing != nil {
+		t.Errorf("Expected nil for NULL json, got %+v", got.Missing)
+	}
+}
+
+func TestQueryStructsJSONTaggedField(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("scores"),
+		rows:   []mockRow{{values: []interface{}{[]any{1.5, 2.5}}}},
+	}
+
+	var rows []struct {
+		Scores []float64 `db:"scores,json"`
+	}
+	if err := QueryStructs(ctx, mock, "SELECT scores FROM t", &rows); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if !reflect.DeepEqual(rows[0].Scores, []float64{1.5, 2.5}) {
+		t.Errorf("Scores = %v", rows[0].Scores)
+	}
+}
+
+func TestInsertStructJSONField(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	type Account struct {
+		Name     string            `db:"name"`
+		Settings testSettings      `db:"settings,json"`
+		Labels   map[string]string `db:"labels,json"`
+	}
+
+	account := Account{Name: "a", Settings: testSettings{Theme: "dark"}}
+	if err := InsertStruct(ctx, mock, "accounts", account); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+
+	expected := []any{"a", `{"theme":"dark","alerts":false,"tags":null}`, nil}
+	if !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected args %#v, got %#v", expected, mock.lastArgs)
+	}
+}
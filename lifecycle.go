@@ -0,0 +1,80 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// BeforeInserter is implemented by structs that need to prepare themselves
+// before InsertStruct and the other struct insert helpers, the upsert
+// helpers, CopyStructs, and Batch.InsertStruct extract their fields, for
+// example to normalize an email address. Implement it on the
+// pointer receiver to modify the struct; when a struct value is passed, the
+// hook runs on a copy and the copy is inserted.
+type BeforeInserter interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+// BeforeUpdater is like BeforeInserter but runs before UpdateStruct and
+// Batch.UpdateStruct.
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterScanner is implemented by structs that compute derived fields once
+// QueryStructs, QueryStruct, QueryStructsIter, or InsertStructReturning has
+// mapped a row into them.
+type AfterScanner interface {
+	AfterScan(ctx context.Context) error
+}
+
+// beforeInsert runs the BeforeInsert hook of data, if it has one, and
+// returns the value to extract fields from: data itself when it is a
+// pointer, or an addressable copy of it otherwise.
+func beforeInsert(ctx context.Context, data any) (any, error) {
+	data, hook, ok := hookTarget[BeforeInserter](data)
+	if !ok {
+		return data, nil
+	}
+	if err := hook.BeforeInsert(ctx); err != nil {
+		return nil, fmt.Errorf("BeforeInsert failed: %w", err)
+	}
+	return data, nil
+}
+
+// beforeUpdate is like beforeInsert for the BeforeUpdate hook.
+func beforeUpdate(ctx context.Context, data any) (any, error) {
+	data, hook, ok := hookTarget[BeforeUpdater](data)
+	if !ok {
+		return data, nil
+	}
+	if err := hook.BeforeUpdate(ctx); err != nil {
+		return nil, fmt.Errorf("BeforeUpdate failed: %w", err)
+	}
+	return data, nil
+}
+
+// hookTarget returns data, or an addressable copy when data is a struct
+// value, along with it as an H if it implements H.
+func hookTarget[H any](data any) (any, H, bool) {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Struct && reflect.PointerTo(v.Type()).Implements(reflect.TypeOf((*H)(nil)).Elem()) {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		data = ptr.Interface()
+	}
+	hook, ok := data.(H)
+	return data, hook, ok
+}
+
+// afterScan runs the AfterScan hook of the struct that ptr points to, if it
+// has one.
+func afterScan(ctx context.Context, ptr reflect.Value) error {
+	if hook, ok := ptr.Interface().(AfterScanner); ok {
+		if err := hook.AfterScan(ctx); err != nil {
+			return fmt.Errorf("AfterScan failed: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type hookedUser struct {
+	ID          int    `db:"id,pk"`
+	Email       string `db:"email"`
+	DisplayName string `db:"-"`
+}
+
+func (u *hookedUser) BeforeInsert(ctx context.Context) error {
+	if u.Email == "" {
+		return errors.New("email is required")
+	}
+	u.Email = strings.ToLower(u.Email)
+	return nil
+}
+
+func (u *hookedUser) BeforeUpdate(ctx context.Context) error {
+	u.Email = strings.ToLower(u.Email)
+	return nil
+}
+
+func (u *hookedUser) AfterScan(ctx context.Context) error {
+	if u.ID < 0 {
+		return errors.New("negative id")
+	}
+	u.DisplayName = "user " + u.Email
+	return nil
+}
+
+func TestBeforeInsert(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("INSERT 0 1")}
+
+	// A value is copied so the hook can still modify what is inserted
+	if err := InsertStruct(ctx, mock, "users", hookedUser{Email: "John@Example.com"}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if expected := []any{"john@example.com"}; !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected args %#v, got %#v", expected, mock.lastArgs)
+	}
+
+	users := []hookedUser{{Email: "A@Example.com"}, {Email: "B@Example.com"}}
+	if _, err := InsertStructs(ctx, mock, "users", &users); err != nil {
+		t.Fatalf("InsertStructs failed: %v", err)
+	}
+	if users[1].Email != "b@example.com" {
+		t.Errorf("Expected hook to run on the slice elements, got %q", users[1].Email)
+	}
+
+	mock.lastSQL = ""
+	err := InsertStruct(ctx, mock, "users", &hookedUser{})
+	if err == nil || !strings.Contains(err.Error(), "email is required") {
+		t.Errorf("Expected hook error, got %v", err)
+	}
+	if _, err := InsertStructs(ctx, mock, "users", []hookedUser{{Email: "a"}, {}}); err == nil || !strings.Contains(err.Error(), "element 1") {
+		t.Errorf("Expected hook error for element 1, got %v", err)
+	}
+	if mock.lastSQL != "" {
+		t.Error("Expected no statement to run")
+	}
+}
+
+func TestBeforeUpdate(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("UPDATE 1")}
+
+	user := &hookedUser{ID: 7, Email: "John@Example.com"}
+	if _, err := UpdateStruct(ctx, mock, "users", user, "id = $1", user.ID); err != nil {
+		t.Fatalf("UpdateStruct failed: %v", err)
+	}
+	if expected := []any{"john@example.com", 7}; !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected args %#v, got %#v", expected, mock.lastArgs)
+	}
+}
+
+func TestAfterScan(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "email"),
+		rows: []mockRow{
+			{values: []interface{}{1, "a@example.com"}},
+			{values: []interface{}{2, "b@example.com"}},
+		},
+	}
+
+	var users []hookedUser
+	if err := QueryStructs(ctx, mock, "SELECT id, email FROM users", &users); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if len(users) != 2 || users[1].DisplayName != "user b@example.com" {
+		t.Errorf("Expected AfterScan to fill DisplayName, got %+v", users)
+	}
+
+	iter, err := QueryStructsIter[*hookedUser](ctx, mock, "SELECT id, email FROM users")
+	if err != nil {
+		t.Fatalf("QueryStructsIter failed: %v", err)
+	}
+	for iter.Next() {
+		if iter.Row().DisplayName == "" {
+			t.Error("Expected AfterScan to run for iterated rows")
+		}
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+
+	mock.rows = append(mock.rows, mockRow{values: []interface{}{-1, "c@example.com"}})
+	err = QueryStructs(ctx, mock, "SELECT id, email FROM users", &users)
+	if err == nil || !strings.Contains(err.Error(), "row 2") || !strings.Contains(err.Error(), "negative id") {
+		t.Errorf("Expected AfterScan error for row 2, got %v", err)
+	}
+
+	mock.rows = mock.rows[2:]
+	var user hookedUser
+	if err := QueryStruct(ctx, mock, "SELECT id, email FROM users", &user); err == nil {
+		t.Error("Expected AfterScan error from QueryStruct")
+	}
+}
+
+func TestBeforeInsertOtherHelpers(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields:  mockFields("id", "email"),
+		rows:    []mockRow{{values: []interface{}{1, "john@example.com"}}},
+		execTag: pgconn.NewCommandTag("INSERT 0 1"),
+	}
+
+	user := &hookedUser{Email: "John@Example.com"}
+	if err := InsertStructReturning(ctx, mock, "users", user); err != nil {
+		t.Fatalf("InsertStructReturning failed: %v", err)
+	}
+	if expected := []any{"john@example.com"}; !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected InsertStructReturning args %#v, got %#v", expected, mock.lastArgs)
+	}
+
+	if _, err := UpsertStruct(ctx, mock, "users", hookedUser{ID: 1, Email: "John@Example.com"}, []string{"id"}); err != nil {
+		t.Fatalf("UpsertStruct failed: %v", err)
+	}
+	if expected := []any{1, "john@example.com"}; !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected UpsertStruct args %#v, got %#v", expected, mock.lastArgs)
+	}
+
+	if _, err := UpsertStructUpdate(ctx, mock, "users", &hookedUser{ID: 1, Email: "John@Example.com"}, []string{"id"}, []string{"email"}); err != nil {
+		t.Fatalf("UpsertStructUpdate failed: %v", err)
+	}
+	if expected := []any{1, "john@example.com"}; !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected UpsertStructUpdate args %#v, got %#v", expected, mock.lastArgs)
+	}
+
+	mock.lastSQL = ""
+	if _, err := UpsertStruct(ctx, mock, "users", hookedUser{ID: 1}, []string{"id"}); err == nil || !strings.Contains(err.Error(), "email is required") {
+		t.Errorf("Expected hook error from UpsertStruct, got %v", err)
+	}
+	if mock.lastSQL != "" {
+		t.Error("Expected no statement to run")
+	}
+
+	copier := &mockCopier{}
+	users := []hookedUser{{ID: 1, Email: "A@Example.com"}, {ID: 2, Email: "B@Example.com"}}
+	if _, err := CopyStructs(ctx, copier, "users", users); err != nil {
+		t.Fatalf("CopyStructs failed: %v", err)
+	}
+	if expected := [][]any{{1, "a@example.com"}, {2, "b@example.com"}}; !reflect.DeepEqual(copier.rows, expected) {
+		t.Errorf("Expected CopyStructs rows %#v, got %#v", expected, copier.rows)
+	}
+	copier = &mockCopier{}
+	if _, err := CopyStructs(ctx, copier, "users", []hookedUser{{ID: 1, Email: "a"}, {ID: 2}}); err == nil || !strings.Contains(err.Error(), "element 1") {
+		t.Errorf("Expected hook error for element 1, got %v", err)
+	}
+	if copier.rows != nil {
+		t.Error("Expected no rows to be copied")
+	}
+}
+
+func TestBatchHooks(t *testing.T) {
+	var b Batch
+	b.InsertStruct("users", hookedUser{ID: 1, Email: "John@Example.com"})
+	b.UpdateStruct("users", &hookedUser{ID: 1, Email: "Jane@Example.com"}, "id = $1", 1)
+	if b.err != nil {
+		t.Fatalf("Unexpected batch error: %v", b.err)
+	}
+	queued := b.batch.QueuedQueries
+	if expected := []any{1, "john@example.com"}; !reflect.DeepEqual(queued[0].Arguments, expected) {
+		t.Errorf("Expected insert args %#v, got %#v", expected, queued[0].Arguments)
+	}
+	if expected := []any{"jane@example.com", 1}; !reflect.DeepEqual(queued[1].Arguments, expected) {
+		t.Errorf("Expected update args %#v, got %#v", expected, queued[1].Arguments)
+	}
+
+	b.InsertStruct("users", hookedUser{ID: 2})
+	if b.err == nil || !strings.Contains(b.err.Error(), "email is required") {
+		t.Errorf("Expected hook error, got %v", b.err)
+	}
+}
@@ -0,0 +1,136 @@
+package dbx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Delays between attempts to re-establish a lost LISTEN connection. The
+// delay doubles after each failed attempt, up to the maximum, is jittered,
+// and starts again from the base once listening resumes.
+var (
+	listenRetryBaseDelay = 100 * time.Millisecond
+	listenRetryMaxDelay  = 30 * time.Second
+)
+
+// Listen takes a connection out of pool, LISTENs on channel, and calls
+// handler with the payload of each notification, one at a time, until ctx
+// is canceled. If the connection is lost it is replaced and LISTEN issued
+// again, after a backoff delay; notifications sent in the meantime are
+// missed, so a cache invalidated this way should be flushed by the caller
+// when that matters. Failures are reported to the package logger at warn
+// level. Listen blocks, and returns nil once ctx is canceled.
+//
+// The channel name is used exactly, as pg_notify('channel', ...) does; a
+// NOTIFY statement folds unquoted names to lower case.
+//
+//	go dbx.Listen(ctx, pool, "cache_invalidation", func(payload string) {
+//		cache.Delete(payload)
+//	})
+func Listen(ctx context.Context, pool *pgxpool.Pool, channel string, handler func(payload string)) error {
+	return ListenChannels(ctx, pool, []string{channel}, func(_, payload string) {
+		handler(payload)
+	})
+}
+
+// ListenChannels is like Listen but LISTENs on several channels over one
+// connection, passing handler the channel of each notification along with
+// its payload.
+func ListenChannels(ctx context.Context, pool *pgxpool.Pool, channels []string, handler func(channel, payload string)) error {
+	return listen(ctx, poolListenConn(pool), channels, handler)
+}
+
+// ListenJSON is like Listen but decodes each payload from JSON into a T
+// before calling handler. Payloads that fail to decode are reported to the
+// package logger at error level and skipped.
+func ListenJSON[T any](ctx context.Context, pool *pgxpool.Pool, channel string, handler func(T)) error {
+	return Listen(ctx, pool, channel, func(payload string) {
+		var v T
+		if err := json.Unmarshal([]byte(payload), &v); err != nil {
+			getLogger().Errorf("[dbx] Listen %s: failed to decode payload as %T: %v", channel, v, err)
+			return
+		}
+		handler(v)
+	})
+}
+
+// listenConn is the part of a connection Listen uses.
+type listenConn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	WaitForNotification(ctx context.Context) (*pgconn.Notification, error)
+	Close(ctx context.Context) error
+}
+
+// poolListenConn returns a function taking a connection out of pool for
+// good, so that its LISTENs end with it rather than outliving it in the
+// pool.
+func poolListenConn(pool *pgxpool.Pool) func(ctx context.Context) (listenConn, error) {
+	return func(ctx context.Context) (listenConn, error) {
+		conn, err := pool.Acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return conn.Hijack(), nil
+	}
+}
+
+// listen implements ListenChannels, obtaining connections from connect.
+func listen(ctx context.Context, connect func(ctx context.Context) (listenConn, error), channels []string, handler func(channel, payload string)) error {
+	if len(channels) == 0 {
+		return errors.New("no channels to listen on")
+	}
+
+	delay := listenRetryBaseDelay
+	for {
+		listening, err := listenOnce(ctx, connect, channels, handler)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if listening {
+			delay = listenRetryBaseDelay
+		}
+
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		getLogger().Warnf("[dbx] Listen %v: %v; reconnecting in %s", channels, err, wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		delay = min(delay*2, listenRetryMaxDelay)
+	}
+}
+
+// listenOnce opens a connection, LISTENs on channels, and dispatches
+// notifications until the connection fails or ctx is canceled. listening
+// reports whether LISTEN succeeded.
+func listenOnce(ctx context.Context, connect func(ctx context.Context) (listenConn, error), channels []string, handler func(channel, payload string)) (listening bool, err error) {
+	conn, err := connect(ctx)
+	if err != nil {
+		return false, fmt.Errorf("connect failed: %w", err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	for _, channel := range channels {
+		if _, err := conn.Exec(ctx, "LISTEN "+quoteIdent(channel)); err != nil {
+			return false, fmt.Errorf("listen %s failed: %w", channel, err)
+		}
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, fmt.Errorf("wait for notification failed: %w", err)
+		}
+		handler(n.Channel, n.Payload)
+	}
+}
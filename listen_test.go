@@ -0,0 +1,135 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeListenConn delivers queued notifications, then fails with err, or
+// blocks until the context ends if err is nil.
+type fakeListenConn struct {
+	mu            sync.Mutex
+	statements    []string
+	notifications []*pgconn.Notification
+	err           error
+	closed        bool
+}
+
+func (c *fakeListenConn) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.statements = append(c.statements, sql)
+	return pgconn.NewCommandTag("LISTEN"), nil
+}
+
+func (c *fakeListenConn) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	c.mu.Lock()
+	if len(c.notifications) > 0 {
+		n := c.notifications[0]
+		c.notifications = c.notifications[1:]
+		c.mu.Unlock()
+		return n, nil
+	}
+	err := c.err
+	c.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (c *fakeListenConn) Close(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func withListenRetryDelay(d time.Duration) func() {
+	prev := listenRetryBaseDelay
+	listenRetryBaseDelay = d
+	return func() { listenRetryBaseDelay = prev }
+}
+
+func TestListenReconnects(t *testing.T) {
+	defer withListenRetryDelay(time.Millisecond)()
+	useRecordingLogger(t)
+
+	conns := []*fakeListenConn{
+		{
+			notifications: []*pgconn.Notification{{Channel: "cache", Payload: "users:1"}},
+			err:           errors.New("connection reset"),
+		},
+		{
+			notifications: []*pgconn.Notification{{Channel: "Jobs", Payload: "42"}},
+		},
+	}
+	var attempts int
+	connect := func(ctx context.Context) (listenConn, error) {
+		attempts++
+		switch attempts {
+		case 1:
+			return conns[0], nil
+		case 2:
+			return nil, errors.New("pool closed")
+		default:
+			return conns[1], nil
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var got []string
+	err := listen(ctx, connect, []string{"cache", "Jobs"}, func(channel, payload string) {
+		got = append(got, channel+"="+payload)
+		if len(got) == 2 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("listen returned %v", err)
+	}
+
+	if want := []string{"cache=users:1", "Jobs=42"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("notifications = %v, want %v", got, want)
+	}
+	for i, c := range conns {
+		if want := []string{`LISTEN "cache"`, `LISTEN "Jobs"`}; !reflect.DeepEqual(c.statements, want) {
+			t.Errorf("connection %d ran %v, want %v", i, c.statements, want)
+		}
+		if !c.closed {
+			t.Errorf("connection %d was not closed", i)
+		}
+	}
+}
+
+func TestListenStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- listen(ctx, func(context.Context) (listenConn, error) {
+			return &fakeListenConn{}, nil
+		}, []string{"cache"}, func(string, string) {})
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("listen returned %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("listen did not stop after cancel")
+	}
+
+	if err := listen(context.Background(), nil, nil, nil); err == nil {
+		t.Error("expected error for no channels")
+	}
+}
@@ -0,0 +1,113 @@
+package dbx
+
+import (
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Logger receives diagnostic output from dbx. Implementations must be safe
+// for concurrent use.
+type Logger interface {
+	Debugf(format string, args ...any)
+	Warnf(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+// loggerBox lets Logger values of different concrete types share one atomic pointer.
+type loggerBox struct {
+	Logger
+}
+
+var currentLogger atomic.Pointer[loggerBox]
+
+func init() {
+	currentLogger.Store(&loggerBox{nopLogger{}})
+}
+
+// SetLogger sets the package-level logger. Passing nil restores the default
+// no-op logger.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	currentLogger.Store(&loggerBox{l})
+}
+
+// getLogger returns the package-level logger.
+func getLogger() Logger {
+	return currentLogger.Load().Logger
+}
+
+// slowQueryThreshold is the duration, in nanoseconds, at or above which a
+// statement is logged as slow; zero disables slow query logging.
+var slowQueryThreshold atomic.Int64
+
+// slowQuerySQLLength is the longest SQL text, in bytes, included in a slow
+// query warning.
+var slowQuerySQLLength atomic.Int64
+
+func init() {
+	slowQuerySQLLength.Store(maxErrorSQL)
+}
+
+// SetSlowQueryThreshold makes dbx log statements that take d or longer
+// at warn level, with the helper that ran them, their SQL, duration, and
+// row count. Only time spent in the database counts: for queries, the call
+// itself plus fetching the rows, but not the caller's work between rows. A
+// threshold of zero or less, the default, disables the check.
+func SetSlowQueryThreshold(d time.Duration) {
+	slowQueryThreshold.Store(int64(d))
+}
+
+// SetSlowQuerySQLLength sets the longest SQL text, in bytes, included in a
+// slow query warning; longer statements are truncated. The default is 500,
+// and zero or less logs statements whole.
+func SetSlowQuerySQLLength(n int) {
+	slowQuerySQLLength.Store(int64(n))
+}
+
+// slowQueryLogging reports whether a slow query threshold is set.
+func slowQueryLogging() bool {
+	return slowQueryThreshold.Load() > 0
+}
+
+// logSlowQuery warns about a statement run on behalf of op if it took at
+// least the slow query threshold.
+func logSlowQuery(op, sql string, duration time.Duration, rowCount int64) {
+	threshold := time.Duration(slowQueryThreshold.Load())
+	if threshold <= 0 || duration < threshold {
+		return
+	}
+	sql = truncateSQL(sql, int(slowQuerySQLLength.Load()))
+	getLogger().Warnf("[dbx] slow %s took %s: %s (rows=%d)", op, duration, sql, rowCount)
+}
+
+// nopLogger discards all output.
+type nopLogger struct{}
+
+func (nopLogger) Debugf(string, ...any) {}
+func (nopLogger) Warnf(string, ...any)  {}
+func (nopLogger) Errorf(string, ...any) {}
+
+// SlogLogger adapts a *slog.Logger to the Logger interface.
+func SlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s slogLogger) Debugf(format string, args ...any) {
+	s.l.Debug(fmt.Sprintf(format, args...))
+}
+
+func (s slogLogger) Warnf(format string, args ...any) {
+	s.l.Warn(fmt.Sprintf(format, args...))
+}
+
+func (s slogLogger) Errorf(format string, args ...any) {
+	s.l.Error(fmt.Sprintf(format, args...))
+}
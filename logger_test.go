@@ -0,0 +1,180 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type recordingLogger struct {
+	mu     sync.Mutex
+	debugs []string
+	warns  []string
+	errors []string
+}
+
+func (l *recordingLogger) Debugf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.debugs = append(l.debugs, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Warnf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warns = append(l.warns, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Errorf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, fmt.Sprintf(format, args...))
+}
+
+func useRecordingLogger(t *testing.T) *recordingLogger {
+	t.Helper()
+	l := &recordingLogger{}
+	SetLogger(l)
+	t.Cleanup(func() { SetLogger(nil) })
+	return l
+}
+
+func TestLoggerReceivesQueryDetails(t *testing.T) {
+	logger := useRecordingLogger(t)
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, "John", "john@example.com"}},
+			{values: []interface{}{2, "Jane", "jane@example.com"}},
+		},
+	}
+
+	if _, err := QueryMaps(ctx, mock, "SELECT * FROM users WHERE active = $1", true); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+
+	if len(logger.debugs) != 1 {
+		t.Fatalf("Expected 1 debug line, got %v", logger.debugs)
+	}
+
+	line := logger.debugs[0]
+	for _, want := range []string{"QueryMaps", "SELECT * FROM users WHERE active = $1", "args=1", "rows=2", "duration="} {
+		if !strings.Contains(line, want) {
+			t.Errorf("Expected debug line to contain %q, got %q", want, line)
+		}
+	}
+}
+
+func TestLoggerReceivesQueryErrors(t *testing.T) {
+	logger := useRecordingLogger(t)
+	ctx := context.Background()
+	mock := &mockQueryer{err: errors.New("connection refused")}
+
+	if _, err := QueryMaps(ctx, mock, "SELECT 1"); err == nil {
+		t.Fatal("Expected error")
+	}
+
+	if len(logger.errors) != 1 || !strings.Contains(logger.errors[0], "connection refused") {
+		t.Errorf("Expected error line with cause, got %v", logger.errors)
+	}
+}
+
+func TestSetLoggerNilRestoresNop(t *testing.T) {
+	SetLogger(nil)
+	if _, ok := getLogger().(nopLogger); !ok {
+		t.Errorf("Expected nopLogger, got %T", getLogger())
+	}
+}
+
+// slowQueryer delays each Query and Exec call by delay.
+type slowQueryer struct {
+	mockQueryer
+	delay time.Duration
+}
+
+func (s *slowQueryer) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	time.Sleep(s.delay)
+	return s.mockQueryer.Query(ctx, sql, args...)
+}
+
+func (s *slowQueryer) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	time.Sleep(s.delay)
+	return s.mockQueryer.Exec(ctx, sql, args...)
+}
+
+func useSlowQueryThreshold(t *testing.T, d time.Duration) {
+	t.Helper()
+	SetSlowQueryThreshold(d)
+	t.Cleanup(func() { SetSlowQueryThreshold(0) })
+}
+
+func TestSlowQueryLogging(t *testing.T) {
+	logger := useRecordingLogger(t)
+	useSlowQueryThreshold(t, 10*time.Millisecond)
+	SetSlowQuerySQLLength(20)
+	defer SetSlowQuerySQLLength(maxErrorSQL)
+
+	ctx := context.Background()
+	db := &slowQueryer{delay: 20 * time.Millisecond}
+	db.rows = []mockRow{{values: []interface{}{1, "John", "john@example.com"}}}
+
+	if _, err := QueryMaps(ctx, db, "SELECT id, name, email FROM users"); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if len(logger.warns) != 1 {
+		t.Fatalf("Expected 1 warning, got %v", logger.warns)
+	}
+	for _, want := range []string{"slow QueryMaps", "SELECT id, name, ema...", "rows=1"} {
+		if !strings.Contains(logger.warns[0], want) {
+			t.Errorf("Expected warning to contain %q, got %q", want, logger.warns[0])
+		}
+	}
+
+	db.execTag = pgconn.NewCommandTag("INSERT 0 1")
+	if err := InsertStruct(ctx, db, "users", insertUser{Name: "John"}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if len(logger.warns) != 2 || !strings.Contains(logger.warns[1], "slow InsertStruct") {
+		t.Errorf("Expected a warning for InsertStruct, got %v", logger.warns)
+	}
+
+	db.delay = 0
+	if _, err := QueryMaps(ctx, db, "SELECT 1"); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if len(logger.warns) != 2 {
+		t.Errorf("Expected no warning for a fast query, got %v", logger.warns)
+	}
+}
+
+func TestSlowQueryExcludesCallerTime(t *testing.T) {
+	logger := useRecordingLogger(t)
+	useSlowQueryThreshold(t, 10*time.Millisecond)
+
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, "John", "john@example.com"}},
+			{values: []interface{}{2, "Jane", "jane@example.com"}},
+		},
+	}
+
+	rows, err := query(context.Background(), mock, "QueryRows", "SELECT * FROM users", nil, nil)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	for rows.Next() {
+		time.Sleep(15 * time.Millisecond)
+	}
+	rows.Close()
+
+	if len(logger.warns) != 0 {
+		t.Errorf("Expected time between rows not to count, got %v", logger.warns)
+	}
+}
@@ -0,0 +1,136 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Mapper maps query results into values of struct type T for queries run
+// repeatedly, such as in hot loops. T's tags are parsed when the Mapper is
+// created, and the mapping from a result's columns to T's fields is worked
+// out on first use and reused for as long as later results have the same
+// columns. A Mapper is safe for concurrent use.
+//
+//	var users = dbx.NewMapper[User]()
+//
+//	func activeUsers(ctx context.Context, db dbx.DB) ([]User, error) {
+//		return users.QueryAll(ctx, db, "SELECT "+strings.Join(users.Columns(), ", ")+" FROM users WHERE active")
+//	}
+type Mapper[T any] struct {
+	options []any // Options passed to NewMapper, applied before those of each call
+	err     error // set if T can't be mapped
+	layout  atomic.Pointer[mapperLayout]
+}
+
+// mapperLayout is the column-to-field mapping a Mapper resolved for the
+// result columns names under the options identified by key.
+type mapperLayout struct {
+	names    []string
+	key      layoutKey
+	fieldMap map[int]int
+}
+
+// layoutKey identifies the options that change how columns map to fields.
+type layoutKey struct {
+	tag        string
+	mapper     *nameMapper
+	strict     bool
+	requireAll bool
+}
+
+// NewMapper returns a Mapper for struct type T. Options given here, such
+// as TagName or Lenient, apply to every call, before any passed to
+// QueryAll. If T is not a struct, or its tags are inconsistent, the
+// Mapper's methods return the error.
+func NewMapper[T any](opts ...Option) *Mapper[T] {
+	m := &Mapper[T]{options: withOptions(nil, opts)}
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() != reflect.Struct {
+		m.err = fmt.Errorf("dbx.Mapper type must be a struct, got %s", typ)
+		return m
+	}
+	o, _ := splitArgs(m.options)
+	m.err = getStructMeta(typ, o).Err
+	return m
+}
+
+// QueryAll executes a query and returns its rows mapped into T, as
+// QueryStructsT does. Options may be passed among the args.
+func (m *Mapper[T]) QueryAll(ctx context.Context, db DB, sql string, args ...any) ([]T, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	opts, args := splitArgs(append(m.options[:len(m.options):len(m.options)], args...))
+
+	rows, err := query(ctx, db, "Mapper.QueryAll", sql, args, opts)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+	return m.collect(ctx, rows, opts)
+}
+
+// ScanRows reads the remaining rows of rows into T and closes them, for
+// results obtained from pgx directly. AfterScanner hooks receive a
+// background context.
+func (m *Mapper[T]) ScanRows(rows pgx.Rows) ([]T, error) {
+	defer rows.Close()
+	if m.err != nil {
+		return nil, m.err
+	}
+	opts, _ := splitArgs(m.options)
+	return m.collect(context.Background(), rows, opts)
+}
+
+// Columns returns the select list the Mapper expects, one entry per mapped
+// field in declaration order, for building the query from the struct: "id"
+// for a field tagged id, and "users"."email" AS "users.email" for one
+// tagged users.email, so that the result columns match the tags exactly.
+// Nested struct fields give their prefixed columns, and rest and group
+// fields none. Columns returns nil if the Mapper's type can't be mapped.
+// ColumnsOf gives the same list qualified by a table alias.
+func (m *Mapper[T]) Columns() []string {
+	if m.err != nil {
+		return nil
+	}
+	opts, _ := splitArgs(m.options)
+	return selectList(getStructMeta(reflect.TypeOf((*T)(nil)).Elem(), opts), "")
+}
+
+// collect reads rows into a new slice of T, resolving the column mapping
+// or reusing the one from the last call with the same columns.
+func (m *Mapper[T]) collect(ctx context.Context, rows pgx.Rows, opts *options) ([]T, error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	fieldDescs := rows.FieldDescriptions()
+	names := make([]string, len(fieldDescs))
+	for i, fd := range fieldDescs {
+		names[i] = fd.Name
+	}
+
+	key := layoutKey{tag: opts.tagName, mapper: opts.nameMapper, strict: opts.strictColumns, requireAll: opts.requireAll}
+	layout := m.layout.Load()
+	if layout == nil || layout.key != key || !slices.Equal(layout.names, names) {
+		if err := checkColumns(names, opts); err != nil {
+			return nil, err
+		}
+		fieldMap, err := mapColumns(names, typ, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build field mapping: %w", err)
+		}
+		layout = &mapperLayout{names: names, key: key, fieldMap: fieldMap}
+		m.layout.Store(layout)
+	}
+
+	var result []T
+	scanner := newMappedScanner(fieldDescs, typ, names, layout.fieldMap, opts)
+	if err := collectStructs(ctx, rows, scanner, reflect.ValueOf(&result).Elem(), opts); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
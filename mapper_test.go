@@ -0,0 +1,127 @@
+package dbx
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type mapperUser struct {
+	ID    int    `db:"id"`
+	Name  string `db:"name"`
+	Email string `db:"users.email"`
+}
+
+func TestMapperQueryAll(t *testing.T) {
+	ctx := context.Background()
+	m := NewMapper[mapperUser]()
+	mock := &mockQueryer{
+		fields: mockFields("id", "name", "users.email"),
+		rows: []mockRow{
+			{values: []interface{}{1, "Alice", "alice@example.com"}},
+			{values: []interface{}{2, "Bob", "bob@example.com"}},
+		},
+	}
+
+	users, err := m.QueryAll(ctx, mock, "SELECT * FROM users WHERE active = $1", true)
+	if err != nil {
+		t.Fatalf("QueryAll failed: %v", err)
+	}
+	want := []mapperUser{{1, "Alice", "alice@example.com"}, {2, "Bob", "bob@example.com"}}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("Expected %+v, got %+v", want, users)
+	}
+	if len(mock.lastArgs) != 1 || mock.lastArgs[0] != true {
+		t.Errorf("Expected the args passed through, got %#v", mock.lastArgs)
+	}
+
+	layout := m.layout.Load()
+	if _, err := m.QueryAll(ctx, mock, "SELECT * FROM users"); err != nil {
+		t.Fatalf("QueryAll failed: %v", err)
+	}
+	if m.layout.Load() != layout {
+		t.Error("Expected the column layout reused for the same columns")
+	}
+
+	mock.fields = mockFields("email", "id")
+	mock.rows = []mockRow{{values: []interface{}{"carol@example.com", 3}}}
+	users, err = m.QueryAll(ctx, mock, "SELECT email, id FROM users")
+	if err != nil {
+		t.Fatalf("QueryAll failed: %v", err)
+	}
+	if want := []mapperUser{{ID: 3, Email: "carol@example.com"}}; !reflect.DeepEqual(users, want) {
+		t.Errorf("Expected %+v after the columns changed, got %+v", want, users)
+	}
+	if m.layout.Load() == layout {
+		t.Error("Expected a new column layout for different columns")
+	}
+}
+
+func TestMapperOptions(t *testing.T) {
+	type Row struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	m := NewMapper[Row](TagName("json"))
+	mock := &mockQueryer{
+		fields: mockFields("id", "name"),
+		rows:   []mockRow{{values: []interface{}{1, "Alice"}}},
+	}
+
+	rows, err := m.QueryAll(context.Background(), mock, "SELECT id, name FROM t")
+	if err != nil || len(rows) != 1 || rows[0].Name != "Alice" {
+		t.Fatalf("Expected the mapper's TagName applied, got %+v, %v", rows, err)
+	}
+
+	mock.fields = mockFields("id")
+	if _, err := m.QueryAll(context.Background(), mock, "SELECT id FROM t", RequireAllFields()); err == nil {
+		t.Error("Expected per-call RequireAllFields to fail for the missing name column")
+	}
+}
+
+func TestMapperScanRows(t *testing.T) {
+	mock := &mockQueryer{
+		fields: mockFields("id", "name"),
+		rows:   []mockRow{{values: []interface{}{1, "Alice"}}},
+	}
+	rows, err := mock.Query(context.Background(), "SELECT id, name FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := NewMapper[mapperUser]().ScanRows(rows)
+	if err != nil {
+		t.Fatalf("ScanRows failed: %v", err)
+	}
+	if want := []mapperUser{{ID: 1, Name: "Alice"}}; !reflect.DeepEqual(users, want) {
+		t.Errorf("Expected %+v, got %+v", want, users)
+	}
+}
+
+func TestMapperColumns(t *testing.T) {
+	type Address struct {
+		City string `db:"city"`
+	}
+	type Order struct {
+		ID       int     `db:"id"`
+		Customer string  `db:"customers.name"`
+		Ship     Address `db:"ship"`
+		Extra    RowMap  `db:",rest"`
+		Ignored  string  `db:"-"`
+	}
+
+	got := strings.Join(NewMapper[Order]().Columns(), ", ")
+	want := `"id", "customers"."name" AS "customers.name", "ship"."city" AS "ship.city"`
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	m := NewMapper[int]()
+	if _, err := m.QueryAll(context.Background(), &mockQueryer{}, "SELECT 1"); err == nil || !strings.Contains(err.Error(), "must be a struct") {
+		t.Errorf("Expected an error for a non-struct type, got %v", err)
+	}
+	if m.Columns() != nil {
+		t.Errorf("Expected no columns for a non-struct type, got %v", m.Columns())
+	}
+}
@@ -0,0 +1,127 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Mapping describes how the columns of a result map onto the fields of a
+// struct type, as QueryStructs resolves it: by full tag, then by the column
+// part of a table.column tag, then by Go field name. Print it with %v to
+// see the mapping one column per line.
+type Mapping struct {
+	Type    reflect.Type    // the struct type
+	Columns []ColumnMapping // one per result column, in result order
+	Missing []string        // tags of fields no column feeds, as RequireAllFields reports them
+}
+
+// ColumnMapping is the field a result column is read into. Field and Index
+// are empty for a column that feeds no field, which a rest field collects.
+type ColumnMapping struct {
+	Column string // column name
+	Field  string // path of the Go field, such as "Customer.Name"
+	Tag    string // the field's tag, such as "customers.name"
+	Index  []int  // index sequence of the field for reflect.Value.FieldByIndex
+}
+
+// MappingFor resolves the mapping of a result with the given columns onto
+// structType, with the tag name, name mapper, and column checks of opts
+// and the defaults. It fails where QueryStructs would, for instance when
+// two fields take the same column, or with RequireAllFields when a field
+// has none.
+func MappingFor(columns []string, structType reflect.Type, opts ...Option) (Mapping, error) {
+	if structType == nil || structType.Kind() != reflect.Struct {
+		return Mapping{}, fmt.Errorf("structType must be a struct type, got %v", structType)
+	}
+	o, _ := splitArgs(withOptions(nil, opts))
+	if err := checkColumns(columns, o); err != nil {
+		return Mapping{}, err
+	}
+	fieldMap, err := mapColumns(columns, structType, o)
+	if err != nil {
+		return Mapping{}, err
+	}
+
+	meta := getStructMeta(structType, o)
+	m := Mapping{
+		Type:    structType,
+		Columns: make([]ColumnMapping, len(columns)),
+		Missing: missingFields(meta, fieldMap),
+	}
+	for i, name := range columns {
+		m.Columns[i].Column = name
+		if pos, ok := fieldMap[i]; ok {
+			f := &meta.Fields[pos]
+			m.Columns[i].Field = fieldPath(structType, f.Index)
+			m.Columns[i].Tag = f.Tag
+			m.Columns[i].Index = f.Index
+		}
+	}
+	return m, nil
+}
+
+// Unmapped returns the names of the columns that feed no field.
+func (m Mapping) Unmapped() []string {
+	var names []string
+	for _, c := range m.Columns {
+		if c.Index == nil {
+			names = append(names, c.Column)
+		}
+	}
+	return names
+}
+
+// String lists the columns and the fields they feed, one per line, such
+// as name -> Customer.Name, and the fields left without a column.
+func (m Mapping) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "mapping onto %s:", m.Type)
+	for _, c := range m.Columns {
+		field := "(unmapped)"
+		if c.Index != nil {
+			field = c.Field
+		}
+		fmt.Fprintf(&b, "\n  %s -> %s", c.Column, field)
+	}
+	for _, tag := range m.Missing {
+		fmt.Fprintf(&b, "\n  (no column) -> %s", tag)
+	}
+	return b.String()
+}
+
+// fieldPath returns the dotted Go path of the field at index in t.
+func fieldPath(t reflect.Type, index []int) string {
+	names := make([]string, len(index))
+	for i, x := range index {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		f := t.Field(x)
+		names[i] = f.Name
+		t = f.Type
+	}
+	return strings.Join(names, ".")
+}
+
+// ScanRowsInto reads the remaining rows of rows into dest, a pointer to a
+// slice of structs or struct pointers, mapping columns as QueryStructs
+// does, and closes rows. It serves rows obtained elsewhere, such as from
+// a batch. AfterScanner hooks receive a background context.
+func ScanRowsInto(rows pgx.Rows, dest any, opts ...Option) error {
+	defer rows.Close()
+	sliceValue, elemType, err := structSliceDest(dest)
+	if err != nil {
+		return err
+	}
+
+	o, _ := splitArgs(withOptions(nil, opts))
+	scanner, err := newStructScanner(rows, elemType, o)
+	if err != nil {
+		return err
+	}
+	return collectStructs(context.Background(), rows, scanner, sliceValue, o)
+}
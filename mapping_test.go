@@ -0,0 +1,86 @@
+package dbx
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMappingFor(t *testing.T) {
+	type Customer struct {
+		Name string `db:"name"`
+	}
+	type Invoice struct {
+		ID       int      `db:"invoices.id"`
+		Amount   string   `db:"amount"`
+		Paid     bool     `db:"paid"`
+		Note     string   `db:"note,optional"`
+		Customer Customer `db:"customer"`
+	}
+
+	m, err := MappingFor([]string{"id", "amount", "customer.name", "extra"}, reflect.TypeOf(Invoice{}))
+	if err != nil {
+		t.Fatalf("MappingFor failed: %v", err)
+	}
+	want := []ColumnMapping{
+		{Column: "id", Field: "ID", Tag: "invoices.id", Index: []int{0}},
+		{Column: "amount", Field: "Amount", Tag: "amount", Index: []int{1}},
+		{Column: "customer.name", Field: "Customer.Name", Tag: "customer.name", Index: []int{4, 0}},
+		{Column: "extra"},
+	}
+	if !reflect.DeepEqual(m.Columns, want) {
+		t.Errorf("Expected columns %+v, got %+v", want, m.Columns)
+	}
+	if !reflect.DeepEqual(m.Missing, []string{"paid"}) {
+		t.Errorf("Expected paid missing, got %v", m.Missing)
+	}
+	if got := m.Unmapped(); !reflect.DeepEqual(got, []string{"extra"}) {
+		t.Errorf("Expected extra unmapped, got %v", got)
+	}
+	if s := m.String(); !strings.Contains(s, "customer.name -> Customer.Name") || !strings.Contains(s, "extra -> (unmapped)") {
+		t.Errorf("Unexpected String output:\n%s", s)
+	}
+
+	if _, err := MappingFor([]string{"amount"}, reflect.TypeOf(Invoice{}), RequireAllFields()); err == nil {
+		t.Error("Expected RequireAllFields to fail for the missing fields")
+	}
+	if _, err := MappingFor([]string{"id", "id"}, reflect.TypeOf(Invoice{}), StrictColumns()); err == nil {
+		t.Error("Expected StrictColumns to fail for the duplicate column")
+	}
+	if _, err := MappingFor([]string{"id"}, reflect.TypeOf(&Invoice{})); err == nil {
+		t.Error("Expected an error for a non-struct type")
+	}
+}
+
+func TestScanRowsInto(t *testing.T) {
+	type User struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	mock := &mockQueryer{
+		fields: mockFields("id", "name"),
+		rows: []mockRow{
+			{values: []interface{}{1, "Alice"}},
+			{values: []interface{}{2, "Bob"}},
+		},
+	}
+	rows, err := mock.Query(context.Background(), "SELECT id, name FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var users []*User
+	if err := ScanRowsInto(rows, &users, TagName("json")); err != nil {
+		t.Fatalf("ScanRowsInto failed: %v", err)
+	}
+	if len(users) != 2 || users[1].ID != 2 || users[1].Name != "Bob" {
+		t.Errorf("Unexpected users: %+v", users)
+	}
+
+	rows, _ = mock.Query(context.Background(), "SELECT id, name FROM users")
+	var notSlice User
+	if err := ScanRowsInto(rows, &notSlice); err == nil {
+		t.Error("Expected an error for a dest that is not a slice")
+	}
+}
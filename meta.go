@@ -0,0 +1,325 @@
+package dbx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// fieldMeta describes a db-tagged struct field.
+type fieldMeta struct {
+	Index   []int      // index sequence for reflect.Value.FieldByIndex
+	Name    string     // Go field name
+	Tag     string     // tag name as written, e.g. "users.id"
+	Column  string     // column part of the tag, e.g. "id"
+	Options tagOptions // tag options, e.g. "pk"
+	Groups  []int      // positions in structMeta.Groups of enclosing nested structs, outermost first
+
+	Endpoints *endpointFields // for fields tagged lower or upper, the fields taking each end of the range
+}
+
+// endpointFields holds the index sequences of the fields tagged lower and
+// upper for one range column, such as db:"period,lower" and
+// db:"period,upper". Either may be nil. The two are mapped as a single
+// field, whose Index is that of the first declared.
+type endpointFields struct {
+	Lower []int
+	Upper []int
+}
+
+// HasTable reports whether the tag used the "table.column" form.
+func (f *fieldMeta) HasTable() bool {
+	return strings.Contains(f.Tag, ".")
+}
+
+// value returns the field within struct value v. ok is false if the field
+// is promoted through an embedded pointer that is nil.
+func (f *fieldMeta) value(v reflect.Value) (field reflect.Value, ok bool) {
+	field, err := v.FieldByIndexErr(f.Index)
+	return field, err == nil
+}
+
+// Nested reports whether the field belongs to a nested struct rather than
+// to the struct itself, in which case it does not name a column of the
+// struct's table.
+func (f *fieldMeta) Nested() bool {
+	return len(f.Groups) > 0
+}
+
+// settable returns the field within struct value v for assignment,
+// allocating any nil pointers on the way to it.
+func (f *fieldMeta) settable(v reflect.Value) reflect.Value {
+	return fieldByIndexAlloc(v, f.Index)
+}
+
+// fieldByIndexAlloc is like v.FieldByIndex but allocates nil struct
+// pointers along the path instead of panicking.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// encode returns the value to send to the database for the field within
+// struct value v. Fields behind a nil embedded pointer are sent as NULL.
+func (f *fieldMeta) encode(v reflect.Value) (any, error) {
+	if f.Endpoints != nil {
+		return encodeEndpoints(v, f.Endpoints)
+	}
+	field, ok := f.value(v)
+	if !ok {
+		return nil, nil
+	}
+	return encodeValue(field, f.Options)
+}
+
+// nestedGroup describes a struct field whose tag is used as a table prefix
+// for the columns of its own fields, e.g. db:"invoice" mapping the column
+// "invoice.amount" to its Amount field.
+type nestedGroup struct {
+	Index   []int // index sequence of the nested struct field
+	Pointer bool  // whether the field is a pointer to a struct
+}
+
+// collectionMeta describes a slice field tagged with the group option,
+// e.g. db:"line_item,group", which QueryNested fills with the child rows
+// whose columns are prefixed with the field's tag.
+type collectionMeta struct {
+	Index   []int        // index sequence of the slice field
+	Name    string       // Go field name
+	Prefix  string       // column prefix, e.g. "line_item"
+	Elem    reflect.Type // struct type of the slice elements
+	Pointer bool         // whether the elements are pointers to structs
+}
+
+// structMeta holds the parsed db tags of a struct type.
+type structMeta struct {
+	Fields      []fieldMeta      // db-tagged fields in declaration order
+	Groups      []nestedGroup    // nested structs, outer ones before those they contain
+	Collections []collectionMeta // group-tagged slice fields
+	Rest        []int            // index sequence of the rest-tagged field, if any
+	Err         error            // set if two fields of one struct share a tag
+}
+
+// structMetaKey identifies a struct type parsed with a given tag name.
+type structMetaKey struct {
+	t   reflect.Type
+	tag string
+}
+
+// structMetaCache maps structMetaKey to *structMeta for structs parsed
+// without a name mapper. Those parsed with one are cached on the mapper.
+var structMetaCache sync.Map
+
+// getStructMeta returns the parsed metadata for a struct type, reading the
+// struct tag named by opts ("db" unless changed with TagName), computing
+// and caching it on first use. It is safe for concurrent use.
+//
+// Exported fields without a tag name are skipped unless opts has a name
+// mapper, as set by AutoSnakeCase, in which case the mapped field name is
+// used in place of the tag name.
+//
+// Untagged embedded structs, and pointers to them, are flattened so their
+// fields appear as if declared inline. When several fields share a tag the
+// shallowest one wins, following Go's rules for promoted fields; if more
+// than one is at that depth, none of them is mapped. Two fields declared in
+// the same struct with the same tag are an error, recorded in Err.
+//
+// Tagged struct fields are also nested: their fields are collected with the
+// field's tag as a table prefix. The struct field itself is still mapped,
+// so a single json column of the same name keeps working.
+func getStructMeta(t reflect.Type, opts *options) *structMeta {
+	key := structMetaKey{t: t, tag: opts.tagName}
+	cache := &structMetaCache
+	if opts.nameMapper != nil {
+		cache = &opts.nameMapper.cache
+	}
+	if cached, ok := cache.Load(key); ok {
+		return cached.(*structMeta)
+	}
+
+	meta := &structMeta{}
+	fields := mergeEndpoints(meta.collectFields(t, opts, nil, "", nil, map[reflect.Type]bool{t: true}))
+
+	// Unlike fields promoted from different embedded structs, two fields of
+	// the same struct sharing a tag are a mistake, reported when mapping
+	declared := make(map[string]string)
+	for _, f := range fields {
+		key := fmt.Sprint(f.Index[:len(f.Index)-1]) + " " + f.Tag
+		if prev, ok := declared[key]; ok && meta.Err == nil {
+			meta.Err = fmt.Errorf("fields %s and %s of %s both map to column %q", prev, f.Name, t, f.Tag)
+		}
+		declared[key] = f.Name
+	}
+
+	// For each tag, find the shallowest depth and how many fields share it
+	minDepth := make(map[string]int)
+	atMin := make(map[string]int)
+	for _, f := range fields {
+		depth, ok := minDepth[f.Tag]
+		switch {
+		case !ok || len(f.Index) < depth:
+			minDepth[f.Tag], atMin[f.Tag] = len(f.Index), 1
+		case len(f.Index) == depth:
+			atMin[f.Tag]++
+		}
+	}
+
+	for _, f := range fields {
+		if len(f.Index) == minDepth[f.Tag] && atMin[f.Tag] == 1 {
+			meta.Fields = append(meta.Fields, f)
+		}
+	}
+
+	actual, _ := cache.LoadOrStore(key, meta)
+	return actual.(*structMeta)
+}
+
+// mergeEndpoints combines the fields tagged lower and upper for the same
+// column of the same struct into one field with Endpoints set, in place of
+// the first of them. A second lower or upper field for a column is kept
+// apart, to be reported as a duplicate tag.
+func mergeEndpoints(fields []fieldMeta) []fieldMeta {
+	merged := fields[:0:0]
+	pairs := make(map[string]int)
+	for _, f := range fields {
+		lower, upper := f.Options.Contains("lower"), f.Options.Contains("upper")
+		if lower == upper {
+			merged = append(merged, f)
+			continue
+		}
+		key := fmt.Sprint(f.Index[:len(f.Index)-1]) + " " + f.Tag
+		if i, ok := pairs[key]; ok {
+			e := merged[i].Endpoints
+			if lower && e.Lower == nil {
+				e.Lower = f.Index
+				continue
+			}
+			if upper && e.Upper == nil {
+				e.Upper = f.Index
+				continue
+			}
+		} else {
+			pairs[key] = len(merged)
+		}
+		f.Endpoints = &endpointFields{}
+		if lower {
+			f.Endpoints.Lower = f.Index
+		} else {
+			f.Endpoints.Upper = f.Index
+		}
+		merged = append(merged, f)
+	}
+	return merged
+}
+
+// collectFields returns the fields of t tagged as opts describes, descending
+// into untagged embedded structs and tagged nested structs, and records the
+// nested structs in m.Groups. Fields are returned in declaration order.
+// prefix is the index sequence of t within the outermost struct, tagPrefix
+// and groups describe the nested structs enclosing t, and seen guards
+// against cycles.
+func (m *structMeta) collectFields(t reflect.Type, opts *options, prefix []int, tagPrefix string, groups []int, seen map[reflect.Type]bool) []fieldMeta {
+	var fields []fieldMeta
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		dbTag, tagOpts := parseTag(field.Tag.Get(opts.tagName))
+
+		index := make([]int, len(prefix)+1)
+		copy(index, prefix)
+		index[len(prefix)] = i
+
+		if dbTag == "" && field.Anonymous {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				// Pointers to unexported types cannot be allocated
+				if !field.IsExported() {
+					continue
+				}
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !seen[ft] {
+				seen[ft] = true
+				fields = append(fields, m.collectFields(ft, opts, index, tagPrefix, groups, seen)...)
+				delete(seen, ft)
+			}
+			continue
+		}
+
+		// A rest field collects the columns no other field maps
+		if tagOpts.Contains("rest") && len(groups) == 0 {
+			if !reflect.TypeOf(RowMap(nil)).ConvertibleTo(field.Type) || field.Type.Kind() != reflect.Map {
+				if m.Err == nil {
+					m.Err = fmt.Errorf("rest field %s must be a dbx.RowMap, got %s", field.Name, field.Type)
+				}
+			} else if m.Rest == nil {
+				m.Rest = index
+			}
+			continue
+		}
+
+		// Name untagged exported fields with the mapper, if any
+		if dbTag == "" && opts.nameMapper != nil && field.IsExported() {
+			dbTag = opts.nameMapper.fn(field.Name)
+		}
+
+		// Skip fields with no db tag or explicitly ignored
+		if dbTag == "" || dbTag == "-" {
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Slice && tagOpts.Contains("group") {
+			if elem, isPtr, err := structElemType(field.Type); err == nil {
+				m.Collections = append(m.Collections, collectionMeta{
+					Index:   index,
+					Name:    field.Name,
+					Prefix:  tagPrefix + dbTag,
+					Elem:    elem,
+					Pointer: isPtr,
+				})
+				continue
+			}
+		}
+
+		fields = append(fields, fieldMeta{
+			Index:   index,
+			Name:    field.Name,
+			Tag:     tagPrefix + dbTag,
+			Column:  columnFromTag(dbTag),
+			Options: tagOpts,
+			Groups:  groups,
+		})
+
+		if ft, ok := nestedStructType(field.Type, tagOpts); ok && field.IsExported() && !seen[ft] {
+			seen[ft] = true
+			m.Groups = append(m.Groups, nestedGroup{Index: index, Pointer: field.Type.Kind() == reflect.Pointer})
+			inner := append(groups[:len(groups):len(groups)], len(m.Groups)-1)
+			fields = append(fields, m.collectFields(ft, opts, index, tagPrefix+dbTag+".", inner, seen)...)
+			delete(seen, ft)
+		}
+	}
+	return fields
+}
+
+// nestedStructType returns the struct type of a tagged field that should
+// have its fields mapped by table prefix. Structs that are scanned as a
+// single value, such as time.Time, sql.Scanner implementations, and
+// json-tagged fields, are excluded.
+func nestedStructType(t reflect.Type, opts tagOptions) (reflect.Type, bool) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType || opts.Contains("json") ||
+		reflect.PointerTo(t).Implements(scannerType) {
+		return nil, false
+	}
+	return t, true
+}
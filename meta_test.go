@@ -0,0 +1,290 @@
+package dbx
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestGetStructMeta(t *testing.T) {
+	type TestUser struct {
+		ID      int    `db:"users.id,pk"`
+		Name    string `db:"name"`
+		Ignored string `db:"-"`
+		NoTag   string
+	}
+
+	meta := getStructMeta(reflect.TypeOf(TestUser{}), defaults())
+
+	expected := []fieldMeta{
+		{Index: []int{0}, Name: "ID", Tag: "users.id", Column: "id", Options: tagOptions{"pk"}},
+		{Index: []int{1}, Name: "Name", Tag: "name", Column: "name"},
+	}
+	if !reflect.DeepEqual(meta.Fields, expected) {
+		t.Errorf("Expected fields %+v, got %+v", expected, meta.Fields)
+	}
+
+	if again := getStructMeta(reflect.TypeOf(TestUser{}), defaults()); again != meta {
+		t.Error("Expected cached metadata to be reused")
+	}
+}
+
+func TestGetStructMetaConcurrent(t *testing.T) {
+	type TestUser struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	typ := reflect.TypeOf(TestUser{})
+	results := make([]*structMeta, 16)
+
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = getStructMeta(typ, defaults())
+		}(i)
+	}
+	wg.Wait()
+
+	for _, meta := range results {
+		if meta != results[0] {
+			t.Fatal("Expected all goroutines to observe the same cached metadata")
+		}
+	}
+}
+
+func BenchmarkBuildFieldMapping(b *testing.B) {
+	mock := &mockQueryer{}
+	rows, _ := mock.Query(context.Background(), "SELECT * FROM users")
+
+	type TestUser struct {
+		ID    int    `db:"users.id"`
+		Name  string `db:"users.name"`
+		Email string `db:"users.email"`
+	}
+	typ := reflect.TypeOf(TestUser{})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := buildFieldMapping(rows, typ, defaults()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type testAudited struct {
+	CreatedAt string `db:"created_at"`
+	UpdatedAt string `db:"updated_at"`
+}
+
+type testOwned struct {
+	OwnerID int    `db:"owner_id"`
+	Note    string `db:"note"`
+}
+
+func TestGetStructMetaEmbedded(t *testing.T) {
+	type Post struct {
+		ID int `db:"id"`
+		testAudited
+		testOwned
+		Note string `db:"note"` // shadows testOwned.Note
+	}
+
+	meta := getStructMeta(reflect.TypeOf(Post{}), defaults())
+
+	expected := []fieldMeta{
+		{Index: []int{0}, Name: "ID", Tag: "id", Column: "id"},
+		{Index: []int{1, 0}, Name: "CreatedAt", Tag: "created_at", Column: "created_at"},
+		{Index: []int{1, 1}, Name: "UpdatedAt", Tag: "updated_at", Column: "updated_at"},
+		{Index: []int{2, 0}, Name: "OwnerID", Tag: "owner_id", Column: "owner_id"},
+		{Index: []int{3}, Name: "Note", Tag: "note", Column: "note"},
+	}
+	if !reflect.DeepEqual(meta.Fields, expected) {
+		t.Errorf("Expected fields %+v, got %+v", expected, meta.Fields)
+	}
+}
+
+func TestGetStructMetaEmbeddedPointer(t *testing.T) {
+	type Owned struct {
+		OwnerID int `db:"owner_id"`
+	}
+	type Post struct {
+		*Owned
+		*testAudited // unexported, so it can't be allocated and is skipped
+	}
+
+	meta := getStructMeta(reflect.TypeOf(Post{}), defaults())
+
+	expected := []fieldMeta{{Index: []int{0, 0}, Name: "OwnerID", Tag: "owner_id", Column: "owner_id"}}
+	if !reflect.DeepEqual(meta.Fields, expected) {
+		t.Errorf("Expected fields %+v, got %+v", expected, meta.Fields)
+	}
+}
+
+func TestGetStructMetaEmbeddedAmbiguous(t *testing.T) {
+	type A struct {
+		Name string `db:"name"`
+	}
+	type B struct {
+		Name string `db:"name"`
+	}
+	type Row struct {
+		ID int `db:"id"`
+		A
+		B
+	}
+
+	meta := getStructMeta(reflect.TypeOf(Row{}), defaults())
+
+	if len(meta.Fields) != 1 || meta.Fields[0].Tag != "id" {
+		t.Errorf("Expected conflicting fields at the same depth to be dropped, got %+v", meta.Fields)
+	}
+}
+
+func TestQueryStructsEmbedded(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "created_at", "updated_at", "extra"),
+		rows:   []mockRow{{values: []interface{}{1, "2024-01-01", "2024-01-02", "x"}}},
+	}
+
+	type Extra struct {
+		Extra string `db:"extra"`
+	}
+	type Post struct {
+		ID int `db:"id"`
+		testAudited
+		*Extra
+	}
+
+	var posts []Post
+	if err := QueryStructs(ctx, mock, "SELECT * FROM posts", &posts); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	got := posts[0]
+	if got.ID != 1 || got.CreatedAt != "2024-01-01" || got.UpdatedAt != "2024-01-02" {
+		t.Errorf("Unexpected embedded mapping: %+v", got)
+	}
+	if got.Extra == nil || got.Extra.Extra != "x" {
+		t.Errorf("Expected embedded pointer to be allocated, got %+v", got.Extra)
+	}
+}
+
+func TestInsertStructEmbedded(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	type Extra struct {
+		Extra string `db:"extra"`
+	}
+	type Post struct {
+		ID int `db:"id"`
+		testAudited
+		*Extra
+	}
+
+	post := Post{ID: 1, testAudited: testAudited{CreatedAt: "c", UpdatedAt: "u"}}
+	if err := InsertStruct(ctx, mock, "posts", post); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+
+	expectedSQL := `INSERT INTO "posts" ("id", "created_at", "updated_at", "extra") VALUES ($1, $2, $3, $4)`
+	if mock.lastSQL != expectedSQL {
+		t.Errorf("Expected SQL %q, got %q", expectedSQL, mock.lastSQL)
+	}
+	expectedArgs := []any{1, "c", "u", nil}
+	if !reflect.DeepEqual(mock.lastArgs, expectedArgs) {
+		t.Errorf("Expected args %#v, got %#v", expectedArgs, mock.lastArgs)
+	}
+}
+
+func TestTagName(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID    int    `json:"id" db:"user_id"`
+		Email string `json:"email,omitempty"`
+		Skip  string `json:"-"`
+	}
+
+	mock := &mockQueryer{
+		fields: mockFields("id", "email", "user_id"),
+		rows:   []mockRow{{values: []interface{}{1, "a@example.com", 2}}},
+	}
+
+	var users []User
+	if err := QueryStructs(ctx, mock, "SELECT ...", &users, TagName("json")); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if expected := []User{{ID: 1, Email: "a@example.com"}}; !reflect.DeepEqual(users, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, users)
+	}
+
+	// The default tag must not get the json mapping from the cache
+	if err := QueryStructs(ctx, mock, "SELECT ...", &users); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if expected := []User{{ID: 2}}; !reflect.DeepEqual(users, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, users)
+	}
+
+	SetDefaults(TagName("json"))
+	defer SetDefaults()
+
+	mock.execTag = pgconn.NewCommandTag("INSERT 0 1")
+	if err := InsertStruct(ctx, mock, "users", User{ID: 3, Email: "b@example.com"}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("id", "email") VALUES ($1, $2)`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+}
+
+func TestDuplicateFieldTags(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields:  mockFields("id", "email"),
+		rows:    []mockRow{{values: []interface{}{1, "a@example.com"}}},
+		execTag: pgconn.NewCommandTag("INSERT 0 1"),
+	}
+
+	type Copied struct {
+		ID     int    `db:"id"`
+		Email  string `db:"users.email"`
+		Email2 string `db:"users.email"`
+	}
+
+	var rows []Copied
+	err := QueryStructs(ctx, mock, "SELECT id, email FROM users", &rows)
+	if err == nil || !strings.Contains(err.Error(), "Email and Email2") || !strings.Contains(err.Error(), "users.email") {
+		t.Errorf("Expected duplicate tag error naming both fields, got %v", err)
+	}
+	if err := InsertStruct(ctx, mock, "users", Copied{}); err == nil {
+		t.Error("Expected duplicate tag error from InsertStruct")
+	}
+
+	type Overlapping struct {
+		Email   string `db:"users.email"`
+		Contact string `db:"email"`
+	}
+
+	var overlapping []Overlapping
+	err = QueryStructs(ctx, mock, "SELECT id, email FROM users", &overlapping)
+	if err == nil || !strings.Contains(err.Error(), "Email and Contact") || !strings.Contains(err.Error(), `"email"`) {
+		t.Errorf("Expected conflict error naming both fields, got %v", err)
+	}
+
+	// Two columns of the same name are shared out rather than conflicting
+	mock.fields = mockFields("email", "email")
+	mock.rows = []mockRow{{values: []interface{}{"a", "b"}}}
+	if err := QueryStructs(ctx, mock, "SELECT ...", &overlapping); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package dbx
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MetricsCollector receives one observation per statement dbx executes.
+// The op label is the query name set with WithQueryName, or the upper-cased
+// SQL verb ("SELECT", "INSERT", ...) when no name is set, so label
+// cardinality stays bounded. Implementations must be safe for concurrent use.
+type MetricsCollector interface {
+	ObserveQuery(op string, duration time.Duration, err error)
+}
+
+// metricsBox lets collectors of different concrete types share one atomic pointer.
+type metricsBox struct {
+	MetricsCollector
+}
+
+var currentMetrics atomic.Pointer[metricsBox]
+
+// SetMetricsCollector installs the package-level metrics collector.
+// Passing nil disables metrics.
+func SetMetricsCollector(c MetricsCollector) {
+	if c == nil {
+		currentMetrics.Store(nil)
+		return
+	}
+	currentMetrics.Store(&metricsBox{c})
+}
+
+// observeQuery reports a completed statement to the metrics collector, if any.
+func observeQuery(ctx context.Context, sql string, duration time.Duration, err error) {
+	m := currentMetrics.Load()
+	if m == nil {
+		return
+	}
+	op := queryName(ctx)
+	if op == "" {
+		op = sqlVerb(sql)
+	}
+	m.ObserveQuery(op, duration, err)
+}
+
+type queryNameKey struct{}
+
+// WithQueryName returns a context that labels statements executed with it as
+// name in metrics, instead of the SQL verb.
+func WithQueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryNameKey{}, name)
+}
+
+// queryName returns the name stored by WithQueryName, or "".
+func queryName(ctx context.Context) string {
+	name, _ := ctx.Value(queryNameKey{}).(string)
+	return name
+}
+
+// sqlVerb returns the upper-cased leading keyword of sql, e.g. "SELECT".
+// Leading comments and whitespace are skipped.
+func sqlVerb(sql string) string {
+	for i := 0; i < len(sql); {
+		switch {
+		case strings.HasPrefix(sql[i:], "--"), strings.HasPrefix(sql[i:], "/*"):
+			i = skipQuotedOrComment(sql, i)
+		case strings.IndexByte(" \t\r\n(", sql[i]) != -1:
+			i++
+		default:
+			j := i
+			for j < len(sql) && isIdentChar(sql[j]) {
+				j++
+			}
+			return strings.ToUpper(sql[i:j])
+		}
+	}
+	return ""
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// CacheCollector is implemented by MetricsCollectors that also count
+// lookups in a CachedDB, labelled by op as for ObserveQuery.
+type CacheCollector interface {
+	ObserveCache(op string, hit bool)
+}
+
+// observeCache reports a CachedDB lookup to the metrics collector, if it
+// implements CacheCollector.
+func observeCache(ctx context.Context, sql string, hit bool) {
+	m := currentMetrics.Load()
+	if m == nil {
+		return
+	}
+	c, ok := m.MetricsCollector.(CacheCollector)
+	if !ok {
+		return
+	}
+	op := queryName(ctx)
+	if op == "" {
+		op = sqlVerb(sql)
+	}
+	c.ObserveCache(op, hit)
+}
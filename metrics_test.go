@@ -0,0 +1,74 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingCollector struct {
+	mu   sync.Mutex
+	ops  []string
+	errs []error
+}
+
+func (c *recordingCollector) ObserveQuery(op string, duration time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ops = append(c.ops, op)
+	c.errs = append(c.errs, err)
+}
+
+func TestMetricsCollectorObservesStatements(t *testing.T) {
+	collector := &recordingCollector{}
+	SetMetricsCollector(collector)
+	t.Cleanup(func() { SetMetricsCollector(nil) })
+
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	if _, err := QueryMaps(ctx, mock, "SELECT * FROM users"); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if err := InsertStruct(ctx, mock, "users", insertUser{Name: "John"}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if _, err := QueryJSON(WithQueryName(ctx, "ListUsers"), mock, "SELECT * FROM users"); err != nil {
+		t.Fatalf("QueryJSON failed: %v", err)
+	}
+
+	mock.err = errors.New("boom")
+	_, _ = QueryMaps(ctx, mock, "SELECT 1")
+
+	expectedOps := []string{"SELECT", "INSERT", "ListUsers", "SELECT"}
+	if len(collector.ops) != len(expectedOps) {
+		t.Fatalf("Expected ops %v, got %v", expectedOps, collector.ops)
+	}
+	for i := range expectedOps {
+		if collector.ops[i] != expectedOps[i] {
+			t.Errorf("Observation %d: expected op %q, got %q", i, expectedOps[i], collector.ops[i])
+		}
+	}
+	if collector.errs[3] == nil {
+		t.Error("Expected the failed query to be observed with its error")
+	}
+}
+
+func TestSQLVerb(t *testing.T) {
+	tests := map[string]string{
+		"SELECT 1":                             "SELECT",
+		"  insert into users values ($1)":      "INSERT",
+		"-- fetch users\nselect * from users":  "SELECT",
+		"/* app */ UPDATE users SET x = 1":     "UPDATE",
+		"(SELECT 1) UNION (SELECT 2)":          "SELECT",
+		"WITH x AS (SELECT 1) SELECT * FROM x": "WITH",
+		"":                                     "",
+	}
+	for sql, want := range tests {
+		if got := sqlVerb(sql); got != want {
+			t.Errorf("sqlVerb(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
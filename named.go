@@ -0,0 +1,183 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QueryMapsNamed is like QueryMaps but takes SQL with :name placeholders,
+// bound from params, which must be a map with string keys or a struct (or
+// pointer to struct) whose db tags name the parameters. See bindNamed for
+// the placeholder rules.
+func QueryMapsNamed(ctx context.Context, db DB, sql string, params any, opts ...Option) ([]RowMap, error) {
+	sql, args, err := bindNamed(sql, params)
+	if err != nil {
+		return nil, err
+	}
+	return QueryMaps(ctx, db, sql, withOptions(args, opts)...)
+}
+
+// QueryStructsNamed is like QueryStructs but takes SQL with :name
+// placeholders bound from params, as for QueryMapsNamed.
+func QueryStructsNamed(ctx context.Context, db DB, sql string, dest any, params any, opts ...Option) error {
+	sql, args, err := bindNamed(sql, params)
+	if err != nil {
+		return err
+	}
+	return QueryStructs(ctx, db, sql, dest, withOptions(args, opts)...)
+}
+
+// ExecNamed executes a statement with :name placeholders bound from params,
+// as for QueryMapsNamed, and returns the number of rows affected.
+func ExecNamed(ctx context.Context, db DB, sql string, params any) (int64, error) {
+	sql, args, err := bindNamed(sql, params)
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := exec(ctx, db, "ExecNamed", sql, args, nil)
+	if err != nil {
+		return 0, fmt.Errorf("exec failed: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// withOptions appends opts to args so they can be split out again.
+func withOptions(args []any, opts []Option) []any {
+	for _, opt := range opts {
+		args = append(args, opt)
+	}
+	return args
+}
+
+// bindNamed rewrites :name placeholders in sql to $n and returns the
+// matching arguments from params. A name used more than once is bound to a
+// single argument. Type casts (::type), string literals, quoted identifiers,
+// and comments are left untouched.
+//
+// Every placeholder must have a value. Map params must also have no keys
+// that are not used by the query; struct fields are allowed to go unused,
+// since structs usually carry more fields than one query needs.
+func bindNamed(sql string, params any) (string, []any, error) {
+	values, strict, err := namedValues(params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var b strings.Builder
+	b.Grow(len(sql))
+
+	var args []any
+	var missing []string
+	positions := make(map[string]int)
+
+	for i := 0; i < len(sql); {
+		if n := skipQuotedOrComment(sql, i); n > i {
+			b.WriteString(sql[i:n])
+			i = n
+			continue
+		}
+
+		if sql[i] == ':' {
+			// A cast such as value::text
+			if i+1 < len(sql) && sql[i+1] == ':' {
+				b.WriteString("::")
+				i += 2
+				continue
+			}
+
+			j := i + 1
+			if j < len(sql) && isIdentChar(sql[j]) && (sql[j] < '0' || sql[j] > '9') {
+				for j < len(sql) && isIdentChar(sql[j]) {
+					j++
+				}
+				name := sql[i+1 : j]
+
+				pos, ok := positions[name]
+				if !ok {
+					value, found := values[name]
+					if !found {
+						missing = append(missing, name)
+					}
+					args = append(args, value)
+					pos = len(args)
+					positions[name] = pos
+				}
+
+				b.WriteByte('$')
+				b.WriteString(strconv.Itoa(pos))
+				i = j
+				continue
+			}
+		}
+
+		b.WriteByte(sql[i])
+		i++
+	}
+
+	if len(missing) > 0 {
+		return "", nil, fmt.Errorf("missing named parameters: %s", strings.Join(missing, ", "))
+	}
+
+	if strict {
+		var unused []string
+		for name := range values {
+			if _, ok := positions[name]; !ok {
+				unused = append(unused, name)
+			}
+		}
+		if len(unused) > 0 {
+			sort.Strings(unused)
+			return "", nil, fmt.Errorf("unused named parameters: %s", strings.Join(unused, ", "))
+		}
+	}
+
+	return b.String(), args, nil
+}
+
+// namedValues returns the values of params by name. strict reports whether
+// every value must be used, which is the case for maps.
+func namedValues(params any) (values map[string]any, strict bool, err error) {
+	if params == nil {
+		return nil, true, nil
+	}
+	if m, ok := params.(map[string]any); ok {
+		return m, true, nil
+	}
+
+	v := reflect.ValueOf(params)
+	if v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String {
+		values = make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			values[iter.Key().String()] = iter.Value().Interface()
+		}
+		return values, true, nil
+	}
+
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false, fmt.Errorf("named parameters must be a map with string keys or a struct, got %T", params)
+	}
+
+	meta := getStructMeta(v.Type(), defaults())
+	values = make(map[string]any, len(meta.Fields))
+	for _, f := range meta.Fields {
+		if _, exists := values[f.Column]; exists || f.Nested() {
+			continue
+		}
+		value, err := f.encode(v)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to encode field %s: %w", f.Name, err)
+		}
+		values[f.Column] = value
+	}
+	return values, false, nil
+}
@@ -0,0 +1,147 @@
+package dbx
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestBindNamed(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		params   any
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "simple",
+			sql:      "SELECT * FROM users WHERE id = :id AND active = :active",
+			params:   map[string]any{"id": 1, "active": true},
+			wantSQL:  "SELECT * FROM users WHERE id = $1 AND active = $2",
+			wantArgs: []any{1, true},
+		},
+		{
+			name:     "repeated name",
+			sql:      "SELECT * FROM t WHERE a = :v OR b = :v",
+			params:   map[string]any{"v": "x"},
+			wantSQL:  "SELECT * FROM t WHERE a = $1 OR b = $1",
+			wantArgs: []any{"x"},
+		},
+		{
+			name:     "casts, literals, and comments",
+			sql:      "SELECT :id::int, ':skip', \":skip\" -- :skip\nFROM t /* :skip */",
+			params:   map[string]any{"id": "7"},
+			wantSQL:  "SELECT $1::int, ':skip', \":skip\" -- :skip\nFROM t /* :skip */",
+			wantArgs: []any{"7"},
+		},
+		{
+			name:     "array slice",
+			sql:      "SELECT arr[1:2] FROM t WHERE id = :id",
+			params:   map[string]string{"id": "a"},
+			wantSQL:  "SELECT arr[1:2] FROM t WHERE id = $1",
+			wantArgs: []any{"a"},
+		},
+		{
+			name: "struct",
+			sql:  "UPDATE users SET email = :email WHERE id = :id",
+			params: &struct {
+				ID    int    `db:"users.id"`
+				Email string `db:"email"`
+				Name  string `db:"name"`
+			}{ID: 3, Email: "a@example.com"},
+			wantSQL:  "UPDATE users SET email = $1 WHERE id = $2",
+			wantArgs: []any{"a@example.com", 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := bindNamed(tt.sql, tt.params)
+			if err != nil {
+				t.Fatalf("bindNamed failed: %v", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("Expected SQL %q, got %q", tt.wantSQL, sql)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Expected args %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}
+
+func TestBindNamedErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		params  any
+		wantErr string
+	}{
+		{"missing", "SELECT :a, :b, :c", map[string]any{"b": 1}, "missing named parameters: a, c"},
+		{"unused", "SELECT :a", map[string]any{"a": 1, "z": 2, "y": 3}, "unused named parameters: y, z"},
+		{"struct missing", "SELECT :nope", struct {
+			ID int `db:"id"`
+		}{}, "missing named parameters: nope"},
+		{"bad params", "SELECT :a", 42, "named parameters must be a map with string keys or a struct, got int"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := bindNamed(tt.sql, tt.params)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestQueryStructsNamed(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, "John", "john@example.com"}},
+		},
+	}
+
+	type TestUser struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	var users []TestUser
+	err := QueryStructsNamed(ctx, mock, "SELECT * FROM users WHERE name = :name", &users, map[string]any{"name": "John"}, Lenient())
+	if err != nil {
+		t.Fatalf("QueryStructsNamed failed: %v", err)
+	}
+
+	if mock.lastSQL != "SELECT * FROM users WHERE name = $1" {
+		t.Errorf("Unexpected SQL: %s", mock.lastSQL)
+	}
+	if !reflect.DeepEqual(mock.lastArgs, []any{"John"}) {
+		t.Errorf("Expected options to be removed from args, got %v", mock.lastArgs)
+	}
+	if len(users) != 1 || users[0].Name != "John" {
+		t.Errorf("Unexpected users: %+v", users)
+	}
+}
+
+func TestExecNamed(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("DELETE 2")}
+
+	n, err := ExecNamed(ctx, mock, "DELETE FROM users WHERE active = :active", map[string]any{"active": false})
+	if err != nil {
+		t.Fatalf("ExecNamed failed: %v", err)
+	}
+
+	if n != 2 {
+		t.Errorf("Expected 2 rows affected, got %d", n)
+	}
+	if mock.lastSQL != "DELETE FROM users WHERE active = $1" {
+		t.Errorf("Unexpected SQL: %s", mock.lastSQL)
+	}
+}
@@ -0,0 +1,27 @@
+package dbx
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SnakeCase converts a Go field name such as CreatedAt into snake_case
+// (created_at), for use with NameMapper. Runs of capitals are treated as one
+// word, so ID becomes id, UserID becomes user_id, and URLPath becomes
+// url_path.
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	b.Grow(len(name) + 4)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
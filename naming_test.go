@@ -0,0 +1,101 @@
+package dbx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"Name":          "name",
+		"CreatedAt":     "created_at",
+		"ID":            "id",
+		"UserID":        "user_id",
+		"URLPath":       "url_path",
+		"HTTPServerURL": "http_server_url",
+		"Address2":      "address2",
+		"V2Name":        "v2_name",
+		"already_snake": "already_snake",
+	}
+	for in, want := range tests {
+		if got := SnakeCase(in); got != want {
+			t.Errorf("SnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAutoSnakeCase(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		UserID    int
+		CreatedAt string
+		Email     string `db:"email_address"`
+		Skipped   string `db:"-"`
+		internal  string
+	}
+
+	mock := &mockQueryer{
+		fields: mockFields("user_id", "created_at", "email_address"),
+		rows:   []mockRow{{values: []interface{}{1, "today", "a@example.com"}}},
+	}
+
+	var users []User
+	if err := QueryStructs(ctx, mock, "SELECT ...", &users, AutoSnakeCase()); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if expected := []User{{UserID: 1, CreatedAt: "today", Email: "a@example.com"}}; !reflect.DeepEqual(users, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, users)
+	}
+
+	// Without the option untagged fields stay unmapped
+	if err := QueryStructs(ctx, mock, "SELECT ...", &users); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if expected := []User{{Email: "a@example.com"}}; !reflect.DeepEqual(users, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, users)
+	}
+
+	SetDefaults(AutoSnakeCase())
+	defer SetDefaults()
+
+	mock.execTag = pgconn.NewCommandTag("INSERT 0 1")
+	if err := InsertStruct(ctx, mock, "users", User{UserID: 2, CreatedAt: "now"}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if want := `INSERT INTO "users" ("user_id", "created_at", "email_address") VALUES ($1, $2, $3)`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+}
+
+func TestNameMapperClosures(t *testing.T) {
+	type Row struct {
+		Name string
+	}
+	prefixed := func(prefix string) Option {
+		return NameMapper(func(name string) string { return prefix + SnakeCase(name) })
+	}
+	mock := &mockQueryer{
+		fields: mockFields("a_name", "b_name"),
+		rows:   []mockRow{{values: []interface{}{"from a", "from b"}}},
+	}
+
+	for prefix, want := range map[string]string{"a_": "from a", "b_": "from b"} {
+		var rows []Row
+		if err := QueryStructs(context.Background(), mock, "SELECT * FROM t", &rows, prefixed(prefix)); err != nil {
+			t.Fatalf("QueryStructs failed: %v", err)
+		}
+		if rows[0].Name != want {
+			t.Errorf("Expected the %s mapper to give %q, got %q", prefix, want, rows[0].Name)
+		}
+
+		m := NewMapper[Row](prefixed(prefix))
+		got, err := m.QueryAll(context.Background(), mock, "SELECT * FROM t")
+		if err != nil || got[0].Name != want {
+			t.Errorf("Expected the Mapper with the %s mapper to give %q, got %+v, %v", prefix, want, got, err)
+		}
+	}
+}
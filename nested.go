@@ -0,0 +1,188 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// QueryNested executes a query whose rows join parents to their children
+// and maps them into dest, a pointer to a slice of parent structs. Slice
+// fields tagged with the group option collect the child rows:
+//
+//	type Invoice struct {
+//		ID    int        `db:"id,pk"`
+//		Items []LineItem `db:"line_item,group"`
+//	}
+//
+// Parent fields are matched as in QueryStructs, and child fields by the
+// columns prefixed with the group tag, e.g. "line_item.sku". Rows with the
+// same values in the parent's pk-tagged fields are merged into one parent,
+// in order of first appearance. A child whose columns are all NULL, as for
+// a LEFT JOIN without a match, is not appended, and children with pk-tagged
+// fields are appended once per parent even if repeated. Only one level of
+// grouping is supported. As with QueryStructs, dest is truncated first
+// unless the Append option is passed; appended parents are not merged with
+// those already in the slice.
+func QueryNested(ctx context.Context, db DB, sql string, dest any, args ...any) error {
+	opts, args := splitArgs(args)
+	if dest == nil {
+		return fmt.Errorf("dest cannot be nil; must be a pointer to a slice of structs")
+	}
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Pointer {
+		return fmt.Errorf("dest must be a pointer to a slice of structs, got %T", dest)
+	}
+	if destValue.IsNil() {
+		return fmt.Errorf("dest pointer is nil; must be a pointer to a slice of structs")
+	}
+
+	sliceValue := destValue.Elem()
+	if sliceValue.Kind() != reflect.Slice {
+		return fmt.Errorf("dest must be a pointer to a slice of structs, got pointer to %s", sliceValue.Kind())
+	}
+
+	elemType, isPtr, err := structElemType(sliceValue.Type())
+	if err != nil {
+		return err
+	}
+
+	meta := getStructMeta(elemType, opts)
+	parentKeys := primaryKeyFields(meta)
+	if len(parentKeys) == 0 {
+		return fmt.Errorf("no primary key fields found on %s; tag them with the pk option, e.g. db:\"id,pk\"", elemType)
+	}
+
+	rows, err := query(ctx, db, "QueryNested", sql, args, opts)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	scanner, err := newStructScanner(rows, elemType, opts)
+	if err != nil {
+		return err
+	}
+
+	childScanners := make([]*structScanner, len(meta.Collections))
+	childKeys := make([][]*fieldMeta, len(meta.Collections))
+	for i, c := range meta.Collections {
+		if childScanners[i], err = newPrefixedScanner(rows, c.Elem, c.Prefix, opts); err != nil {
+			return err
+		}
+		childKeys[i] = primaryKeyFields(getStructMeta(c.Elem, opts))
+	}
+
+	// Position in the slice of each parent by key, and the children
+	// already appended to each parent
+	type childKey struct {
+		parent, collection int
+		key                any
+	}
+	parents := make(map[any]int)
+	seenChildren := make(map[childKey]bool)
+
+	if !opts.appendRows {
+		sliceValue.SetLen(0)
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return fmt.Errorf("failed to get row values: %w", err)
+		}
+
+		elem := reflect.New(elemType)
+		if err := scanner.scan(values, elem.Elem()); err != nil {
+			return err
+		}
+
+		key, err := rowKey(elem.Elem(), parentKeys)
+		if err != nil {
+			return err
+		}
+		pos, ok := parents[key]
+		if !ok {
+			pos = sliceValue.Len()
+			parents[key] = pos
+			if isPtr {
+				sliceValue.Set(reflect.Append(sliceValue, elem))
+			} else {
+				sliceValue.Set(reflect.Append(sliceValue, elem.Elem()))
+			}
+		}
+		parent := reflect.Indirect(sliceValue.Index(pos))
+
+		for i, c := range meta.Collections {
+			if childScanners[i].allNull(values) {
+				continue
+			}
+
+			child := reflect.New(c.Elem)
+			if err := childScanners[i].scan(values, child.Elem()); err != nil {
+				return err
+			}
+
+			if len(childKeys[i]) > 0 {
+				key, err := rowKey(child.Elem(), childKeys[i])
+				if err != nil {
+					return err
+				}
+				k := childKey{parent: pos, collection: i, key: key}
+				if seenChildren[k] {
+					continue
+				}
+				seenChildren[k] = true
+			}
+
+			field := fieldByIndexAlloc(parent, c.Index)
+			if c.Pointer {
+				field.Set(reflect.Append(field, child))
+			} else {
+				field.Set(reflect.Append(field, child.Elem()))
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return nil
+}
+
+// primaryKeyFields returns the pk-tagged fields of a struct that name its
+// own columns.
+func primaryKeyFields(meta *structMeta) []*fieldMeta {
+	var keys []*fieldMeta
+	for i := range meta.Fields {
+		if f := &meta.Fields[i]; f.Options.Contains("pk") && !f.Nested() {
+			keys = append(keys, f)
+		}
+	}
+	return keys
+}
+
+// rowKey returns a comparable value identifying elem by the given key
+// fields, for use as a map key. Pointer fields are compared by the values
+// they point to.
+func rowKey(elem reflect.Value, keys []*fieldMeta) (any, error) {
+	key := reflect.New(reflect.ArrayOf(len(keys), reflect.TypeOf((*any)(nil)).Elem())).Elem()
+	for i, f := range keys {
+		field, ok := f.value(elem)
+		if !ok {
+			continue
+		}
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+		if !field.Type().Comparable() {
+			return nil, fmt.Errorf("primary key field %s of type %s cannot be compared", f.Name, field.Type())
+		}
+		key.Index(i).Set(field)
+	}
+	return key.Interface(), nil
+}
@@ -0,0 +1,100 @@
+package dbx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type testLineItem struct {
+	ID  int    `db:"id,pk"`
+	SKU string `db:"sku"`
+}
+
+type testNestedInvoice struct {
+	ID    int            `db:"id,pk"`
+	Total float64        `db:"total"`
+	Items []testLineItem `db:"line_item,group"`
+}
+
+func TestQueryNested(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "total", "line_item.id", "line_item.sku"),
+		rows: []mockRow{
+			{values: []interface{}{2, 5.0, 20, "b"}},
+			{values: []interface{}{1, 9.5, 10, "a"}},
+			{values: []interface{}{2, 5.0, 21, "c"}},
+			{values: []interface{}{2, 5.0, 20, "b"}}, // repeated child
+			{values: []interface{}{3, 0.0, nil, nil}},
+		},
+	}
+
+	var invoices []testNestedInvoice
+	if err := QueryNested(ctx, mock, "SELECT ... FROM invoice LEFT JOIN line_item ON ...", &invoices); err != nil {
+		t.Fatalf("QueryNested failed: %v", err)
+	}
+
+	expected := []testNestedInvoice{
+		{ID: 2, Total: 5.0, Items: []testLineItem{{ID: 20, SKU: "b"}, {ID: 21, SKU: "c"}}},
+		{ID: 1, Total: 9.5, Items: []testLineItem{{ID: 10, SKU: "a"}}},
+		{ID: 3},
+	}
+	if !reflect.DeepEqual(invoices, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, invoices)
+	}
+}
+
+func TestQueryNestedPointers(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "line_item.id", "line_item.sku"),
+		rows: []mockRow{
+			{values: []interface{}{1, 10, "a"}},
+			{values: []interface{}{1, 11, "b"}},
+		},
+	}
+
+	type Invoice struct {
+		ID    int             `db:"id,pk"`
+		Items []*testLineItem `db:"line_item,group"`
+	}
+
+	var invoices []*Invoice
+	if err := QueryNested(ctx, mock, "SELECT ...", &invoices); err != nil {
+		t.Fatalf("QueryNested failed: %v", err)
+	}
+
+	if len(invoices) != 1 || len(invoices[0].Items) != 2 || invoices[0].Items[1].SKU != "b" {
+		t.Errorf("Unexpected result: %+v", invoices)
+	}
+}
+
+func TestQueryNestedRequiresPrimaryKey(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	type Invoice struct {
+		ID    int            `db:"id"`
+		Items []testLineItem `db:"line_item,group"`
+	}
+
+	var invoices []Invoice
+	if err := QueryNested(ctx, mock, "SELECT 1", &invoices); err == nil {
+		t.Error("Expected error for parent without pk-tagged fields")
+	}
+}
+
+func TestGetStructMetaCollections(t *testing.T) {
+	meta := getStructMeta(reflect.TypeOf(testNestedInvoice{}), defaults())
+
+	expected := []collectionMeta{{Index: []int{2}, Name: "Items", Prefix: "line_item", Elem: reflect.TypeOf(testLineItem{})}}
+	if !reflect.DeepEqual(meta.Collections, expected) {
+		t.Errorf("Expected collections %+v, got %+v", expected, meta.Collections)
+	}
+	for _, f := range meta.Fields {
+		if f.Name == "Items" {
+			t.Error("Expected group field not to be mapped as a column")
+		}
+	}
+}
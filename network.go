@@ -0,0 +1,119 @@
+package dbx
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"reflect"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	addrType   = reflect.TypeOf(netip.Addr{})
+	prefixType = reflect.TypeOf(netip.Prefix{})
+)
+
+// inetHost returns the value of an inet column of type oid that holds a
+// single host, which pgx returns as a netip.Prefix with a full-length mask,
+// as its netip.Addr, so that it prints as Postgres prints it: 10.0.0.1
+// rather than 10.0.0.1/32. The elements of inet arrays are converted alike,
+// in a new slice. cidr values, and inet values with a shorter mask, are
+// returned unchanged.
+func inetHost(v any, oid uint32) any {
+	switch oid {
+	case pgtype.InetOID:
+		if p, ok := v.(netip.Prefix); ok && p.IsValid() && p.Bits() == p.Addr().BitLen() {
+			return p.Addr()
+		}
+	case pgtype.InetArrayOID:
+		if elems, ok := v.([]any); ok {
+			hosts := make([]any, len(elems))
+			for i, e := range elems {
+				hosts[i] = inetHost(e, pgtype.InetOID)
+			}
+			return hosts
+		}
+	}
+	return v
+}
+
+// assignNetwork sets a netip.Addr, netip.Prefix, or string field from an
+// inet or cidr value, which pgx returns as a netip.Prefix, or a macaddr,
+// and netip fields from their text form. handled is false if value and
+// field aren't a network combination, leaving it to assignValue.
+//
+// An address with a netmask, such as inet '10.0.0.1/24', only maps into a
+// netip.Prefix or a string, since a netip.Addr would drop the mask. String
+// fields take the form Postgres prints, which the scanner arranges through
+// inetHost: a single inet host as 10.0.0.1, and a cidr as 10.0.0.1/32.
+func assignNetwork(field reflect.Value, value any) (handled bool, err error) {
+	switch v := value.(type) {
+	case netip.Prefix:
+		switch {
+		case field.Type() == prefixType:
+			field.Set(reflect.ValueOf(v))
+		case field.Type() == addrType:
+			if v.Bits() != v.Addr().BitLen() {
+				return true, fmt.Errorf("%s has a netmask, which netip.Addr cannot hold; use netip.Prefix", v)
+			}
+			field.Set(reflect.ValueOf(v.Addr()))
+		case field.Kind() == reflect.String:
+			field.SetString(v.String())
+		default:
+			return false, nil
+		}
+		return true, nil
+
+	case netip.Addr:
+		switch {
+		case field.Type() == addrType:
+			field.Set(reflect.ValueOf(v))
+		case field.Type() == prefixType:
+			field.Set(reflect.ValueOf(netip.PrefixFrom(v, v.BitLen())))
+		case field.Kind() == reflect.String:
+			field.SetString(v.String())
+		default:
+			return false, nil
+		}
+		return true, nil
+
+	case net.HardwareAddr:
+		if field.Kind() != reflect.String {
+			return false, nil
+		}
+		field.SetString(v.String())
+		return true, nil
+
+	case string:
+		switch field.Type() {
+		case addrType:
+			p, err := parsePrefix(v)
+			if err != nil {
+				return true, err
+			}
+			return assignNetwork(field, p)
+		case prefixType:
+			p, err := parsePrefix(v)
+			if err != nil {
+				return true, err
+			}
+			field.Set(reflect.ValueOf(p))
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// parsePrefix parses an address with or without a netmask, as inet text
+// may be, giving a bare address a prefix covering only itself.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("cannot parse %q as a network address", s)
+	}
+	return p, nil
+}
@@ -0,0 +1,169 @@
+package dbx
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestAssignValueNetwork(t *testing.T) {
+	host := netip.MustParsePrefix("10.0.0.1/32")
+	subnet := netip.MustParsePrefix("10.0.0.0/24")
+	addr := netip.MustParseAddr("2001:db8::1")
+
+	var gotAddr netip.Addr
+	for _, value := range []any{host, netip.MustParseAddr("10.0.0.1"), "10.0.0.1", "10.0.0.1/32"} {
+		gotAddr = netip.Addr{}
+		if err := assignValue(reflect.ValueOf(&gotAddr).Elem(), value, defaults()); err != nil || gotAddr != host.Addr() {
+			t.Errorf("assignValue(%#v) into netip.Addr = %v, %v", value, gotAddr, err)
+		}
+	}
+	if err := assignValue(reflect.ValueOf(&gotAddr).Elem(), subnet, defaults()); err == nil || !strings.Contains(err.Error(), "netmask") {
+		t.Errorf("Expected an error for a subnet into netip.Addr, got %v", err)
+	}
+
+	var gotPrefix netip.Prefix
+	for value, want := range map[any]netip.Prefix{
+		subnet:         subnet,
+		addr:           netip.MustParsePrefix("2001:db8::1/128"),
+		"10.0.0.0/24":  subnet,
+		"2001:db8::1":  netip.MustParsePrefix("2001:db8::1/128"),
+		"10.0.0.1/32":  host,
+		netip.Prefix{}: {},
+	} {
+		gotPrefix = netip.Prefix{}
+		if err := assignValue(reflect.ValueOf(&gotPrefix).Elem(), value, defaults()); err != nil || gotPrefix != want {
+			t.Errorf("assignValue(%#v) into netip.Prefix = %v, %v; want %v", value, gotPrefix, err, want)
+		}
+	}
+	if err := assignValue(reflect.ValueOf(&gotPrefix).Elem(), "not an address", defaults()); err == nil {
+		t.Error("Expected an error for unparseable text")
+	}
+
+	mac, _ := net.ParseMAC("08:00:2b:01:02:03")
+	var gotString string
+	for value, want := range map[any]string{
+		subnet: "10.0.0.0/24",
+		host:   "10.0.0.1/32",
+		addr:   "2001:db8::1",
+	} {
+		if err := assignValue(reflect.ValueOf(&gotString).Elem(), value, defaults()); err != nil || gotString != want {
+			t.Errorf("assignValue(%#v) into string = %q, %v; want %q", value, gotString, err, want)
+		}
+	}
+	if err := assignValue(reflect.ValueOf(&gotString).Elem(), mac, defaults()); err != nil || gotString != "08:00:2b:01:02:03" {
+		t.Errorf("Expected the MAC address as text, got %q, %v", gotString, err)
+	}
+}
+
+func TestQueryStructsNetwork(t *testing.T) {
+	type Customer struct {
+		ID        int64          `db:"id"`
+		LastIP    *netip.Addr    `db:"last_ip"`
+		Network   netip.Prefix   `db:"network"`
+		Allowlist []netip.Prefix `db:"allowlist"`
+		Allowed   []string       `db:"allowed"`
+	}
+	allowlist := []any{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.1.0/24")}
+	mock := &mockQueryer{
+		fields: mockFields("id", "last_ip", "network", "allowlist", "allowed"),
+		rows: []mockRow{
+			{values: []interface{}{int64(1), netip.MustParsePrefix("203.0.113.7/32"), netip.MustParsePrefix("203.0.113.0/24"), allowlist, allowlist}},
+			{values: []interface{}{int64(2), nil, nil, nil, nil}},
+		},
+	}
+
+	var customers []Customer
+	if err := QueryStructs(context.Background(), mock, "SELECT * FROM customers", &customers); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	got := customers[0]
+	if got.LastIP == nil || got.LastIP.String() != "203.0.113.7" || got.Network.String() != "203.0.113.0/24" {
+		t.Errorf("Unexpected addresses: %+v", got)
+	}
+	if len(got.Allowlist) != 2 || got.Allowlist[1].String() != "192.168.1.0/24" {
+		t.Errorf("Unexpected allowlist: %v", got.Allowlist)
+	}
+	if want := []string{"10.0.0.0/8", "192.168.1.0/24"}; !reflect.DeepEqual(got.Allowed, want) {
+		t.Errorf("Expected allowed %v, got %v", want, got.Allowed)
+	}
+	if customers[1].LastIP != nil || customers[1].Network.IsValid() || customers[1].Allowlist != nil {
+		t.Errorf("Expected NULLs to give zero values, got %+v", customers[1])
+	}
+}
+
+func TestQueryJSONNetwork(t *testing.T) {
+	mac, _ := net.ParseMAC("08:00:2b:01:02:03")
+	mock := &mockQueryer{
+		fields: mockFields("ip", "block", "allowlist", "mac"),
+		rows: []mockRow{{values: []interface{}{
+			netip.MustParsePrefix("10.0.0.1/32"),
+			netip.MustParsePrefix("10.0.0.1/32"),
+			[]any{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("10.0.0.2/32"), nil},
+			mac,
+		}}},
+	}
+	mock.fields[0].DataTypeOID = pgtype.InetOID
+	mock.fields[1].DataTypeOID = pgtype.CIDROID
+	mock.fields[2].DataTypeOID = pgtype.InetArrayOID
+
+	data, err := QueryJSON(context.Background(), mock, "SELECT ip, block, allowlist, mac FROM devices")
+	if err != nil {
+		t.Fatalf("QueryJSON failed: %v", err)
+	}
+	if want := `[{"ip":"10.0.0.1","block":"10.0.0.1/32","allowlist":["10.0.0.0/8","10.0.0.2",null],"mac":"08:00:2b:01:02:03"}]`; string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+}
+
+func TestQueryStructsInetString(t *testing.T) {
+	type Device struct {
+		IP     string       `db:"ip"`
+		Host   netip.Prefix `db:"host"`
+		Subnet string       `db:"subnet"`
+		Block  string       `db:"block"`
+	}
+	host := netip.MustParsePrefix("10.0.0.1/32")
+	mock := &mockQueryer{
+		fields: mockFields("ip", "host", "subnet", "block"),
+		rows:   []mockRow{{values: []interface{}{host, host, netip.MustParsePrefix("10.0.0.1/24"), host}}},
+	}
+	for i, oid := range []uint32{pgtype.InetOID, pgtype.InetOID, pgtype.InetOID, pgtype.CIDROID} {
+		mock.fields[i].DataTypeOID = oid
+	}
+
+	var devices []Device
+	if err := QueryStructs(context.Background(), mock, "SELECT * FROM devices", &devices); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	want := Device{IP: "10.0.0.1", Host: host, Subnet: "10.0.0.1/24", Block: "10.0.0.1/32"}
+	if len(devices) != 1 || devices[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, devices)
+	}
+}
+
+func TestInsertStructNetwork(t *testing.T) {
+	mock := &mockQueryer{}
+	type Device struct {
+		IP      netip.Addr     `db:"ip"`
+		Network netip.Prefix   `db:"network"`
+		Allowed []netip.Prefix `db:"allowed"`
+	}
+	device := Device{
+		IP:      netip.MustParseAddr("10.0.0.1"),
+		Network: netip.MustParsePrefix("10.0.0.0/24"),
+		Allowed: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	}
+
+	if err := InsertStruct(context.Background(), mock, "devices", device); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if want := []any{device.IP, device.Network, device.Allowed}; !reflect.DeepEqual(mock.lastArgs, want) {
+		t.Errorf("Expected the netip values passed to pgx as they are, got %#v", mock.lastArgs)
+	}
+}
@@ -0,0 +1,85 @@
+package dbx
+
+import (
+	"math"
+	"net"
+	"net/netip"
+	"strconv"
+)
+
+// NormalizeTypes makes QueryMaps, the other helpers returning RowMaps, and
+// the JSON helpers reduce the Go types pgx produces to a small, predictable
+// set, so that code asserting on row values doesn't depend on the exact
+// column types:
+//
+//	int, int8, int16, int32, uint8, uint16, uint32   int64
+//	uint, uint64 that fit                             int64
+//	float32                                           float64, by its shortest decimal form
+//	numeric                                           float64, or string with ExactNumerics
+//	uuid                                              string, as without the option
+//	inet, cidr (netip.Addr, netip.Prefix, net.IP,
+//	*net.IPNet), macaddr (net.HardwareAddr)          string, in Postgres text form
+//
+// A single inet host prints without its mask, as 10.0.0.1, while cidr
+// values keep it, as 10.0.0.1/32. Elements of arrays are normalized too.
+// The RowMap getters and RowValue accept both the raw and the normalized
+// forms.
+//
+//	dbx.SetDefaults(dbx.NormalizeTypes())
+func NormalizeTypes() Option {
+	return func(o *options) {
+		o.normalize = true
+	}
+}
+
+// normalizeValue converts v as described for NormalizeTypes, returning
+// other values unchanged.
+func normalizeValue(v any) any {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint:
+		if uint64(v) <= math.MaxInt64 {
+			return int64(v)
+		}
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v)
+		}
+	case float32:
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+		return f
+	case netip.Addr:
+		return v.String()
+	case netip.Prefix:
+		return v.String()
+	case net.IP:
+		if v == nil {
+			return nil
+		}
+		return v.String()
+	case *net.IPNet:
+		if v == nil {
+			return nil
+		}
+		return v.String()
+	case net.HardwareAddr:
+		if v == nil {
+			return nil
+		}
+		return v.String()
+	}
+	return v
+}
@@ -0,0 +1,115 @@
+package dbx
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestNormalizeValue(t *testing.T) {
+	_, ipNet, _ := net.ParseCIDR("10.0.0.0/8")
+	mac, _ := net.ParseMAC("08:00:2b:01:02:03")
+
+	tests := []struct {
+		name string
+		in   any
+		want any
+	}{
+		{"int", int(1), int64(1)},
+		{"int8", int8(-2), int64(-2)},
+		{"int16", int16(3), int64(3)},
+		{"int32", int32(-4), int64(-4)},
+		{"int64", int64(5), int64(5)},
+		{"uint8", uint8(6), int64(6)},
+		{"uint16", uint16(7), int64(7)},
+		{"uint32", uint32(8), int64(8)},
+		{"uint", uint(9), int64(9)},
+		{"uint64", uint64(10), int64(10)},
+		{"uint64 overflow", uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{"float32", float32(0.1), 0.1},
+		{"float64", 2.5, 2.5},
+		{"netip.Addr", netip.MustParseAddr("192.168.0.1"), "192.168.0.1"},
+		{"netip.Prefix", netip.MustParsePrefix("10.0.0.0/8"), "10.0.0.0/8"},
+		{"net.IP", net.ParseIP("::1"), "::1"},
+		{"nil net.IP", net.IP(nil), nil},
+		{"*net.IPNet", ipNet, "10.0.0.0/8"},
+		{"net.HardwareAddr", mac, "08:00:2b:01:02:03"},
+		{"string", "text", "text"},
+		{"bool", true, true},
+		{"nil", nil, nil},
+	}
+	for _, tt := range tests {
+		if got := normalizeValue(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: normalizeValue(%#v) = %#v (%T), want %#v (%T)", tt.name, tt.in, got, got, tt.want, tt.want)
+		}
+	}
+}
+
+func TestQueryMapsNormalizeTypes(t *testing.T) {
+	var numeric pgtype.Numeric
+	if err := numeric.Scan("12.50"); err != nil {
+		t.Fatal(err)
+	}
+	uuid := [16]byte{0x12, 0x34, 0x56, 0x78, 0x12, 0x34, 0x56, 0x78, 0x12, 0x34, 0x56, 0x78, 0x12, 0x34, 0x56, 0x78}
+	mock := &mockQueryer{
+		fields: mockFields("id", "ratio", "amount", "ref", "addr", "block", "counts"),
+		rows: []mockRow{{values: []interface{}{
+			int32(7), float32(0.25), numeric, uuid, netip.MustParsePrefix("10.1.2.3/32"), netip.MustParsePrefix("10.1.2.3/32"), []any{int16(1), int16(2)},
+		}}},
+	}
+	mock.fields[4].DataTypeOID = pgtype.InetOID
+	mock.fields[5].DataTypeOID = pgtype.CIDROID
+
+	rows, err := QueryMaps(context.Background(), mock, "SELECT * FROM t", NormalizeTypes())
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	want := RowMap{
+		"id":     int64(7),
+		"ratio":  0.25,
+		"amount": 12.5,
+		"ref":    "12345678-1234-5678-1234-567812345678",
+		"addr":   "10.1.2.3",
+		"block":  "10.1.2.3/32",
+		"counts": []any{int64(1), int64(2)},
+	}
+	if !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("Expected %#v, got %#v", want, rows[0])
+	}
+
+	if n, ok := rows[0].Int("id"); !ok || n != 7 {
+		t.Errorf("Expected Int to read the normalized id, got %v, %v", n, ok)
+	}
+
+	rows, err = QueryMaps(context.Background(), mock, "SELECT * FROM t")
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if _, ok := rows[0]["id"].(int32); !ok {
+		t.Errorf("Expected int32 without NormalizeTypes, got %T", rows[0]["id"])
+	}
+	if s, ok := rows[0].String("addr"); !ok || s != "10.1.2.3" {
+		t.Errorf("Expected String to read an inet as text, got %q, %v", s, ok)
+	}
+}
+
+func TestQueryJSONNormalizeTypes(t *testing.T) {
+	mac, _ := net.ParseMAC("08:00:2b:01:02:03")
+	mock := &mockQueryer{
+		fields: mockFields("mac"),
+		rows:   []mockRow{{values: []interface{}{mac}}},
+	}
+
+	data, err := QueryJSON(context.Background(), mock, "SELECT mac FROM devices", NormalizeTypes())
+	if err != nil {
+		t.Fatalf("QueryJSON failed: %v", err)
+	}
+	if got, want := string(data), `[{"mac":"08:00:2b:01:02:03"}]`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
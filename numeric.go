@@ -0,0 +1,200 @@
+package dbx
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// numericTypes maps a registered decimal type to a func(string) (any, error)
+// that parses exact decimal text into it.
+var numericTypes sync.Map
+
+// ExactNumerics keeps numeric values exact everywhere. QueryMaps and the
+// other helpers returning RowMaps give them as their decimal text, such as
+// "1234.50", instead of float64, and the JSON helpers write them as JSON
+// strings rather than numbers, for clients that would parse numbers as
+// floats. Struct mapping, which already fills string and registered decimal
+// fields from the exact text, refuses to put a value into a float field
+// that can't hold it exactly. QueryCSV always writes the exact text.
+//
+//	dbx.SetDefaults(dbx.ExactNumerics())
+func ExactNumerics() Option {
+	return func(o *options) {
+		o.exactNumerics = true
+	}
+}
+
+// RegisterNumericType registers a decimal type that numeric columns can be
+// mapped into. parse receives the exact decimal text of the value, so no
+// precision is lost. For example, with github.com/shopspring/decimal:
+//
+//	dbx.RegisterNumericType(decimal.NewFromString)
+//
+// Registration is typically done once at startup; it is safe for concurrent use.
+func RegisterNumericType[T any](parse func(string) (T, error)) {
+	numericTypes.Store(reflect.TypeOf((*T)(nil)).Elem(), func(s string) (any, error) {
+		return parse(s)
+	})
+}
+
+// numericText returns the exact decimal text of n, or "NaN", "Infinity",
+// or "-Infinity" for special values.
+func numericText(n pgtype.Numeric) (string, error) {
+	v, err := n.Value()
+	if err != nil {
+		return "", err
+	}
+	s, _ := v.(string)
+	return s, nil
+}
+
+// assignNumeric sets field from a numeric value. Float fields receive the
+// nearest float64, or with ExactNumerics only a value they hold exactly,
+// integer fields the exact integer (erroring on fractions or overflow),
+// string fields the exact decimal text, and registered decimal types are
+// parsed from the exact text.
+func assignNumeric(field reflect.Value, n pgtype.Numeric, opts *options) error {
+	if !n.Valid {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	if parse, ok := numericTypes.Load(field.Type()); ok {
+		text, err := numericText(n)
+		if err != nil {
+			return err
+		}
+		v, err := parse.(func(string) (any, error))(text)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(v))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if opts.exactNumerics {
+			return assignExactFloat(field, n)
+		}
+		f, err := n.Float64Value()
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f.Float64)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := n.Int64Value()
+		if err != nil {
+			return err
+		}
+		if field.OverflowInt(i.Int64) {
+			return fmt.Errorf("value %d overflows %s", i.Int64, field.Type())
+		}
+		field.SetInt(i.Int64)
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := n.Int64Value()
+		if err != nil {
+			return err
+		}
+		if i.Int64 < 0 || field.OverflowUint(uint64(i.Int64)) {
+			return fmt.Errorf("value %d overflows %s", i.Int64, field.Type())
+		}
+		field.SetUint(uint64(i.Int64))
+		return nil
+
+	case reflect.String:
+		text, err := numericText(n)
+		if err != nil {
+			return err
+		}
+		field.SetString(text)
+		return nil
+	}
+
+	return errNotConvertible
+}
+
+// assignExactFloat sets a float field from a finite numeric only if the
+// field holds it exactly, as 12.5 but not 0.1. NaN and infinities carry
+// over as they are.
+func assignExactFloat(field reflect.Value, n pgtype.Numeric) error {
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		f, err := n.Float64Value()
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f.Float64)
+		return nil
+	}
+	text, err := numericText(n)
+	if err != nil {
+		return err
+	}
+	r, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return fmt.Errorf("numeric %s has no exact float value", text)
+	}
+	var exact bool
+	var f float64
+	if field.Kind() == reflect.Float32 {
+		var f32 float32
+		f32, exact = r.Float32()
+		f = float64(f32)
+	} else {
+		f, exact = r.Float64()
+	}
+	if !exact {
+		return fmt.Errorf("numeric %s cannot be represented exactly in %s; use a string or decimal field", text, field.Type())
+	}
+	field.SetFloat(f)
+	return nil
+}
+
+// numericString converts n to its exact decimal text for RowMap values
+// with ExactNumerics. NULL becomes nil.
+func numericString(n pgtype.Numeric) any {
+	if !n.Valid {
+		return nil
+	}
+	text, _ := numericText(n)
+	return text
+}
+
+// numericFloat converts n to float64 for RowMap values. NULL becomes nil.
+func numericFloat(n pgtype.Numeric) any {
+	if !n.Valid {
+		return nil
+	}
+	f, err := n.Float64Value()
+	if err != nil {
+		return math.NaN()
+	}
+	return f.Float64
+}
+
+// numericJSON renders n for JSON output: finite values as a JSON number with
+// the exact decimal digits, or a string of them with ExactNumerics, special
+// values as strings, NULL as null.
+func numericJSON(n pgtype.Numeric, opts *options) any {
+	if !n.Valid {
+		return nil
+	}
+	if opts.exactNumerics {
+		return numericString(n)
+	}
+	text, err := numericText(n)
+	if err != nil || n.NaN || n.InfinityModifier != pgtype.Finite {
+		return text
+	}
+	return json.Number(text)
+}
@@ -0,0 +1,212 @@
+package dbx
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// testDecimal stands in for a third-party decimal type such as shopspring/decimal.
+type testDecimal struct {
+	text string
+}
+
+func parseTestDecimal(s string) (testDecimal, error) {
+	return testDecimal{text: s}, nil
+}
+
+// scannedDecimal is a decimal type that implements sql.Scanner instead of
+// being registered.
+type scannedDecimal struct {
+	text string
+}
+
+func (d *scannedDecimal) Scan(src any) error {
+	d.text = src.(string)
+	return nil
+}
+
+func numeric(unscaled int64, exp int32) pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(unscaled), Exp: exp, Valid: true}
+}
+
+func TestAssignNumeric(t *testing.T) {
+	RegisterNumericType(parseTestDecimal)
+
+	amount := numeric(1050, -2) // 10.50
+
+	var f float64
+	var s string
+	var d testDecimal
+	var sd scannedDecimal
+	var ns sql.NullString
+	var i int
+	var p *float64
+
+	tests := []struct {
+		name  string
+		field any
+		want  any
+	}{
+		{"float64", &f, 10.5},
+		{"string", &s, "10.50"},
+		{"registered type", &d, testDecimal{text: "10.50"}},
+		{"scanner", &sd, scannedDecimal{text: "10.50"}},
+		{"sql.NullString", &ns, sql.NullString{String: "10.50", Valid: true}},
+		{"int", &i, 12000},
+		{"pointer", &p, 10.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := amount
+			if tt.name == "int" {
+				value = numeric(12, 3)
+			}
+
+			field := reflect.ValueOf(tt.field).Elem()
+			if err := assignValue(field, value, defaults()); err != nil {
+				t.Fatalf("assignValue failed: %v", err)
+			}
+
+			got := field.Interface()
+			if field.Kind() == reflect.Pointer {
+				got = field.Elem().Interface()
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAssignNumericFractionIntoInt(t *testing.T) {
+	var i int
+	if err := assignValue(reflect.ValueOf(&i).Elem(), numeric(1050, -2), defaults()); err == nil {
+		t.Errorf("Expected error assigning 10.50 to int, got %d", i)
+	}
+
+	var i8 int8
+	if err := assignValue(reflect.ValueOf(&i8).Elem(), numeric(300, 0), defaults()); err == nil {
+		t.Errorf("Expected overflow error assigning 300 to int8, got %d", i8)
+	}
+}
+
+func TestQueryMapsNumericAsFloat(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("amount", "missing"),
+		rows:   []mockRow{{values: []interface{}{numeric(1050, -2), pgtype.Numeric{}}}},
+	}
+
+	rows, err := QueryMaps(ctx, mock, "SELECT amount, missing FROM invoice")
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+
+	if rows[0]["amount"] != 10.5 {
+		t.Errorf("Expected 10.5, got %#v", rows[0]["amount"])
+	}
+	if rows[0]["missing"] != nil {
+		t.Errorf("Expected NULL numeric to be nil, got %#v", rows[0]["missing"])
+	}
+}
+
+func TestQueryJSONNumeric(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("amount", "precise", "nan"),
+		rows: []mockRow{{values: []interface{}{
+			numeric(1050, -2),
+			pgtype.Numeric{Int: new(big.Int).SetBytes([]byte(strings.Repeat("\xff", 12))), Exp: -10, Valid: true},
+			pgtype.Numeric{NaN: true, Valid: true},
+		}}},
+	}
+
+	data, err := QueryJSON(ctx, mock, "SELECT amount, precise, nan FROM invoice")
+	if err != nil {
+		t.Fatalf("QueryJSON failed: %v", err)
+	}
+
+	expected := `[{"amount":10.50,"precise":7922816251426433759.3543950335,"nan":"NaN"}]`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
+
+func TestExactNumerics(t *testing.T) {
+	ctx := context.Background()
+	precise := pgtype.Numeric{Int: new(big.Int).SetBytes([]byte(strings.Repeat("\xff", 12))), Exp: -10, Valid: true}
+	newMock := func() *mockQueryer {
+		return &mockQueryer{
+			fields: mockFields("amount", "precise", "nan", "missing"),
+			rows:   []mockRow{{values: []interface{}{numeric(1050, -2), precise, pgtype.Numeric{NaN: true, Valid: true}, pgtype.Numeric{}}}},
+		}
+	}
+
+	rows, err := QueryMaps(ctx, newMock(), "SELECT * FROM invoice", ExactNumerics())
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	want := RowMap{"amount": "10.50", "precise": "7922816251426433759.3543950335", "nan": "NaN", "missing": nil}
+	if !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("Expected %v, got %v", want, rows[0])
+	}
+
+	data, err := QueryJSON(ctx, newMock(), "SELECT * FROM invoice", ExactNumerics())
+	if err != nil {
+		t.Fatalf("QueryJSON failed: %v", err)
+	}
+	if want := `[{"amount":"10.50","precise":"7922816251426433759.3543950335","nan":"NaN","missing":null}]`; string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	var buf bytes.Buffer
+	if err := QueryCSV(ctx, newMock(), &buf, "SELECT * FROM invoice", ExactNumerics()); err != nil {
+		t.Fatalf("QueryCSV failed: %v", err)
+	}
+	if want := "amount,precise,nan,missing\n10.50,7922816251426433759.3543950335,NaN,\n"; buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestExactNumericsStructs(t *testing.T) {
+	RegisterNumericType(parseTestDecimal)
+	type Invoice struct {
+		Amount  string      `db:"amount"`
+		Precise testDecimal `db:"precise"`
+		Rate    float64     `db:"rate"`
+	}
+	mock := &mockQueryer{
+		fields: mockFields("amount", "precise", "rate"),
+		rows:   []mockRow{{values: []interface{}{numeric(1050, -2), numeric(79228162514264337, -10), numeric(125, -1)}}},
+	}
+
+	var invoices []Invoice
+	if err := QueryStructs(context.Background(), mock, "SELECT * FROM invoice", &invoices, ExactNumerics()); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	want := Invoice{Amount: "10.50", Precise: testDecimal{text: "7922816.2514264337"}, Rate: 12.5}
+	if invoices[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, invoices[0])
+	}
+
+	mock.rows = []mockRow{{values: []interface{}{numeric(1050, -2), numeric(1, 0), numeric(1, -1)}}}
+	var convErr *ConversionError
+	err := QueryStructs(context.Background(), mock, "SELECT * FROM invoice", &invoices, ExactNumerics())
+	if !errors.As(err, &convErr) || convErr.Field != "Rate" || !strings.Contains(err.Error(), "exactly") {
+		t.Errorf("Expected a ConversionError for 0.1 into float64, got %v", err)
+	}
+
+	var f float64
+	if err := assignValue(reflect.ValueOf(&f).Elem(), numeric(1, -1), defaults()); err != nil || f != 0.1 {
+		t.Errorf("Expected the nearest float without ExactNumerics, got %v, %v", f, err)
+	}
+}
@@ -0,0 +1,306 @@
+package dbx
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Option configures a single dbx call. Options are passed alongside the query
+// arguments and are removed before the arguments are sent to the database:
+//
+//	err := dbx.QueryStructs(ctx, db, sql, &rows, dbx.Lenient(), userID)
+//
+// Package-wide defaults can be installed with SetDefaults.
+type Option func(*options)
+
+// options holds the settings that can be changed per call.
+type options struct {
+	lenient       bool
+	strictColumns bool
+	requireAll    bool
+	timesInUTC    bool
+	timeFormat    string
+	normalize     bool
+	exactNumerics bool
+	appendRows    bool
+	capacity      int
+	directScan    bool
+	keepLast      bool
+	prepared      string
+	timeout       time.Duration
+	planOnly      bool
+	forUpdate     bool
+	allRows       bool
+	csvDelimiter  rune
+	csvNull       string
+	binaryFormat  BinaryFormat
+	maxBinary     int
+	jsonKey       func(string) string
+	tagName       string
+	nameMapper    *nameMapper
+}
+
+// defaultOptions holds the options installed by SetDefaults.
+var defaultOptions atomic.Pointer[options]
+
+func init() {
+	defaultOptions.Store(newOptions())
+}
+
+// newOptions returns the built-in defaults.
+func newOptions() *options {
+	return &options{
+		timeFormat:   time.RFC3339Nano,
+		csvDelimiter: ',',
+		tagName:      "db",
+	}
+}
+
+// SetDefaults sets options applied to every call before any per-call options.
+// Each call replaces the previous defaults; calling it with no options
+// restores the built-in defaults.
+func SetDefaults(opts ...Option) {
+	o := newOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	defaultOptions.Store(o)
+}
+
+// Lenient makes struct mapping skip values that cannot be converted to the
+// target field type, leaving the field at its zero value, instead of
+// returning a *ConversionError.
+func Lenient() Option {
+	return func(o *options) {
+		o.lenient = true
+	}
+}
+
+// StrictColumns makes queries fail when the result has two columns with the
+// same name, as from SELECT a.id, b.id, listing the duplicates. By default
+// QueryMaps and the JSON helpers rename later duplicates id_2, id_3, ...,
+// and struct mapping assigns them positionally.
+func StrictColumns() Option {
+	return func(o *options) {
+		o.strictColumns = true
+	}
+}
+
+// TagName sets the struct tag that names columns, in place of the default
+// "db", so that models already carrying matching tags need no db tags:
+//
+//	dbx.SetDefaults(dbx.TagName("json"))
+//
+// Options after the name, such as ",omitempty", are stripped as for db
+// tags. Struct mapping in queries honours TagName per call; helpers that
+// take no options, such as InsertStruct and UpdateStruct, use the tag set
+// with SetDefaults.
+func TagName(name string) Option {
+	return func(o *options) {
+		o.tagName = name
+	}
+}
+
+// AutoSnakeCase maps exported fields without a tag to the snake_case form
+// of their name, so CreatedAt matches the column created_at and UserID
+// matches user_id. It is shorthand for NameMapper(SnakeCase).
+func AutoSnakeCase() Option {
+	return func(o *options) {
+		o.nameMapper = snakeCaseMapper
+	}
+}
+
+// snakeCaseMapper is the mapper set by AutoSnakeCase, shared so that
+// every use of the option shares its cached struct metadata.
+var snakeCaseMapper = &nameMapper{fn: SnakeCase}
+
+// NameMapper sets a function that derives the column name of an exported
+// field without a tag from the field's Go name. By default such fields are
+// not mapped. Struct metadata parsed with the mapper is cached on the
+// Option, so create it once and reuse it, for instance with SetDefaults or
+// in a package-level variable, rather than for every call. Like TagName,
+// helpers that take no options use the mapper set with SetDefaults.
+func NameMapper(fn func(string) string) Option {
+	m := &nameMapper{fn: fn}
+	return func(o *options) {
+		o.nameMapper = m
+	}
+}
+
+// nameMapper is a mapper set with NameMapper, along with the struct
+// metadata parsed with it, which depends on what fn does and so can't be
+// shared with other mappers, even ones made from the same function.
+type nameMapper struct {
+	fn    func(string) string
+	cache sync.Map // of structMetaKey to *structMeta
+}
+
+// RequireAllFields makes struct mapping fail when a tagged field has no
+// matching column in the result, instead of leaving it at its zero value.
+// The error lists the missing tags and the columns the query returned.
+// Fields tagged optional, as in db:"nickname,optional", are exempt, as are
+// tagged nested structs, whose own fields are checked instead.
+func RequireAllFields() Option {
+	return func(o *options) {
+		o.requireAll = true
+	}
+}
+
+// Prepared runs the query as the named prepared statement, preparing it on
+// first use. It works with a Preparer, such as *pgx.Conn or pgx.Tx, and
+// with a *pgxpool.Pool, where the statement runs on a connection acquired
+// from the pool for the query; statements set up there with PrepareAll or
+// PrepareOnConnect are reused. With other DBs, such as stdsql's, the SQL
+// is sent as usual and pgx's statement cache applies.
+//
+//	err := dbx.QueryStruct(ctx, conn, "SELECT * FROM users WHERE id = $1", &user, dbx.Prepared("get_user_by_id"), id)
+func Prepared(name string) Option {
+	return func(o *options) {
+		o.prepared = name
+	}
+}
+
+// Analyze controls whether Explain runs EXPLAIN ANALYZE, which executes
+// the statement, or only plans it. It is on by default; pass Analyze(false)
+// to explain writes without performing them.
+func Analyze(on bool) Option {
+	return func(o *options) {
+		o.planOnly = !on
+	}
+}
+
+// Timeout bounds each statement the call runs to d, independently of the
+// caller's context; a deadline on that context which expires sooner still
+// applies. A statement stopped by the timeout fails with a *TimeoutError.
+// Set it with SetDefaults to give every statement a limit, including those
+// run by helpers that take no options, such as InsertStruct:
+//
+//	dbx.SetDefaults(dbx.Timeout(5 * time.Second))
+func Timeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// Append makes QueryStructs and QueryNested append rows to the destination
+// slice instead of first truncating it to zero length.
+func Append() Option {
+	return func(o *options) {
+		o.appendRows = true
+	}
+}
+
+// Capacity sizes the result of QueryStructs, QueryStructsT, and QueryMaps
+// for n rows up front, when the row count is known or bounded, as with a
+// LIMIT, so the slice doesn't grow as rows arrive. More rows are still read
+// if the query returns them.
+//
+//	err := dbx.QueryStructs(ctx, db, "SELECT * FROM users LIMIT 500", &users, dbx.Capacity(500))
+func Capacity(n int) Option {
+	return func(o *options) {
+		o.capacity = n
+	}
+}
+
+// KeepLast makes QueryStructsMap keep the last row when two rows share a
+// key, instead of failing.
+func KeepLast() Option {
+	return func(o *options) {
+		o.keepLast = true
+	}
+}
+
+// ForUpdate makes GetByID and GetBy lock the row they read with
+// SELECT ... FOR UPDATE. It only has an effect inside a transaction.
+func ForUpdate() Option {
+	return func(o *options) {
+		o.forUpdate = true
+	}
+}
+
+// AllRows allows DeleteWhere and UpdateWhere to run with an empty where
+// clause, affecting every row of the table. Without it they refuse, to guard
+// against accidental table-wide writes.
+func AllRows() Option {
+	return func(o *options) {
+		o.allRows = true
+	}
+}
+
+// TimesInUTC converts every time.Time read from the database to UTC,
+// regardless of the session time zone.
+func TimesInUTC() Option {
+	return func(o *options) {
+		o.timesInUTC = true
+	}
+}
+
+// JSONTimeFormat sets the layout used to render time values in JSON output.
+// The default is time.RFC3339Nano.
+func JSONTimeFormat(layout string) Option {
+	return func(o *options) {
+		o.timeFormat = layout
+	}
+}
+
+// JSONKeys sets a function that turns column names into the object keys
+// used by QueryJSON and the other JSON helpers, such as CamelCase or
+// StripTablePrefix. If two columns produce the same key the query fails
+// rather than one value silently replacing the other.
+func JSONKeys(fn func(string) string) Option {
+	return func(o *options) {
+		o.jsonKey = fn
+	}
+}
+
+// CSVDelimiter sets the field separator used by QueryCSV, e.g. '\t' for
+// TSV. The default is a comma.
+func CSVDelimiter(r rune) Option {
+	return func(o *options) {
+		o.csvDelimiter = r
+	}
+}
+
+// CSVNull sets the text QueryCSV writes for NULL values. The default is an
+// empty field.
+func CSVNull(s string) Option {
+	return func(o *options) {
+		o.csvNull = s
+	}
+}
+
+// splitArgs separates Option values from query arguments and returns the
+// resulting options layered over the package defaults.
+func splitArgs(args []any) (*options, []any) {
+	opts := new(options)
+	*opts = *defaultOptions.Load()
+
+	var queryArgs []any
+	for i, arg := range args {
+		opt, ok := arg.(Option)
+		if !ok {
+			if queryArgs != nil {
+				queryArgs = append(queryArgs, arg)
+			}
+			continue
+		}
+		if queryArgs == nil {
+			queryArgs = append(make([]any, 0, len(args)-1), args[:i]...)
+		}
+		opt(opts)
+	}
+
+	if queryArgs == nil {
+		return opts, args
+	}
+	return opts, queryArgs
+}
+
+// defaults returns a copy of the package defaults, for calls that take no
+// per-call options.
+func defaults() *options {
+	opts := new(options)
+	*opts = *defaultOptions.Load()
+	return opts
+}
@@ -0,0 +1,33 @@
+package dbx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []any
+		want    []any
+		lenient bool
+	}{
+		{"no args", nil, nil, false},
+		{"no options", []any{1, "a"}, []any{1, "a"}, false},
+		{"leading option", []any{Lenient(), 1, "a"}, []any{1, "a"}, true},
+		{"trailing option", []any{1, "a", Lenient()}, []any{1, "a"}, true},
+		{"only option", []any{Lenient()}, []any{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, args := splitArgs(tt.args)
+			if !reflect.DeepEqual(args, tt.want) {
+				t.Errorf("Expected args %v, got %v", tt.want, args)
+			}
+			if opts.lenient != tt.lenient {
+				t.Errorf("Expected lenient %v, got %v", tt.lenient, opts.lenient)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Page selects a window of a query's rows.
+type Page struct {
+	Limit  int // maximum number of rows, must be positive
+	Offset int // number of rows to skip
+}
+
+// PageResult describes the outcome of QueryPage.
+type PageResult struct {
+	Total int64 // number of rows the query matches without the page window
+	Rows  int   // number of rows scanned into dest
+}
+
+// QueryPage runs a query for one page of results, scanning the rows into
+// dest as QueryStructs does, and also counts the rows the query matches
+// in total. The count wraps sql in SELECT count(*) FROM (...), so it
+// respects the query's WHERE conditions, and the page is fetched by
+// appending LIMIT and OFFSET placeholders after args.
+//
+// sql must not contain LIMIT or OFFSET itself, including in subqueries,
+// since the page window would then be applied twice. Give it an ORDER BY
+// for stable pages.
+func QueryPage(ctx context.Context, db DB, sql string, dest any, page Page, args ...any) (PageResult, error) {
+	opts, args := splitArgs(args)
+
+	if page.Limit <= 0 {
+		return PageResult{}, fmt.Errorf("page limit must be positive, got %d", page.Limit)
+	}
+	if page.Offset < 0 {
+		return PageResult{}, fmt.Errorf("page offset must not be negative, got %d", page.Offset)
+	}
+	for _, keyword := range []string{"LIMIT", "OFFSET"} {
+		if containsKeyword(sql, keyword) {
+			return PageResult{}, fmt.Errorf("query already contains %s; QueryPage adds its own", keyword)
+		}
+	}
+
+	total, err := countRows(ctx, db, sql, args, opts)
+	if err != nil {
+		return PageResult{}, err
+	}
+
+	// Measure the rows added rather than the final length, in case the
+	// Append option kept earlier rows
+	var before int
+	if v := reflect.ValueOf(dest); v.Kind() == reflect.Pointer && !v.IsNil() && v.Elem().Kind() == reflect.Slice && opts.appendRows {
+		before = v.Elem().Len()
+	}
+
+	pageSQL := fmt.Sprintf("%s LIMIT $%d OFFSET $%d", strings.TrimRight(sql, " \t\n;"), len(args)+1, len(args)+2)
+	pageArgs := append(append(make([]any, 0, len(args)+2), args...), page.Limit, page.Offset)
+	if err := queryStructs(ctx, db, "QueryPage", pageSQL, dest, opts, pageArgs); err != nil {
+		return PageResult{}, err
+	}
+
+	return PageResult{
+		Total: total,
+		Rows:  reflect.ValueOf(dest).Elem().Len() - before,
+	}, nil
+}
+
+// countRows returns the number of rows sql produces. A prepared statement
+// name in opts gets a _count suffix, as the count is a different statement.
+func countRows(ctx context.Context, db DB, sql string, args []any, opts *options) (int64, error) {
+	countSQL := "SELECT count(*) FROM (" + strings.TrimRight(sql, " \t\n;") + ") AS dbx_page"
+
+	if opts.prepared != "" {
+		countOpts := *opts
+		countOpts.prepared += "_count"
+		opts = &countOpts
+	}
+
+	rows, err := query(ctx, db, "QueryPage", countSQL, args, opts)
+	if err != nil {
+		return 0, fmt.Errorf("count query failed: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, fmt.Errorf("count query failed: %w", err)
+		}
+		return 0, fmt.Errorf("count query returned no rows")
+	}
+
+	values, err := rows.Values()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get row values: %w", err)
+	}
+
+	var total int64
+	if len(values) != 1 {
+		return 0, fmt.Errorf("count query returned %d columns", len(values))
+	}
+	if err := assignValue(reflect.ValueOf(&total).Elem(), values[0], defaults()); err != nil {
+		return 0, fmt.Errorf("failed to read count: %w", err)
+	}
+	return total, nil
+}
+
+// containsKeyword reports whether sql contains keyword as a whole word,
+// ignoring case, outside string literals, quoted identifiers, and comments.
+func containsKeyword(sql, keyword string) bool {
+	for i := 0; i < len(sql); {
+		if n := skipQuotedOrComment(sql, i); n > i {
+			i = n
+			continue
+		}
+		if !isIdentChar(sql[i]) {
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(sql) && isIdentChar(sql[j]) {
+			j++
+		}
+		if strings.EqualFold(sql[i:j], keyword) {
+			return true
+		}
+		i = j
+	}
+	return false
+}
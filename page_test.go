@@ -0,0 +1,113 @@
+package dbx
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// pageQueryer answers count queries with total and other queries with rows.
+type pageQueryer struct {
+	mockQueryer
+	total int64
+	sqls  []string
+	args  [][]any
+}
+
+func (m *pageQueryer) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	m.sqls = append(m.sqls, sql)
+	m.args = append(m.args, args)
+	if strings.HasPrefix(sql, "SELECT count(*)") {
+		return &mockRows{rows: []mockRow{{values: []any{m.total}}}, fields: mockFields("count"), current: -1}, nil
+	}
+	return m.mockQueryer.Query(ctx, sql, args...)
+}
+
+func TestQueryPage(t *testing.T) {
+	ctx := context.Background()
+	mock := &pageQueryer{
+		mockQueryer: mockQueryer{rows: []mockRow{
+			{values: []interface{}{3, "John", "john@example.com"}},
+			{values: []interface{}{4, "Jane", "jane@example.com"}},
+		}},
+		total: 42,
+	}
+
+	type TestUser struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	var users []TestUser
+	result, err := QueryPage(ctx, mock, "SELECT * FROM users WHERE active = $1 ORDER BY id;", &users, Page{Limit: 2, Offset: 2}, true)
+	if err != nil {
+		t.Fatalf("QueryPage failed: %v", err)
+	}
+
+	if result != (PageResult{Total: 42, Rows: 2}) {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if len(users) != 2 || users[1].Name != "Jane" {
+		t.Errorf("Unexpected users: %+v", users)
+	}
+
+	expectedSQLs := []string{
+		"SELECT count(*) FROM (SELECT * FROM users WHERE active = $1 ORDER BY id) AS dbx_page",
+		"SELECT * FROM users WHERE active = $1 ORDER BY id LIMIT $2 OFFSET $3",
+	}
+	if !reflect.DeepEqual(mock.sqls, expectedSQLs) {
+		t.Errorf("Expected SQL %q, got %q", expectedSQLs, mock.sqls)
+	}
+	expectedArgs := [][]any{{true}, {true, 2, 2}}
+	if !reflect.DeepEqual(mock.args, expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, mock.args)
+	}
+}
+
+func TestQueryPageRejects(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		sql     string
+		page    Page
+		wantErr string
+	}{
+		{"limit in query", "SELECT * FROM users limit 5", Page{Limit: 10}, "already contains LIMIT"},
+		{"offset in query", "SELECT * FROM users OFFSET 5", Page{Limit: 10}, "already contains OFFSET"},
+		{"zero limit", "SELECT * FROM users", Page{}, "page limit must be positive"},
+		{"negative offset", "SELECT * FROM users", Page{Limit: 1, Offset: -1}, "page offset must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dest []struct{}
+			_, err := QueryPage(ctx, &pageQueryer{}, tt.sql, &dest, tt.page)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestContainsKeyword(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"SELECT * FROM t LIMIT 1", true},
+		{"SELECT * FROM t\nlimit\n1", true},
+		{"SELECT 'LIMIT' FROM t", false},
+		{"SELECT \"limit\" FROM t -- limit", false},
+		{"SELECT limits FROM t", false},
+	}
+
+	for _, tt := range tests {
+		if got := containsKeyword(tt.sql, "LIMIT"); got != tt.want {
+			t.Errorf("containsKeyword(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Parallel runs tasks concurrently against db and waits for them all to
+// finish. Each task gets a context that is canceled as soon as any task
+// fails, so the rest can stop early; tasks that have not started by then
+// are skipped. The errors of the tasks that failed, other than those that
+// merely report that cancellation, are returned joined, each prefixed with
+// the task's position. A panicking task is recovered and reported as an
+// error along with its stack.
+//
+// db must be safe for concurrent use, as a *pgxpool.Pool is; a pgx.Tx or
+// *pgx.Conn is refused, since it can only run one statement at a time.
+//
+//	var users []User
+//	var total int64
+//	err := dbx.Parallel(ctx, pool,
+//		func(ctx context.Context, db dbx.DB) error {
+//			return dbx.QueryStructs(ctx, db, "SELECT * FROM users LIMIT 10", &users)
+//		},
+//		func(ctx context.Context, db dbx.DB) (err error) {
+//			total, err = dbx.QueryScalar[int64](ctx, db, "SELECT count(*) FROM orders")
+//			return err
+//		},
+//	)
+func Parallel(ctx context.Context, db DB, tasks ...func(ctx context.Context, db DB) error) error {
+	return ParallelN(ctx, db, len(tasks), tasks...)
+}
+
+// ParallelN is like Parallel but runs at most limit tasks at once, such as
+// to leave connections in the pool for other work.
+func ParallelN(ctx context.Context, db DB, limit int, tasks ...func(ctx context.Context, db DB) error) error {
+	switch db.(type) {
+	case pgx.Tx, *pgx.Conn:
+		return fmt.Errorf("%T cannot run statements concurrently; use a pool", db)
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+	if limit <= 0 {
+		return fmt.Errorf("limit must be positive, got %d", limit)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed bool
+		errs   = make([]error, len(tasks))
+		sem    = make(chan struct{}, limit)
+	)
+	for i, task := range tasks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, task func(context.Context, DB) error) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := runTask(ctx, db, task)
+			if err == nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			// Once a task has failed, the others' cancellation errors are
+			// only noise
+			if failed && errors.Is(err, context.Canceled) {
+				return
+			}
+			errs[i] = fmt.Errorf("task %d: %w", i, err)
+			failed = true
+			cancel()
+		}(i, task)
+	}
+	wg.Wait()
+
+	if !failed {
+		// The caller's context may have ended before any task failed
+		return ctx.Err()
+	}
+	return errors.Join(errs...)
+}
+
+// runTask calls task, turning a panic into an error.
+func runTask(ctx context.Context, db DB, task func(context.Context, DB) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v\n%s", p, debug.Stack())
+		}
+	}()
+	return task(ctx, db)
+}
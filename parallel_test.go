@@ -0,0 +1,92 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallel(t *testing.T) {
+	var results [3]int
+	err := Parallel(context.Background(), &mockQueryer{},
+		func(ctx context.Context, db DB) error { results[0] = 1; return nil },
+		func(ctx context.Context, db DB) error { results[1] = 2; return nil },
+		func(ctx context.Context, db DB) error { results[2] = 3; return nil },
+	)
+	if err != nil {
+		t.Fatalf("Parallel failed: %v", err)
+	}
+	if results != [3]int{1, 2, 3} {
+		t.Errorf("results = %v", results)
+	}
+}
+
+func TestParallelCancelsOnError(t *testing.T) {
+	failure := errors.New("query failed")
+	err := Parallel(context.Background(), &mockQueryer{},
+		func(ctx context.Context, db DB) error { return failure },
+		func(ctx context.Context, db DB) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+				return errors.New("not canceled")
+			}
+		},
+	)
+	if !errors.Is(err, failure) || !strings.Contains(err.Error(), "task 0: query failed") {
+		t.Fatalf("expected task 0's error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "canceled") || strings.Contains(err.Error(), "not canceled") {
+		t.Errorf("expected the other task to be canceled quietly, got %v", err)
+	}
+}
+
+func TestParallelJoinsErrorsAndRecoversPanics(t *testing.T) {
+	started := make(chan struct{})
+	err := Parallel(context.Background(), &mockQueryer{},
+		func(ctx context.Context, db DB) error {
+			<-started
+			return errors.New("first")
+		},
+		func(ctx context.Context, db DB) error {
+			close(started)
+			panic("boom")
+		},
+	)
+	for _, want := range []string{"task 0: first", "task 1: panic: boom"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+}
+
+func TestParallelN(t *testing.T) {
+	var running, peak atomic.Int32
+	task := func(ctx context.Context, db DB) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	}
+
+	if err := ParallelN(context.Background(), &mockQueryer{}, 2, task, task, task, task, task); err != nil {
+		t.Fatalf("ParallelN failed: %v", err)
+	}
+	if peak.Load() > 2 {
+		t.Errorf("ran %d tasks at once, want at most 2", peak.Load())
+	}
+
+	if err := Parallel(context.Background(), &mockTx{}, task); err == nil {
+		t.Error("expected an error for a transaction")
+	}
+}
@@ -0,0 +1,112 @@
+package dbx
+
+import (
+	"strconv"
+	"strings"
+)
+
+// shiftPlaceholders renumbers $n placeholders in sql by adding offset to each.
+// Placeholders inside string literals, quoted identifiers, and comments are
+// left untouched.
+func shiftPlaceholders(sql string, offset int) string {
+	if offset == 0 {
+		return sql
+	}
+
+	var b strings.Builder
+	b.Grow(len(sql) + 8)
+
+	for i := 0; i < len(sql); {
+		if n := skipQuotedOrComment(sql, i); n > i {
+			b.WriteString(sql[i:n])
+			i = n
+			continue
+		}
+
+		if sql[i] == '$' {
+			j := i + 1
+			for j < len(sql) && sql[j] >= '0' && sql[j] <= '9' {
+				j++
+			}
+			if j > i+1 {
+				n, _ := strconv.Atoi(sql[i+1 : j])
+				b.WriteByte('$')
+				b.WriteString(strconv.Itoa(n + offset))
+				i = j
+				continue
+			}
+		}
+
+		b.WriteByte(sql[i])
+		i++
+	}
+
+	return b.String()
+}
+
+// skipQuotedOrComment returns the index just past the string literal, quoted
+// identifier, dollar-quoted string, or comment starting at sql[i]. If none
+// starts there, i is returned.
+func skipQuotedOrComment(sql string, i int) int {
+	switch {
+	case sql[i] == '$':
+		tag := dollarQuoteTag(sql, i)
+		if tag == "" {
+			return i
+		}
+		if end := strings.Index(sql[i+len(tag):], tag); end != -1 {
+			return i + len(tag) + end + len(tag)
+		}
+		return len(sql)
+	case sql[i] == '\'' || sql[i] == '"':
+		quote := sql[i]
+		j := i + 1
+		for j < len(sql) {
+			if sql[j] == quote {
+				// A doubled quote is an escaped quote
+				if j+1 < len(sql) && sql[j+1] == quote {
+					j += 2
+					continue
+				}
+				return j + 1
+			}
+			j++
+		}
+		return len(sql)
+	case strings.HasPrefix(sql[i:], "--"):
+		if end := strings.IndexByte(sql[i:], '\n'); end != -1 {
+			return i + end + 1
+		}
+		return len(sql)
+	case strings.HasPrefix(sql[i:], "/*"):
+		if end := strings.Index(sql[i+2:], "*/"); end != -1 {
+			return i + 2 + end + 2
+		}
+		return len(sql)
+	}
+	return i
+}
+
+// dollarQuoteTag returns the opening tag of the dollar-quoted string starting
+// at sql[i], such as $$ or $body$, or "" if there is none. A $ followed by a
+// digit is a placeholder, not a tag.
+func dollarQuoteTag(sql string, i int) string {
+	// A $ inside an identifier such as tbl$x does not start a tag
+	if i > 0 && isIdentChar(sql[i-1]) {
+		return ""
+	}
+
+	j := i + 1
+	if j < len(sql) && sql[j] != '$' {
+		if !isIdentChar(sql[j]) || sql[j] >= '0' && sql[j] <= '9' {
+			return ""
+		}
+		for j < len(sql) && isIdentChar(sql[j]) {
+			j++
+		}
+	}
+	if j >= len(sql) || sql[j] != '$' {
+		return ""
+	}
+	return sql[i : j+1]
+}
@@ -0,0 +1,32 @@
+package dbx
+
+import "testing"
+
+func TestShiftPlaceholders(t *testing.T) {
+	tests := []struct {
+		sql    string
+		offset int
+		want   string
+	}{
+		{"id = $1", 2, "id = $3"},
+		{"id = $1 AND name = $2", 10, "id = $11 AND name = $12"},
+		{"id = $1", 0, "id = $1"},
+		{"name = '$1' AND id = $1", 1, "name = '$1' AND id = $2"},
+		{`"col$1" = $1`, 1, `"col$1" = $2`},
+		{"id = $1 -- $1\nAND x = $2", 1, "id = $2 -- $1\nAND x = $3"},
+		{"id = $1 /* $2 */", 1, "id = $2 /* $2 */"},
+		{"note = 'it''s $1' AND id = $1", 3, "note = 'it''s $1' AND id = $4"},
+		{"price > $", 1, "price > $"},
+		{"body = $$ $1 $$ AND id = $1", 1, "body = $$ $1 $$ AND id = $2"},
+		{"body = $fn$ it's $1 $fn$ AND id = $1", 1, "body = $fn$ it's $1 $fn$ AND id = $2"},
+		{"id = $1 AND x = $$unterminated $2", 1, "id = $2 AND x = $$unterminated $2"},
+		{"tbl$x$ = $1", 1, "tbl$x$ = $2"},
+	}
+
+	for _, tt := range tests {
+		got := shiftPlaceholders(tt.sql, tt.offset)
+		if got != tt.want {
+			t.Errorf("shiftPlaceholders(%q, %d) = %q, want %q", tt.sql, tt.offset, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Preparer is implemented by DBs that can create named prepared statements.
+// *pgx.Conn and pgx.Tx satisfy it. *pgxpool.Pool does not, as each of its
+// connections has its own statements; the Prepared option prepares and
+// runs the statement on a connection acquired from the pool instead.
+type Preparer interface {
+	Prepare(ctx context.Context, name, sql string) (*pgconn.StatementDescription, error)
+}
+
+// connPool is a DB whose connections each hold their own prepared
+// statements, so that a statement has to be prepared and run on a single
+// connection acquired from it. *pgxpool.Pool is adapted to it by pgxPool.
+type connPool interface {
+	acquire(ctx context.Context) (conn DB, p Preparer, release func(), err error)
+}
+
+// pgxPool adapts a *pgxpool.Pool to connPool.
+type pgxPool struct {
+	*pgxpool.Pool
+}
+
+func (p pgxPool) acquire(ctx context.Context) (DB, Preparer, func(), error) {
+	conn, err := p.Acquire(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return conn, conn.Conn(), conn.Release, nil
+}
+
+// prepare returns the statement to send for sql and the DB to send it to.
+// Without the Prepared option, or when db can neither prepare statements
+// nor hand out connections that can, that is sql with any comment tags
+// appended, sent to db. Otherwise it is the statement name, once the
+// statement is prepared on db, or on a connection acquired from db if it
+// is a pool. release is then non-nil and must be called once the
+// statement's results have been read. Preparing a statement again with the
+// same name and SQL is a no-op in pgx, so a connection or transaction that
+// already has it is fine.
+func prepare(ctx context.Context, db DB, sql string, opts *options) (statement string, conn DB, release func(), err error) {
+	if opts == nil || opts.prepared == "" {
+		return sqlFor(ctx, db, sql), db, nil, nil
+	}
+
+	var pool connPool
+	switch d := db.(type) {
+	case *pgxpool.Pool:
+		pool = pgxPool{d}
+	case connPool:
+		pool = d
+	}
+	p, ok := db.(Preparer)
+	switch {
+	case pool != nil:
+		if conn, p, release, err = pool.acquire(ctx); err != nil {
+			return "", nil, nil, err
+		}
+	case ok:
+		conn = db
+	default:
+		return sqlFor(ctx, db, sql), db, nil, nil
+	}
+
+	if _, err := p.Prepare(ctx, opts.prepared, sql); err != nil {
+		if release != nil {
+			release()
+		}
+		return "", nil, nil, fmt.Errorf("prepare %s failed: %w", opts.prepared, err)
+	}
+	return opts.prepared, conn, release, nil
+}
+
+// sqlFor returns sql with any comment tags appended, as sent to db. A
+// CachedDB gets sql as is and appends the comment when it passes the
+// statement on, so that per-request tags don't end up in its cache keys.
+func sqlFor(ctx context.Context, db DB, sql string) string {
+	if _, ok := db.(*CachedDB); ok {
+		return sql
+	}
+	return commentSQL(ctx, sql)
+}
+
+// PrepareAll prepares statements, a map from statement name to SQL, so that
+// queries passing the Prepared option don't prepare them on first use. db
+// may be a Preparer, or a *pgxpool.Pool, in which case the statements are
+// prepared on its idle connections. Connections a pool opens later don't
+// have them; install PrepareOnConnect as the pool's AfterConnect hook to
+// cover those.
+func PrepareAll(ctx context.Context, db any, statements map[string]string) error {
+	switch db := db.(type) {
+	case Preparer:
+		return prepareAll(ctx, db, statements)
+	case *pgxpool.Pool:
+		conns := db.AcquireAllIdle(ctx)
+		defer func() {
+			for _, conn := range conns {
+				conn.Release()
+			}
+		}()
+		for _, conn := range conns {
+			if err := prepareAll(ctx, conn.Conn(), statements); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("cannot prepare statements on %T", db)
+	}
+}
+
+// PrepareOnConnect returns a pgxpool AfterConnect hook that prepares
+// statements on every new connection:
+//
+//	config.AfterConnect = dbx.PrepareOnConnect(statements)
+func PrepareOnConnect(statements map[string]string) func(context.Context, *pgx.Conn) error {
+	return func(ctx context.Context, conn *pgx.Conn) error {
+		return prepareAll(ctx, conn, statements)
+	}
+}
+
+// prepareAll prepares each of statements on p.
+func prepareAll(ctx context.Context, p Preparer, statements map[string]string) error {
+	for name, sql := range statements {
+		if _, err := p.Prepare(ctx, name, sql); err != nil {
+			return fmt.Errorf("prepare %s failed: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type mockPreparer struct {
+	mockQueryer
+	prepared   map[string]string
+	prepareErr error
+}
+
+func (m *mockPreparer) Prepare(ctx context.Context, name, sql string) (*pgconn.StatementDescription, error) {
+	if m.prepareErr != nil {
+		return nil, m.prepareErr
+	}
+	if m.prepared == nil {
+		m.prepared = make(map[string]string)
+	}
+	m.prepared[name] = sql
+	return &pgconn.StatementDescription{Name: name, SQL: sql}, nil
+}
+
+func TestPrepared(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockPreparer{}
+	mock.rows = []mockRow{{values: []interface{}{1, "John", "john@example.com"}}}
+
+	type TestUser struct {
+		ID    int    `db:"id"`
+		Name  string `db:"name"`
+		Email string `db:"email"`
+	}
+
+	sql := "SELECT id, name, email FROM users WHERE id = $1"
+	var users []TestUser
+	if err := QueryStructs(ctx, mock, sql, &users, 1, Prepared("user_by_id")); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	if mock.lastSQL != "user_by_id" {
+		t.Errorf("sent %q, want statement name", mock.lastSQL)
+	}
+	if mock.prepared["user_by_id"] != sql {
+		t.Errorf("prepared %q, want %q", mock.prepared["user_by_id"], sql)
+	}
+	if len(mock.lastArgs) != 1 || mock.lastArgs[0] != 1 {
+		t.Errorf("args = %v, want [1]", mock.lastArgs)
+	}
+	if len(users) != 1 {
+		t.Errorf("got %d users, want 1", len(users))
+	}
+
+	if _, err := Exec(ctx, mock, "DELETE FROM users WHERE id = $1", 1, Prepared("delete_user")); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if mock.lastSQL != "delete_user" {
+		t.Errorf("sent %q, want statement name", mock.lastSQL)
+	}
+
+	mock.prepareErr = errors.New("syntax error")
+	err := QueryStructs(ctx, mock, "SELEC 1", &users, Prepared("broken"))
+	if err == nil || !strings.Contains(err.Error(), "prepare broken failed") {
+		t.Errorf("expected prepare error, got %v", err)
+	}
+}
+
+func TestPreparedWithoutPreparer(t *testing.T) {
+	mock := &mockQueryer{}
+
+	sql := "DELETE FROM users WHERE id = $1"
+	if _, err := Exec(context.Background(), mock, sql, 1, Prepared("delete_user")); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if mock.lastSQL != sql {
+		t.Errorf("sent %q, want the SQL text", mock.lastSQL)
+	}
+
+	useCommentTags(t, CommentTag{Key: "app", Value: func(context.Context) string { return "billing" }})
+	if _, err := Exec(context.Background(), mock, sql, 1, Prepared("delete_user")); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if want := sql + "\n/* app=billing */"; mock.lastSQL != want {
+		t.Errorf("sent %q, want the SQL text with its comment %q", mock.lastSQL, want)
+	}
+}
+
+func TestPrepareAll(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockPreparer{}
+
+	statements := map[string]string{
+		"user_by_id":  "SELECT * FROM users WHERE id = $1",
+		"delete_user": "DELETE FROM users WHERE id = $1",
+	}
+	if err := PrepareAll(ctx, mock, statements); err != nil {
+		t.Fatalf("PrepareAll failed: %v", err)
+	}
+	for name, sql := range statements {
+		if mock.prepared[name] != sql {
+			t.Errorf("prepared %s = %q, want %q", name, mock.prepared[name], sql)
+		}
+	}
+
+	if err := PrepareAll(ctx, &mockQueryer{}, statements); err == nil {
+		t.Error("expected error for a DB that can't prepare statements")
+	}
+}
+
+// mockPool hands out conn for each statement prepared on it, counting the
+// connections released.
+type mockPool struct {
+	mockQueryer
+	conn     *mockPreparer
+	released int
+}
+
+func (p *mockPool) acquire(ctx context.Context) (DB, Preparer, func(), error) {
+	return p.conn, p.conn, func() { p.released++ }, nil
+}
+
+func TestPreparedOnPool(t *testing.T) {
+	ctx := context.Background()
+	pool := &mockPool{conn: &mockPreparer{}}
+	pool.conn.rows = []mockRow{{values: []interface{}{1, "John", "john@example.com"}}}
+
+	sql := "SELECT id, name, email FROM users WHERE id = $1"
+	rows, err := QueryMaps(ctx, pool, sql, 1, Prepared("user_by_id"))
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Errorf("got %d rows, want 1", len(rows))
+	}
+	if pool.conn.lastSQL != "user_by_id" || pool.conn.prepared["user_by_id"] != sql {
+		t.Errorf("expected the statement prepared and run by name on a pool connection, sent %q", pool.conn.lastSQL)
+	}
+	if pool.lastSQL != "" {
+		t.Errorf("expected nothing sent to the pool itself, sent %q", pool.lastSQL)
+	}
+	if pool.released != 1 {
+		t.Errorf("released %d connections, want 1", pool.released)
+	}
+
+	if _, err := Exec(ctx, pool, "DELETE FROM users WHERE id = $1", 1, Prepared("delete_user")); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if pool.conn.lastSQL != "delete_user" || pool.released != 2 {
+		t.Errorf("sent %q and released %d connections, want delete_user and 2", pool.conn.lastSQL, pool.released)
+	}
+
+	pool.conn.prepareErr = errors.New("syntax error")
+	if _, err := Exec(ctx, pool, "DELET", Prepared("broken")); err == nil || pool.released != 3 {
+		t.Errorf("expected a prepare error with the connection released, got %v and %d releases", err, pool.released)
+	}
+}
@@ -0,0 +1,197 @@
+package dbx
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Queries is a registry of named SQL statements loaded by LoadQueries. It
+// is not modified after loading, so it can be shared between goroutines.
+type Queries struct {
+	sql map[string]string
+}
+
+// LoadQueries reads the .sql files in dir, which is not searched
+// recursively, and returns the statements they contain. Each statement is
+// introduced by a line naming it, in the style of dotsql:
+//
+//	-- name: GetActiveUsers
+//	SELECT * FROM users WHERE active
+//
+// A statement runs until the next name line or the end of the file.
+// Anything other than blank lines and comments before the first name line is
+// an error, as is a name used twice, even in different files. fsys is
+// typically an embed.FS:
+//
+//	//go:embed sql/*.sql
+//	var sqlFiles embed.FS
+//
+//	queries, err := dbx.LoadQueries(sqlFiles, "sql")
+func LoadQueries(fsys fs.FS, dir string) (*Queries, error) {
+	files, err := fs.Glob(fsys, path.Join(path.Clean(dir), "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .sql files in %s", dir)
+	}
+
+	q := &Queries{sql: make(map[string]string)}
+	origin := make(map[string]string)
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		statements, err := parseQueries(file, string(data))
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range statements {
+			if prev, ok := origin[s.name]; ok {
+				return nil, fmt.Errorf("query %q is defined in both %s and %s", s.name, prev, file)
+			}
+			origin[s.name] = file
+			q.sql[s.name] = s.sql
+		}
+	}
+	return q, nil
+}
+
+// namedQuery is a statement read from a .sql file.
+type namedQuery struct {
+	name, sql string
+}
+
+// parseQueries splits the contents of a .sql file into its named
+// statements. file is used in error messages.
+func parseQueries(file, data string) ([]namedQuery, error) {
+	var (
+		statements []namedQuery
+		body       strings.Builder
+		name       string
+		nameLine   int
+	)
+	finish := func() error {
+		if name == "" {
+			return nil
+		}
+		sql := strings.TrimSpace(body.String())
+		if sql == "" {
+			return fmt.Errorf("%s:%d: query %q is empty", file, nameLine, name)
+		}
+		for _, s := range statements {
+			if s.name == name {
+				return fmt.Errorf("%s:%d: query %q is defined twice", file, nameLine, name)
+			}
+		}
+		statements = append(statements, namedQuery{name: name, sql: sql})
+		body.Reset()
+		return nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if comment, ok := strings.CutPrefix(trimmed, "--"); ok {
+			if queryName, ok := strings.CutPrefix(strings.TrimSpace(comment), "name:"); ok {
+				if err := finish(); err != nil {
+					return nil, err
+				}
+				name, nameLine = strings.TrimSpace(queryName), n
+				if name == "" {
+					return nil, fmt.Errorf("%s:%d: missing query name", file, n)
+				}
+				continue
+			}
+		}
+
+		if name == "" {
+			if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return nil, fmt.Errorf("%s:%d: SQL before the first -- name: line", file, n)
+			}
+			continue
+		}
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// SQL returns the statement with the given name.
+func (q *Queries) SQL(name string) (string, error) {
+	sql, ok := q.sql[name]
+	if !ok {
+		return "", fmt.Errorf("unknown query %q; known queries: %s", name, strings.Join(q.Names(), ", "))
+	}
+	return sql, nil
+}
+
+// Names returns the names of the loaded statements in sorted order.
+func (q *Queries) Names() []string {
+	names := make([]string, 0, len(q.sql))
+	for name := range q.sql {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Maps runs the named statement as QueryMaps does.
+func (q *Queries) Maps(ctx context.Context, db DB, name string, args ...any) ([]RowMap, error) {
+	sql, err := q.SQL(name)
+	if err != nil {
+		return nil, err
+	}
+	opts, args := splitArgs(args)
+	return queryMaps(ctx, db, "Queries.Maps", sql, opts, args, mapValue)
+}
+
+// Structs runs the named statement as QueryStructs does.
+func (q *Queries) Structs(ctx context.Context, db DB, name string, dest any, args ...any) error {
+	sql, err := q.SQL(name)
+	if err != nil {
+		return err
+	}
+	opts, args := splitArgs(args)
+	return queryStructs(ctx, db, "Queries.Structs", sql, dest, opts, args)
+}
+
+// Struct runs the named statement as QueryStruct does.
+func (q *Queries) Struct(ctx context.Context, db DB, name string, dest any, args ...any) error {
+	sql, err := q.SQL(name)
+	if err != nil {
+		return err
+	}
+	opts, args := splitArgs(args)
+	return queryStruct(ctx, db, "Queries.Struct", sql, dest, opts, args)
+}
+
+// Exec runs the named statement as Exec does.
+func (q *Queries) Exec(ctx context.Context, db DB, name string, args ...any) (int64, error) {
+	sql, err := q.SQL(name)
+	if err != nil {
+		return 0, err
+	}
+	opts, args := splitArgs(args)
+
+	tag, err := exec(ctx, db, "Queries.Exec", sql, args, opts)
+	if err != nil {
+		return 0, fmt.Errorf("exec failed: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,98 @@
+package dbx
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var testQueryFiles = fstest.MapFS{
+	"sql/users.sql": {Data: []byte(`-- Queries on users
+
+-- name: GetActiveUsers
+-- Users who logged in recently
+SELECT id, name, email
+FROM users
+WHERE active = $1;
+
+--name:DeactivateUser
+UPDATE users SET active = false WHERE id = $1;
+`)},
+	"sql/notes.txt":          {Data: []byte("not SQL")},
+	"sql/reports/totals.sql": {Data: []byte("-- name: Nested\nSELECT 1")},
+}
+
+func TestLoadQueries(t *testing.T) {
+	q, err := LoadQueries(testQueryFiles, "sql/")
+	if err != nil {
+		t.Fatalf("LoadQueries failed: %v", err)
+	}
+
+	if names := strings.Join(q.Names(), ","); names != "DeactivateUser,GetActiveUsers" {
+		t.Errorf("Names() = %s", names)
+	}
+	sql, err := q.SQL("GetActiveUsers")
+	if err != nil {
+		t.Fatalf("SQL failed: %v", err)
+	}
+	if want := "-- Users who logged in recently\nSELECT id, name, email\nFROM users\nWHERE active = $1;"; sql != want {
+		t.Errorf("SQL = %q, want %q", sql, want)
+	}
+
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{{values: []interface{}{1, "John", "john@example.com"}}},
+	}
+	type User struct {
+		ID    int    `db:"id"`
+		Name  string `db:"name"`
+		Email string `db:"email"`
+	}
+	var users []User
+	if err := q.Structs(ctx, mock, "GetActiveUsers", &users, true); err != nil {
+		t.Fatalf("Structs failed: %v", err)
+	}
+	if len(users) != 1 || users[0].Name != "John" || mock.lastSQL != sql {
+		t.Errorf("users = %+v, sent %q", users, mock.lastSQL)
+	}
+
+	if _, err := q.Exec(ctx, mock, "DeactivateUser", 1); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if mock.lastSQL != "UPDATE users SET active = false WHERE id = $1;" {
+		t.Errorf("sent %q", mock.lastSQL)
+	}
+
+	_, err = q.Maps(ctx, mock, "GetUsers")
+	if err == nil || !strings.Contains(err.Error(), `unknown query "GetUsers"; known queries: DeactivateUser, GetActiveUsers`) {
+		t.Errorf("expected unknown query error listing names, got %v", err)
+	}
+}
+
+func TestLoadQueriesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  string
+	}{
+		{"no files", fstest.MapFS{"sql/a.txt": {}}, "no .sql files"},
+		{"SQL before name", fstest.MapFS{"sql/a.sql": {Data: []byte("SELECT 1\n-- name: A\nSELECT 2")}}, "a.sql:1: SQL before the first"},
+		{"empty query", fstest.MapFS{"sql/a.sql": {Data: []byte("-- name: A\n\n-- name: B\nSELECT 1")}}, `a.sql:1: query "A" is empty`},
+		{"missing name", fstest.MapFS{"sql/a.sql": {Data: []byte("-- name:\nSELECT 1")}}, "missing query name"},
+		{"duplicate in file", fstest.MapFS{"sql/a.sql": {Data: []byte("-- name: A\nSELECT 1\n-- name: A\nSELECT 2")}}, `a.sql:3: query "A" is defined twice`},
+		{"duplicate across files", fstest.MapFS{
+			"sql/a.sql": {Data: []byte("-- name: A\nSELECT 1")},
+			"sql/b.sql": {Data: []byte("-- name: A\nSELECT 2")},
+		}, `query "A" is defined in both sql/a.sql and sql/b.sql`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadQueries(tt.files, "sql")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
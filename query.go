@@ -0,0 +1,170 @@
+package dbx
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// query runs sql through db.Query on behalf of the helper named by op,
+// applying opts, which may be nil for the package defaults. The returned
+// rows report the call to the hooks, metrics, and logger once they are
+// fully read or closed, so the reported duration and row count cover the
+// whole result set. Errors from the database, including those reported by
+// the rows' Err method, are wrapped in a *QueryError.
+func query(ctx context.Context, db DB, op, sql string, args []any, opts *options) (pgx.Rows, error) {
+	if opts == nil {
+		opts = defaultOptions.Load()
+	}
+	hooks, ctx := hooksBefore(ctx, sql, args)
+	queryCtx, cancel := withTimeout(ctx, opts)
+
+	start := time.Now()
+	statement, conn, release, err := prepare(queryCtx, db, sql, opts)
+	var rows pgx.Rows
+	if err == nil {
+		rows, err = conn.Query(queryCtx, statement, args...)
+	}
+	if err != nil {
+		if release != nil {
+			release()
+		}
+		cancel()
+		err = timeoutError(ctx, queryCtx, opts.timeout, err)
+		duration := time.Since(start)
+		hooks.after(ctx, sql, args, err, duration)
+		observeQuery(ctx, sql, duration, err)
+		logQuery(op, sql, args, duration, 0, err)
+		return nil, newQueryError(op, sql, args, err)
+	}
+
+	tracked := &trackedRows{
+		Rows: rows,
+		wrap: func(err error) error {
+			return newQueryError(op, sql, args, timeoutError(ctx, queryCtx, opts.timeout, err))
+		},
+		done: func(rowCount int64, dbTime time.Duration, err error) {
+			if release != nil {
+				release()
+			}
+			cancel()
+			err = timeoutError(ctx, queryCtx, opts.timeout, err)
+			duration := time.Since(start)
+			hooks.after(ctx, sql, args, err, duration)
+			observeQuery(ctx, sql, duration, err)
+			logQuery(op, sql, args, duration, rowCount, err)
+			if err == nil {
+				logSlowQuery(op, sql, dbTime, rowCount)
+			}
+		},
+	}
+	if slowQueryLogging() {
+		tracked.timed = true
+		tracked.dbTime = time.Since(start)
+	}
+	return tracked, nil
+}
+
+// exec runs sql through db.Exec on behalf of the helper named by op,
+// applying opts as query does, and reports the call to the hooks,
+// metrics, and logger. Errors are wrapped in a *QueryError.
+func exec(ctx context.Context, db DB, op, sql string, args []any, opts *options) (pgconn.CommandTag, error) {
+	if opts == nil {
+		opts = defaultOptions.Load()
+	}
+	hooks, ctx := hooksBefore(ctx, sql, args)
+	execCtx, cancel := withTimeout(ctx, opts)
+	defer cancel()
+
+	start := time.Now()
+	statement, conn, release, err := prepare(execCtx, db, sql, opts)
+	var tag pgconn.CommandTag
+	if err == nil {
+		tag, err = conn.Exec(execCtx, statement, args...)
+	}
+	if release != nil {
+		release()
+	}
+	err = timeoutError(ctx, execCtx, opts.timeout, err)
+	duration := time.Since(start)
+
+	hooks.after(ctx, sql, args, err, duration)
+	observeQuery(ctx, sql, duration, err)
+	logQuery(op, sql, args, duration, tag.RowsAffected(), err)
+	if err != nil {
+		return tag, newQueryError(op, sql, args, err)
+	}
+	logSlowQuery(op, sql, duration, tag.RowsAffected())
+	return tag, nil
+}
+
+// logQuery reports a completed statement to the package-level logger.
+func logQuery(op, sql string, args []any, duration time.Duration, rowCount int64, err error) {
+	if err != nil {
+		getLogger().Errorf("[dbx] %s failed after %s: %s (args=%d): %v", op, duration, sql, len(args), err)
+		return
+	}
+	getLogger().Debugf("[dbx] %s: %s (args=%d rows=%d duration=%s)", op, sql, len(args), rowCount, duration)
+}
+
+// trackedRows wraps pgx.Rows to count rows and invoke done exactly once,
+// when iteration finishes or the rows are closed, whichever comes first.
+// Errors returned by Err are passed through wrap. When timed is set, the
+// time spent fetching rows, but not the caller's work between them, is
+// added to dbTime.
+type trackedRows struct {
+	pgx.Rows
+	wrap     func(err error) error
+	done     func(rowCount int64, dbTime time.Duration, err error)
+	rowCount int64
+	timed    bool
+	dbTime   time.Duration
+	finished bool
+}
+
+func (r *trackedRows) Next() bool {
+	var start time.Time
+	if r.timed {
+		start = time.Now()
+	}
+	next := r.Rows.Next()
+	if r.timed {
+		r.dbTime += time.Since(start)
+	}
+
+	if next {
+		r.rowCount++
+		return true
+	}
+	r.finish()
+	return false
+}
+
+func (r *trackedRows) Err() error {
+	if err := r.Rows.Err(); err != nil {
+		return r.wrap(err)
+	}
+	return nil
+}
+
+func (r *trackedRows) Close() {
+	var start time.Time
+	if r.timed && !r.finished {
+		start = time.Now()
+	}
+	r.Rows.Close()
+	if !start.IsZero() {
+		r.dbTime += time.Since(start)
+	}
+	r.finish()
+}
+
+func (r *trackedRows) finish() {
+	if r.finished {
+		return
+	}
+	r.finished = true
+	r.done(r.rowCount, r.dbTime, r.Rows.Err())
+}
@@ -0,0 +1,261 @@
+package dbx
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Range is a range column such as int4range, numrange, or tstzrange, with
+// endpoints of type T. A nil Lower or Upper is unbounded on that side, as
+// in '[2024-01-01,)', and the inclusive flags give the bracket on each side.
+// Empty is set for the empty range, which has no endpoints. Use *Range[T]
+// for nullable columns.
+//
+// Struct mapping fills Range fields from range columns, converting the
+// endpoints with the same rules as other fields, so an int4range maps into
+// a Range[int] and a tstzrange into a Range[time.Time]. Multirange columns
+// map into []Range[T]. Range implements pgtype.RangeValuer, so InsertStruct
+// and friends send it as a range.
+type Range[T any] struct {
+	Lower          *T
+	Upper          *T
+	LowerInclusive bool
+	UpperInclusive bool
+	Empty          bool
+}
+
+// IsNull implements pgtype.RangeValuer. A Range is never NULL; use a nil
+// *Range[T] for that.
+func (r Range[T]) IsNull() bool {
+	return false
+}
+
+// BoundTypes implements pgtype.RangeValuer.
+func (r Range[T]) BoundTypes() (lower, upper pgtype.BoundType) {
+	if r.Empty {
+		return pgtype.Empty, pgtype.Empty
+	}
+	return boundType(r.Lower != nil, r.LowerInclusive), boundType(r.Upper != nil, r.UpperInclusive)
+}
+
+// Bounds implements pgtype.RangeValuer.
+func (r Range[T]) Bounds() (lower, upper any) {
+	if r.Lower != nil {
+		lower = *r.Lower
+	}
+	if r.Upper != nil {
+		upper = *r.Upper
+	}
+	return lower, upper
+}
+
+// MarshalJSON writes the range as an object with its endpoints and
+// brackets, such as {"lower":1,"upper":10,"bounds":"[)"}. Unbounded
+// endpoints are null, and the empty range has bounds "empty".
+func (r Range[T]) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Lower  *T     `json:"lower"`
+		Upper  *T     `json:"upper"`
+		Bounds string `json:"bounds"`
+	}{Bounds: "empty"}
+	if !r.Empty {
+		out.Lower, out.Upper = r.Lower, r.Upper
+		out.Bounds = rangeBrackets(r.LowerInclusive, r.UpperInclusive)
+	}
+	return json.Marshal(out)
+}
+
+// setRange fills r from a range value returned by the driver, converting
+// its endpoints with assignValue.
+func (r *Range[T]) setRange(src pgtype.Range[any], opts *options) error {
+	*r = Range[T]{}
+	if src.LowerType == pgtype.Empty {
+		r.Empty = true
+		return nil
+	}
+	endpoint := func(value any, bound pgtype.BoundType) (*T, error) {
+		if bound == pgtype.Unbounded {
+			return nil, nil
+		}
+		v := new(T)
+		if err := assignValue(reflect.ValueOf(v).Elem(), value, opts); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	var err error
+	if r.Lower, err = endpoint(src.Lower, src.LowerType); err != nil {
+		return err
+	}
+	if r.Upper, err = endpoint(src.Upper, src.UpperType); err != nil {
+		return err
+	}
+	r.LowerInclusive = src.LowerType == pgtype.Inclusive
+	r.UpperInclusive = src.UpperType == pgtype.Inclusive
+	return nil
+}
+
+// rangeSetter is implemented by pointers to Range types.
+type rangeSetter interface {
+	setRange(src pgtype.Range[any], opts *options) error
+}
+
+// assignRange sets a Range field from a range value. handled is false if
+// field is not a Range.
+func assignRange(field reflect.Value, src pgtype.Range[any], opts *options) (handled bool, err error) {
+	if !field.CanAddr() {
+		return false, nil
+	}
+	r, ok := field.Addr().Interface().(rangeSetter)
+	if !ok {
+		return false, nil
+	}
+	if !src.Valid {
+		field.Set(reflect.Zero(field.Type()))
+		return true, nil
+	}
+	return true, r.setRange(src, opts)
+}
+
+// assignEndpoints sets the fields tagged lower and upper for a range
+// column from its endpoints. NULL, empty, and unbounded endpoints leave
+// the zero value, and the brackets are dropped.
+func assignEndpoints(elem reflect.Value, e *endpointFields, value any, opts *options) error {
+	src, _ := value.(pgtype.Range[any])
+	if value != nil && !src.Valid {
+		return errNotConvertible
+	}
+	set := func(index []int, v any, bound pgtype.BoundType) error {
+		if index == nil {
+			return nil
+		}
+		if bound == pgtype.Unbounded || bound == pgtype.Empty {
+			v = nil
+		}
+		return assignValue(fieldByIndexAlloc(elem, index), v, opts)
+	}
+	if err := set(e.Lower, src.Lower, src.LowerType); err != nil {
+		return err
+	}
+	return set(e.Upper, src.Upper, src.UpperType)
+}
+
+// encodeEndpoints returns the range to send for the fields tagged lower and
+// upper, in the canonical '[)' form. A nil pointer leaves that side
+// unbounded.
+func encodeEndpoints(v reflect.Value, e *endpointFields) (any, error) {
+	r := pgtype.Range[any]{LowerType: pgtype.Unbounded, UpperType: pgtype.Unbounded, Valid: true}
+	side := func(index []int, bound pgtype.BoundType) (any, pgtype.BoundType, error) {
+		if index == nil {
+			return nil, pgtype.Unbounded, nil
+		}
+		field, err := v.FieldByIndexErr(index)
+		if err != nil {
+			return nil, pgtype.Unbounded, nil
+		}
+		value, err := encodeValue(field, nil)
+		if value == nil || err != nil {
+			return nil, pgtype.Unbounded, err
+		}
+		return value, bound, nil
+	}
+	var err error
+	if r.Lower, r.LowerType, err = side(e.Lower, pgtype.Inclusive); err != nil {
+		return nil, err
+	}
+	if r.Upper, r.UpperType, err = side(e.Upper, pgtype.Exclusive); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// outputRange converts a range value for RowMaps and JSON output, passing
+// the endpoints through convert.
+func outputRange(src pgtype.Range[any], opts *options, convert func(any, *options) any) any {
+	if !src.Valid {
+		return nil
+	}
+	r := Range[any]{Empty: src.LowerType == pgtype.Empty}
+	if !r.Empty {
+		if src.LowerType != pgtype.Unbounded {
+			lower := convert(src.Lower, opts)
+			r.Lower = &lower
+		}
+		if src.UpperType != pgtype.Unbounded {
+			upper := convert(src.Upper, opts)
+			r.Upper = &upper
+		}
+		r.LowerInclusive = src.LowerType == pgtype.Inclusive
+		r.UpperInclusive = src.UpperType == pgtype.Inclusive
+	}
+	return r
+}
+
+// outputMultirange converts a multirange value as outputRange does each of
+// its ranges.
+func outputMultirange(src pgtype.Multirange[pgtype.Range[any]], opts *options, convert func(any, *options) any) any {
+	out := make([]any, len(src))
+	for i, r := range src {
+		out[i] = outputRange(r, opts, convert)
+	}
+	return out
+}
+
+// formatRange renders a range in Postgres text form, such as [1,10), for
+// CSV output, formatting each endpoint with text.
+func formatRange(src pgtype.Range[any], text func(any) (string, error)) (string, error) {
+	if src.LowerType == pgtype.Empty {
+		return "empty", nil
+	}
+	brackets := rangeBrackets(src.LowerType == pgtype.Inclusive, src.UpperType == pgtype.Inclusive)
+	var b strings.Builder
+	b.WriteByte(brackets[0])
+	for i, side := range []struct {
+		value any
+		bound pgtype.BoundType
+	}{{src.Lower, src.LowerType}, {src.Upper, src.UpperType}} {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if side.bound == pgtype.Unbounded {
+			continue
+		}
+		s, err := text(side.value)
+		if err != nil {
+			return "", err
+		}
+		if strings.ContainsAny(s, `,()[]" \`) || s == "" {
+			s = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+		}
+		b.WriteString(s)
+	}
+	b.WriteByte(brackets[1])
+	return b.String(), nil
+}
+
+// rangeBrackets returns the brackets of a range with the given
+// inclusivity, such as "[)".
+func rangeBrackets(lowerInclusive, upperInclusive bool) string {
+	brackets := []byte("()")
+	if lowerInclusive {
+		brackets[0] = '['
+	}
+	if upperInclusive {
+		brackets[1] = ']'
+	}
+	return string(brackets)
+}
+
+// boundType returns the bound type of a range endpoint.
+func boundType(bounded, inclusive bool) pgtype.BoundType {
+	switch {
+	case !bounded:
+		return pgtype.Unbounded
+	case inclusive:
+		return pgtype.Inclusive
+	}
+	return pgtype.Exclusive
+}
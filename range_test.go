@@ -0,0 +1,202 @@
+package dbx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func intRange(lower, upper any, lowerType, upperType pgtype.BoundType) pgtype.Range[any] {
+	return pgtype.Range[any]{Lower: lower, Upper: upper, LowerType: lowerType, UpperType: upperType, Valid: true}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestQueryStructsRange(t *testing.T) {
+	type Booking struct {
+		Seats   Range[int]        `db:"seats"`
+		Period  *Range[time.Time] `db:"period"`
+		Price   Range[string]     `db:"price"`
+		Open    Range[int64]      `db:"open"`
+		Nothing Range[int]        `db:"nothing"`
+		Slots   []Range[int]      `db:"slots"`
+		Missing *Range[time.Time] `db:"missing"`
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	mock := &mockQueryer{
+		fields: mockFields("seats", "period", "price", "open", "nothing", "slots", "missing"),
+		rows: []mockRow{{values: []interface{}{
+			intRange(int32(1), int32(10), pgtype.Inclusive, pgtype.Exclusive),
+			intRange(start, end, pgtype.Inclusive, pgtype.Inclusive),
+			intRange(numeric(1050, -2), nil, pgtype.Exclusive, pgtype.Unbounded),
+			intRange(nil, int64(5), pgtype.Unbounded, pgtype.Exclusive),
+			intRange(nil, nil, pgtype.Empty, pgtype.Empty),
+			pgtype.Multirange[pgtype.Range[any]]{
+				intRange(int32(1), int32(3), pgtype.Inclusive, pgtype.Exclusive),
+				intRange(int32(5), int32(7), pgtype.Inclusive, pgtype.Exclusive),
+			},
+			nil,
+		}}},
+	}
+
+	var bookings []Booking
+	if err := QueryStructs(context.Background(), mock, "SELECT * FROM bookings", &bookings); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	got := bookings[0]
+	if want := (Range[int]{Lower: ptr(1), Upper: ptr(10), LowerInclusive: true}); !reflect.DeepEqual(got.Seats, want) {
+		t.Errorf("Expected seats %+v, got %+v", want, got.Seats)
+	}
+	if got.Period == nil || !got.Period.Lower.Equal(start) || !got.Period.Upper.Equal(end) || !got.Period.UpperInclusive {
+		t.Errorf("Unexpected period: %+v", got.Period)
+	}
+	if got.Price.Lower == nil || *got.Price.Lower != "10.50" || got.Price.Upper != nil || got.Price.LowerInclusive {
+		t.Errorf("Unexpected price: %+v", got.Price)
+	}
+	if got.Open.Lower != nil || got.Open.Upper == nil || *got.Open.Upper != 5 {
+		t.Errorf("Unexpected open range: %+v", got.Open)
+	}
+	if !got.Nothing.Empty || got.Nothing.Lower != nil {
+		t.Errorf("Expected an empty range, got %+v", got.Nothing)
+	}
+	if len(got.Slots) != 2 || *got.Slots[1].Lower != 5 || *got.Slots[1].Upper != 7 {
+		t.Errorf("Unexpected slots: %+v", got.Slots)
+	}
+	if got.Missing != nil {
+		t.Errorf("Expected NULL to give nil, got %+v", got.Missing)
+	}
+}
+
+func TestQueryStructsRangeEndpoints(t *testing.T) {
+	type Promotion struct {
+		Name   string     `db:"name"`
+		Start  time.Time  `db:"period,lower"`
+		End    *time.Time `db:"period,upper"`
+		MinQty int        `db:"qty,lower"`
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	mock := &mockQueryer{
+		fields: mockFields("name", "period", "qty"),
+		rows: []mockRow{
+			{values: []interface{}{"january", intRange(start, end, pgtype.Inclusive, pgtype.Exclusive), intRange(int32(3), nil, pgtype.Inclusive, pgtype.Unbounded)}},
+			{values: []interface{}{"forever", intRange(start, nil, pgtype.Inclusive, pgtype.Unbounded), nil}},
+		},
+	}
+
+	var promotions []Promotion
+	if err := QueryStructs(context.Background(), mock, "SELECT * FROM promotions", &promotions); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if got := promotions[0]; !got.Start.Equal(start) || got.End == nil || !got.End.Equal(end) || got.MinQty != 3 {
+		t.Errorf("Unexpected endpoints: %+v", got)
+	}
+	if got := promotions[1]; !got.Start.Equal(start) || got.End != nil || got.MinQty != 0 {
+		t.Errorf("Expected unbounded and NULL ends to give zero values, got %+v", got)
+	}
+
+	mock.fields = mockFields("period")
+	mock.rows = []mockRow{{values: []interface{}{"not a range"}}}
+	var convErr *ConversionError
+	err := QueryStructs(context.Background(), mock, "SELECT period FROM promotions", &promotions)
+	if !errors.As(err, &convErr) || convErr.Field != "Start" {
+		t.Errorf("Expected a ConversionError for a non-range value, got %v", err)
+	}
+}
+
+func TestInsertStructRange(t *testing.T) {
+	mock := &mockQueryer{}
+	type Promotion struct {
+		Seats Range[int] `db:"seats"`
+		Start time.Time  `db:"period,lower"`
+		End   *time.Time `db:"period,upper"`
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	promo := Promotion{Seats: Range[int]{Lower: ptr(1), Upper: ptr(10), LowerInclusive: true}, Start: start}
+
+	if err := InsertStruct(context.Background(), mock, "promotions", promo); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if len(mock.lastArgs) != 2 {
+		t.Fatalf("Expected 2 args, got %#v", mock.lastArgs)
+	}
+	if _, ok := mock.lastArgs[0].(pgtype.RangeValuer); !ok {
+		t.Errorf("Expected the Range sent as a pgtype.RangeValuer, got %T", mock.lastArgs[0])
+	}
+	want := pgtype.Range[any]{Lower: start, LowerType: pgtype.Inclusive, UpperType: pgtype.Unbounded, Valid: true}
+	if !reflect.DeepEqual(mock.lastArgs[1], want) {
+		t.Errorf("Expected the endpoints sent as %+v, got %#v", want, mock.lastArgs[1])
+	}
+}
+
+func TestRangeJSON(t *testing.T) {
+	tests := []struct {
+		r    Range[int]
+		want string
+	}{
+		{Range[int]{Lower: ptr(1), Upper: ptr(10), LowerInclusive: true}, `{"lower":1,"upper":10,"bounds":"[)"}`},
+		{Range[int]{Upper: ptr(10), UpperInclusive: true}, `{"lower":null,"upper":10,"bounds":"(]"}`},
+		{Range[int]{Empty: true}, `{"lower":null,"upper":null,"bounds":"empty"}`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.r)
+		if err != nil || string(data) != tt.want {
+			t.Errorf("json.Marshal(%+v) = %s, %v; want %s", tt.r, data, err, tt.want)
+		}
+	}
+}
+
+func TestQueryJSONRange(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newMock := func() *mockQueryer {
+		fields := mockFields("seats", "period", "slots", "empty", "missing")
+		fields[1].DataTypeOID = pgtype.DaterangeOID
+		return &mockQueryer{
+			fields: fields,
+			rows: []mockRow{{values: []interface{}{
+				intRange(int32(1), int32(10), pgtype.Inclusive, pgtype.Exclusive),
+				intRange(start, nil, pgtype.Inclusive, pgtype.Unbounded),
+				pgtype.Multirange[pgtype.Range[any]]{intRange(numeric(15, -1), numeric(3, 0), pgtype.Exclusive, pgtype.Inclusive)},
+				intRange(nil, nil, pgtype.Empty, pgtype.Empty),
+				nil,
+			}}},
+		}
+	}
+
+	data, err := QueryJSON(context.Background(), newMock(), "SELECT * FROM bookings")
+	if err != nil {
+		t.Fatalf("QueryJSON failed: %v", err)
+	}
+	want := `[{"seats":{"lower":1,"upper":10,"bounds":"[)"},` +
+		`"period":{"lower":"2024-01-01T00:00:00Z","upper":null,"bounds":"[)"},` +
+		`"slots":[{"lower":1.5,"upper":3,"bounds":"(]"}],` +
+		`"empty":{"lower":null,"upper":null,"bounds":"empty"},"missing":null}]`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	rows, err := QueryMaps(context.Background(), newMock(), "SELECT * FROM bookings")
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if r, ok := rows[0]["seats"].(Range[any]); !ok || *r.Lower != int32(1) || !r.LowerInclusive {
+		t.Errorf("Expected a Range[any] in the RowMap, got %#v", rows[0]["seats"])
+	}
+
+	var buf bytes.Buffer
+	if err := QueryCSV(context.Background(), newMock(), &buf, "SELECT * FROM bookings"); err != nil {
+		t.Fatalf("QueryCSV failed: %v", err)
+	}
+	if want := "seats,period,slots,empty,missing\n\"[1,10)\",\"[2024-01-01,)\",\"{(1.5,3]}\",empty,\n"; buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
@@ -0,0 +1,125 @@
+package dbx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect names a database's bind parameter and identifier quoting style,
+// for Rebind.
+type Dialect string
+
+const (
+	Postgres  Dialect = "postgres"  // $1, $2, ...
+	MySQL     Dialect = "mysql"     // ?
+	SQLite    Dialect = "sqlite"    // ?
+	SQLServer Dialect = "sqlserver" // @p1, @p2, ...
+)
+
+// Rebind converts the $1, $2, ... placeholders in sql, as written for
+// Postgres and generated by dbx, to the style of dialect. Placeholders inside
+// string literals, dollar-quoted strings, quoted identifiers, and comments
+// are left alone. For MySQL, whose default sql_mode reads double-quoted
+// text as a string, double-quoted identifiers such as those dbx generates
+// are rewritten with backticks: "users" becomes `users`. SQL for Postgres
+// or an unknown dialect is returned unchanged.
+//
+// With ? placeholders the arguments must appear in the order the
+// placeholders do, once per use; RebindArgs reorders them to match.
+func Rebind(dialect Dialect, sql string) string {
+	sql, _ = rebind(dialect, sql)
+	return sql
+}
+
+// RebindArgs is like Rebind but also rearranges args to match the rebound
+// SQL. For ? placeholders, which are positional, each placeholder takes its
+// own copy of the argument it refers to, so $2, $1, $2 becomes ?, ?, ? with
+// args 2, 1, 2. It fails if a placeholder refers past the end of args, or
+// is $0.
+func RebindArgs(dialect Dialect, sql string, args []any) (string, []any, error) {
+	sql, order := rebind(dialect, sql)
+	if order == nil {
+		return sql, args, nil
+	}
+
+	rebound := make([]any, len(order))
+	for i, n := range order {
+		if n < 1 {
+			return "", nil, fmt.Errorf("invalid placeholder $%d: placeholders start at $1", n)
+		}
+		if n > len(args) {
+			return "", nil, fmt.Errorf("placeholder $%d has no argument; got %d", n, len(args))
+		}
+		rebound[i] = args[n-1]
+	}
+	return sql, rebound, nil
+}
+
+// rebind implements Rebind. For positional dialects it also returns the
+// number of the placeholder at each position; otherwise order is nil.
+func rebind(dialect Dialect, sql string) (string, []int) {
+	var positional bool
+	switch dialect {
+	case MySQL, SQLite:
+		positional = true
+	case SQLServer:
+	default:
+		return sql, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(sql) + 8)
+	order := []int{}
+
+	for i := 0; i < len(sql); {
+		if n := skipQuotedOrComment(sql, i); n > i {
+			if dialect == MySQL && sql[i] == '"' {
+				b.WriteString(backtickIdent(sql[i:n]))
+			} else {
+				b.WriteString(sql[i:n])
+			}
+			i = n
+			continue
+		}
+
+		if sql[i] == '$' {
+			j := i + 1
+			for j < len(sql) && sql[j] >= '0' && sql[j] <= '9' {
+				j++
+			}
+			if j > i+1 {
+				n, _ := strconv.Atoi(sql[i+1 : j])
+				if positional {
+					b.WriteByte('?')
+					order = append(order, n)
+				} else {
+					b.WriteString("@p")
+					b.WriteString(sql[i+1 : j])
+				}
+				i = j
+				continue
+			}
+		}
+
+		b.WriteByte(sql[i])
+		i++
+	}
+
+	if !positional {
+		return b.String(), nil
+	}
+	return b.String(), order
+}
+
+// backtickIdent rewrites the double-quoted identifier quoted with MySQL's
+// backticks, doubling any embedded backticks. An unterminated identifier
+// is returned unchanged.
+func backtickIdent(quoted string) string {
+	end := closingQuote(quoted)
+	if end < 0 {
+		return quoted
+	}
+	name := strings.ReplaceAll(quoted[1:end], `""`, `"`)
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,70 @@
+package dbx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		sql     string
+		want    string
+	}{
+		{Postgres, "id = $1 AND name = $2", "id = $1 AND name = $2"},
+		{MySQL, "id = $1 AND name = $2", "id = ? AND name = ?"},
+		{SQLite, "id = $1", "id = ?"},
+		{SQLServer, "id = $1 AND name = $12", "id = @p1 AND name = @p12"},
+		{MySQL, "name = '$1' AND id = $1", "name = '$1' AND id = ?"},
+		{MySQL, "x = $$ $1 $$ AND id = $1::int", "x = $$ $1 $$ AND id = ?::int"},
+		{MySQL, "id = $1 -- $2\nAND `a` = $2", "id = ? -- $2\nAND `a` = ?"},
+		{MySQL, "price > $", "price > $"},
+		{MySQL, "SELECT \"Order\".\"a\"\"b\" FROM \"x`y\" WHERE id = $1", "SELECT `Order`.`a\"b` FROM `x``y` WHERE id = ?"},
+		{SQLite, `SELECT "name" FROM "users"`, `SELECT "name" FROM "users"`},
+		{"oracle", "id = $1", "id = $1"},
+	}
+
+	for _, tt := range tests {
+		if got := Rebind(tt.dialect, tt.sql); got != tt.want {
+			t.Errorf("Rebind(%s, %q) = %q, want %q", tt.dialect, tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestRebindArgs(t *testing.T) {
+	sql, args, err := RebindArgs(MySQL, "a = $2 OR b = $1 OR c = $2", []any{"one", "two"})
+	if err != nil {
+		t.Fatalf("RebindArgs failed: %v", err)
+	}
+	if sql != "a = ? OR b = ? OR c = ?" {
+		t.Errorf("Unexpected SQL: %s", sql)
+	}
+	if !reflect.DeepEqual(args, []any{"two", "one", "two"}) {
+		t.Errorf("Unexpected args: %v", args)
+	}
+
+	sql, args, err = RebindArgs(SQLServer, "a = $2 OR b = $1", []any{"one", "two"})
+	if err != nil {
+		t.Fatalf("RebindArgs failed: %v", err)
+	}
+	if sql != "a = @p2 OR b = @p1" || !reflect.DeepEqual(args, []any{"one", "two"}) {
+		t.Errorf("Unexpected result: %s %v", sql, args)
+	}
+
+	if _, _, err := RebindArgs(MySQL, "a = $3", []any{"one"}); err == nil {
+		t.Error("Expected error for missing argument")
+	}
+	if _, _, err := RebindArgs(MySQL, "a = $0", []any{"one"}); err == nil {
+		t.Error("Expected error for $0")
+	}
+}
+
+func TestRebindInsertStruct(t *testing.T) {
+	sql, _, err := buildInsertStruct(nil, "users", insertUser{Name: "John", Email: "john@example.com"})
+	if err != nil {
+		t.Fatalf("buildInsertStruct failed: %v", err)
+	}
+	if got := Rebind(MySQL, sql); got != "INSERT INTO `users` (`name`, `email`) VALUES (?, ?)" {
+		t.Errorf("Unexpected SQL: %s", got)
+	}
+}
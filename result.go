@@ -0,0 +1,77 @@
+package dbx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Result holds the rows of a query with its columns in the order the query
+// selected them, which a RowMap loses. It suits CSV export, generic table
+// views, and other output where column order matters.
+type Result struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// QueryRows executes a query and returns its column names and rows in
+// query order. Values are converted as by QueryMaps.
+func QueryRows(ctx context.Context, db DB, sql string, args ...any) (*Result, error) {
+	opts, args := splitArgs(args)
+
+	rows, err := query(ctx, db, "QueryRows", sql, args, opts)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	fieldDescs := rows.FieldDescriptions()
+	result := &Result{Columns: make([]string, len(fieldDescs)), Rows: [][]any{}}
+	for i, fd := range fieldDescs {
+		result.Columns[i] = fd.Name
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get row values: %w", err)
+		}
+		row := make([]any, len(values))
+		for i, v := range values {
+			row[i] = mapValue(inetHost(v, fieldDescs[i].DataTypeOID), opts)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return result, nil
+}
+
+// Maps returns the rows as RowMaps, as QueryMaps would have, including its
+// renaming of duplicate column names.
+func (r *Result) Maps() []RowMap {
+	columns := uniqueColumns(r.Columns)
+	maps := make([]RowMap, len(r.Rows))
+	for i, values := range r.Rows {
+		row := make(RowMap, len(values))
+		for j, v := range values {
+			row[columns[j]] = v
+		}
+		maps[i] = row
+	}
+	return maps
+}
+
+// MarshalJSON renders the rows as a JSON array of objects whose keys follow
+// the column order, with values formatted as by QueryJSON.
+func (r *Result) MarshalJSON() ([]byte, error) {
+	opts := defaults()
+	columns := uniqueColumns(r.Columns)
+	objects := make([]jsonObject, len(r.Rows))
+	for i, values := range r.Rows {
+		objects[i] = jsonRow(columns, values, opts)
+	}
+	return json.Marshal(objects)
+}
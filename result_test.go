@@ -0,0 +1,74 @@
+package dbx
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestQueryRows(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("name", "id", "amount"),
+		rows: []mockRow{
+			{values: []interface{}{"John", 1, numeric(1050, -2)}},
+			{values: []interface{}{"Jane", 2, nil}},
+		},
+	}
+
+	result, err := QueryRows(ctx, mock, "SELECT name, id, amount FROM users")
+	if err != nil {
+		t.Fatalf("QueryRows failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(result.Columns, []string{"name", "id", "amount"}) {
+		t.Errorf("Unexpected columns: %v", result.Columns)
+	}
+	expected := [][]any{{"John", 1, 10.5}, {"Jane", 2, nil}}
+	if !reflect.DeepEqual(result.Rows, expected) {
+		t.Errorf("Expected %v, got %v", expected, result.Rows)
+	}
+
+	maps := result.Maps()
+	if maps[1]["name"] != "Jane" || maps[0]["amount"] != 10.5 {
+		t.Errorf("Unexpected maps: %v", maps)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if want := `[{"name":"John","id":1,"amount":10.5},{"name":"Jane","id":2,"amount":null}]`; string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+}
+
+func TestQueryRowsEmpty(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{fields: mockFields("id", "name")}
+
+	result, err := QueryRows(ctx, mock, "SELECT id, name FROM users WHERE false")
+	if err != nil {
+		t.Fatalf("QueryRows failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Columns, []string{"id", "name"}) || len(result.Rows) != 0 {
+		t.Errorf("Expected columns without rows, got %+v", result)
+	}
+}
+
+func TestQueryJSONColumnOrder(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("zeta", "alpha", "mid"),
+		rows:   []mockRow{{values: []interface{}{1, 2, 3}}},
+	}
+
+	data, err := QueryJSON(ctx, mock, "SELECT zeta, alpha, mid FROM t")
+	if err != nil {
+		t.Fatalf("QueryJSON failed: %v", err)
+	}
+	if want := `[{"zeta":1,"alpha":2,"mid":3}]`; string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+}
@@ -0,0 +1,171 @@
+package dbx
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// RowValue converts the value of column key in row to T using the same rules
+// as struct mapping, so an int32 or float64 column can be read as int and a
+// numeric as float64. Values normalized by NormalizeTypes convert as the
+// originals do, and an inet or macaddr can be read as a string either way.
+// A NULL gives the zero value of T, or nil if T is a pointer. The error
+// names the column, and for values that cannot be converted, their type.
+func RowValue[T any](row RowMap, key string) (T, error) {
+	var result T
+	value, ok := row[key]
+	if !ok {
+		return result, fmt.Errorf("column %q not in row", key)
+	}
+	dest := reflect.ValueOf(&result).Elem()
+	err := assignColumn(dest, value, 0, defaults())
+	if err != nil && dest.Kind() == reflect.String {
+		// Network addresses convert to strings by their text form
+		if text, ok := normalizeValue(value).(string); ok {
+			err = assignColumn(dest, text, 0, defaults())
+		}
+	}
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("column %q: %w", key, err)
+	}
+	return result, nil
+}
+
+// rowValue is RowValue reporting failure, including NULL, as false.
+func rowValue[T any](row RowMap, key string) (T, bool) {
+	var zero T
+	if row[key] == nil {
+		return zero, false
+	}
+	v, err := RowValue[T](row, key)
+	return v, err == nil
+}
+
+// String returns the value of column key as a string. It reports false if
+// the column is missing, NULL, or not convertible; see RowValue.
+func (r RowMap) String(key string) (string, bool) {
+	return rowValue[string](r, key)
+}
+
+// Int returns the value of column key as an int. It reports false if the
+// column is missing, NULL, or not convertible; see RowValue.
+func (r RowMap) Int(key string) (int, bool) {
+	return rowValue[int](r, key)
+}
+
+// Int64 returns the value of column key as an int64. It reports false if
+// the column is missing, NULL, or not convertible; see RowValue.
+func (r RowMap) Int64(key string) (int64, bool) {
+	return rowValue[int64](r, key)
+}
+
+// Float returns the value of column key as a float64. It reports false if
+// the column is missing, NULL, or not convertible; see RowValue.
+func (r RowMap) Float(key string) (float64, bool) {
+	return rowValue[float64](r, key)
+}
+
+// Bool returns the value of column key as a bool. It reports false if the
+// column is missing, NULL, or not convertible; see RowValue.
+func (r RowMap) Bool(key string) (bool, bool) {
+	return rowValue[bool](r, key)
+}
+
+// Time returns the value of column key as a time.Time. It reports false if
+// the column is missing, NULL, or not convertible; see RowValue.
+func (r RowMap) Time(key string) (time.Time, bool) {
+	return rowValue[time.Time](r, key)
+}
+
+// MapToStruct assigns the values of row to the fields of the struct dest
+// points to, matching keys to fields and converting values as QueryStruct
+// does for result columns: a field tagged db:"users.email" takes the key
+// "users.email" or "email", and a rest field collects the keys no field
+// takes. Fields without a key are left alone. A value that cannot be
+// converted gives a *ConversionError.
+//
+//	var user User
+//	err := dbx.MapToStruct(row, &user)
+func MapToStruct(row RowMap, dest any) error {
+	ptr := reflect.ValueOf(dest)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("dest must be a non-nil pointer to a struct, got %T", dest)
+	}
+	structType := ptr.Elem().Type()
+
+	keys := make([]string, 0, len(row))
+	for key := range row {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fieldDescs := make([]pgconn.FieldDescription, len(keys))
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		fieldDescs[i] = pgconn.FieldDescription{Name: key}
+		values[i] = row[key]
+	}
+
+	opts := defaults()
+	fieldMap, err := mapColumns(keys, structType, opts)
+	if err != nil {
+		return fmt.Errorf("failed to build field mapping: %w", err)
+	}
+	return newMappedScanner(fieldDescs, structType, keys, fieldMap, opts).scan(values, ptr.Elem())
+}
+
+// StructToMap returns the db-tagged fields of src, a struct or pointer to
+// one, as a RowMap keyed by column name, without any table prefix. Values
+// are as InsertStruct would send them, with pointers dereferenced: nil
+// pointers are nil, driver.Valuer fields hold the result of Value, and
+// fields tagged json hold their JSON encoding. The entries of a rest field are
+// added under their own keys. Fields of nested structs are left out, as
+// they belong to other tables.
+//
+//	before, _ := dbx.StructToMap(old)
+//	after, _ := dbx.StructToMap(user)
+func StructToMap(src any) (RowMap, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, fmt.Errorf("src must not be a nil pointer")
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("src must be a struct or pointer to struct, got %T", src)
+	}
+
+	meta := getStructMeta(v.Type(), defaults())
+	if meta.Err != nil {
+		return nil, meta.Err
+	}
+	row := make(RowMap, len(meta.Fields))
+	for _, f := range meta.Fields {
+		if f.Nested() {
+			continue
+		}
+		value, err := f.encode(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode field %s: %w", f.Name, err)
+		}
+		if pv := reflect.ValueOf(value); pv.Kind() == reflect.Pointer && !pv.IsNil() {
+			value = pv.Elem().Interface()
+		}
+		row[f.Column] = value
+	}
+	if meta.Rest != nil {
+		if rest, ok := v.FieldByIndexErr(meta.Rest); ok == nil {
+			for key, value := range rest.Convert(reflect.TypeOf(RowMap(nil))).Interface().(RowMap) {
+				if _, taken := row[key]; !taken {
+					row[key] = value
+				}
+			}
+		}
+	}
+	return row, nil
+}
@@ -0,0 +1,174 @@
+package dbx
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRowMapAccessors(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	row := RowMap{
+		"id":         int32(7),
+		"amount":     float64(12.5),
+		"active":     true,
+		"email":      "john@example.com",
+		"created_at": created,
+		"deleted_at": nil,
+	}
+
+	if v, ok := row.Int("id"); !ok || v != 7 {
+		t.Errorf("Int: got %v, %v", v, ok)
+	}
+	if v, ok := row.Int64("id"); !ok || v != 7 {
+		t.Errorf("Int64: got %v, %v", v, ok)
+	}
+	if v, ok := row.Float("amount"); !ok || v != 12.5 {
+		t.Errorf("Float: got %v, %v", v, ok)
+	}
+	if v, ok := row.Float("id"); !ok || v != 7 {
+		t.Errorf("Float from int32: got %v, %v", v, ok)
+	}
+	if v, ok := row.Bool("active"); !ok || !v {
+		t.Errorf("Bool: got %v, %v", v, ok)
+	}
+	if v, ok := row.String("email"); !ok || v != "john@example.com" {
+		t.Errorf("String: got %v, %v", v, ok)
+	}
+	if v, ok := row.Time("created_at"); !ok || !v.Equal(created) {
+		t.Errorf("Time: got %v, %v", v, ok)
+	}
+
+	if _, ok := row.Time("deleted_at"); ok {
+		t.Error("Expected NULL to report false")
+	}
+	if _, ok := row.String("missing"); ok {
+		t.Error("Expected missing column to report false")
+	}
+	if _, ok := row.Bool("email"); ok {
+		t.Error("Expected unconvertible value to report false")
+	}
+}
+
+func TestRowValue(t *testing.T) {
+	row := RowMap{"id": int64(7), "email": "john@example.com", "deleted_at": nil}
+
+	if v, err := RowValue[int](row, "id"); err != nil || v != 7 {
+		t.Errorf("Expected 7, got %v, %v", v, err)
+	}
+	if v, err := RowValue[*time.Time](row, "deleted_at"); err != nil || v != nil {
+		t.Errorf("Expected nil for NULL, got %v, %v", v, err)
+	}
+
+	_, err := RowValue[bool](row, "email")
+	if err == nil || !strings.Contains(err.Error(), `column "email"`) || !strings.Contains(err.Error(), "string") {
+		t.Errorf("Expected error naming the column and type, got %v", err)
+	}
+	if _, err := RowValue[string](row, "name"); err == nil || !strings.Contains(err.Error(), `"name"`) {
+		t.Errorf("Expected missing column error, got %v", err)
+	}
+}
+
+type mapStructAccount struct {
+	ID      int64      `db:"accounts.id,pk"`
+	Email   string     `db:"accounts.email"`
+	Plan    *string    `db:"plan"`
+	Balance float64    `db:"balance"`
+	Closed  *time.Time `db:"closed_at"`
+	Prefs   []string   `db:"prefs,json"`
+	Extra   RowMap     `db:",rest"`
+}
+
+func TestMapToStruct(t *testing.T) {
+	row := RowMap{
+		"accounts.id": int32(7),
+		"email":       "john@example.com",
+		"plan":        "pro",
+		"balance":     int64(12),
+		"closed_at":   nil,
+		"prefs":       `["dark"]`,
+		"region":      "eu",
+	}
+
+	closed := time.Now()
+	account := mapStructAccount{Closed: &closed}
+	if err := MapToStruct(row, &account); err != nil {
+		t.Fatalf("MapToStruct failed: %v", err)
+	}
+	if account.ID != 7 || account.Email != "john@example.com" || account.Balance != 12 {
+		t.Errorf("Unexpected fields: %+v", account)
+	}
+	if account.Plan == nil || *account.Plan != "pro" {
+		t.Errorf("Expected plan pro, got %v", account.Plan)
+	}
+	if account.Closed != nil {
+		t.Errorf("Expected NULL to reset closed_at, got %v", account.Closed)
+	}
+	if len(account.Prefs) != 1 || account.Prefs[0] != "dark" {
+		t.Errorf("Expected prefs decoded from JSON, got %v", account.Prefs)
+	}
+	if len(account.Extra) != 1 || account.Extra["region"] != "eu" {
+		t.Errorf("Expected the rest field to hold region, got %v", account.Extra)
+	}
+
+	var convErr *ConversionError
+	err := MapToStruct(RowMap{"balance": "lots"}, &account)
+	if !errors.As(err, &convErr) || convErr.Column != "balance" || convErr.Field != "Balance" {
+		t.Errorf("Expected a ConversionError for balance, got %v", err)
+	}
+
+	if err := MapToStruct(row, account); err == nil {
+		t.Error("Expected an error for a non-pointer dest")
+	}
+}
+
+func TestStructToMap(t *testing.T) {
+	plan := "pro"
+	account := &mapStructAccount{
+		ID:      7,
+		Email:   "john@example.com",
+		Plan:    &plan,
+		Balance: 12.5,
+		Prefs:   []string{"dark"},
+		Extra:   RowMap{"region": "eu", "email": "ignored"},
+	}
+
+	row, err := StructToMap(account)
+	if err != nil {
+		t.Fatalf("StructToMap failed: %v", err)
+	}
+	expected := RowMap{
+		"id":        int64(7),
+		"email":     "john@example.com",
+		"plan":      "pro",
+		"balance":   12.5,
+		"closed_at": nil,
+		"prefs":     `["dark"]`,
+		"region":    "eu",
+	}
+	if len(row) != len(expected) {
+		t.Fatalf("Expected %d keys, got %v", len(expected), row)
+	}
+	for key, want := range expected {
+		got := row[key]
+		if b, ok := got.([]byte); ok {
+			got = string(b)
+		}
+		if got != want {
+			t.Errorf("Key %s: expected %#v, got %#v", key, want, got)
+		}
+	}
+
+	var roundTrip mapStructAccount
+	if err := MapToStruct(row, &roundTrip); err != nil {
+		t.Fatalf("MapToStruct failed: %v", err)
+	}
+	if roundTrip.ID != 7 || *roundTrip.Plan != "pro" || roundTrip.Prefs[0] != "dark" || roundTrip.Extra["region"] != "eu" {
+		t.Errorf("Round trip lost values: %+v", roundTrip)
+	}
+
+	if _, err := StructToMap("not a struct"); err == nil {
+		t.Error("Expected an error for a non-struct")
+	}
+}
@@ -0,0 +1,117 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// QueryScalar executes a query that produces a single value, such as
+// SELECT count(*) or SELECT exists(...), and converts it to T using the same
+// rules as struct mapping, so an int8 column can be read as int and a numeric
+// as float64. A NULL gives the zero value of T, or nil if T is a pointer.
+//
+// The query must return exactly one column. ErrNoRows is returned when it
+// produces no rows and ErrTooManyRows when it produces more than one.
+func QueryScalar[T any](ctx context.Context, db DB, sql string, args ...any) (T, error) {
+	opts, args := splitArgs(args)
+	return queryScalar[T](ctx, db, "QueryScalar", sql, opts, args)
+}
+
+// queryScalar implements QueryScalar, reporting the query as op.
+func queryScalar[T any](ctx context.Context, db DB, op, sql string, opts *options, args []any) (T, error) {
+	var zero T
+	rows, err := query(ctx, db, op, sql, args, opts)
+	if err != nil {
+		return zero, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	fieldDescs := rows.FieldDescriptions()
+	if len(fieldDescs) != 1 {
+		return zero, fmt.Errorf("query returned %d columns; %s needs exactly one", len(fieldDescs), op)
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return zero, fmt.Errorf("row iteration error: %w", err)
+		}
+		return zero, ErrNoRows
+	}
+
+	values, err := rows.Values()
+	if err != nil {
+		return zero, fmt.Errorf("failed to get row values: %w", err)
+	}
+
+	var result T
+	if err := assignColumn(reflect.ValueOf(&result).Elem(), values[0], fieldDescs[0].DataTypeOID, opts); err != nil {
+		return zero, fmt.Errorf("column %q: %w", fieldDescs[0].Name, err)
+	}
+
+	if rows.Next() {
+		return zero, ErrTooManyRows
+	}
+	if err := rows.Err(); err != nil {
+		return zero, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return result, nil
+}
+
+// assignColumn converts a single column value into dest as struct mapping
+// would assign it to a field of the same type.
+func assignColumn(dest reflect.Value, value any, oid uint32, opts *options) error {
+	// Render date columns without a time of day in strings
+	if t, ok := value.(time.Time); ok && oid == pgtype.DateOID && dest.Kind() == reflect.String {
+		value = t.Format(time.DateOnly)
+	}
+
+	err := assignValue(dest, value, opts)
+	if err == errNotConvertible {
+		return fmt.Errorf("cannot convert %T to %s", value, dest.Type())
+	}
+	return err
+}
+
+// QueryColumn executes a query that produces a single column and returns its
+// values as a []T, converted as by QueryScalar. NULLs give the zero value of
+// T, or nil if T is a pointer. A value that cannot be converted fails the
+// whole query with an error naming its row.
+func QueryColumn[T any](ctx context.Context, db DB, sql string, args ...any) ([]T, error) {
+	opts, args := splitArgs(args)
+
+	rows, err := query(ctx, db, "QueryColumn", sql, args, opts)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	fieldDescs := rows.FieldDescriptions()
+	if len(fieldDescs) != 1 {
+		return nil, fmt.Errorf("query returned %d columns; QueryColumn needs exactly one", len(fieldDescs))
+	}
+
+	var result []T
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get row values: %w", err)
+		}
+
+		var v T
+		if err := assignColumn(reflect.ValueOf(&v).Elem(), values[0], fieldDescs[0].DataTypeOID, opts); err != nil {
+			return nil, fmt.Errorf("row %d: column %q: %w", len(result)+1, fieldDescs[0].Name, err)
+		}
+		result = append(result, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return result, nil
+}
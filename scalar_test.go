@@ -0,0 +1,128 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestQueryScalar(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("count"),
+		rows:   []mockRow{{values: []interface{}{int64(42)}}},
+	}
+
+	count, err := QueryScalar[int](ctx, mock, "SELECT count(*) FROM users WHERE active = $1", true)
+	if err != nil {
+		t.Fatalf("QueryScalar failed: %v", err)
+	}
+	if count != 42 {
+		t.Errorf("Expected 42, got %d", count)
+	}
+	if len(mock.lastArgs) != 1 || mock.lastArgs[0] != true {
+		t.Errorf("Unexpected args: %v", mock.lastArgs)
+	}
+
+	mock.rows = []mockRow{{values: []interface{}{pgtype.Numeric{Int: big.NewInt(1250), Exp: -2, Valid: true}}}}
+	total, err := QueryScalar[float64](ctx, mock, "SELECT sum(amount) FROM invoices")
+	if err != nil {
+		t.Fatalf("QueryScalar failed: %v", err)
+	}
+	if total != 12.5 {
+		t.Errorf("Expected 12.5, got %v", total)
+	}
+
+	mock.rows = []mockRow{{values: []interface{}{nil}}}
+	maxID, err := QueryScalar[*int64](ctx, mock, "SELECT max(id) FROM users")
+	if err != nil {
+		t.Fatalf("QueryScalar failed: %v", err)
+	}
+	if maxID != nil {
+		t.Errorf("Expected nil, got %v", *maxID)
+	}
+}
+
+func TestQueryScalarErrors(t *testing.T) {
+	ctx := context.Background()
+
+	mock := &mockQueryer{fields: mockFields("count")}
+	if _, err := QueryScalar[int](ctx, mock, "SELECT count(*) FROM users"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows, got %v", err)
+	}
+
+	mock.rows = []mockRow{{values: []interface{}{1}}, {values: []interface{}{2}}}
+	if _, err := QueryScalar[int](ctx, mock, "SELECT id FROM users"); !errors.Is(err, ErrTooManyRows) {
+		t.Errorf("Expected ErrTooManyRows, got %v", err)
+	}
+
+	mock.fields = mockFields("id", "name")
+	mock.rows = []mockRow{{values: []interface{}{1, "John"}}}
+	_, err := QueryScalar[int](ctx, mock, "SELECT id, name FROM users")
+	if err == nil || !strings.Contains(err.Error(), "returned 2 columns") {
+		t.Errorf("Expected column count error, got %v", err)
+	}
+
+	mock.fields = mockFields("name")
+	mock.rows = []mockRow{{values: []interface{}{"John"}}}
+	_, err = QueryScalar[int](ctx, mock, "SELECT name FROM users")
+	if err == nil || !strings.Contains(err.Error(), `column "name": cannot convert string to int`) {
+		t.Errorf("Expected conversion error, got %v", err)
+	}
+}
+
+func TestQueryColumn(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id"),
+		rows: []mockRow{
+			{values: []interface{}{int64(1)}},
+			{values: []interface{}{nil}},
+			{values: []interface{}{int64(3)}},
+		},
+	}
+
+	ids, err := QueryColumn[int](ctx, mock, "SELECT manager_id FROM users")
+	if err != nil {
+		t.Fatalf("QueryColumn failed: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int{1, 0, 3}) {
+		t.Errorf("Expected [1 0 3], got %v", ids)
+	}
+
+	ptrs, err := QueryColumn[*int](ctx, mock, "SELECT manager_id FROM users")
+	if err != nil {
+		t.Fatalf("QueryColumn failed: %v", err)
+	}
+	if len(ptrs) != 3 || *ptrs[0] != 1 || ptrs[1] != nil || *ptrs[2] != 3 {
+		t.Errorf("Unexpected result: %v", ptrs)
+	}
+}
+
+func TestQueryColumnErrors(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "name"),
+		rows:   []mockRow{{values: []interface{}{1, "John"}}},
+	}
+
+	_, err := QueryColumn[int](ctx, mock, "SELECT id, name FROM users")
+	if err == nil || !strings.Contains(err.Error(), "returned 2 columns") {
+		t.Errorf("Expected column count error, got %v", err)
+	}
+
+	mock.fields = mockFields("id")
+	mock.rows = []mockRow{
+		{values: []interface{}{int64(1)}},
+		{values: []interface{}{"two"}},
+	}
+	_, err = QueryColumn[int](ctx, mock, "SELECT id FROM users")
+	if err == nil || !strings.Contains(err.Error(), `row 2: column "id": cannot convert string to int`) {
+		t.Errorf("Expected conversion error, got %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+package dbx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ConversionError reports a database value that could not be assigned to the
+// struct field its column is mapped to.
+type ConversionError struct {
+	Column    string       // result column name
+	Field     string       // Go struct field name
+	ValueType reflect.Type // Go type of the value returned by the driver
+	FieldType reflect.Type // type of the target field
+	Err       error        // underlying cause, such as an error from sql.Scanner, if any
+}
+
+func (e *ConversionError) Error() string {
+	msg := fmt.Sprintf("cannot assign column %q of type %s to field %s of type %s",
+		e.Column, e.ValueType, e.Field, e.FieldType)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// structScanner maps the rows of a single result set into values of a
+// struct type.
+type structScanner struct {
+	columns  []string
+	oids     []uint32
+	fields   []fieldMeta
+	fieldMap map[int]int // column index to position in fields
+	groups   []nestedGroup
+	nullable map[int][]int // pointer group position to the columns mapped within it
+	rest     []int         // index sequence of the rest field, if any
+	restCols []int         // unmapped columns collected by the rest field
+	restKeys []string      // keys of restCols in the rest field
+	opts     *options
+
+	// Set by planDirect for the DirectScan path
+	structType reflect.Type
+	direct     []directColumn // columns scanned into reusable destinations
+	targets    []any          // Rows.Scan destinations, nil for the other columns
+	slow       []int          // mapped columns read through Values
+}
+
+// newStructScanner resolves the column-to-field mapping for rows.
+func newStructScanner(rows pgx.Rows, structType reflect.Type, opts *options) (*structScanner, error) {
+	names := make([]string, len(rows.FieldDescriptions()))
+	for i, fd := range rows.FieldDescriptions() {
+		names[i] = fd.Name
+	}
+
+	if opts == nil {
+		opts = defaults()
+	} else if err := checkColumns(names, opts); err != nil {
+		return nil, err
+	}
+
+	fieldMap, err := buildFieldMapping(rows, structType, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build field mapping: %w", err)
+	}
+	return newMappedScanner(rows.FieldDescriptions(), structType, names, fieldMap, opts), nil
+}
+
+// newPrefixedScanner is like newStructScanner but only maps the columns
+// named "prefix.column", matching them by the column part.
+func newPrefixedScanner(rows pgx.Rows, structType reflect.Type, prefix string, opts *options) (*structScanner, error) {
+	fieldDescs := rows.FieldDescriptions()
+	names := make([]string, len(fieldDescs))
+	for i, fd := range fieldDescs {
+		if column, found := strings.CutPrefix(fd.Name, prefix+"."); found {
+			names[i] = column
+		}
+	}
+	fieldMap, err := mapColumns(names, structType, opts)
+	if err != nil {
+		return nil, err
+	}
+	return newMappedScanner(rows.FieldDescriptions(), structType, names, fieldMap, opts), nil
+}
+
+// newMappedScanner returns a scanner for rows with the given columns using
+// the given mapping from column indices to field positions. names are the
+// column names used for the mapping; columns with an empty name are ignored
+// by a rest field.
+func newMappedScanner(fieldDescs []pgconn.FieldDescription, structType reflect.Type, names []string, fieldMap map[int]int, opts *options) *structScanner {
+	columns := make([]string, len(fieldDescs))
+	oids := make([]uint32, len(fieldDescs))
+	for i, fd := range fieldDescs {
+		columns[i] = fd.Name
+		oids[i] = fd.DataTypeOID
+	}
+
+	if opts == nil {
+		opts = defaults()
+	}
+
+	// Nested struct pointers are left nil when all of their columns are
+	// NULL, as for a LEFT JOIN without a match
+	meta := getStructMeta(structType, opts)
+	nullable := make(map[int][]int)
+	for colIndex, fieldPos := range fieldMap {
+		for _, g := range meta.Fields[fieldPos].Groups {
+			if meta.Groups[g].Pointer {
+				nullable[g] = append(nullable[g], colIndex)
+			}
+		}
+	}
+
+	var restCols []int
+	var restKeys []string
+	if meta.Rest != nil {
+		keys := uniqueColumns(names)
+		for i, name := range names {
+			if _, mapped := fieldMap[i]; !mapped && name != "" {
+				restCols = append(restCols, i)
+				restKeys = append(restKeys, keys[i])
+			}
+		}
+	}
+
+	return &structScanner{
+		columns:  columns,
+		oids:     oids,
+		fields:   meta.Fields,
+		fieldMap: fieldMap,
+		groups:   meta.Groups,
+		nullable: nullable,
+		rest:     meta.Rest,
+		restCols: restCols,
+		restKeys: restKeys,
+		opts:     opts,
+
+		structType: structType,
+	}
+}
+
+// allNull reports whether every mapped column is NULL in values.
+func (s *structScanner) allNull(values []any) bool {
+	for colIndex := range s.fieldMap {
+		if colIndex < len(values) && values[colIndex] != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// nullGroups returns the positions of the nested struct pointers whose
+// columns are all NULL in values.
+func (s *structScanner) nullGroups(values []any) map[int]bool {
+	var null map[int]bool
+	for g, cols := range s.nullable {
+		allNull := true
+		for _, colIndex := range cols {
+			if colIndex < len(values) && values[colIndex] != nil {
+				allNull = false
+				break
+			}
+		}
+		if allNull {
+			if null == nil {
+				null = make(map[int]bool)
+			}
+			null[g] = true
+		}
+	}
+	return null
+}
+
+// inNullGroup reports whether f belongs to one of the null groups.
+func inNullGroup(f *fieldMeta, null map[int]bool) bool {
+	for _, g := range f.Groups {
+		if null[g] {
+			return true
+		}
+	}
+	return false
+}
+
+// scanRow reads the current row of rows into elem, through the direct
+// path if planDirect set it up.
+func (s *structScanner) scanRow(rows pgx.Rows, elem reflect.Value) error {
+	if s.direct != nil {
+		return s.scanDirect(rows, elem)
+	}
+	values, err := rows.Values()
+	if err != nil {
+		return fmt.Errorf("failed to get row values: %w", err)
+	}
+	return s.scan(values, elem)
+}
+
+// scan assigns row values to the fields of elem, which must be a settable
+// value of the scanner's struct type.
+func (s *structScanner) scan(values []any, elem reflect.Value) error {
+	null := s.nullGroups(values)
+
+	for colIndex, fieldPos := range s.fieldMap {
+		if colIndex >= len(values) {
+			continue
+		}
+
+		meta := &s.fields[fieldPos]
+		if inNullGroup(meta, null) {
+			continue
+		}
+
+		if err := s.assign(elem, colIndex, meta, values[colIndex]); err != nil {
+			return err
+		}
+	}
+
+	// Collect the unmapped columns, leaving the rest field nil if there are
+	// none
+	if s.rest != nil {
+		var rest RowMap
+		if len(s.restCols) > 0 {
+			rest = make(RowMap, len(s.restCols))
+			for i, colIndex := range s.restCols {
+				if colIndex < len(values) {
+					rest[s.restKeys[i]] = mapValue(inetHost(values[colIndex], s.oids[colIndex]), s.opts)
+				}
+			}
+		}
+		field := fieldByIndexAlloc(elem, s.rest)
+		field.Set(reflect.ValueOf(rest).Convert(field.Type()))
+	}
+
+	// Reset null groups in case elem already held a value. Inner groups
+	// come after the groups containing them, so resetting in reverse order
+	// leaves outer pointers nil.
+	for g := len(s.groups) - 1; g >= 0; g-- {
+		if null[g] {
+			field := fieldByIndexAlloc(elem, s.groups[g].Index)
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+	return nil
+}
+
+// assign sets the field described by meta within elem from value, the
+// value of column colIndex, returning a *ConversionError if it can't be
+// converted, unless the Lenient option is set.
+func (s *structScanner) assign(elem reflect.Value, colIndex int, meta *fieldMeta, value any) error {
+	field := meta.settable(elem)
+	if !field.CanSet() {
+		return nil
+	}
+	original := value
+	value = inetHost(value, s.oids[colIndex])
+
+	// Render date columns without a time of day in string fields
+	if t, ok := value.(time.Time); ok && s.oids[colIndex] == pgtype.DateOID && field.Kind() == reflect.String {
+		value = t.Format(time.DateOnly)
+	}
+
+	var err error
+	if meta.Endpoints != nil {
+		err = assignEndpoints(elem, meta.Endpoints, value, s.opts)
+	} else if meta.Options.Contains("json") || isJSONColumn(s.oids[colIndex]) && isDocumentTarget(field.Type()) {
+		err = assignJSON(field, value)
+	} else {
+		err = assignValue(field, value, s.opts)
+	}
+	if err != nil {
+		return s.conversionError(colIndex, meta, reflect.TypeOf(original), field, err)
+	}
+	return nil
+}
+
+// conversionError returns the error for a failure to assign a value of
+// type valueType from column colIndex to field, or nil with the Lenient
+// option.
+func (s *structScanner) conversionError(colIndex int, meta *fieldMeta, valueType reflect.Type, field reflect.Value, err error) error {
+	if s.opts.lenient {
+		return nil
+	}
+	convErr := &ConversionError{
+		Column:    s.columns[colIndex],
+		Field:     meta.Name,
+		ValueType: valueType,
+		FieldType: field.Type(),
+	}
+	if err != errNotConvertible {
+		convErr.Err = err
+	}
+	return convErr
+}
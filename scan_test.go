@@ -0,0 +1,208 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestQueryStructsConversionError(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{pgtype.Numeric{Int: big.NewInt(1050), Exp: -2, Valid: true}, "John", "john@example.com"}},
+		},
+	}
+
+	type TestUser struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	var users []TestUser
+	err := QueryStructs(ctx, mock, "SELECT * FROM users", &users)
+
+	var convErr *ConversionError
+	if !errors.As(err, &convErr) {
+		t.Fatalf("Expected *ConversionError, got %v", err)
+	}
+
+	if convErr.Column != "id" || convErr.Field != "ID" {
+		t.Errorf("Expected column id and field ID, got %q and %q", convErr.Column, convErr.Field)
+	}
+	if convErr.ValueType != reflect.TypeOf(pgtype.Numeric{}) || convErr.FieldType != reflect.TypeOf(0) {
+		t.Errorf("Unexpected types in error: %v", convErr)
+	}
+}
+
+func TestQueryStructsLenient(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{pgtype.Numeric{Int: big.NewInt(1050), Exp: -2, Valid: true}, "John", "john@example.com"}},
+		},
+	}
+
+	type TestUser struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	var users []TestUser
+	if err := QueryStructs(ctx, mock, "SELECT * FROM users WHERE id = $1", &users, Lenient(), 1); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	if len(users) != 1 || users[0].ID != 0 || users[0].Name != "John" {
+		t.Errorf("Expected incompatible field to be skipped, got %+v", users)
+	}
+
+	if !reflect.DeepEqual(mock.lastArgs, []interface{}{1}) {
+		t.Errorf("Expected option to be removed from args, got %v", mock.lastArgs)
+	}
+}
+
+func TestAssignValueRejectsIntegerToString(t *testing.T) {
+	var s string
+	if err := assignValue(reflect.ValueOf(&s).Elem(), int64(65), defaults()); err == nil {
+		t.Errorf("Expected integer to string assignment to fail, got %q", s)
+	}
+}
+
+type testInvoice struct {
+	ID     int     `db:"id"`
+	Amount float64 `db:"amount"`
+}
+
+type testCustomer struct {
+	ID    int    `db:"id"`
+	Email string `db:"email"`
+}
+
+func TestQueryStructsNested(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("invoice.id", "invoice.amount", "customer.id", "customer.email"),
+		rows: []mockRow{
+			{values: []interface{}{1, 9.5, 7, "a@example.com"}},
+			{values: []interface{}{2, 3.0, nil, nil}},
+		},
+	}
+
+	type Row struct {
+		Invoice  testInvoice   `db:"invoice"`
+		Customer *testCustomer `db:"customer"`
+	}
+
+	var rows []Row
+	if err := QueryStructs(ctx, mock, "SELECT ... FROM invoice LEFT JOIN customer ON ...", &rows); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	if rows[0].Invoice != (testInvoice{ID: 1, Amount: 9.5}) {
+		t.Errorf("Unexpected invoice: %+v", rows[0].Invoice)
+	}
+	if rows[0].Customer == nil || *rows[0].Customer != (testCustomer{ID: 7, Email: "a@example.com"}) {
+		t.Errorf("Unexpected customer: %+v", rows[0].Customer)
+	}
+
+	if rows[1].Invoice.ID != 2 {
+		t.Errorf("Unexpected invoice: %+v", rows[1].Invoice)
+	}
+	if rows[1].Customer != nil {
+		t.Errorf("Expected nil customer for all-NULL columns, got %+v", rows[1].Customer)
+	}
+}
+
+func TestQueryStructsNestedIgnoresBareColumns(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "amount"),
+		rows:   []mockRow{{values: []interface{}{1, 9.5}}},
+	}
+
+	var rows []struct {
+		Invoice testInvoice `db:"invoice"`
+	}
+	if err := QueryStructs(ctx, mock, "SELECT id, amount FROM invoice", &rows); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	if rows[0].Invoice != (testInvoice{}) {
+		t.Errorf("Expected unprefixed columns not to map into nested struct, got %+v", rows[0].Invoice)
+	}
+}
+
+func TestExtractStructFieldsSkipsNestedFields(t *testing.T) {
+	type Row struct {
+		ID      int         `db:"id"`
+		Invoice testInvoice `db:"invoice"`
+	}
+
+	meta := getStructMeta(reflect.TypeOf(Row{}), defaults())
+	var nested []string
+	for _, f := range meta.Fields {
+		if f.Nested() {
+			nested = append(nested, f.Tag)
+		}
+	}
+	if !reflect.DeepEqual(nested, []string{"invoice.id", "invoice.amount"}) {
+		t.Errorf("Expected nested tags, got %v", nested)
+	}
+
+	fields, _, err := extractStructFields(Row{ID: 1})
+	if err != nil {
+		t.Fatalf("extractStructFields failed: %v", err)
+	}
+	if !reflect.DeepEqual(fields, []string{"id", "invoice"}) {
+		t.Errorf("Expected nested fields not to be inserted, got %v", fields)
+	}
+}
+
+func TestQueryStructsRest(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "name", "region", "score"),
+		rows: []mockRow{
+			{values: []interface{}{1, "Acme", "EU", 7}},
+		},
+	}
+
+	type Account struct {
+		ID    int    `db:"id"`
+		Name  string `db:"name"`
+		Extra RowMap `db:",rest"`
+	}
+
+	var accounts []Account
+	if err := QueryStructs(ctx, mock, "SELECT * FROM account_report", &accounts, RequireAllFields(), StrictColumns()); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	expected := []Account{{ID: 1, Name: "Acme", Extra: RowMap{"region": "EU", "score": 7}}}
+	if !reflect.DeepEqual(accounts, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, accounts)
+	}
+
+	// Without leftovers the field stays nil
+	mock.fields = mockFields("id", "name")
+	mock.rows = []mockRow{{values: []interface{}{2, "Globex"}}}
+	if err := QueryStructs(ctx, mock, "SELECT id, name FROM account", &accounts); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+	if accounts[0].Extra != nil {
+		t.Errorf("Expected nil rest field, got %v", accounts[0].Extra)
+	}
+
+	type Bad struct {
+		ID    int    `db:"id"`
+		Extra string `db:",rest"`
+	}
+	var bad []Bad
+	if err := QueryStructs(ctx, mock, "SELECT id, name FROM account", &bad); err == nil {
+		t.Error("Expected error for a rest field that is not a map")
+	}
+}
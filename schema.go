@@ -0,0 +1,88 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SchemaDB is a DB whose helpers place unqualified table names in a default
+// schema. Create one with WithSchema.
+type SchemaDB struct {
+	DB
+	schema string
+}
+
+// WithSchema wraps db so that the helpers taking a table name, such as
+// InsertStruct, UpdateStruct, DeleteStruct, UpsertStruct, GetByID, and
+// Count, put unqualified names in schema: with WithSchema(db, "tenant_42"),
+// "invoice" becomes "tenant_42"."invoice". Names that already carry a schema,
+// such as "billing.invoice", are left alone. The schema name is validated and
+// quoted like a table name. Statements queued on a Batch are built before
+// any DB is known, so they are not affected.
+//
+// Only SQL that dbx generates is affected; statements written by hand are
+// sent as they are. To have the database resolve every unqualified name in
+// a schema, use SetSearchPath inside a transaction instead.
+//
+// Wrappers that hide the SchemaDB, such as dbxotel, should be applied before
+// WithSchema, not after.
+func WithSchema(db DB, schema string) *SchemaDB {
+	return &SchemaDB{DB: db, schema: schema}
+}
+
+// Schema returns the default schema.
+func (s *SchemaDB) Schema() string {
+	return s.schema
+}
+
+// qualifyTable validates and quotes table as quoteTable does. When table is
+// unqualified and db has a default schema, as a SchemaDB does, the quoted
+// schema is prefixed.
+func qualifyTable(db DB, table string) (string, error) {
+	parts, err := splitName("table", table)
+	if err != nil {
+		return "", err
+	}
+
+	if s, ok := db.(interface{ Schema() string }); ok && len(parts) == 1 && s.Schema() != "" {
+		schema, err := quoteName("schema", s.Schema())
+		if err != nil {
+			return "", err
+		}
+		return schema + "." + parts[0], nil
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// SetSearchPath sets the schema search path for the rest of the current
+// transaction with SET LOCAL, so that unqualified names in every statement,
+// hand-written or generated, resolve in schemas, in order. db must be a
+// transaction, such as the one RunInTx passes to its function; outside a
+// transaction the setting has no effect. Schema names are validated and
+// quoted like table names.
+//
+//	err := dbx.RunInTx(ctx, pool, func(tx dbx.DB) error {
+//		if err := dbx.SetSearchPath(ctx, tx, "tenant_42", "public"); err != nil {
+//			return err
+//		}
+//		...
+//	})
+func SetSearchPath(ctx context.Context, db DB, schemas ...string) error {
+	if len(schemas) == 0 {
+		return fmt.Errorf("at least one schema is required")
+	}
+
+	quoted := make([]string, len(schemas))
+	for i, schema := range schemas {
+		var err error
+		if quoted[i], err = quoteName("schema", schema); err != nil {
+			return err
+		}
+	}
+
+	if _, err := exec(ctx, db, "SetSearchPath", "SET LOCAL search_path TO "+strings.Join(quoted, ", "), nil, nil); err != nil {
+		return fmt.Errorf("set search_path failed: %w", err)
+	}
+	return nil
+}
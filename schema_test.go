@@ -0,0 +1,73 @@
+package dbx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestWithSchema(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("INSERT 0 1")}
+	db := WithSchema(mock, "tenant_42")
+
+	if err := InsertStruct(ctx, db, "users", insertUser{Name: "John"}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if want := `INSERT INTO "tenant_42"."users" ("name", "email") VALUES ($1, $2)`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+
+	if _, err := UpdateStruct(ctx, db, "billing.invoice", insertUser{Name: "John"}, "id = $1", 1); err != nil {
+		t.Fatalf("UpdateStruct failed: %v", err)
+	}
+	if want := `UPDATE "billing"."invoice" SET "name" = $1, "email" = $2 WHERE id = $3`; mock.lastSQL != want {
+		t.Errorf("Expected qualified name to be kept, got %q", mock.lastSQL)
+	}
+
+	mock.fields = mockFields("count")
+	mock.rows = []mockRow{{values: []interface{}{int64(3)}}}
+	if _, err := Count(ctx, db, Ident("Audit Log"), ""); err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if want := `SELECT count(*) FROM "tenant_42"."Audit Log"`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+
+	type User struct {
+		ID int `db:"id,pk"`
+	}
+	mock.fields = mockFields("id")
+	mock.rows = []mockRow{{values: []interface{}{7}}}
+	var user User
+	if err := GetByID(ctx, db, "users", &user, 7); err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if want := `SELECT * FROM "tenant_42"."users" WHERE "id" = $1 LIMIT 1`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+
+	if err := InsertStruct(ctx, WithSchema(mock, "tenant 42"), "users", insertUser{}); err == nil {
+		t.Error("Expected error for an invalid schema name")
+	}
+}
+
+func TestSetSearchPath(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("SET")}
+
+	if err := SetSearchPath(ctx, mock, "tenant_42", "public"); err != nil {
+		t.Fatalf("SetSearchPath failed: %v", err)
+	}
+	if want := `SET LOCAL search_path TO "tenant_42", "public"`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+
+	if err := SetSearchPath(ctx, mock); err == nil {
+		t.Error("Expected error without schemas")
+	}
+	if err := SetSearchPath(ctx, mock, "x; RESET ALL"); err == nil {
+		t.Error("Expected error for an invalid schema name")
+	}
+}
@@ -0,0 +1,125 @@
+package dbx
+
+import (
+	"context"
+	"strings"
+)
+
+// SelectBuilder assembles a SELECT statement from optional parts, for
+// listing queries whose filters, ordering, and pagination vary per call.
+// Values are always sent as arguments; column names, table names, joins,
+// and ORDER BY expressions are written into the SQL as given and must not
+// come from untrusted input.
+//
+//	var users []User
+//	err := dbx.Select("users").
+//		Where(dbx.Eq("active", true)).
+//		OrderBy("created_at DESC").
+//		Limit(50).
+//		Structs(ctx, db, &users)
+type SelectBuilder struct {
+	table    string
+	columns  []string
+	joins    []string
+	conds    []Cond
+	orderBy  []string
+	limit    int
+	offset   int
+	hasLimit bool
+}
+
+// Select starts a SELECT statement from table.
+func Select(table string) *SelectBuilder {
+	return &SelectBuilder{table: table}
+}
+
+// Columns adds columns or expressions to the select list. Without any,
+// the statement selects *.
+func (s *SelectBuilder) Columns(columns ...string) *SelectBuilder {
+	s.columns = append(s.columns, columns...)
+	return s
+}
+
+// Join adds a join clause as written, e.g. "JOIN customer c ON c.id = i.customer_id".
+func (s *SelectBuilder) Join(clause string) *SelectBuilder {
+	s.joins = append(s.joins, clause)
+	return s
+}
+
+// Where adds conditions, which are combined with AND. Nil conditions are
+// ignored.
+func (s *SelectBuilder) Where(conds ...Cond) *SelectBuilder {
+	s.conds = append(s.conds, conds...)
+	return s
+}
+
+// OrderBy adds ORDER BY expressions, e.g. "created_at DESC".
+func (s *SelectBuilder) OrderBy(exprs ...string) *SelectBuilder {
+	s.orderBy = append(s.orderBy, exprs...)
+	return s
+}
+
+// Limit sets the maximum number of rows returned.
+func (s *SelectBuilder) Limit(n int) *SelectBuilder {
+	s.limit, s.hasLimit = n, true
+	return s
+}
+
+// Offset sets the number of rows to skip.
+func (s *SelectBuilder) Offset(n int) *SelectBuilder {
+	s.offset = n
+	return s
+}
+
+// Build renders the statement and its arguments.
+func (s *SelectBuilder) Build() (string, []any) {
+	var b strings.Builder
+	b.WriteString("SELECT ")
+	if len(s.columns) == 0 {
+		b.WriteString("*")
+	} else {
+		b.WriteString(strings.Join(s.columns, ", "))
+	}
+	b.WriteString(" FROM ")
+	b.WriteString(s.table)
+
+	for _, join := range s.joins {
+		b.WriteString(" " + join)
+	}
+
+	where, args := Where(s.conds...).Build(0)
+	if where != "" {
+		b.WriteString(" " + where)
+	}
+
+	if len(s.orderBy) > 0 {
+		b.WriteString(" ORDER BY ")
+		b.WriteString(strings.Join(s.orderBy, ", "))
+	}
+
+	// Limit and offset are arguments too, numbered after the conditions
+	tail := &condBuilder{offset: len(args)}
+	if s.hasLimit {
+		tail.sql.WriteString(" LIMIT ")
+		tail.arg(s.limit)
+	}
+	if s.offset > 0 {
+		tail.sql.WriteString(" OFFSET ")
+		tail.arg(s.offset)
+	}
+	b.WriteString(tail.sql.String())
+
+	return b.String(), append(args, tail.args...)
+}
+
+// Structs runs the statement with QueryStructs.
+func (s *SelectBuilder) Structs(ctx context.Context, db DB, dest any, opts ...Option) error {
+	sql, args := s.Build()
+	return QueryStructs(ctx, db, sql, dest, withOptions(args, opts)...)
+}
+
+// Maps runs the statement with QueryMaps.
+func (s *SelectBuilder) Maps(ctx context.Context, db DB, opts ...Option) ([]RowMap, error) {
+	sql, args := s.Build()
+	return QueryMaps(ctx, db, sql, withOptions(args, opts)...)
+}
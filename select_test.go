@@ -0,0 +1,90 @@
+package dbx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSelectBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *SelectBuilder
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:    "all columns",
+			builder: Select("users"),
+			wantSQL: "SELECT * FROM users",
+		},
+		{
+			name: "full",
+			builder: Select("invoice i").
+				Columns("i.id", "i.amount", "c.email").
+				Join("JOIN customer c ON c.id = i.customer_id").
+				Where(Eq("c.active", true), nil, Gt("i.amount", 100)).
+				OrderBy("i.created_at DESC", "i.id").
+				Limit(50).
+				Offset(100),
+			wantSQL: "SELECT i.id, i.amount, c.email FROM invoice i JOIN customer c ON c.id = i.customer_id " +
+				"WHERE c.active = $1 AND i.amount > $2 ORDER BY i.created_at DESC, i.id LIMIT $3 OFFSET $4",
+			wantArgs: []any{true, 100, 50, 100},
+		},
+		{
+			name:     "offset without conditions",
+			builder:  Select("users").Offset(10),
+			wantSQL:  "SELECT * FROM users OFFSET $1",
+			wantArgs: []any{10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := tt.builder.Build()
+			if sql != tt.wantSQL {
+				t.Errorf("Expected SQL %q, got %q", tt.wantSQL, sql)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Expected args %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}
+
+func TestSelectStructs(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, "John", "john@example.com"}},
+		},
+	}
+
+	type TestUser struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	var users []TestUser
+	if err := Select("users").Where(Eq("id", 1)).Limit(1).Structs(ctx, mock, &users, Lenient()); err != nil {
+		t.Fatalf("Structs failed: %v", err)
+	}
+
+	if mock.lastSQL != "SELECT * FROM users WHERE id = $1 LIMIT $2" {
+		t.Errorf("Unexpected SQL: %s", mock.lastSQL)
+	}
+	if !reflect.DeepEqual(mock.lastArgs, []any{1, 1}) {
+		t.Errorf("Unexpected args: %v", mock.lastArgs)
+	}
+	if len(users) != 1 || users[0].Name != "John" {
+		t.Errorf("Unexpected users: %+v", users)
+	}
+
+	rows, err := Select("users").Maps(ctx, mock)
+	if err != nil {
+		t.Fatalf("Maps failed: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Errorf("Expected 1 row, got %d", len(rows))
+	}
+}
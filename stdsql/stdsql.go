@@ -0,0 +1,205 @@
+// Package stdsql lets dbx run on database/sql drivers such as lib/pq.
+//
+// Wrap a *sql.DB, *sql.Tx, or *sql.Conn to get a dbx.DB:
+//
+//	sqldb, err := sql.Open("postgres", dsn)
+//	db := stdsql.Wrap(sqldb)
+//	users, err := dbx.QueryStructsT[User](ctx, db, "SELECT * FROM users")
+//
+// Values arrive as the driver scans them into interface{} rather than as
+// pgx decodes them, so type fidelity is lower than with pgx: numerics, for
+// example, usually arrive as text. Byte slices from columns that are not
+// binary types are turned into strings, since most drivers return text that
+// way. Column types are reported by name only, except that DATE columns are
+// recognized so dates render without a time of day.
+//
+// dbx writes Postgres-style $1 placeholders, both in its generated SQL and
+// by convention in yours. For drivers that expect another style, pass
+// WithDialect and every statement is rebound before it is sent:
+//
+//	db := stdsql.Wrap(mysqldb, stdsql.WithDialect(dbx.MySQL))
+package stdsql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/JoeFinlinson/dbx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Queryer is implemented by *sql.DB, *sql.Tx, and *sql.Conn.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// DB adapts a database/sql handle to dbx.DB.
+type DB struct {
+	db      Queryer
+	dialect dbx.Dialect
+}
+
+var _ dbx.DB = (*DB)(nil)
+
+// Option configures a DB.
+type Option func(*DB)
+
+// WithDialect makes the DB rebind $1 placeholders, and for MySQL quoted
+// identifiers, to the style of dialect, using dbx.RebindArgs, before
+// sending each statement. The default is
+// dbx.Postgres, which sends statements unchanged.
+func WithDialect(dialect dbx.Dialect) Option {
+	return func(d *DB) {
+		d.dialect = dialect
+	}
+}
+
+// Wrap returns a DB that sends every statement to db.
+func Wrap(db Queryer, opts ...Option) *DB {
+	d := &DB{db: db, dialect: dbx.Postgres}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Query runs sql and returns its rows through the pgx.Rows interface.
+func (d *DB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	sql, args, err := dbx.RebindArgs(d.dialect, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := d.db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+
+	r := &Rows{
+		rows:   rows,
+		fields: make([]pgconn.FieldDescription, len(types)),
+		binary: make([]bool, len(types)),
+	}
+	for i, ct := range types {
+		typeName := strings.ToUpper(ct.DatabaseTypeName())
+		r.fields[i] = pgconn.FieldDescription{Name: ct.Name()}
+		if typeName == "DATE" {
+			r.fields[i].DataTypeOID = pgtype.DateOID
+		}
+		r.binary[i] = binaryTypes[typeName]
+	}
+	return r, nil
+}
+
+// Exec runs sql and reports the rows affected in the returned command tag.
+// The tag carries the statement's leading keyword and the row count only.
+func (d *DB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	sql, args, err := dbx.RebindArgs(d.dialect, sql, args)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+
+	result, err := d.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+
+	// Drivers that cannot count rows report an error; treat that as zero
+	affected, _ := result.RowsAffected()
+
+	verb, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	return pgconn.NewCommandTag(strings.ToUpper(verb) + " " + strconv.FormatInt(affected, 10)), nil
+}
+
+// binaryTypes lists the database type names, across common drivers, whose
+// []byte values are binary data rather than text.
+var binaryTypes = map[string]bool{
+	"BYTEA":      true,
+	"BLOB":       true,
+	"TINYBLOB":   true,
+	"MEDIUMBLOB": true,
+	"LONGBLOB":   true,
+	"BINARY":     true,
+	"VARBINARY":  true,
+	"IMAGE":      true,
+}
+
+// Rows adapts *sql.Rows to pgx.Rows. Only the methods dbx uses are
+// meaningful: RawValues returns nil, and CommandTag and Conn return zero
+// values.
+type Rows struct {
+	rows   *sql.Rows
+	fields []pgconn.FieldDescription
+	binary []bool
+	err    error
+}
+
+var _ pgx.Rows = (*Rows)(nil)
+
+func (r *Rows) Close() {
+	if err := r.rows.Close(); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *Rows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+func (r *Rows) CommandTag() pgconn.CommandTag {
+	return pgconn.CommandTag{}
+}
+
+func (r *Rows) FieldDescriptions() []pgconn.FieldDescription {
+	return r.fields
+}
+
+func (r *Rows) Next() bool {
+	return r.rows.Next()
+}
+
+func (r *Rows) Scan(dest ...any) error {
+	return r.rows.Scan(dest...)
+}
+
+// Values scans the current row into a []any. Byte slices from columns that
+// are not binary types are returned as strings.
+func (r *Rows) Values() ([]any, error) {
+	values := make([]any, len(r.fields))
+	ptrs := make([]any, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := r.rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+
+	for i, v := range values {
+		if b, ok := v.([]byte); ok && !r.binary[i] {
+			values[i] = string(b)
+		}
+	}
+	return values, nil
+}
+
+func (r *Rows) RawValues() [][]byte {
+	return nil
+}
+
+func (r *Rows) Conn() *pgx.Conn {
+	return nil
+}
@@ -0,0 +1,199 @@
+package stdsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/JoeFinlinson/dbx"
+)
+
+// fakeDriver serves a fixed result set for every query and records the last
+// statement it was given.
+type fakeDriver struct {
+	columns  []string
+	types    []string
+	rows     [][]driver.Value
+	affected int64
+	lastSQL  string
+	lastArgs []driver.NamedValue
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d: d}, nil
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.d.lastSQL, c.d.lastArgs = query, args
+	return &fakeRows{d: c.d}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.lastSQL, c.d.lastArgs = query, args
+	return driver.RowsAffected(c.d.affected), nil
+}
+
+type fakeRows struct {
+	d   *fakeDriver
+	pos int
+}
+
+func (r *fakeRows) Columns() []string { return r.d.columns }
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(i int) string { return r.d.types[i] }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.d.rows) {
+		return io.EOF
+	}
+	copy(dest, r.d.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+// openFake opens a *sql.DB backed by d.
+func openFake(t *testing.T, d *fakeDriver) *sql.DB {
+	t.Helper()
+	db := sql.OpenDB(connector{d})
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+type connector struct {
+	d *fakeDriver
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) { return c.d.Open("") }
+
+func (c connector) Driver() driver.Driver { return c.d }
+
+func TestQueryMaps(t *testing.T) {
+	ctx := context.Background()
+	d := &fakeDriver{
+		columns: []string{"id", "name", "avatar"},
+		types:   []string{"INT8", "TEXT", "BYTEA"},
+		rows: [][]driver.Value{
+			{int64(1), []byte("John"), []byte{0xde, 0xad}},
+			{int64(2), []byte("Jane"), nil},
+		},
+	}
+	db := Wrap(openFake(t, d))
+
+	rows, err := dbx.QueryMaps(ctx, db, "SELECT id, name, avatar FROM users WHERE active = $1", true)
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+
+	expected := []dbx.RowMap{
+		{"id": int64(1), "name": "John", "avatar": []byte{0xde, 0xad}},
+		{"id": int64(2), "name": "Jane", "avatar": nil},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected %v, got %v", expected, rows)
+	}
+	if len(d.lastArgs) != 1 || d.lastArgs[0].Value != true {
+		t.Errorf("Unexpected args: %v", d.lastArgs)
+	}
+}
+
+func TestQueryStructs(t *testing.T) {
+	ctx := context.Background()
+	d := &fakeDriver{
+		columns: []string{"id", "email", "birthday"},
+		types:   []string{"INT4", "VARCHAR", "DATE"},
+		rows: [][]driver.Value{
+			{int64(1), "john@example.com", time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	db := Wrap(openFake(t, d))
+
+	type User struct {
+		ID       int    `db:"users.id"`
+		Email    string `db:"users.email"`
+		Birthday string `db:"users.birthday"`
+	}
+
+	users, err := dbx.QueryStructsT[User](ctx, db, "SELECT * FROM users")
+	if err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	expected := []User{{ID: 1, Email: "john@example.com", Birthday: "1990-05-01"}}
+	if !reflect.DeepEqual(users, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, users)
+	}
+}
+
+func TestExec(t *testing.T) {
+	ctx := context.Background()
+	d := &fakeDriver{affected: 3}
+	db := Wrap(openFake(t, d))
+
+	type User struct {
+		Name string `db:"name"`
+	}
+
+	affected, err := dbx.UpdateStruct(ctx, db, "users", User{Name: "John"}, "team_id = $1", 4)
+	if err != nil {
+		t.Fatalf("UpdateStruct failed: %v", err)
+	}
+	if affected != 3 {
+		t.Errorf("Expected 3 rows affected, got %d", affected)
+	}
+	if d.lastSQL != `UPDATE "users" SET "name" = $1 WHERE team_id = $2` {
+		t.Errorf("Unexpected SQL: %s", d.lastSQL)
+	}
+}
+
+func TestWithDialect(t *testing.T) {
+	ctx := context.Background()
+	d := &fakeDriver{affected: 1}
+	db := Wrap(openFake(t, d), WithDialect(dbx.MySQL))
+
+	type User struct {
+		Name  string `db:"name"`
+		Email string `db:"email"`
+	}
+
+	if err := dbx.InsertStruct(ctx, db, "users", User{Name: "John", Email: "john@example.com"}); err != nil {
+		t.Fatalf("InsertStruct failed: %v", err)
+	}
+	if d.lastSQL != "INSERT INTO `users` (`name`, `email`) VALUES (?, ?)" {
+		t.Errorf("Unexpected SQL: %s", d.lastSQL)
+	}
+
+	d.columns, d.types = []string{"id"}, []string{"INT"}
+	if _, err := dbx.QueryMaps(ctx, db, "SELECT id FROM users WHERE team_id = $2 AND (owner_id = $1 OR $1 = 0)", 7, 3); err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if d.lastSQL != "SELECT id FROM users WHERE team_id = ? AND (owner_id = ? OR ? = 0)" {
+		t.Errorf("Unexpected SQL: %s", d.lastSQL)
+	}
+	var args []any
+	for _, a := range d.lastArgs {
+		args = append(args, a.Value)
+	}
+	if !reflect.DeepEqual(args, []any{int64(3), int64(7), int64(7)}) {
+		t.Errorf("Unexpected args: %v", args)
+	}
+}
@@ -0,0 +1,226 @@
+package dbx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// QueryJSONStream executes a query and writes the results to w as a JSON
+// array, encoding each row as it is read rather than collecting the whole
+// result first. Rows are rendered as by QueryJSON, except that an empty
+// result is written as [] rather than null. It returns the number of rows
+// written.
+//
+// Nothing is written until the first row has been read and encoded, or
+// the query has finished without rows, so an error in running the query
+// leaves w untouched. If an error occurs after output has started, writing
+// stops and the error is returned, leaving w holding a truncated, invalid
+// JSON document. When streaming to an HTTP response this surfaces to
+// clients as malformed JSON rather than as an error status, since the
+// status line has already been sent.
+func QueryJSONStream(ctx context.Context, db DB, w io.Writer, sql string, args ...any) (int64, error) {
+	opts, args := splitArgs(args)
+
+	// The opening bracket goes out with the first row
+	sep := "["
+	count, err := eachRow(ctx, db, "QueryJSONStream", sql, args, opts, jsonRows(opts, func(row jsonObject) error {
+		data, err := json.Marshal(row)
+		if err != nil {
+			return fmt.Errorf("failed to encode row: %w", err)
+		}
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+		sep = ","
+		_, err = w.Write(data)
+		return err
+	}))
+	if err != nil {
+		return count, err
+	}
+
+	end := "]"
+	if sep == "[" {
+		end = "[]"
+	}
+	if _, err := io.WriteString(w, end); err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+// ndjsonFlushRows is how many rows QueryNDJSON writes between flushes of a
+// buffered writer.
+const ndjsonFlushRows = 1000
+
+// QueryNDJSON executes a query and writes the results to w as
+// newline-delimited JSON: one object per row, each on its own line, with
+// no surrounding array. Rows are rendered exactly as by QueryJSON,
+// including NULLs and key names. If w has a Flush method, such as a
+// bufio.Writer or http.Flusher, it is flushed every 1000 rows and at the
+// end. It returns the number of rows written; on error, the lines already
+// written remain valid.
+func QueryNDJSON(ctx context.Context, db DB, w io.Writer, sql string, args ...any) (int64, error) {
+	opts, args := splitArgs(args)
+
+	enc := json.NewEncoder(w)
+	written := 0
+	count, err := eachRow(ctx, db, "QueryNDJSON", sql, args, opts, jsonRows(opts, func(row jsonObject) error {
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+		if written++; written%ndjsonFlushRows == 0 {
+			return flush(w)
+		}
+		return nil
+	}))
+	if err != nil {
+		return count, err
+	}
+	return count, flush(w)
+}
+
+// flush flushes w if it supports it.
+func flush(w io.Writer) error {
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+	return nil
+}
+
+// jsonObject is a row rendered as a JSON object whose keys keep the column
+// order of the query, which a RowMap would lose.
+type jsonObject struct {
+	keys   []string
+	values []any
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	for i, key := range o.keys {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key, err)
+		}
+		buf = append(buf, k...)
+		buf = append(buf, ':')
+		buf = append(buf, v...)
+	}
+	return append(buf, '}'), nil
+}
+
+// jsonRow builds the object for a row as rendered in JSON output, keyed by
+// keys.
+func jsonRow(keys []string, values []any, opts *options) jsonObject {
+	converted := make([]any, len(values))
+	for i, v := range values {
+		converted[i] = jsonValue(v, opts)
+	}
+	return jsonObject{keys: keys, values: converted}
+}
+
+// jsonKeys returns the JSON object keys for the given column names, made
+// unique as for QueryMaps, applying the JSONKeys function if one is set. It
+// fails if the function maps two columns to the same key.
+func jsonKeys(names []string, opts *options) ([]string, error) {
+	if err := checkColumns(names, opts); err != nil {
+		return nil, err
+	}
+	names = uniqueColumns(names)
+	if opts.jsonKey == nil {
+		return names, nil
+	}
+
+	keys := make([]string, len(names))
+	seen := make(map[string]int, len(names))
+	for i, name := range names {
+		key := opts.jsonKey(name)
+		if j, ok := seen[key]; ok {
+			return nil, fmt.Errorf("columns %q and %q both map to JSON key %q", names[j], name, key)
+		}
+		seen[key] = i
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+// jsonRows adapts fn for use with eachRow, passing it each row as rendered
+// in JSON output. The keys, and the columns left out by BinaryAs(BinaryOmit),
+// are worked out once, from the first row.
+func jsonRows(opts *options, fn func(row jsonObject) error) func(fieldDescs []pgconn.FieldDescription, values []any) error {
+	var keys []string
+	var kept []int
+	return func(fieldDescs []pgconn.FieldDescription, values []any) error {
+		if keys == nil {
+			names := make([]string, len(fieldDescs))
+			for i, fd := range fieldDescs {
+				names[i] = fd.Name
+			}
+			all, err := jsonKeys(names, opts)
+			if err != nil {
+				return err
+			}
+			keys = make([]string, 0, len(all))
+			kept = keptColumns(fieldDescs, opts)
+			for _, i := range kept {
+				keys = append(keys, all[i])
+			}
+		}
+		for i := range values {
+			values[i] = inetHost(values[i], fieldDescs[i].DataTypeOID)
+		}
+		if len(kept) < len(values) {
+			keptValues := make([]any, len(kept))
+			for j, i := range kept {
+				keptValues[j] = values[i]
+			}
+			values = keptValues
+		}
+		return fn(jsonRow(keys, values, opts))
+	}
+}
+
+// eachRow runs a query on behalf of op and calls fn with the columns and
+// values of each row, stopping at the first error. It returns the number of
+// rows for which fn succeeded.
+func eachRow(ctx context.Context, db DB, op, sql string, args []any, opts *options, fn func(fieldDescs []pgconn.FieldDescription, values []any) error) (int64, error) {
+	rows, err := query(ctx, db, op, sql, args, opts)
+	if err != nil {
+		return 0, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	fieldDescs := rows.FieldDescriptions()
+
+	var count int64
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return count, fmt.Errorf("failed to get row values: %w", err)
+		}
+		if err := fn(fieldDescs, values); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("row iteration error: %w", err)
+	}
+	return count, nil
+}
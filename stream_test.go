@@ -0,0 +1,124 @@
+package dbx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestQueryJSONStream(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, "John", "john@example.com"}},
+			{values: []interface{}{2, "Jane", nil}},
+		},
+	}
+
+	var buf bytes.Buffer
+	n, err := QueryJSONStream(ctx, mock, &buf, "SELECT * FROM users")
+	if err != nil {
+		t.Fatalf("QueryJSONStream failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 rows, got %d", n)
+	}
+
+	// Output must match QueryJSON byte for byte
+	expected, err := QueryJSON(ctx, mock, "SELECT * FROM users")
+	if err != nil {
+		t.Fatalf("QueryJSON failed: %v", err)
+	}
+	if buf.String() != string(expected) {
+		t.Errorf("Expected %s, got %s", expected, buf.String())
+	}
+}
+
+func TestQueryJSONStreamEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := QueryJSONStream(context.Background(), &mockQueryer{}, &buf, "SELECT * FROM users WHERE false")
+	if err != nil {
+		t.Fatalf("QueryJSONStream failed: %v", err)
+	}
+	if n != 0 || buf.String() != "[]" {
+		t.Errorf("Expected empty array, got %q (%d rows)", buf.String(), n)
+	}
+}
+
+func TestQueryJSONStreamQueryError(t *testing.T) {
+	var buf bytes.Buffer
+	mock := &mockQueryer{err: errors.New("syntax error")}
+	if _, err := QueryJSONStream(context.Background(), mock, &buf, "SELEC 1"); err == nil {
+		t.Fatal("Expected the query error")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing written when the query fails, got %q", buf.String())
+	}
+}
+
+// failingWriter fails once more than limit bytes have been written.
+type failingWriter struct {
+	limit int
+	buf   bytes.Buffer
+}
+
+var errWriteFailed = errors.New("write failed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.buf.Len()+len(p) > w.limit {
+		return 0, errWriteFailed
+	}
+	return w.buf.Write(p)
+}
+
+func TestQueryJSONStreamWriteError(t *testing.T) {
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, "John", "john@example.com"}},
+			{values: []interface{}{2, "Jane", "jane@example.com"}},
+		},
+	}
+
+	w := &failingWriter{limit: 60}
+	n, err := QueryJSONStream(context.Background(), mock, w, "SELECT * FROM users")
+	if !errors.Is(err, errWriteFailed) {
+		t.Fatalf("Expected write error, got %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 row written before the error, got %d", n)
+	}
+}
+
+func TestQueryNDJSON(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		rows: []mockRow{
+			{values: []interface{}{1, "John", "john@example.com"}},
+			{values: []interface{}{2, "Jane", nil}},
+		},
+	}
+
+	var buf bytes.Buffer
+	w := bufio.NewWriterSize(&buf, 4096)
+	n, err := QueryNDJSON(ctx, mock, w, "SELECT * FROM users")
+	if err != nil {
+		t.Fatalf("QueryNDJSON failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 rows, got %d", n)
+	}
+
+	// Each line must match the corresponding QueryJSON element
+	var rows []json.RawMessage
+	data, _ := QueryJSON(ctx, mock, "SELECT * FROM users")
+	if err := json.Unmarshal(data, &rows); err != nil {
+		t.Fatalf("Invalid QueryJSON output: %v", err)
+	}
+	expected := string(rows[0]) + "\n" + string(rows[1]) + "\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q (buffered writer must be flushed)", expected, buf.String())
+	}
+}
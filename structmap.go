@@ -0,0 +1,86 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// QueryStructsMap executes a query and returns its rows mapped into V, as
+// by QueryStructsT, keyed by the value of keyColumn converted to K as by
+// QueryScalar. The key column need not correspond to a field of V. A NULL
+// key is an error, and so is a key shared by two rows unless the KeepLast
+// option is passed, in which case the later row wins. Errors name rows
+// counting from 1, as QueryColumn's do.
+//
+//	users, err := dbx.QueryStructsMap[int, User](ctx, db, "SELECT * FROM users WHERE id = ANY($1)", "id", ids)
+func QueryStructsMap[K comparable, V any](ctx context.Context, db DB, sql string, keyColumn string, args ...any) (map[K]V, error) {
+	opts, args := splitArgs(args)
+
+	elemType, isPtr, err := structElemType(reflect.TypeOf([]V(nil)))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query(ctx, db, "QueryStructsMap", sql, args, opts)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	keyIndex := -1
+	for i, fd := range rows.FieldDescriptions() {
+		if fd.Name == keyColumn {
+			keyIndex = i
+			break
+		}
+	}
+	if keyIndex < 0 {
+		return nil, fmt.Errorf("key column %q not in result", keyColumn)
+	}
+	keyOID := rows.FieldDescriptions()[keyIndex].DataTypeOID
+
+	scanner, err := newStructScanner(rows, elemType, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[K]V)
+	for row := 0; rows.Next(); row++ {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get row values: %w", err)
+		}
+
+		if values[keyIndex] == nil {
+			return nil, fmt.Errorf("row %d: key column %q is NULL", row+1, keyColumn)
+		}
+		var key K
+		if err := assignColumn(reflect.ValueOf(&key).Elem(), values[keyIndex], keyOID, opts); err != nil {
+			return nil, fmt.Errorf("row %d: key column %q: %w", row+1, keyColumn, err)
+		}
+		if _, exists := result[key]; exists && !opts.keepLast {
+			return nil, fmt.Errorf("duplicate key %v in column %q; pass dbx.KeepLast() to keep the last row", key, keyColumn)
+		}
+
+		elem := reflect.New(elemType)
+		if err := scanner.scan(values, elem.Elem()); err != nil {
+			return nil, err
+		}
+		if err := afterScan(ctx, elem); err != nil {
+			return nil, fmt.Errorf("row %d: %w", row+1, err)
+		}
+
+		if isPtr {
+			result[key] = elem.Interface().(V)
+		} else {
+			result[key] = elem.Elem().Interface().(V)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,77 @@
+package dbx
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQueryStructsMap(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id", "name"),
+		rows: []mockRow{
+			{values: []interface{}{int64(1), "John"}},
+			{values: []interface{}{int64(2), "Jane"}},
+		},
+	}
+
+	type User struct {
+		Name string `db:"name"`
+	}
+
+	users, err := QueryStructsMap[int, User](ctx, mock, "SELECT id, name FROM users", "id")
+	if err != nil {
+		t.Fatalf("QueryStructsMap failed: %v", err)
+	}
+	expected := map[int]User{1: {Name: "John"}, 2: {Name: "Jane"}}
+	if !reflect.DeepEqual(users, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, users)
+	}
+
+	byName, err := QueryStructsMap[string, *User](ctx, mock, "SELECT id, name FROM users", "name")
+	if err != nil {
+		t.Fatalf("QueryStructsMap failed: %v", err)
+	}
+	if len(byName) != 2 || byName["Jane"].Name != "Jane" {
+		t.Errorf("Expected rows keyed by name, got %+v", byName)
+	}
+
+	if _, err := QueryStructsMap[int, User](ctx, mock, "SELECT id, name FROM users", "user_id"); err == nil || !strings.Contains(err.Error(), "user_id") {
+		t.Errorf("Expected error for a missing key column, got %v", err)
+	}
+}
+
+func TestQueryStructsMapDuplicateKeys(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("team", "name"),
+		rows: []mockRow{
+			{values: []interface{}{"red", "John"}},
+			{values: []interface{}{"red", "Jane"}},
+		},
+	}
+
+	type Member struct {
+		Name string `db:"name"`
+	}
+
+	if _, err := QueryStructsMap[string, Member](ctx, mock, "SELECT team, name FROM members", "team"); err == nil || !strings.Contains(err.Error(), "duplicate key red") {
+		t.Errorf("Expected duplicate key error, got %v", err)
+	}
+
+	members, err := QueryStructsMap[string, Member](ctx, mock, "SELECT team, name FROM members", "team", KeepLast())
+	if err != nil {
+		t.Fatalf("QueryStructsMap failed: %v", err)
+	}
+	if members["red"].Name != "Jane" {
+		t.Errorf("Expected the last row to win, got %+v", members)
+	}
+
+	// Rows are numbered from 1, as by QueryColumn
+	mock.rows = []mockRow{{values: []interface{}{"red", "John"}}, {values: []interface{}{nil, "Jane"}}}
+	if _, err := QueryStructsMap[string, Member](ctx, mock, "SELECT team, name FROM members", "team"); err == nil || !strings.Contains(err.Error(), `row 2: key column "team" is NULL`) {
+		t.Errorf("Expected NULL key error for row 2, got %v", err)
+	}
+}
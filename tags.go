@@ -0,0 +1,73 @@
+package dbx
+
+import (
+	"reflect"
+	"strings"
+)
+
+// tagOptions is the comma-separated list of options following the name in a
+// db struct tag, e.g. "pk" in db:"users.id,pk".
+type tagOptions []string
+
+// Contains reports whether the option list includes opt.
+func (o tagOptions) Contains(opt string) bool {
+	for _, o := range o {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// omits reports whether a field with these options and value v is left
+// out of an INSERT: with pk or omitzero when v is its zero value, so that
+// serial and identity keys are generated, and with omitempty when v is
+// false, 0, a nil pointer or interface, or an empty string, slice, map, or
+// array. A pointer to a zero value is not omitted.
+func (o tagOptions) omits(v reflect.Value) bool {
+	if (o.Contains("pk") || o.Contains("omitzero")) && v.IsZero() {
+		return true
+	}
+	if !o.Contains("omitempty") {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// timestamp reports whether the options mark a field that is set to the
+// current time automatically, with createdAt or updatedAt.
+func (o tagOptions) timestamp() bool {
+	return o.Contains("createdAt") || o.Contains("updatedAt")
+}
+
+// parseTag splits a db struct tag into its name and options.
+func parseTag(tag string) (string, tagOptions) {
+	name, rest, found := strings.Cut(tag, ",")
+	if !found {
+		return name, nil
+	}
+	return name, tagOptions(strings.Split(rest, ","))
+}
+
+// columnFromTag returns the column part of a tag name, dropping any
+// "table." prefix.
+func columnFromTag(name string) string {
+	if dotIndex := strings.Index(name, "."); dotIndex != -1 {
+		return name[dotIndex+1:]
+	}
+	return name
+}
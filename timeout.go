@@ -0,0 +1,56 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeoutError reports a statement stopped by the Timeout option. It
+// matches context.DeadlineExceeded with errors.Is, and reaches callers
+// wrapped in a *QueryError, which holds the statement's SQL.
+type TimeoutError struct {
+	Timeout time.Duration // the Timeout option's duration
+	Err     error         // error returned by the driver
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s: %v", e.Timeout, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is context.DeadlineExceeded, which a timeout
+// always is, whatever error the driver returned.
+func (e *TimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// IsTimeout reports whether err was caused by the Timeout option expiring,
+// as opposed to a deadline on the caller's context.
+func IsTimeout(err error) bool {
+	var timeoutErr *TimeoutError
+	return errors.As(err, &timeoutErr)
+}
+
+// withTimeout returns ctx bounded by the Timeout option, if one is set. The
+// cancel function must be called once the statement is finished with.
+func withTimeout(ctx context.Context, opts *options) (context.Context, context.CancelFunc) {
+	if opts.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, opts.timeout)
+}
+
+// timeoutError returns err as a *TimeoutError if it occurred because
+// statementCtx, derived from ctx by withTimeout, expired while ctx itself
+// was still live. Otherwise err is returned unchanged.
+func timeoutError(ctx, statementCtx context.Context, timeout time.Duration, err error) error {
+	if err == nil || ctx.Err() != nil || !errors.Is(statementCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return &TimeoutError{Timeout: timeout, Err: err}
+}
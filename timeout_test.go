@@ -0,0 +1,100 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// blockingQueryer waits for the statement's context to end and fails with
+// its error, recording the context it was given.
+type blockingQueryer struct {
+	ctx context.Context
+}
+
+func (b *blockingQueryer) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	b.ctx = ctx
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (b *blockingQueryer) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	b.ctx = ctx
+	<-ctx.Done()
+	return pgconn.CommandTag{}, ctx.Err()
+}
+
+func TestTimeout(t *testing.T) {
+	db := &blockingQueryer{}
+
+	var users []insertUser
+	err := QueryStructs(context.Background(), db, "SELECT * FROM users", &users, Timeout(10*time.Millisecond))
+	if !IsTimeout(err) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error to match context.DeadlineExceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "SELECT * FROM users") || !strings.Contains(err.Error(), "timed out after 10ms") {
+		t.Errorf("error %q should include the SQL and the timeout", err)
+	}
+}
+
+func TestTimeoutCallerDeadlineWins(t *testing.T) {
+	db := &blockingQueryer{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := Exec(ctx, db, "DELETE FROM users", Timeout(time.Hour))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if IsTimeout(err) {
+		t.Errorf("caller's deadline reported as a dbx timeout: %v", err)
+	}
+}
+
+func TestTimeoutDefault(t *testing.T) {
+	SetDefaults(Timeout(10 * time.Millisecond))
+	defer SetDefaults()
+
+	db := &blockingQueryer{}
+	err := InsertStruct(context.Background(), db, "users", insertUser{Name: "John"})
+	if !IsTimeout(err) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+}
+
+func TestTimeoutReleasesContext(t *testing.T) {
+	mock := &mockQueryer{}
+	db := &contextRecorder{DB: mock}
+
+	var users []insertUser
+	if err := QueryStructs(context.Background(), db, "SELECT * FROM users", &users, Timeout(time.Hour)); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	if _, ok := db.ctx.Deadline(); !ok {
+		t.Error("statement context has no deadline")
+	}
+	if db.ctx.Err() != context.Canceled {
+		t.Errorf("statement context not canceled after rows closed: %v", db.ctx.Err())
+	}
+}
+
+// contextRecorder records the context of the most recent query.
+type contextRecorder struct {
+	DB
+	ctx context.Context
+}
+
+func (c *contextRecorder) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	c.ctx = ctx
+	return c.DB.Query(ctx, sql, args...)
+}
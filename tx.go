@@ -0,0 +1,148 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Beginner is implemented by database handles that can start a
+// transaction. *pgxpool.Pool, *pgx.Conn and pgx.Tx all satisfy it.
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// TxBeginner is implemented by database handles that can start a
+// transaction with options. *pgxpool.Pool and *pgx.Conn satisfy it.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// RunInTx runs fn in a transaction begun on db. The transaction is committed
+// if fn returns nil and rolled back if it returns an error or panics; a
+// panic is re-raised after the rollback. fn must use the DB it is given,
+// not db, for its statements to be part of the transaction. If db is
+// itself a pgx.Tx, the inner transaction is a savepoint, as for RunNested.
+//
+//	err := dbx.RunInTx(ctx, pool, func(tx dbx.DB) error {
+//		if _, err := dbx.UpdateStruct(ctx, tx, "accounts", from, "id = $1", from.ID); err != nil {
+//			return err
+//		}
+//		_, err := dbx.UpdateStruct(ctx, tx, "accounts", to, "id = $1", to.ID)
+//		return err
+//	})
+func RunInTx(ctx context.Context, db Beginner, fn func(tx DB) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin failed: %w", err)
+	}
+	return runTx(ctx, tx, fn)
+}
+
+// RunNested runs fn in a transaction if db is a pool or connection, or in a
+// savepoint if db is already a transaction, so library code can work the
+// same way whether or not its caller has a transaction open. When fn fails
+// inside a savepoint only the savepoint is rolled back, and the caller's
+// transaction may carry on. db must be able to begin transactions, as
+// *pgxpool.Pool, *pgx.Conn and pgx.Tx can.
+func RunNested(ctx context.Context, db DB, fn func(tx DB) error) error {
+	b, ok := db.(Beginner)
+	if !ok {
+		return fmt.Errorf("%T cannot begin transactions", db)
+	}
+	return RunInTx(ctx, b, fn)
+}
+
+// RunInTxOpts is like RunInTx but begins the transaction with the given
+// options, such as the isolation level and access mode.
+func RunInTxOpts(ctx context.Context, db TxBeginner, opts pgx.TxOptions, fn func(tx DB) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("begin failed: %w", err)
+	}
+	return runTx(ctx, tx, fn)
+}
+
+// Delays between RunInTxRetry attempts. The delay doubles after each
+// attempt, up to the maximum, and is then jittered.
+var (
+	txRetryBaseDelay = 10 * time.Millisecond
+	txRetryMaxDelay  = time.Second
+)
+
+// RunInTxRetry is like RunInTxOpts but runs the whole transaction again,
+// up to maxAttempts times in all, when it fails with a serialization
+// failure (SQLSTATE 40001) or deadlock (40P01), as SERIALIZABLE
+// transactions are expected to. Attempts are separated by exponential
+// backoff with jitter. Other errors are returned at once.
+//
+// Because fn may run more than once, it must be idempotent: it should only
+// affect the database through the transaction it is given, and must not
+// have side effects such as sending email or mutating captured state that
+// would be wrong to repeat.
+//
+// If every attempt fails, the last error is returned wrapped with the
+// attempt count. Canceling ctx stops the retries immediately.
+func RunInTxRetry(ctx context.Context, db TxBeginner, opts pgx.TxOptions, maxAttempts int, fn func(tx DB) error) error {
+	if maxAttempts < 1 {
+		return fmt.Errorf("maxAttempts must be at least 1, got %d", maxAttempts)
+	}
+
+	delay := txRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := RunInTxOpts(ctx, db, opts, fn)
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+		if attempt == maxAttempts {
+			return fmt.Errorf("transaction failed after %d attempts: %w", attempt, err)
+		}
+
+		// Sleep for a random duration between half and all of delay
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("transaction retry aborted after %d attempts: %w", attempt, ctx.Err())
+		case <-timer.C:
+		}
+		delay = min(delay*2, txRetryMaxDelay)
+	}
+}
+
+// isRetryable reports whether err is a serialization failure or deadlock,
+// after which a transaction can be retried.
+func isRetryable(err error) bool {
+	return hasSQLState(err, codeSerializationFailure) || hasSQLState(err, codeDeadlockDetected)
+}
+
+// runTx calls fn with tx, then commits or rolls back tx.
+func runTx(ctx context.Context, tx pgx.Tx, fn func(tx DB) error) error {
+	// Roll back even if ctx has been canceled, so the connection is
+	// released cleanly
+	rollbackCtx := context.WithoutCancel(ctx)
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(rollbackCtx)
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(rollbackCtx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
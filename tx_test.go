@@ -0,0 +1,302 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// mockTx records how a transaction ended. Statements are passed to db, and
+// Begin starts a savepoint recorded in savepoints.
+type mockTx struct {
+	pgx.Tx
+	db          *mockQueryer
+	committed   bool
+	rolledBack  bool
+	commitErr   error
+	rollbackErr error
+	savepoints  []*mockTx
+}
+
+func (m *mockTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	sp := &mockTx{db: m.db}
+	m.savepoints = append(m.savepoints, sp)
+	return sp, nil
+}
+
+func (m *mockTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return m.db.Query(ctx, sql, args...)
+}
+
+func (m *mockTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return m.db.Exec(ctx, sql, args...)
+}
+
+func (m *mockTx) Commit(ctx context.Context) error {
+	m.committed = true
+	return m.commitErr
+}
+
+func (m *mockTx) Rollback(ctx context.Context) error {
+	if m.committed {
+		return pgx.ErrTxClosed
+	}
+	m.rolledBack = true
+	return m.rollbackErr
+}
+
+// mockBeginner hands out a fresh mockTx for every transaction begun.
+type mockBeginner struct {
+	txs  []*mockTx
+	opts pgx.TxOptions
+	err  error
+}
+
+func (m *mockBeginner) Begin(ctx context.Context) (pgx.Tx, error) {
+	return m.BeginTx(ctx, pgx.TxOptions{})
+}
+
+func (m *mockBeginner) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.opts = opts
+	tx := &mockTx{db: &mockQueryer{execTag: pgconn.NewCommandTag("UPDATE 1")}}
+	m.txs = append(m.txs, tx)
+	return tx, nil
+}
+
+func TestRunInTxCommits(t *testing.T) {
+	ctx := context.Background()
+	db := &mockBeginner{}
+
+	err := RunInTx(ctx, db, func(tx DB) error {
+		_, err := Exec(ctx, tx, "UPDATE accounts SET balance = balance - $1 WHERE id = $2", 10, 1)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("RunInTx failed: %v", err)
+	}
+
+	tx := db.txs[0]
+	if !tx.committed || tx.rolledBack {
+		t.Errorf("Expected commit only, got committed=%v rolledBack=%v", tx.committed, tx.rolledBack)
+	}
+	if tx.db.execCount != 1 {
+		t.Errorf("Expected statement to run in the transaction, got %d", tx.db.execCount)
+	}
+}
+
+func TestRunInTxRollsBackOnError(t *testing.T) {
+	ctx := context.Background()
+	db := &mockBeginner{}
+	fnErr := errors.New("insufficient funds")
+
+	err := RunInTx(ctx, db, func(tx DB) error { return fnErr })
+	if !errors.Is(err, fnErr) {
+		t.Errorf("Expected fn error, got %v", err)
+	}
+
+	tx := db.txs[0]
+	if tx.committed || !tx.rolledBack {
+		t.Errorf("Expected rollback only, got committed=%v rolledBack=%v", tx.committed, tx.rolledBack)
+	}
+}
+
+func TestRunInTxReportsRollbackFailure(t *testing.T) {
+	ctx := context.Background()
+	db := &mockBeginner{}
+	fnErr := errors.New("insufficient funds")
+
+	err := RunInTx(ctx, db, func(tx DB) error {
+		tx.(*mockTx).rollbackErr = errors.New("conn busy")
+		return fnErr
+	})
+	if !errors.Is(err, fnErr) || !strings.Contains(err.Error(), "rollback failed: conn busy") {
+		t.Errorf("Expected fn error with rollback failure, got %v", err)
+	}
+}
+
+func TestRunInTxRollsBackOnPanic(t *testing.T) {
+	ctx := context.Background()
+	db := &mockBeginner{}
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("Expected panic to be re-raised, got %v", p)
+		}
+		if tx := db.txs[0]; tx.committed || !tx.rolledBack {
+			t.Errorf("Expected rollback only, got committed=%v rolledBack=%v", tx.committed, tx.rolledBack)
+		}
+	}()
+
+	_ = RunInTx(ctx, db, func(tx DB) error { panic("boom") })
+}
+
+func TestRunInTxBeginAndCommitErrors(t *testing.T) {
+	ctx := context.Background()
+
+	db := &mockBeginner{err: errors.New("pool closed")}
+	if err := RunInTx(ctx, db, func(tx DB) error { return nil }); err == nil || !strings.Contains(err.Error(), "begin failed") {
+		t.Errorf("Expected begin error, got %v", err)
+	}
+
+	db = &mockBeginner{}
+	err := RunInTx(ctx, db, func(tx DB) error {
+		tx.(*mockTx).commitErr = errors.New("serialization failure")
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "commit failed") {
+		t.Errorf("Expected commit error, got %v", err)
+	}
+}
+
+func TestRunInTxOpts(t *testing.T) {
+	ctx := context.Background()
+	db := &mockBeginner{}
+
+	opts := pgx.TxOptions{IsoLevel: pgx.Serializable, AccessMode: pgx.ReadOnly}
+	if err := RunInTxOpts(ctx, db, opts, func(tx DB) error { return nil }); err != nil {
+		t.Fatalf("RunInTxOpts failed: %v", err)
+	}
+	if db.opts != opts {
+		t.Errorf("Expected options %+v, got %+v", opts, db.opts)
+	}
+	if !db.txs[0].committed {
+		t.Error("Expected commit")
+	}
+}
+
+func TestRunInTxRetry(t *testing.T) {
+	ctx := context.Background()
+	db := &mockBeginner{}
+	defer setRetryDelay(time.Millisecond)()
+
+	calls := 0
+	err := RunInTxRetry(ctx, db, pgx.TxOptions{IsoLevel: pgx.Serializable}, 3, func(tx DB) error {
+		calls++
+		if calls < 3 {
+			return &pgconn.PgError{Code: "40001"}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunInTxRetry failed: %v", err)
+	}
+	if calls != 3 || len(db.txs) != 3 {
+		t.Errorf("Expected 3 attempts, got %d calls and %d transactions", calls, len(db.txs))
+	}
+	if !db.txs[0].rolledBack || !db.txs[1].rolledBack || !db.txs[2].committed {
+		t.Error("Expected failed attempts rolled back and the last committed")
+	}
+}
+
+func TestRunInTxRetryGivesUp(t *testing.T) {
+	ctx := context.Background()
+	db := &mockBeginner{}
+	defer setRetryDelay(time.Millisecond)()
+
+	err := RunInTxRetry(ctx, db, pgx.TxOptions{}, 2, func(tx DB) error {
+		return &pgconn.PgError{Code: "40P01"}
+	})
+	if err == nil || !strings.Contains(err.Error(), "after 2 attempts") {
+		t.Errorf("Expected attempt count in error, got %v", err)
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "40P01" {
+		t.Errorf("Expected last error to be wrapped, got %v", err)
+	}
+	if len(db.txs) != 2 {
+		t.Errorf("Expected 2 attempts, got %d", len(db.txs))
+	}
+}
+
+func TestRunInTxRetryOtherErrors(t *testing.T) {
+	ctx := context.Background()
+	db := &mockBeginner{}
+
+	err := RunInTxRetry(ctx, db, pgx.TxOptions{}, 5, func(tx DB) error {
+		return &pgconn.PgError{Code: "23505"}
+	})
+	if !IsUniqueViolation(err) {
+		t.Errorf("Expected unique violation, got %v", err)
+	}
+	if len(db.txs) != 1 {
+		t.Errorf("Expected no retries, got %d attempts", len(db.txs))
+	}
+
+	if err := RunInTxRetry(ctx, db, pgx.TxOptions{}, 0, func(tx DB) error { return nil }); err == nil {
+		t.Error("Expected error for maxAttempts 0")
+	}
+}
+
+func TestRunInTxRetryCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	db := &mockBeginner{}
+	defer setRetryDelay(time.Hour)()
+
+	err := RunInTxRetry(ctx, db, pgx.TxOptions{}, 5, func(tx DB) error {
+		cancel()
+		return &pgconn.PgError{Code: "40001"}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(db.txs) != 1 {
+		t.Errorf("Expected a single attempt, got %d", len(db.txs))
+	}
+}
+
+func TestRunNested(t *testing.T) {
+	ctx := context.Background()
+	db := &mockBeginner{}
+	innerErr := errors.New("duplicate line item")
+
+	err := RunInTx(ctx, db, func(tx DB) error {
+		if err := RunNested(ctx, tx, func(sp DB) error { return innerErr }); !errors.Is(err, innerErr) {
+			t.Errorf("Expected inner error, got %v", err)
+		}
+		return RunNested(ctx, tx, func(sp DB) error {
+			_, err := Exec(ctx, sp, "INSERT INTO line_item (id) VALUES ($1)", 1)
+			return err
+		})
+	})
+	if err != nil {
+		t.Fatalf("RunInTx failed: %v", err)
+	}
+
+	outer := db.txs[0]
+	if !outer.committed || outer.rolledBack {
+		t.Errorf("Expected outer transaction committed, got committed=%v rolledBack=%v", outer.committed, outer.rolledBack)
+	}
+	if len(outer.savepoints) != 2 {
+		t.Fatalf("Expected 2 savepoints, got %d", len(outer.savepoints))
+	}
+	if !outer.savepoints[0].rolledBack || outer.savepoints[0].committed {
+		t.Error("Expected first savepoint rolled back")
+	}
+	if !outer.savepoints[1].committed {
+		t.Error("Expected second savepoint released")
+	}
+}
+
+func TestRunNestedRequiresBeginner(t *testing.T) {
+	ctx := context.Background()
+
+	if err := RunNested(ctx, &mockQueryer{}, func(DB) error { return nil }); err == nil {
+		t.Error("Expected error for a DB that cannot begin transactions")
+	}
+}
+
+// setRetryDelay sets the base RunInTxRetry delay and returns a function
+// restoring the previous one.
+func setRetryDelay(d time.Duration) func() {
+	prev := txRetryBaseDelay
+	txRetryBaseDelay = d
+	return func() { txRetryBaseDelay = prev }
+}
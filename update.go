@@ -0,0 +1,261 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// errWhereRequired is returned by DeleteWhere and UpdateWhere when called
+// with an empty where clause and without the AllRows option.
+var errWhereRequired = errors.New("where clause is required; pass dbx.AllRows() to affect every row")
+
+// ErrStaleRow is returned by UpdateStruct when the struct has a
+// version-tagged field and no row matched both the where clause and the
+// struct's version, meaning the row was changed or deleted since it was read.
+var ErrStaleRow = errors.New("dbx: row was modified concurrently")
+
+// UpdateStruct updates rows in the specified table from a struct.
+// It uses db:"column" tags to build the SET list, the same way InsertStruct
+// builds its column list, except that pk-tagged columns are never set. The
+// where clause uses its own $1, $2, ... placeholders for whereArgs; they are
+// renumbered to follow the SET placeholders.
+// Names are quoted as by InsertStruct, but the where clause is used as
+// written. It returns the number of rows affected.
+//
+// An integer field tagged version, as in db:"version,version", enables
+// optimistic locking: the row is only updated if its version still equals
+// the field, the version is incremented, and ErrStaleRow is returned if no
+// row matched. When data is a pointer the field is incremented as well, so
+// the struct can be saved again.
+//
+// Fields tagged createdAt are never updated, and fields tagged updatedAt are
+// always set to the database's now(), whatever value the struct holds, so
+// that saving a loaded row bumps its update time.
+func UpdateStruct(ctx context.Context, db DB, table string, data any, where string, whereArgs ...any) (int64, error) {
+	data, err := beforeUpdate(ctx, data)
+	if err != nil {
+		return 0, err
+	}
+
+	sql, args, version, err := buildUpdateStruct(db, table, data, where, whereArgs)
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := exec(ctx, db, "UpdateStruct", sql, args, nil)
+	if err != nil {
+		return 0, fmt.Errorf("update failed: %w", err)
+	}
+
+	if version != nil {
+		if tag.RowsAffected() == 0 {
+			return 0, ErrStaleRow
+		}
+		if v := reflect.ValueOf(data); v.Kind() == reflect.Pointer {
+			field := version.settable(v.Elem())
+			if field.CanInt() {
+				field.SetInt(field.Int() + 1)
+			} else {
+				field.SetUint(field.Uint() + 1)
+			}
+		}
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// buildUpdateStruct builds the UPDATE statement and arguments for a struct.
+// db supplies the default schema, if any, and may be nil. version is the
+// struct's version-tagged field, or nil if it has none.
+func buildUpdateStruct(db DB, table string, data any, where string, whereArgs []any) (sql string, args []any, version *fieldMeta, err error) {
+	fields, values, err := extractStructFields(data)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to extract struct fields: %w", err)
+	}
+	keys, _, err := extractPrimaryKey(data)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to extract struct fields: %w", err)
+	}
+	version, current, err := versionField(data)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	created, updated := timestampColumns(data)
+
+	// Primary keys identify the row and are left out of the SET list, as are
+	// the version, which is incremented instead, creation times, which
+	// don't change, and update times, which are set to now()
+	n := 0
+	for i, field := range fields {
+		if !containsString(keys, field) && (version == nil || field != version.Column) &&
+			!containsString(created, field) && !containsString(updated, field) {
+			fields[n], values[n] = field, values[i]
+			n++
+		}
+	}
+	fields, values = fields[:n], values[:n]
+
+	if len(fields) == 0 && len(updated) == 0 {
+		return "", nil, nil, fmt.Errorf("no valid fields found for update")
+	}
+
+	if strings.TrimSpace(where) == "" {
+		return "", nil, nil, fmt.Errorf("where clause is required for update")
+	}
+
+	quotedTable, err := qualifyTable(db, table)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	quotedFields, err := quoteColumns(fields)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	assignments := make([]string, len(quotedFields))
+	for i, field := range quotedFields {
+		assignments[i] = fmt.Sprintf("%s = $%d", field, i+1)
+	}
+	for _, column := range updated {
+		quoted, err := quoteColumn(column)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		assignments = append(assignments, fmt.Sprintf("%s = %s", quoted, sqlNow))
+	}
+
+	where = shiftPlaceholders(where, len(fields))
+	args = append(values, whereArgs...)
+
+	if version != nil {
+		quoted, err := quoteColumn(version.Column)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		assignments = append(assignments, fmt.Sprintf("%s = %s + 1", quoted, quoted))
+		args = append(args, current)
+		where = fmt.Sprintf("(%s) AND %s = $%d", where, quoted, len(args))
+	}
+
+	sql = fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+		quotedTable,
+		strings.Join(assignments, ", "),
+		where,
+	)
+
+	return sql, args, version, nil
+}
+
+// timestampColumns returns the columns of the fields of the struct in data
+// tagged createdAt and updatedAt.
+func timestampColumns(data any) (created, updated []string) {
+	t := reflect.TypeOf(data)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	for _, f := range getStructMeta(t, defaults()).Fields {
+		switch {
+		case f.Nested() || f.Options.Contains("readonly"):
+		case f.Options.Contains("createdAt"):
+			created = append(created, f.Column)
+		case f.Options.Contains("updatedAt"):
+			updated = append(updated, f.Column)
+		}
+	}
+	return created, updated
+}
+
+// versionField returns the version-tagged field of the struct in data and
+// its value, or nil if there is none. The field must be an integer.
+func versionField(data any) (*fieldMeta, any, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	meta := getStructMeta(v.Type(), defaults())
+	for i := range meta.Fields {
+		f := &meta.Fields[i]
+		if !f.Options.Contains("version") || f.Nested() {
+			continue
+		}
+
+		field, ok := f.value(v)
+		if !ok {
+			return nil, nil, fmt.Errorf("version field %s is behind a nil pointer", f.Name)
+		}
+		if !field.CanInt() && !field.CanUint() {
+			return nil, nil, fmt.Errorf("version field %s must be an integer, got %s", f.Name, field.Type())
+		}
+		return f, field.Interface(), nil
+	}
+	return nil, nil, nil
+}
+
+// UpdateWhere sets the columns in set on the rows of table matching where
+// and returns the number of rows affected. Columns are written in sorted
+// order so the generated SQL is stable. The where clause uses its own $1,
+// $2, ... placeholders for args; they are renumbered to follow the SET
+// placeholders. An empty where is refused unless the AllRows option is
+// passed among the args. Table and column names are validated and quoted.
+//
+//	n, err := dbx.UpdateWhere(ctx, db, "users", map[string]any{"active": false}, "last_login < $1", cutoff)
+func UpdateWhere(ctx context.Context, db DB, table string, set map[string]any, where string, args ...any) (int64, error) {
+	opts, args := splitArgs(args)
+
+	sql, args, err := buildUpdateWhere(db, table, set, where, opts, args)
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := exec(ctx, db, "UpdateWhere", sql, args, opts)
+	if err != nil {
+		return 0, fmt.Errorf("update failed: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// buildUpdateWhere builds the UPDATE statement and arguments for UpdateWhere.
+func buildUpdateWhere(db DB, table string, set map[string]any, where string, opts *options, whereArgs []any) (string, []any, error) {
+	quoted, err := qualifyTable(db, table)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(set) == 0 {
+		return "", nil, fmt.Errorf("no columns to update")
+	}
+
+	columns := make([]string, 0, len(set))
+	for column := range set {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	assignments := make([]string, len(columns))
+	args := make([]any, 0, len(columns)+len(whereArgs))
+	for i, column := range columns {
+		quotedColumn, err := quoteColumn(column)
+		if err != nil {
+			return "", nil, err
+		}
+		assignments[i] = fmt.Sprintf("%s = $%d", quotedColumn, i+1)
+		args = append(args, set[column])
+	}
+
+	sql := fmt.Sprintf("UPDATE %s SET %s", quoted, strings.Join(assignments, ", "))
+	if strings.TrimSpace(where) != "" {
+		sql += " WHERE " + shiftPlaceholders(where, len(columns))
+	} else if !opts.allRows {
+		return "", nil, errWhereRequired
+	}
+
+	return sql, append(args, whereArgs...), nil
+}
@@ -0,0 +1,209 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestUpdateStruct(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("UPDATE 1")}
+
+	type TestUser struct {
+		Name  string `db:"users.name"`
+		Email string `db:"users.email"`
+		Age   int    `db:"-"`
+	}
+
+	user := TestUser{Name: "John", Email: "john@example.com", Age: 30}
+	affected, err := UpdateStruct(ctx, mock, "users", user, "id = $1", 7)
+	if err != nil {
+		t.Fatalf("UpdateStruct failed: %v", err)
+	}
+
+	if affected != 1 {
+		t.Errorf("Expected 1 row affected, got %d", affected)
+	}
+
+	expectedSQL := `UPDATE "users" SET "name" = $1, "email" = $2 WHERE id = $3`
+	if mock.lastSQL != expectedSQL {
+		t.Errorf("Expected SQL %q, got %q", expectedSQL, mock.lastSQL)
+	}
+
+	expectedArgs := []interface{}{"John", "john@example.com", 7}
+	if !reflect.DeepEqual(mock.lastArgs, expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, mock.lastArgs)
+	}
+}
+
+func TestUpdateStructSkipsKeysAndReadonly(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("UPDATE 1")}
+
+	type TestUser struct {
+		ID        int    `db:"users.id,pk"`
+		Name      string `db:"users.name"`
+		CreatedAt string `db:"created_at,readonly"`
+	}
+
+	user := TestUser{ID: 7, Name: "John", CreatedAt: "yesterday"}
+	if _, err := UpdateStruct(ctx, mock, "users", user, "id = $1", user.ID); err != nil {
+		t.Fatalf("UpdateStruct failed: %v", err)
+	}
+
+	if want := `UPDATE "users" SET "name" = $1 WHERE id = $2`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+	if expected := []interface{}{"John", 7}; !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected args %v, got %v", expected, mock.lastArgs)
+	}
+
+	type KeyOnly struct {
+		ID int `db:"id,pk"`
+	}
+	if _, err := UpdateStruct(ctx, mock, "users", KeyOnly{ID: 7}, "id = $1", 7); err == nil {
+		t.Error("Expected error when only key columns remain")
+	}
+}
+
+func TestUpdateStructVersion(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("UPDATE 1")}
+
+	type Doc struct {
+		ID      int    `db:"id,pk"`
+		Title   string `db:"title"`
+		Version int64  `db:"version,version"`
+	}
+
+	doc := Doc{ID: 7, Title: "Draft", Version: 3}
+	if _, err := UpdateStruct(ctx, mock, "docs", &doc, "id = $1", doc.ID); err != nil {
+		t.Fatalf("UpdateStruct failed: %v", err)
+	}
+	if want := `UPDATE "docs" SET "title" = $1, "version" = "version" + 1 WHERE (id = $2) AND "version" = $3`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+	if expected := []interface{}{"Draft", 7, int64(3)}; !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected args %v, got %v", expected, mock.lastArgs)
+	}
+	if doc.Version != 4 {
+		t.Errorf("Expected version to be incremented to 4, got %d", doc.Version)
+	}
+
+	mock.execTag = pgconn.NewCommandTag("UPDATE 0")
+	if _, err := UpdateStruct(ctx, mock, "docs", &doc, "id = $1", doc.ID); !errors.Is(err, ErrStaleRow) {
+		t.Errorf("Expected ErrStaleRow, got %v", err)
+	}
+	if doc.Version != 4 {
+		t.Errorf("Expected version to stay 4 after a stale update, got %d", doc.Version)
+	}
+
+	type BadDoc struct {
+		Title   string `db:"title"`
+		Version string `db:"version,version"`
+	}
+	if _, err := UpdateStruct(ctx, mock, "docs", BadDoc{}, "id = $1", 1); err == nil {
+		t.Error("Expected error for a non-integer version field")
+	}
+}
+
+func TestUpdateStructTimestamps(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("UPDATE 1")}
+
+	type TestUser struct {
+		ID        int       `db:"id,pk"`
+		Name      string    `db:"name"`
+		CreatedAt time.Time `db:"created_at,createdAt"`
+		UpdatedAt time.Time `db:"updated_at,updatedAt"`
+	}
+
+	loaded := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	user := TestUser{ID: 7, Name: "John", CreatedAt: loaded, UpdatedAt: loaded}
+	if _, err := UpdateStruct(ctx, mock, "users", user, "id = $1", user.ID); err != nil {
+		t.Fatalf("UpdateStruct failed: %v", err)
+	}
+	if want := `UPDATE "users" SET "name" = $1, "updated_at" = now() WHERE id = $2`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+	if expected := []interface{}{"John", 7}; !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected args %v, got %v", expected, mock.lastArgs)
+	}
+
+	// A zero update time is set to now() too
+	user.UpdatedAt = time.Time{}
+	if _, err := UpdateStruct(ctx, mock, "users", user, "id = $1", user.ID); err != nil {
+		t.Fatalf("UpdateStruct failed: %v", err)
+	}
+	if want := `UPDATE "users" SET "name" = $1, "updated_at" = now() WHERE id = $2`; mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+}
+
+func TestUpdateStructRequiresWhere(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	type TestUser struct {
+		Name string `db:"name"`
+	}
+
+	if _, err := UpdateStruct(ctx, mock, "users", TestUser{Name: "John"}, " "); err == nil {
+		t.Error("Expected error for empty where clause")
+	}
+	if mock.lastSQL != "" {
+		t.Errorf("Expected no statement to be executed, got %q", mock.lastSQL)
+	}
+}
+
+func TestUpdateWhere(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("UPDATE 2")}
+
+	set := map[string]any{"name": "John", "active": false, "order": 3}
+	affected, err := UpdateWhere(ctx, mock, "users", set, "team_id = $1 AND id <> $2", 4, 9)
+	if err != nil {
+		t.Fatalf("UpdateWhere failed: %v", err)
+	}
+	if affected != 2 {
+		t.Errorf("Expected 2 rows affected, got %d", affected)
+	}
+
+	expectedSQL := `UPDATE "users" SET "active" = $1, "name" = $2, "order" = $3 WHERE team_id = $4 AND id <> $5`
+	if mock.lastSQL != expectedSQL {
+		t.Errorf("Expected SQL %q, got %q", expectedSQL, mock.lastSQL)
+	}
+	if !reflect.DeepEqual(mock.lastArgs, []interface{}{false, "John", 3, 4, 9}) {
+		t.Errorf("Unexpected args: %v", mock.lastArgs)
+	}
+}
+
+func TestUpdateWhereErrors(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("UPDATE 10")}
+
+	if _, err := UpdateWhere(ctx, mock, "users", map[string]any{"active": false}, ""); err == nil {
+		t.Error("Expected error for empty where clause")
+	}
+	if _, err := UpdateWhere(ctx, mock, "users", nil, "id = $1", 1); err == nil {
+		t.Error("Expected error for empty set")
+	}
+	if _, err := UpdateWhere(ctx, mock, "users", map[string]any{"name = 'x', admin": true}, "id = $1", 1); err == nil {
+		t.Error("Expected error for invalid column name")
+	}
+	if mock.execCount != 0 {
+		t.Errorf("Expected no exec, got %d", mock.execCount)
+	}
+
+	if _, err := UpdateWhere(ctx, mock, "users", map[string]any{"active": false}, "", AllRows()); err != nil {
+		t.Fatalf("UpdateWhere failed: %v", err)
+	}
+	if mock.lastSQL != `UPDATE "users" SET "active" = $1` {
+		t.Errorf("Unexpected SQL: %q", mock.lastSQL)
+	}
+}
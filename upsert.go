@@ -0,0 +1,130 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// UpsertStruct inserts a struct into the specified table, updating the existing
+// row when the insert conflicts on conflictCols. Every extracted column that is
+// not a conflict column is overwritten with its EXCLUDED value; when there are
+// none left the statement uses DO NOTHING.
+// Zero fields tagged createdAt or updatedAt are set to the database's now(),
+// as by InsertStruct. On conflict createdAt columns keep their existing
+// value and updatedAt columns are set to now(), as by UpdateStruct.
+// The BeforeInsert hook runs before the fields are read, whether the row
+// ends up inserted or updated.
+// It reports whether a row was actually inserted or updated.
+func UpsertStruct(ctx context.Context, db DB, table string, data any, conflictCols []string) (bool, error) {
+	data, err := beforeInsert(ctx, data)
+	if err != nil {
+		return false, err
+	}
+	fields, _, err := extractStructFields(data)
+	if err != nil {
+		return false, fmt.Errorf("failed to extract struct fields: %w", err)
+	}
+	created, _ := timestampColumns(data)
+
+	var updateCols []string
+	for _, field := range fields {
+		if !containsString(conflictCols, field) && !containsString(created, field) {
+			updateCols = append(updateCols, field)
+		}
+	}
+
+	return upsertStruct(ctx, db, table, data, conflictCols, updateCols)
+}
+
+// UpsertStructUpdate is like UpsertStruct but only overwrites updateCols on
+// conflict. An empty updateCols produces ON CONFLICT ... DO NOTHING, in which
+// case conflictCols may also be empty to ignore any conflict. Timestamp
+// fields are inserted as by UpsertStruct, but overwritten on conflict only
+// if they are among updateCols, updatedAt columns with now(). The
+// BeforeInsert hook runs as for UpsertStruct.
+// It reports whether a row was actually inserted or updated.
+func UpsertStructUpdate(ctx context.Context, db DB, table string, data any, conflictCols, updateCols []string) (bool, error) {
+	data, err := beforeInsert(ctx, data)
+	if err != nil {
+		return false, err
+	}
+	return upsertStruct(ctx, db, table, data, conflictCols, updateCols)
+}
+
+// upsertStruct implements UpsertStructUpdate once the BeforeInsert hook has
+// run.
+func upsertStruct(ctx context.Context, db DB, table string, data any, conflictCols, updateCols []string) (bool, error) {
+	fields, values, err := extractStructFields(data)
+	if err != nil {
+		return false, fmt.Errorf("failed to extract struct fields: %w", err)
+	}
+
+	if len(fields) == 0 {
+		return false, fmt.Errorf("no valid fields found for insertion")
+	}
+	fillTimestamps(data, fields, values)
+	_, updated := timestampColumns(data)
+
+	if len(updateCols) > 0 && len(conflictCols) == 0 {
+		return false, fmt.Errorf("conflict columns are required for DO UPDATE")
+	}
+
+	quotedTable, err := qualifyTable(db, table)
+	if err != nil {
+		return false, err
+	}
+	quotedFields, err := quoteColumns(fields)
+	if err != nil {
+		return false, err
+	}
+	quotedConflict, err := quoteColumns(conflictCols)
+	if err != nil {
+		return false, err
+	}
+
+	sql, args := insertSQL(quotedTable, quotedFields, values)
+	sql += " ON CONFLICT"
+
+	if len(quotedConflict) > 0 {
+		sql += fmt.Sprintf(" (%s)", strings.Join(quotedConflict, ", "))
+	}
+
+	if len(updateCols) == 0 {
+		sql += " DO NOTHING"
+	} else {
+		assignments := make([]string, len(updateCols))
+		for i, col := range updateCols {
+			if !containsString(fields, col) {
+				return false, fmt.Errorf("update column %q has no corresponding struct field", col)
+			}
+			quoted, err := quoteColumn(col)
+			if err != nil {
+				return false, err
+			}
+			if containsString(updated, col) {
+				assignments[i] = fmt.Sprintf("%s = %s", quoted, sqlNow)
+			} else {
+				assignments[i] = fmt.Sprintf("%s = EXCLUDED.%s", quoted, quoted)
+			}
+		}
+		sql += " DO UPDATE SET " + strings.Join(assignments, ", ")
+	}
+
+	tag, err := exec(ctx, db, "UpsertStruct", sql, args, nil)
+	if err != nil {
+		return false, fmt.Errorf("upsert failed: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package dbx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type upsertUser struct {
+	ID    int    `db:"users.id,pk"`
+	Name  string `db:"users.name"`
+	Email string `db:"users.email"`
+}
+
+func TestUpsertStruct(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("INSERT 0 1")}
+
+	written, err := UpsertStruct(ctx, mock, "users", upsertUser{ID: 1, Name: "John", Email: "john@example.com"}, []string{"id"})
+	if err != nil {
+		t.Fatalf("UpsertStruct failed: %v", err)
+	}
+
+	if !written {
+		t.Error("Expected row to be reported as written")
+	}
+
+	expectedSQL := `INSERT INTO "users" ("id", "name", "email") VALUES ($1, $2, $3) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "email" = EXCLUDED."email"`
+	if mock.lastSQL != expectedSQL {
+		t.Errorf("Expected SQL %q, got %q", expectedSQL, mock.lastSQL)
+	}
+}
+
+func TestUpsertStructDoNothing(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("INSERT 0 0")}
+
+	written, err := UpsertStructUpdate(ctx, mock, "users", upsertUser{ID: 1, Name: "John"}, []string{"email"}, nil)
+	if err != nil {
+		t.Fatalf("UpsertStructUpdate failed: %v", err)
+	}
+
+	if written {
+		t.Error("Expected no row to be reported as written")
+	}
+
+	expectedSQL := `INSERT INTO "users" ("id", "name", "email") VALUES ($1, $2, $3) ON CONFLICT ("email") DO NOTHING`
+	if mock.lastSQL != expectedSQL {
+		t.Errorf("Expected SQL %q, got %q", expectedSQL, mock.lastSQL)
+	}
+}
+
+func TestUpsertStructUpdateUnknownColumn(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{}
+
+	_, err := UpsertStructUpdate(ctx, mock, "users", upsertUser{ID: 1}, []string{"id"}, []string{"nickname"})
+	if err == nil {
+		t.Error("Expected error for update column without a struct field")
+	}
+}
+
+func TestUpsertStructTimestamps(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{execTag: pgconn.NewCommandTag("INSERT 0 1")}
+
+	type TestUser struct {
+		ID        int       `db:"id,pk"`
+		Name      string    `db:"name"`
+		CreatedAt time.Time `db:"created_at,createdAt"`
+		UpdatedAt time.Time `db:"updated_at,updatedAt"`
+	}
+	set := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := UpsertStruct(ctx, mock, "users", TestUser{ID: 1, Name: "John", UpdatedAt: set}, []string{"id"}); err != nil {
+		t.Fatalf("UpsertStruct failed: %v", err)
+	}
+	want := `INSERT INTO "users" ("id", "name", "created_at", "updated_at") VALUES ($1, $2, now(), $3)` +
+		` ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "updated_at" = now()`
+	if mock.lastSQL != want {
+		t.Errorf("Expected SQL %q, got %q", want, mock.lastSQL)
+	}
+	if expected := []any{1, "John", set}; !reflect.DeepEqual(mock.lastArgs, expected) {
+		t.Errorf("Expected args %#v, got %#v", expected, mock.lastArgs)
+	}
+}
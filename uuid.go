@@ -0,0 +1,73 @@
+package dbx
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+)
+
+// uuidBytesType is the type pgx uses for uuid values.
+var uuidBytesType = reflect.TypeOf([16]byte{})
+
+// isUUIDType reports whether t has [16]byte as its underlying type, which
+// covers [16]byte itself and named types such as uuid.UUID.
+func isUUIDType(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Len() == 16 && t.Elem().Kind() == reflect.Uint8
+}
+
+// formatUUID returns the canonical hyphenated form of u.
+func formatUUID(u [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// parseUUID parses a UUID in canonical hyphenated form, with or without
+// hyphens and optional surrounding braces.
+func parseUUID(s string) ([16]byte, error) {
+	var u [16]byte
+
+	if len(s) == 38 && s[0] == '{' && s[37] == '}' {
+		s = s[1:37]
+	}
+
+	var digits []byte
+	switch len(s) {
+	case 36:
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return u, fmt.Errorf("invalid UUID %q", s)
+		}
+		digits = make([]byte, 0, 32)
+		digits = append(digits, s[0:8]...)
+		digits = append(digits, s[9:13]...)
+		digits = append(digits, s[14:18]...)
+		digits = append(digits, s[19:23]...)
+		digits = append(digits, s[24:]...)
+	case 32:
+		digits = []byte(s)
+	default:
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+
+	if _, err := hex.Decode(u[:], digits); err != nil {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	return u, nil
+}
+
+// uuidValue returns the [16]byte held in v if its type is [16]byte or a
+// named [16]byte type.
+func uuidValue(v reflect.Value) ([16]byte, bool) {
+	if v.IsValid() && isUUIDType(v.Type()) {
+		return v.Convert(uuidBytesType).Interface().([16]byte), true
+	}
+	return [16]byte{}, false
+}
@@ -0,0 +1,136 @@
+package dbx
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// testUUID mirrors github.com/google/uuid.UUID: a named [16]byte that
+// implements sql.Scanner and driver.Valuer.
+type testUUID [16]byte
+
+func (u *testUUID) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("testUUID: cannot scan %T", src)
+	}
+	parsed, err := parseUUID(s)
+	*u = parsed
+	return err
+}
+
+func (u testUUID) Value() (driver.Value, error) {
+	return formatUUID(u), nil
+}
+
+// plainUUID is a named [16]byte without any methods.
+type plainUUID [16]byte
+
+var testUUIDBytes = [16]byte{0x55, 0x0e, 0x84, 0x00, 0xe2, 0x9b, 0x41, 0xd4, 0xa7, 0x16, 0x44, 0x66, 0x55, 0x44, 0x00, 0x00}
+
+const testUUIDString = "550e8400-e29b-41d4-a716-446655440000"
+
+func TestFormatAndParseUUID(t *testing.T) {
+	if got := formatUUID(testUUIDBytes); got != testUUIDString {
+		t.Errorf("formatUUID = %q, want %q", got, testUUIDString)
+	}
+
+	for _, s := range []string{testUUIDString, "550e8400e29b41d4a716446655440000", "{" + testUUIDString + "}"} {
+		u, err := parseUUID(s)
+		if err != nil || u != testUUIDBytes {
+			t.Errorf("parseUUID(%q) = %v, %v", s, u, err)
+		}
+	}
+
+	if _, err := parseUUID("not-a-uuid"); err == nil {
+		t.Error("Expected error for invalid UUID")
+	}
+}
+
+func TestQueryStructsUUID(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("a", "b", "c", "d", "e"),
+		rows: []mockRow{{values: []interface{}{
+			testUUIDBytes, testUUIDBytes, testUUIDBytes, testUUIDBytes,
+			pgtype.UUID{Bytes: testUUIDBytes, Valid: true},
+		}}},
+	}
+
+	type Row struct {
+		A string    `db:"a"`
+		B [16]byte  `db:"b"`
+		C testUUID  `db:"c"`
+		D plainUUID `db:"d"`
+		E *string   `db:"e"`
+	}
+
+	var rows []Row
+	if err := QueryStructs(ctx, mock, "SELECT a, b, c, d, e FROM t", &rows); err != nil {
+		t.Fatalf("QueryStructs failed: %v", err)
+	}
+
+	got := rows[0]
+	if got.A != testUUIDString || got.B != testUUIDBytes || got.C != testUUID(testUUIDBytes) || got.D != plainUUID(testUUIDBytes) {
+		t.Errorf("Unexpected UUID mapping: %+v", got)
+	}
+	if got.E == nil || *got.E != testUUIDString {
+		t.Errorf("Expected pgtype.UUID to map into *string, got %v", got.E)
+	}
+}
+
+func TestAssignValueStringIntoUUID(t *testing.T) {
+	var u plainUUID
+	if err := assignValue(reflect.ValueOf(&u).Elem(), testUUIDString, defaults()); err != nil {
+		t.Fatalf("assignValue failed: %v", err)
+	}
+	if u != plainUUID(testUUIDBytes) {
+		t.Errorf("Expected %v, got %v", testUUIDBytes, u)
+	}
+}
+
+func TestExtractStructFieldsUUID(t *testing.T) {
+	type Row struct {
+		A testUUID  `db:"a"`
+		B plainUUID `db:"b"`
+	}
+
+	_, values, err := extractStructFields(Row{A: testUUIDBytes, B: testUUIDBytes})
+	if err != nil {
+		t.Fatalf("extractStructFields failed: %v", err)
+	}
+
+	expected := []any{testUUIDString, testUUIDBytes}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, values)
+	}
+}
+
+func TestQueryMapsAndJSONRenderUUIDs(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockQueryer{
+		fields: mockFields("id"),
+		rows:   []mockRow{{values: []interface{}{testUUIDBytes}}},
+	}
+
+	rows, err := QueryMaps(ctx, mock, "SELECT id FROM t")
+	if err != nil {
+		t.Fatalf("QueryMaps failed: %v", err)
+	}
+	if rows[0]["id"] != testUUIDString {
+		t.Errorf("Expected canonical string, got %#v", rows[0]["id"])
+	}
+
+	data, err := QueryJSON(ctx, mock, "SELECT id FROM t")
+	if err != nil {
+		t.Fatalf("QueryJSON failed: %v", err)
+	}
+	if expected := `[{"id":"` + testUUIDString + `"}]`; string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
@@ -0,0 +1,231 @@
+package dbx
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Cond is a condition for a WHERE clause, built with Eq, Or, Raw, and the
+// other condition constructors. Column names are written into the SQL as
+// given, so they must not come from untrusted input; values are always
+// sent as arguments.
+type Cond interface {
+	build(b *condBuilder)
+}
+
+// condBuilder accumulates the SQL and arguments of a condition.
+type condBuilder struct {
+	sql    strings.Builder
+	args   []any
+	offset int
+}
+
+// arg writes a placeholder for v.
+func (b *condBuilder) arg(v any) {
+	b.args = append(b.args, v)
+	b.sql.WriteByte('$')
+	b.sql.WriteString(strconv.Itoa(b.offset + len(b.args)))
+}
+
+// WhereClause is a WHERE clause made of conditions joined with AND.
+type WhereClause struct {
+	conds []Cond
+}
+
+// Where returns a WHERE clause requiring all of conds. Nil conditions are
+// ignored, so optional filters can be passed as nil.
+//
+//	where, args := dbx.Where(dbx.Eq("active", true), dbx.Like("email", "%@example.com")).Build(0)
+//	rows, err := dbx.QueryMaps(ctx, db, "SELECT * FROM users "+where, args...)
+func Where(conds ...Cond) *WhereClause {
+	return &WhereClause{conds: conds}
+}
+
+// Build renders the clause, including the WHERE keyword, numbering its
+// placeholders from $offset+1. offset is the number of placeholders already
+// used earlier in the statement. A clause without conditions renders as
+// the empty string.
+func (w *WhereClause) Build(offset int) (string, []any) {
+	b := &condBuilder{offset: offset}
+	and := And(w.conds...).(junction)
+	if len(and.conds) == 0 {
+		return "", nil
+	}
+	b.sql.WriteString("WHERE ")
+	and.buildTerms(b)
+	return b.sql.String(), b.args
+}
+
+// comparison is a condition of the form "column op $n".
+type comparison struct {
+	column, op string
+	value      any
+}
+
+func (c comparison) build(b *condBuilder) {
+	b.sql.WriteString(c.column)
+	b.sql.WriteString(" " + c.op + " ")
+	b.arg(c.value)
+}
+
+// Eq requires column = value.
+func Eq(column string, value any) Cond { return comparison{column, "=", value} }
+
+// Neq requires column <> value.
+func Neq(column string, value any) Cond { return comparison{column, "<>", value} }
+
+// Gt requires column > value.
+func Gt(column string, value any) Cond { return comparison{column, ">", value} }
+
+// Gte requires column >= value.
+func Gte(column string, value any) Cond { return comparison{column, ">=", value} }
+
+// Lt requires column < value.
+func Lt(column string, value any) Cond { return comparison{column, "<", value} }
+
+// Lte requires column <= value.
+func Lte(column string, value any) Cond { return comparison{column, "<=", value} }
+
+// Like requires column LIKE pattern.
+func Like(column string, pattern string) Cond { return comparison{column, "LIKE", pattern} }
+
+// in is a condition of the form "column = ANY($n)".
+type in struct {
+	column string
+	values any
+}
+
+func (c in) build(b *condBuilder) {
+	b.sql.WriteString(c.column + " = ANY(")
+	b.arg(c.values)
+	b.sql.WriteByte(')')
+}
+
+// In requires column to equal one of values, which must be a slice. It is
+// rendered as column = ANY($n) with the slice as a single array argument,
+// so an empty slice matches nothing rather than producing invalid SQL.
+func In(column string, values any) Cond { return in{column, values} }
+
+// nullCheck is a condition of the form "column IS [NOT] NULL".
+type nullCheck struct {
+	column string
+	not    bool
+}
+
+func (c nullCheck) build(b *condBuilder) {
+	b.sql.WriteString(c.column)
+	if c.not {
+		b.sql.WriteString(" IS NOT NULL")
+	} else {
+		b.sql.WriteString(" IS NULL")
+	}
+}
+
+// IsNull requires column IS NULL.
+func IsNull(column string) Cond { return nullCheck{column: column} }
+
+// IsNotNull requires column IS NOT NULL.
+func IsNotNull(column string) Cond { return nullCheck{column: column, not: true} }
+
+// between is a condition of the form "column BETWEEN $n AND $m".
+type between struct {
+	column    string
+	low, high any
+}
+
+func (c between) build(b *condBuilder) {
+	b.sql.WriteString(c.column + " BETWEEN ")
+	b.arg(c.low)
+	b.sql.WriteString(" AND ")
+	b.arg(c.high)
+}
+
+// Between requires column BETWEEN low AND high.
+func Between(column string, low, high any) Cond { return between{column, low, high} }
+
+// raw is a caller-written SQL condition.
+type raw struct {
+	sql  string
+	args []any
+}
+
+func (c raw) build(b *condBuilder) {
+	b.sql.WriteByte('(')
+	b.sql.WriteString(shiftPlaceholders(c.sql, b.offset+len(b.args)))
+	b.sql.WriteByte(')')
+	b.args = append(b.args, c.args...)
+}
+
+// Raw is an escape hatch for conditions the builder doesn't cover. sql uses
+// its own placeholders starting at $1, which are renumbered to follow the
+// surrounding conditions. It is wrapped in parentheses so operators inside
+// it cannot bind to neighbouring conditions:
+//
+//	dbx.Raw("created_at > now() - $1::interval", "7 days")
+func Raw(sql string, args ...any) Cond { return raw{sql, args} }
+
+// junction joins conditions with AND or OR.
+type junction struct {
+	op    string
+	conds []Cond
+}
+
+func (j junction) build(b *condBuilder) {
+	switch len(j.conds) {
+	case 0:
+		// The identity of the operator: no conditions for AND always
+		// holds, and none for OR never does
+		if j.op == "AND" {
+			b.sql.WriteString("TRUE")
+		} else {
+			b.sql.WriteString("FALSE")
+		}
+	case 1:
+		j.conds[0].build(b)
+	default:
+		b.sql.WriteByte('(')
+		j.buildTerms(b)
+		b.sql.WriteByte(')')
+	}
+}
+
+// buildTerms writes the conditions separated by the operator.
+func (j junction) buildTerms(b *condBuilder) {
+	for i, c := range j.conds {
+		if i > 0 {
+			b.sql.WriteString(" " + j.op + " ")
+		}
+		c.build(b)
+	}
+}
+
+// And requires all of conds. Nil conditions are ignored.
+func And(conds ...Cond) Cond { return junction{"AND", compact(conds)} }
+
+// Or requires any of conds. Nil conditions are ignored.
+func Or(conds ...Cond) Cond { return junction{"OR", compact(conds)} }
+
+// not negates a condition.
+type not struct {
+	cond Cond
+}
+
+func (c not) build(b *condBuilder) {
+	b.sql.WriteString("NOT (")
+	c.cond.build(b)
+	b.sql.WriteByte(')')
+}
+
+// Not negates cond.
+func Not(cond Cond) Cond { return not{cond} }
+
+// compact returns conds without nil entries.
+func compact(conds []Cond) []Cond {
+	out := make([]Cond, 0, len(conds))
+	for _, c := range conds {
+		if c != nil {
+			out = append(out, c)
+		}
+	}
+	return out
+}
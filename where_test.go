@@ -0,0 +1,76 @@
+package dbx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhereBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		where    *WhereClause
+		offset   int
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "comparisons",
+			where:    Where(Eq("a", 1), Neq("b", 2), Gt("c", 3), Gte("d", 4), Lt("e", 5), Lte("f", 6)),
+			wantSQL:  "WHERE a = $1 AND b <> $2 AND c > $3 AND d >= $4 AND e < $5 AND f <= $6",
+			wantArgs: []any{1, 2, 3, 4, 5, 6},
+		},
+		{
+			name:     "offset",
+			where:    Where(Eq("active", true), Like("email", "%@x.com")),
+			offset:   2,
+			wantSQL:  "WHERE active = $3 AND email LIKE $4",
+			wantArgs: []any{true, "%@x.com"},
+		},
+		{
+			name:     "or and not",
+			where:    Where(Or(Eq("role", "admin"), And(Eq("role", "user"), IsNotNull("verified_at"))), Not(IsNull("email"))),
+			wantSQL:  "WHERE (role = $1 OR (role = $2 AND verified_at IS NOT NULL)) AND NOT (email IS NULL)",
+			wantArgs: []any{"admin", "user"},
+		},
+		{
+			name:     "in and between",
+			where:    Where(In("id", []int{1, 2}), Between("age", 18, 65)),
+			wantSQL:  "WHERE id = ANY($1) AND age BETWEEN $2 AND $3",
+			wantArgs: []any{[]int{1, 2}, 18, 65},
+		},
+		{
+			name:     "raw",
+			where:    Where(Eq("a", 1), Raw("b = $1 OR c = $2", "x", "y"), Eq("d", 2)),
+			wantSQL:  "WHERE a = $1 AND (b = $2 OR c = $3) AND d = $4",
+			wantArgs: []any{1, "x", "y", 2},
+		},
+		{
+			name:     "nil conditions skipped",
+			where:    Where(nil, Eq("a", 1), nil),
+			wantSQL:  "WHERE a = $1",
+			wantArgs: []any{1},
+		},
+		{
+			name:    "empty",
+			where:   Where(),
+			wantSQL: "",
+		},
+		{
+			name:    "empty or",
+			where:   Where(Or()),
+			wantSQL: "WHERE FALSE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := tt.where.Build(tt.offset)
+			if sql != tt.wantSQL {
+				t.Errorf("Expected SQL %q, got %q", tt.wantSQL, sql)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Expected args %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}